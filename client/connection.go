@@ -61,6 +61,9 @@ type ConnectionArgs struct {
 	// OpenID Connect tokens
 	OIDCTokens *oidc.Tokens[*oidc.IDTokenClaims]
 
+	// API bearer token
+	BearerToken string
+
 	// Skip automatic GetServer request upon connection
 	SkipGetServer bool
 
@@ -326,6 +329,7 @@ func httpsLXD(ctx context.Context, requestURL string, args *ConnectionArgs) (Ins
 		httpProtocol:       "https",
 		httpUserAgent:      args.UserAgent,
 		bakeryInteractor:   args.AuthInteractor,
+		bearerToken:        args.BearerToken,
 		ctxConnected:       ctxConnected,
 		ctxConnectedCancel: ctxConnectedCancel,
 		eventConns:         make(map[string]*websocket.Conn),
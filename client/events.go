@@ -15,8 +15,9 @@ type EventListener struct {
 	ctxCancel context.CancelFunc
 	err       error
 
-	// projectName stores which project this event listener is associated with (empty for all projects).
-	projectName string
+	// key identifies the event stream connection used by this event listener, which is shared by the
+	// listeners of the same project (empty for all projects) and arguments.
+	key         string
 	targets     []*EventTarget
 	targetsLock sync.Mutex
 }
@@ -83,11 +84,11 @@ func (e *EventListener) Disconnect() {
 	}
 
 	// Locate and remove it from the global list
-	for i, listener := range e.r.eventListeners[e.projectName] {
+	for i, listener := range e.r.eventListeners[e.key] {
 		if listener == e {
-			copy(e.r.eventListeners[e.projectName][i:], e.r.eventListeners[e.projectName][i+1:])
-			e.r.eventListeners[e.projectName][len(e.r.eventListeners[e.projectName])-1] = nil
-			e.r.eventListeners[e.projectName] = e.r.eventListeners[e.projectName][:len(e.r.eventListeners[e.projectName])-1]
+			copy(e.r.eventListeners[e.key][i:], e.r.eventListeners[e.key][i+1:])
+			e.r.eventListeners[e.key][len(e.r.eventListeners[e.key])-1] = nil
+			e.r.eventListeners[e.key] = e.r.eventListeners[e.key][:len(e.r.eventListeners[e.key])-1]
 			break
 		}
 	}
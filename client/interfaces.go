@@ -83,6 +83,22 @@ type InstanceServer interface {
 	UseTarget(name string) (client InstanceServer)
 	UseProject(name string) (client InstanceServer)
 
+	// Authorization group functions
+	GetAuthGroupNames() (names []string, err error)
+	GetAuthGroups() (groups []api.AuthGroup, err error)
+	GetAuthGroup(name string) (group *api.AuthGroup, ETag string, err error)
+	CreateAuthGroup(group api.AuthGroupsPost) (err error)
+	UpdateAuthGroup(name string, group api.AuthGroupPut, ETag string) (err error)
+	RenameAuthGroup(name string, group api.AuthGroupPost) (err error)
+	DeleteAuthGroup(name string) (err error)
+
+	// API bearer token functions ("auth_tokens" API extension)
+	GetAuthTokenFingerprints() (fingerprints []string, err error)
+	GetAuthTokens() (tokens []api.AuthToken, err error)
+	GetAuthToken(fingerprint string) (token *api.AuthToken, err error)
+	CreateAuthToken(token api.AuthTokensPost) (secret *api.AuthTokenSecret, err error)
+	DeleteAuthToken(fingerprint string) (err error)
+
 	// Certificate functions
 	GetCertificateFingerprints() (fingerprints []string, err error)
 	GetCertificates() (certificates []api.Certificate, err error)
@@ -169,6 +185,8 @@ type InstanceServer interface {
 	CreateInstanceFromImage(source ImageServer, image api.Image, req api.InstancesPost) (op RemoteOperation, err error)
 	CopyInstance(source InstanceServer, instance api.Instance, args *InstanceCopyArgs) (op RemoteOperation, err error)
 	UpdateInstance(name string, instance api.InstancePut, ETag string) (op Operation, err error)
+	GetInstanceConfigRevisions(name string) (revisions []api.ConfigRevision, err error)
+	GetInstanceConfigRevision(name string, revision int64) (configRevision *api.ConfigRevision, err error)
 	RenameInstance(name string, instance api.InstancePost) (op Operation, err error)
 	MigrateInstance(name string, instance api.InstancePost) (op Operation, err error)
 	DeleteInstance(name string) (op Operation, err error)
@@ -177,6 +195,10 @@ type InstanceServer interface {
 	RebuildInstanceFromImage(source ImageServer, image api.Image, instanceName string, req api.InstanceRebuildPost) (op RemoteOperation, err error)
 
 	ExecInstance(instanceName string, exec api.InstanceExecPost, args *InstanceExecArgs) (op Operation, err error)
+	GetInstanceExecSessions(instanceName string) (sessions []api.InstanceExecSession, err error)
+	GetInstanceExecSession(instanceName string, id string) (session *api.InstanceExecSession, err error)
+	DeleteInstanceExecSession(instanceName string, id string) (err error)
+	AttachInstanceExecSession(instanceName string, id string, attach api.InstanceExecSessionAttachPost, args *InstanceConsoleArgs) (op Operation, err error)
 	ConsoleInstance(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (op Operation, err error)
 	ConsoleInstanceDynamic(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (Operation, func(io.ReadWriteCloser) error, error)
 
@@ -186,6 +208,9 @@ type InstanceServer interface {
 	GetInstanceFile(instanceName string, path string) (content io.ReadCloser, resp *InstanceFileResponse, err error)
 	CreateInstanceFile(instanceName string, path string, args InstanceFileArgs) (err error)
 	DeleteInstanceFile(instanceName string, path string) (err error)
+	GetInstanceFileArchive(instanceName string, path string) (content io.ReadCloser, err error)
+	CreateInstanceFileArchive(instanceName string, path string, content io.Reader) (err error)
+	GetInstanceFileWatch(instanceName string, path string) (conn *websocket.Conn, err error)
 
 	GetInstanceFileSFTPConn(instanceName string) (net.Conn, error)
 	GetInstanceFileSFTP(instanceName string) (*sftp.Client, error)
@@ -210,6 +235,9 @@ type InstanceServer interface {
 	CreateInstanceFromBackup(args InstanceBackupArgs) (op Operation, err error)
 
 	GetInstanceState(name string) (state *api.InstanceState, ETag string, err error)
+	GetInstanceProcesses(name string) (processes []api.InstanceProcess, err error)
+	SignalInstanceProcess(name string, pid int64, signal string) (err error)
+	GetInstanceDiff(name string) (changes []api.InstanceFileChange, err error)
 	UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (op Operation, err error)
 
 	GetInstanceLogfiles(name string) (logfiles []string, err error)
@@ -227,6 +255,7 @@ type InstanceServer interface {
 	// Event handling functions
 	GetEvents() (listener *EventListener, err error)
 	GetEventsAllProjects() (listener *EventListener, err error)
+	GetEventsWithArgs(args *EventListenerArgs) (listener *EventListener, err error)
 	SendEvent(event api.Event) error
 
 	// Image functions
@@ -319,6 +348,8 @@ type InstanceServer interface {
 	GetProfile(name string) (profile *api.Profile, ETag string, err error)
 	CreateProfile(profile api.ProfilesPost) (err error)
 	UpdateProfile(name string, profile api.ProfilePut, ETag string) (err error)
+	GetProfileConfigRevisions(name string) (revisions []api.ConfigRevision, err error)
+	GetProfileConfigRevision(name string, revision int64) (configRevision *api.ConfigRevision, err error)
 	RenameProfile(name string, profile api.ProfilePost) (err error)
 	DeleteProfile(name string) (err error)
 
@@ -422,6 +453,14 @@ type InstanceServer interface {
 	UpdateWarning(UUID string, warning api.WarningPut, ETag string) (err error)
 	DeleteWarning(UUID string) (err error)
 
+	// Webhook functions ("webhooks" API extension)
+	GetWebhookNames() (names []string, err error)
+	GetWebhooks() (webhooks []api.Webhook, err error)
+	GetWebhook(name string) (webhook *api.Webhook, ETag string, err error)
+	CreateWebhook(webhook api.WebhooksPost) (err error)
+	UpdateWebhook(name string, webhook api.WebhookPut, ETag string) (err error)
+	DeleteWebhook(name string) (err error)
+
 	// Internal functions (for internal use)
 	RawQuery(method string, path string, data any, queryETag string) (resp *api.Response, ETag string, err error)
 	RawWebsocket(path string) (conn *websocket.Conn, err error)
@@ -457,6 +496,32 @@ type BackupFileResponse struct {
 	Size int64
 }
 
+// The EventListenerArgs struct is used to pass additional options when connecting to the event stream.
+type EventListenerArgs struct {
+	// Whether to get the events of all projects
+	AllProjects bool
+
+	// Replay the logged events whose ID is greater than this one before the live events (requires the
+	// "event_log" API extension)
+	Since uint64
+
+	// Lifecycle actions to deliver (requires the "event_filter" API extension)
+	Actions []string
+
+	// Prefix of the URL of the entity the lifecycle and operation events relate to (requires the
+	// "event_filter" API extension)
+	Entity string
+
+	// Cluster members the events originate from (requires the "event_filter" API extension)
+	Locations []string
+
+	// Minimum level of the logging events (requires the "event_filter" API extension)
+	Level string
+
+	// Filter expression matched against the event fields (requires the "event_filter" API extension)
+	Filter string
+}
+
 // The ImageCreateArgs struct is used for direct image upload.
 type ImageCreateArgs struct {
 	// Reader for the meta file
@@ -603,6 +668,10 @@ type InstanceCopyArgs struct {
 
 	// API extension: instance_allow_inconsistent_copy
 	AllowInconsistent bool
+
+	// API extension: instance_type_conversion
+	// If set, the instance will be converted to this instance type on copy
+	Type api.InstanceType
 }
 
 // The InstanceSnapshotCopyArgs struct is used to pass additional options during instance copy.
@@ -53,7 +53,8 @@ type ProtocolLXD struct {
 	clusterTarget string
 	project       string
 
-	oidcClient *oidcClient
+	oidcClient  *oidcClient
+	bearerToken string
 }
 
 // Disconnect gets rid of any background goroutines.
@@ -168,6 +169,7 @@ func (r *ProtocolLXD) DoHTTP(req *http.Request) (*http.Response, error) {
 // X-LXD-authenticated (if r.requireAuthenticated is set).
 // Bakery authentication header and cookie (if r.bakeryClient is set).
 // OIDC Authorization header (if r.oidcClient is set).
+// API bearer token Authorization header (if r.bearerToken is set).
 func (r *ProtocolLXD) addClientHeaders(req *http.Request) {
 	if r.httpUserAgent != "" {
 		req.Header.Set("User-Agent", r.httpUserAgent)
@@ -187,6 +189,8 @@ func (r *ProtocolLXD) addClientHeaders(req *http.Request) {
 
 	if r.oidcClient != nil {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.oidcClient.getAccessToken()))
+	} else if r.bearerToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.bearerToken))
 	}
 }
 
@@ -0,0 +1,218 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// GetAuthGroupNames returns the names of the authorization groups.
+func (r *ProtocolLXD) GetAuthGroupNames() ([]string, error) {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{}
+
+	_, err = r.queryStruct("GET", "/auth/groups", nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames("/1.0/auth/groups", urls...)
+}
+
+// GetAuthGroups returns the authorization groups.
+func (r *ProtocolLXD) GetAuthGroups() ([]api.AuthGroup, error) {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []api.AuthGroup{}
+
+	_, err = r.queryStruct("GET", "/auth/groups?recursion=1", nil, "", &groups)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetAuthGroup returns information about the given authorization group.
+func (r *ProtocolLXD) GetAuthGroup(name string) (*api.AuthGroup, string, error) {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return nil, "", err
+	}
+
+	group := api.AuthGroup{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/auth/groups/%s", url.PathEscape(name)), nil, "", &group)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &group, etag, nil
+}
+
+// CreateAuthGroup creates a new authorization group.
+func (r *ProtocolLXD) CreateAuthGroup(group api.AuthGroupsPost) error {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return err
+	}
+
+	if len(group.OIDCClaims) > 0 {
+		err = r.CheckExtension("auth_groups_oidc_claims")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _, err = r.query("POST", "/auth/groups", group, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAuthGroup replaces the description, identities, OpenID Connect claims and permissions of the given authorization group.
+func (r *ProtocolLXD) UpdateAuthGroup(name string, group api.AuthGroupPut, ETag string) error {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return err
+	}
+
+	if len(group.OIDCClaims) > 0 {
+		err = r.CheckExtension("auth_groups_oidc_claims")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _, err = r.query("PUT", fmt.Sprintf("/auth/groups/%s", url.PathEscape(name)), group, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RenameAuthGroup changes the name of an existing authorization group.
+func (r *ProtocolLXD) RenameAuthGroup(name string, group api.AuthGroupPost) error {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("POST", fmt.Sprintf("/auth/groups/%s", url.PathEscape(name)), group, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAuthGroup deletes an existing authorization group.
+func (r *ProtocolLXD) DeleteAuthGroup(name string) error {
+	err := r.CheckExtension("auth_groups")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("DELETE", fmt.Sprintf("/auth/groups/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuthTokenFingerprints returns the fingerprints of the API bearer tokens.
+func (r *ProtocolLXD) GetAuthTokenFingerprints() ([]string, error) {
+	err := r.CheckExtension("auth_tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{}
+
+	_, err = r.queryStruct("GET", "/auth/tokens", nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames("/1.0/auth/tokens", urls...)
+}
+
+// GetAuthTokens returns the API bearer tokens.
+func (r *ProtocolLXD) GetAuthTokens() ([]api.AuthToken, error) {
+	err := r.CheckExtension("auth_tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []api.AuthToken{}
+
+	_, err = r.queryStruct("GET", "/auth/tokens?recursion=1", nil, "", &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetAuthToken returns information about the API bearer token whose fingerprint starts with the given prefix.
+func (r *ProtocolLXD) GetAuthToken(fingerprint string) (*api.AuthToken, error) {
+	err := r.CheckExtension("auth_tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	token := api.AuthToken{}
+
+	_, err = r.queryStruct("GET", fmt.Sprintf("/auth/tokens/%s", url.PathEscape(fingerprint)), nil, "", &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CreateAuthToken creates a new API bearer token and returns it.
+func (r *ProtocolLXD) CreateAuthToken(token api.AuthTokensPost) (*api.AuthTokenSecret, error) {
+	err := r.CheckExtension("auth_tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	secret := api.AuthTokenSecret{}
+
+	_, err = r.queryStruct("POST", "/auth/tokens", token, "", &secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// DeleteAuthToken revokes the API bearer token whose fingerprint starts with the given prefix.
+func (r *ProtocolLXD) DeleteAuthToken(fingerprint string) error {
+	err := r.CheckExtension("auth_tokens")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("DELETE", fmt.Sprintf("/auth/tokens/%s", url.PathEscape(fingerprint)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
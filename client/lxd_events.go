@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -15,7 +18,7 @@ import (
 // Event handling functions
 
 // getEvents connects to the LXD monitoring interface.
-func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
+func (r *ProtocolLXD) getEvents(allProjects bool, args *EventListenerArgs) (*EventListener, error) {
 	// Prevent anything else from interacting with the listeners
 	r.eventListenersLock.Lock()
 	defer r.eventListenersLock.Unlock()
@@ -35,40 +38,89 @@ func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
 	}
 
 	if !allProjects {
-		listener.projectName = connInfo.Project
+		listener.key = connInfo.Project
+	}
+
+	query := url.Values{}
+	if allProjects {
+		query.Set("all-projects", "true")
+	}
+
+	dedicated := false
+
+	if args != nil && args.Since > 0 {
+		err := r.CheckExtension("event_log")
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("since", strconv.FormatUint(args.Since, 10))
+		dedicated = true
+	}
+
+	if args != nil && (len(args.Actions) > 0 || args.Entity != "" || len(args.Locations) > 0 || args.Level != "" || args.Filter != "") {
+		err := r.CheckExtension("event_filter")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args.Actions) > 0 {
+			query.Set("action", strings.Join(args.Actions, ","))
+		}
+
+		if args.Entity != "" {
+			query.Set("entity", args.Entity)
+		}
+
+		if len(args.Locations) > 0 {
+			query.Set("location", strings.Join(args.Locations, ","))
+		}
+
+		if args.Level != "" {
+			query.Set("level", args.Level)
+		}
+
+		if args.Filter != "" {
+			query.Set("filter", args.Filter)
+		}
+
+		dedicated = true
+	}
+
+	// Listeners replaying past events or filtering them get their own connection.
+	if dedicated {
+		listener.key = fmt.Sprintf("%s?%s&listener=%p", listener.key, query.Encode(), &listener)
 	}
 
 	// There is an existing Go routine for the required project filter, so just add another target.
-	if r.eventListeners[listener.projectName] != nil {
-		r.eventListeners[listener.projectName] = append(r.eventListeners[listener.projectName], &listener)
+	if r.eventListeners[listener.key] != nil {
+		r.eventListeners[listener.key] = append(r.eventListeners[listener.key], &listener)
 		return &listener, nil
 	}
 
 	// Setup a new connection with LXD
-	var url string
-	var err error
-	if allProjects {
-		url, err = r.setQueryAttributes("/events?all-projects=true")
-	} else {
-		url, err = r.setQueryAttributes("/events")
+	eventsURL := "/events"
+	if len(query) > 0 {
+		eventsURL += "?" + query.Encode()
 	}
 
+	eventsURL, err := r.setQueryAttributes(eventsURL)
 	if err != nil {
 		return nil, err
 	}
 
 	// Connect websocket and save.
-	wsConn, err := r.websocket(url)
+	wsConn, err := r.websocket(eventsURL)
 	if err != nil {
 		return nil, err
 	}
 
 	r.eventConnsLock.Lock()
-	r.eventConns[listener.projectName] = wsConn // Save for others to use.
+	r.eventConns[listener.key] = wsConn // Save for others to use.
 	r.eventConnsLock.Unlock()
 
 	// Initialize the event listener list if we were able to connect to the events websocket.
-	r.eventListeners[listener.projectName] = []*EventListener{&listener}
+	r.eventListeners[listener.key] = []*EventListener{&listener}
 
 	// Spawn a watcher that will close the websocket connection after all
 	// listeners are gone.
@@ -83,14 +135,14 @@ func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
 
 			r.eventListenersLock.Lock()
 			r.eventConnsLock.Lock()
-			if len(r.eventListeners[listener.projectName]) == 0 {
+			if len(r.eventListeners[listener.key]) == 0 {
 				// We don't need the connection anymore, disconnect and clear.
-				if r.eventListeners[listener.projectName] != nil {
-					_ = r.eventConns[listener.projectName].Close()
-					delete(r.eventConns, listener.projectName)
+				if r.eventListeners[listener.key] != nil {
+					_ = r.eventConns[listener.key].Close()
+					delete(r.eventConns, listener.key)
 				}
 
-				r.eventListeners[listener.projectName] = nil
+				r.eventListeners[listener.key] = nil
 				r.eventListenersLock.Unlock()
 				r.eventConnsLock.Unlock()
 
@@ -112,14 +164,14 @@ func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
 				defer r.eventListenersLock.Unlock()
 
 				// Tell all the current listeners about the failure
-				for _, listener := range r.eventListeners[listener.projectName] {
+				for _, listener := range r.eventListeners[listener.key] {
 					listener.err = err
 					listener.ctxCancel()
 				}
 
 				// And remove them all from the list so that when watcher routine runs it will
 				// close the websocket connection.
-				r.eventListeners[listener.projectName] = nil
+				r.eventListeners[listener.key] = nil
 
 				close(stopCh) // Instruct watcher go routine to cleanup.
 
@@ -140,7 +192,7 @@ func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
 
 			// Send the message to all handlers
 			r.eventListenersLock.Lock()
-			for _, listener := range r.eventListeners[listener.projectName] {
+			for _, listener := range r.eventListeners[listener.key] {
 				listener.targetsLock.Lock()
 				for _, target := range listener.targets {
 					if target.types != nil && !shared.StringInSlice(event.Type, target.types) {
@@ -162,12 +214,18 @@ func (r *ProtocolLXD) getEvents(allProjects bool) (*EventListener, error) {
 
 // GetEvents gets the events for the project defined on the client.
 func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
-	return r.getEvents(false)
+	return r.getEvents(false, nil)
 }
 
 // GetEventsAllProjects gets events for all projects.
 func (r *ProtocolLXD) GetEventsAllProjects() (*EventListener, error) {
-	return r.getEvents(true)
+	return r.getEvents(true, nil)
+}
+
+// GetEventsWithArgs gets the events for the project defined on the client, or all projects, using the given
+// arguments.
+func (r *ProtocolLXD) GetEventsWithArgs(args *EventListenerArgs) (*EventListener, error) {
+	return r.getEvents(args.AllProjects, args)
 }
 
 // SendEvent send an event to the server via the client's event listener connection.
@@ -764,6 +764,18 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 			}
 		}
 
+		if args.Type != "" && args.Type != req.Type {
+			if !r.HasExtension("instance_type_conversion") {
+				return nil, fmt.Errorf("The target server is missing the required \"instance_type_conversion\" API extension")
+			}
+
+			if args.Refresh {
+				return nil, fmt.Errorf("Instance type conversion can't be combined with incremental copies")
+			}
+
+			req.Type = args.Type
+		}
+
 		// Allow overriding the target name
 		if args.Name != "" {
 			req.Name = args.Name
@@ -825,6 +837,10 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 		return &rop, nil
 	}
 
+	if req.Type != api.InstanceType(instance.Type) {
+		return nil, fmt.Errorf("Instance type conversion is only supported when copying within the same server")
+	}
+
 	// Source request
 	sourceReq := api.InstancePost{
 		Migration:         true,
@@ -954,6 +970,52 @@ func (r *ProtocolLXD) UpdateInstance(name string, instance api.InstancePut, ETag
 	return op, nil
 }
 
+// GetInstanceConfigRevisions returns the configuration revisions of the instance, oldest first.
+func (r *ProtocolLXD) GetInstanceConfigRevisions(name string) ([]api.ConfigRevision, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.CheckExtension("config_history")
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []api.ConfigRevision{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/revisions?recursion=1", path, url.PathEscape(name)), nil, "", &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetInstanceConfigRevision returns the given configuration revision of the instance.
+func (r *ProtocolLXD) GetInstanceConfigRevision(name string, revision int64) (*api.ConfigRevision, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.CheckExtension("config_history")
+	if err != nil {
+		return nil, err
+	}
+
+	configRevision := api.ConfigRevision{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/revisions/%d", path, url.PathEscape(name), revision), nil, "", &configRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &configRevision, nil
+}
+
 // RenameInstance requests that LXD renames the instance.
 func (r *ProtocolLXD) RenameInstance(name string, instance api.InstancePost) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -1101,6 +1163,12 @@ func (r *ProtocolLXD) ExecInstance(instanceName string, exec api.InstanceExecPos
 		}
 	}
 
+	if exec.Detached {
+		if !r.HasExtension("instance_exec_detached") {
+			return nil, fmt.Errorf("The server is missing the required \"instance_exec_detached\" API extension")
+		}
+	}
+
 	var uri string
 
 	if r.IsAgent() {
@@ -1466,6 +1534,123 @@ func (r *ProtocolLXD) CreateInstanceFile(instanceName string, filePath string, a
 	return nil
 }
 
+// GetInstanceFileArchive retrieves the directory tree at path in the instance as a tar stream.
+func (r *ProtocolLXD) GetInstanceFileArchive(instanceName string, filePath string) (io.ReadCloser, error) {
+	if !r.HasExtension("instance_file_archive") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_file_archive\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the HTTP request
+	requestURL, err := shared.URLEncode(
+		fmt.Sprintf("%s/1.0%s/%s/files", r.httpBaseURL.String(), path, url.PathEscape(instanceName)),
+		map[string]string{"path": filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-LXD-type", "tar")
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, nil
+}
+
+// CreateInstanceFileArchive extracts the tar stream read from content into the directory at path in the instance.
+func (r *ProtocolLXD) CreateInstanceFileArchive(instanceName string, filePath string, content io.Reader) error {
+	if !r.HasExtension("instance_file_archive") {
+		return fmt.Errorf("The server is missing the required \"instance_file_archive\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	// Prepare the HTTP request
+	requestURL := fmt.Sprintf("%s/1.0%s/%s/files?path=%s", r.httpBaseURL.String(), path, url.PathEscape(instanceName), url.QueryEscape(filePath))
+
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", requestURL, content)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-tar")
+	req.Header.Set("X-LXD-type", "tar")
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return err
+	}
+
+	// Check the return value for a cleaner error
+	_, _, err = lxdParseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetInstanceFileWatch returns a websocket on which an api.InstanceFileEvent is sent for every file created,
+// modified or deleted below path in the instance.
+func (r *ProtocolLXD) GetInstanceFileWatch(instanceName string, filePath string) (*websocket.Conn, error) {
+	if !r.HasExtension("instance_file_watch") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_file_watch\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	uri, err := shared.URLEncode(
+		fmt.Sprintf("%s/%s/files", path, url.PathEscape(instanceName)),
+		map[string]string{"path": filePath, "watch": "true"})
+	if err != nil {
+		return nil, err
+	}
+
+	uri, err = r.setQueryAttributes(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.websocket(uri)
+}
+
 // DeleteInstanceFile deletes a file in the instance.
 func (r *ProtocolLXD) DeleteInstanceFile(instanceName string, filePath string) error {
 	if !r.HasExtension("file_delete") {
@@ -2062,6 +2247,107 @@ func (r *ProtocolLXD) GetInstanceState(name string) (*api.InstanceState, string,
 	return &state, etag, nil
 }
 
+// GetInstanceProcesses returns the processes running inside the instance.
+func (r *ProtocolLXD) GetInstanceProcesses(name string) ([]api.InstanceProcess, error) {
+	if !r.HasExtension("instance_processes") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_processes\" API extension")
+	}
+
+	uri, err := r.instanceProcessesPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := []api.InstanceProcess{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", uri, nil, "", &processes)
+	if err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// SignalInstanceProcess sends a signal, given by name or number, to the process with the given PID inside the instance.
+func (r *ProtocolLXD) SignalInstanceProcess(name string, pid int64, signal string) error {
+	if !r.HasExtension("instance_processes") {
+		return fmt.Errorf("The server is missing the required \"instance_processes\" API extension")
+	}
+
+	uri, err := r.instanceProcessesPath(name)
+	if err != nil {
+		return err
+	}
+
+	req := api.InstanceProcessesPost{
+		PID:    pid,
+		Signal: signal,
+	}
+
+	// Send the request
+	_, _, err = r.query("POST", uri, req, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// instanceProcessesPath returns the processes URL of the instance, or of the agent itself.
+func (r *ProtocolLXD) instanceProcessesPath(name string) (string, error) {
+	if r.IsAgent() {
+		return "/processes", nil
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s/processes", path, url.PathEscape(name)), nil
+}
+
+// GetInstanceDiff returns the files of the instance that differ from the image it was created from.
+// It waits for the server to complete the comparison.
+func (r *ProtocolLXD) GetInstanceDiff(name string) ([]api.InstanceFileChange, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.CheckExtension("instance_diff")
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/diff", path, url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the changes from the operation metadata.
+	changes := []api.InstanceFileChange{}
+
+	data, err := json.Marshal(op.Get().Metadata["changes"])
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
 // UpdateInstanceState updates the instance to match the requested state.
 func (r *ProtocolLXD) UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2373,6 +2659,148 @@ func (r *ProtocolLXD) DeleteInstanceTemplateFile(name string, templateName strin
 	return err
 }
 
+// GetInstanceExecSessions returns the detached exec sessions of the instance.
+func (r *ProtocolLXD) GetInstanceExecSessions(instanceName string) ([]api.InstanceExecSession, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instance_exec_detached") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_exec_detached\" API extension")
+	}
+
+	sessions := []api.InstanceExecSession{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/exec-sessions?recursion=1", path, url.PathEscape(instanceName)), nil, "", &sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// GetInstanceExecSession returns the detached exec session with the given ID.
+func (r *ProtocolLXD) GetInstanceExecSession(instanceName string, id string) (*api.InstanceExecSession, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instance_exec_detached") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_exec_detached\" API extension")
+	}
+
+	session := api.InstanceExecSession{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/exec-sessions/%s", path, url.PathEscape(instanceName), url.PathEscape(id)), nil, "", &session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// DeleteInstanceExecSession kills the command of a detached exec session if still running and removes the session.
+func (r *ProtocolLXD) DeleteInstanceExecSession(instanceName string, id string) error {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	if !r.HasExtension("instance_exec_detached") {
+		return fmt.Errorf("The server is missing the required \"instance_exec_detached\" API extension")
+	}
+
+	// Send the request
+	_, _, err = r.query("DELETE", fmt.Sprintf("%s/%s/exec-sessions/%s", path, url.PathEscape(instanceName), url.PathEscape(id)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AttachInstanceExecSession requests that LXD attaches to a detached exec session.
+// The recent output of the session is replayed to the terminal before its live output.
+// If the command exits while attached, its exit code is set in the "return" field of the operation metadata.
+func (r *ProtocolLXD) AttachInstanceExecSession(instanceName string, id string, attach api.InstanceExecSessionAttachPost, args *InstanceConsoleArgs) (Operation, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instance_exec_detached") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_exec_detached\" API extension")
+	}
+
+	if args == nil || args.Terminal == nil {
+		return nil, fmt.Errorf("A terminal must be set")
+	}
+
+	if args.Control == nil {
+		return nil, fmt.Errorf("A control channel must be set")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/exec-sessions/%s/attach", path, url.PathEscape(instanceName), url.PathEscape(id)), attach, "")
+	if err != nil {
+		return nil, err
+	}
+
+	opAPI := op.Get()
+
+	// Parse the fds
+	fds := map[string]string{}
+
+	value, ok := opAPI.Metadata["fds"]
+	if ok {
+		values := value.(map[string]any)
+		for k, v := range values {
+			fds[k] = v.(string)
+		}
+	}
+
+	if fds[api.SecretNameControl] == "" {
+		return nil, fmt.Errorf("Did not receive a file descriptor for the control channel")
+	}
+
+	// Connect to the websocket
+	conn, err := r.GetOperationWebsocket(opAPI.ID, fds["0"])
+	if err != nil {
+		return nil, err
+	}
+
+	// Call the control handler with a connection to the control socket
+	controlConn, err := r.GetOperationWebsocket(opAPI.ID, fds[api.SecretNameControl])
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go args.Control(controlConn)
+
+	// Detach from the session.
+	go func(consoleDisconnect <-chan bool) {
+		<-consoleDisconnect
+		msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Detaching from exec session")
+		// We don't care if this fails. This is just for convenience.
+		_ = controlConn.WriteMessage(websocket.CloseMessage, msg)
+		_ = controlConn.Close()
+	}(args.ConsoleDisconnect)
+
+	// And attach stdin and stdout to it
+	go func() {
+		_, writeDone := ws.Mirror(context.Background(), conn, args.Terminal)
+		<-writeDone
+		_ = conn.Close()
+	}()
+
+	return op, nil
+}
+
 // ConsoleInstance requests that LXD attaches to the console device of a instance.
 func (r *ProtocolLXD) ConsoleInstance(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2392,6 +2820,10 @@ func (r *ProtocolLXD) ConsoleInstance(instanceName string, console api.InstanceC
 		return nil, fmt.Errorf("The server is missing the required \"console_vga_type\" API extension")
 	}
 
+	if console.ReadOnly && !r.HasExtension("instance_console_shared") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_console_shared\" API extension")
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/console", path, url.PathEscape(instanceName)), console, "")
 	if err != nil {
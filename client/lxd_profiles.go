@@ -92,3 +92,39 @@ func (r *ProtocolLXD) DeleteProfile(name string) error {
 
 	return nil
 }
+
+// GetProfileConfigRevisions returns the configuration revisions of the profile, oldest first.
+func (r *ProtocolLXD) GetProfileConfigRevisions(name string) ([]api.ConfigRevision, error) {
+	err := r.CheckExtension("config_history")
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []api.ConfigRevision{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("/profiles/%s/revisions?recursion=1", url.PathEscape(name)), nil, "", &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetProfileConfigRevision returns the given configuration revision of the profile.
+func (r *ProtocolLXD) GetProfileConfigRevision(name string, revision int64) (*api.ConfigRevision, error) {
+	err := r.CheckExtension("config_history")
+	if err != nil {
+		return nil, err
+	}
+
+	configRevision := api.ConfigRevision{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("/profiles/%s/revisions/%d", url.PathEscape(name), revision), nil, "", &configRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &configRevision, nil
+}
@@ -0,0 +1,106 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// GetWebhookNames returns the names of the webhooks.
+func (r *ProtocolLXD) GetWebhookNames() ([]string, error) {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{}
+
+	_, err = r.queryStruct("GET", "/webhooks", nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames("/1.0/webhooks", urls...)
+}
+
+// GetWebhooks returns the webhooks.
+func (r *ProtocolLXD) GetWebhooks() ([]api.Webhook, error) {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := []api.Webhook{}
+
+	_, err = r.queryStruct("GET", "/webhooks?recursion=1", nil, "", &webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns information about the given webhook.
+func (r *ProtocolLXD) GetWebhook(name string) (*api.Webhook, string, error) {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook := api.Webhook{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/webhooks/%s", url.PathEscape(name)), nil, "", &webhook)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &webhook, etag, nil
+}
+
+// CreateWebhook creates a new webhook.
+func (r *ProtocolLXD) CreateWebhook(webhook api.WebhooksPost) error {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("POST", "/webhooks", webhook, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateWebhook replaces the description, URL, event types and projects of the given webhook, along with its
+// secret if set (an empty secret removes it).
+func (r *ProtocolLXD) UpdateWebhook(name string, webhook api.WebhookPut, ETag string) error {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("PUT", fmt.Sprintf("/webhooks/%s", url.PathEscape(name)), webhook, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes an existing webhook.
+func (r *ProtocolLXD) DeleteWebhook(name string) error {
+	err := r.CheckExtension("webhooks")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("DELETE", fmt.Sprintf("/webhooks/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,1294 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+	"github.com/canonical/lxd/shared/termios"
+	"github.com/canonical/lxd/shared/version"
+)
+
+type cmdAuth struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuth) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("auth")
+	cmd.Short = i18n.G("Manage authorization groups, permissions and API bearer tokens")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage authorization groups, permissions and API bearer tokens`))
+
+	// Group
+	authGroupCmd := cmdAuthGroup{global: c.global}
+	cmd.AddCommand(authGroupCmd.Command())
+
+	// Permission
+	authPermissionCmd := cmdAuthPermission{global: c.global}
+	cmd.AddCommand(authPermissionCmd.Command())
+
+	// Token
+	authTokenCmd := cmdAuthToken{global: c.global}
+	cmd.AddCommand(authTokenCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Group.
+type cmdAuthGroup struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroup) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("group")
+	cmd.Short = i18n.G("Manage authorization groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage authorization groups`))
+
+	// Claim
+	authGroupClaimCmd := cmdAuthGroupClaim{global: c.global}
+	cmd.AddCommand(authGroupClaimCmd.Command())
+
+	// Create
+	authGroupCreateCmd := cmdAuthGroupCreate{global: c.global}
+	cmd.AddCommand(authGroupCreateCmd.Command())
+
+	// Delete
+	authGroupDeleteCmd := cmdAuthGroupDelete{global: c.global}
+	cmd.AddCommand(authGroupDeleteCmd.Command())
+
+	// Edit
+	authGroupEditCmd := cmdAuthGroupEdit{global: c.global}
+	cmd.AddCommand(authGroupEditCmd.Command())
+
+	// Identity
+	authGroupIdentityCmd := cmdAuthGroupIdentity{global: c.global}
+	cmd.AddCommand(authGroupIdentityCmd.Command())
+
+	// List
+	authGroupListCmd := cmdAuthGroupList{global: c.global}
+	cmd.AddCommand(authGroupListCmd.Command())
+
+	// Rename
+	authGroupRenameCmd := cmdAuthGroupRename{global: c.global}
+	cmd.AddCommand(authGroupRenameCmd.Command())
+
+	// Show
+	authGroupShowCmd := cmdAuthGroupShow{global: c.global}
+	cmd.AddCommand(authGroupShowCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Create.
+type cmdAuthGroupCreate struct {
+	global *cmdGlobal
+
+	flagDescription string
+}
+
+func (c *cmdAuthGroupCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("create", i18n.G("[<remote>:]<group>"))
+	cmd.Short = i18n.G("Create an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create an authorization group`))
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Group description")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupCreate) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	// Create the group
+	group := api.AuthGroupsPost{
+		Name: resource.name,
+	}
+
+	group.Description = c.flagDescription
+
+	err = resource.server.CreateAuthGroup(group)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Authorization group %s created")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Delete.
+type cmdAuthGroupDelete struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("delete", i18n.G("[<remote>:]<group>"))
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete an authorization group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupDelete) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	// Delete the group
+	err = resource.server.DeleteAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Authorization group %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Edit.
+type cmdAuthGroupEdit struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("edit", i18n.G("[<remote>:]<group>"))
+	cmd.Short = i18n.G("Edit an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit an authorization group`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc auth group edit <group> < group.yaml
+    Update an authorization group using the content of group.yaml`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the authorization group.
+### Any line starting with a '# will be ignored.
+###
+### A sample authorization group looks like:
+### name: operators
+### description: Operators of the production project
+### identities:
+### - oidc/jane@example.com
+### - tls/2bf1c4a4c4e1b8a5b4d1a6c5f1e4b3a2c1d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9
+### oidc_claims:
+### - groups=lxd-operators
+### permissions:
+### - entity_type: project
+###   url: /1.0/projects/prod
+###   entitlement: operator
+### config:
+###   limits.rate.read: 100/m
+###
+### Note that the name is shown but cannot be changed`)
+}
+
+func (c *cmdAuthGroupEdit) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.AuthGroupPut{}
+
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateAuthGroup(resource.name, newdata, "")
+	}
+
+	// Extract the current value
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(group)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.AuthGroupPut{}
+
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateAuthGroup(resource.name, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again or ctrl+c to abort change"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// List.
+type cmdAuthGroupList struct {
+	global *cmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdAuthGroupList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List the authorization groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List the authorization groups`))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	groups, err := resource.server.GetAuthGroups()
+	if err != nil {
+		return err
+	}
+
+	// Render the table
+	data := [][]string{}
+	for _, group := range groups {
+		line := []string{group.Name, group.Description, fmt.Sprintf("%d", len(group.Identities)), fmt.Sprintf("%d", len(group.Permissions))}
+		data = append(data, line)
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("IDENTITIES"),
+		i18n.G("PERMISSIONS"),
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, groups)
+}
+
+// Rename.
+type cmdAuthGroupRename struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupRename) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("rename", i18n.G("[<remote>:]<group> <new-name>"))
+	cmd.Aliases = []string{"mv"}
+	cmd.Short = i18n.G("Rename an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rename an authorization group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupRename) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	// Perform the rename
+	err = resource.server.RenameAuthGroup(resource.name, api.AuthGroupPost{Name: args[1]})
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Authorization group %s renamed to %s")+"\n", resource.name, args[1])
+	}
+
+	return nil
+}
+
+// Show.
+type cmdAuthGroupShow struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<group>"))
+	cmd.Short = i18n.G("Show authorization group configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show authorization group configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	// Show the group
+	group, _, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&group)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Identity.
+type cmdAuthGroupIdentity struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupIdentity) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("identity")
+	cmd.Short = i18n.G("Manage the identities of authorization groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage the identities of authorization groups
+
+Identities are the authentication method followed by a slash and the identifier,
+the certificate fingerprint for "tls" or the user name for "oidc".`))
+
+	// Add
+	authGroupIdentityAddCmd := cmdAuthGroupIdentityAdd{global: c.global}
+	cmd.AddCommand(authGroupIdentityAddCmd.Command())
+
+	// Remove
+	authGroupIdentityRemoveCmd := cmdAuthGroupIdentityRemove{global: c.global}
+	cmd.AddCommand(authGroupIdentityRemoveCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Add.
+type cmdAuthGroupIdentityAdd struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupIdentityAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("add", i18n.G("[<remote>:]<group> <method>/<identifier>"))
+	cmd.Short = i18n.G("Add an identity to an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Add an identity to an authorization group`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc auth group identity add operators oidc/jane@example.com
+    Add the OIDC user "jane@example.com" to the "operators" group.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupIdentityAdd) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if shared.StringInSlice(args[1], group.Identities) {
+		return fmt.Errorf(i18n.G("Identity %s is already in group %s"), args[1], resource.name)
+	}
+
+	group.Identities = append(group.Identities, args[1])
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
+
+// Remove.
+type cmdAuthGroupIdentityRemove struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupIdentityRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("remove", i18n.G("[<remote>:]<group> <method>/<identifier>"))
+	cmd.Short = i18n.G("Remove an identity from an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove an identity from an authorization group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupIdentityRemove) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !shared.StringInSlice(args[1], group.Identities) {
+		return fmt.Errorf(i18n.G("Identity %s isn't in group %s"), args[1], resource.name)
+	}
+
+	identities := []string{}
+	for _, identity := range group.Identities {
+		if identity == args[1] {
+			continue
+		}
+
+		identities = append(identities, identity)
+	}
+
+	group.Identities = identities
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
+
+// Claim.
+type cmdAuthGroupClaim struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupClaim) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("claim")
+	cmd.Short = i18n.G("Manage the OpenID Connect claims of authorization groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage the OpenID Connect claims of authorization groups
+
+OpenID Connect users whose access token contains one of the claims of a group
+are members of the group. Claims are the claim name followed by an equal sign
+and the value, which must be one of the elements of list claims like "groups".
+The "email_domain" claim matches the domain of the "email" claim.`))
+
+	// Add
+	authGroupClaimAddCmd := cmdAuthGroupClaimAdd{global: c.global}
+	cmd.AddCommand(authGroupClaimAddCmd.Command())
+
+	// Remove
+	authGroupClaimRemoveCmd := cmdAuthGroupClaimRemove{global: c.global}
+	cmd.AddCommand(authGroupClaimRemoveCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Add.
+type cmdAuthGroupClaimAdd struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupClaimAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("add", i18n.G("[<remote>:]<group> <claim>=<value>"))
+	cmd.Short = i18n.G("Add an OpenID Connect claim to an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Add an OpenID Connect claim to an authorization group`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc auth group claim add operators groups=lxd-operators
+    Make the members of the "lxd-operators" identity provider group members of the "operators" group.
+
+lxc auth group claim add viewers email_domain=example.com
+    Make all users with an email address at "example.com" members of the "viewers" group.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupClaimAdd) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if shared.StringInSlice(args[1], group.OIDCClaims) {
+		return fmt.Errorf(i18n.G("Claim %s is already in group %s"), args[1], resource.name)
+	}
+
+	group.OIDCClaims = append(group.OIDCClaims, args[1])
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
+
+// Remove.
+type cmdAuthGroupClaimRemove struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthGroupClaimRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("remove", i18n.G("[<remote>:]<group> <claim>=<value>"))
+	cmd.Short = i18n.G("Remove an OpenID Connect claim from an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove an OpenID Connect claim from an authorization group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthGroupClaimRemove) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !shared.StringInSlice(args[1], group.OIDCClaims) {
+		return fmt.Errorf(i18n.G("Claim %s isn't in group %s"), args[1], resource.name)
+	}
+
+	claims := []string{}
+	for _, claim := range group.OIDCClaims {
+		if claim == args[1] {
+			continue
+		}
+
+		claims = append(claims, claim)
+	}
+
+	group.OIDCClaims = claims
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
+
+// Token.
+type cmdAuthToken struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthToken) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("token")
+	cmd.Short = i18n.G("Manage API bearer tokens")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage API bearer tokens`))
+
+	// Create
+	authTokenCreateCmd := cmdAuthTokenCreate{global: c.global}
+	cmd.AddCommand(authTokenCreateCmd.Command())
+
+	// List
+	authTokenListCmd := cmdAuthTokenList{global: c.global}
+	cmd.AddCommand(authTokenListCmd.Command())
+
+	// Revoke
+	authTokenRevokeCmd := cmdAuthTokenRevoke{global: c.global}
+	cmd.AddCommand(authTokenRevokeCmd.Command())
+
+	// Show
+	authTokenShowCmd := cmdAuthTokenShow{global: c.global}
+	cmd.AddCommand(authTokenShowCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Create.
+type cmdAuthTokenCreate struct {
+	global *cmdGlobal
+
+	flagDescription string
+	flagExpiry      string
+	flagReadOnly    bool
+}
+
+func (c *cmdAuthTokenCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("create", i18n.G("[<remote>:]"))
+	cmd.Short = i18n.G("Create an API bearer token")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create an API bearer token
+
+The token is only displayed once, the server only keeps its fingerprint.
+When --project is passed, the token is restricted to that project.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc auth token create --project foo --read-only --expiry 30d
+    Create a read-only token restricted to project "foo" that expires in 30 days.`))
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Token description")+"``")
+	cmd.Flags().StringVar(&c.flagExpiry, "expiry", "", i18n.G("Token lifetime (e.g. 30d or 1y), never expires if unset")+"``")
+	cmd.Flags().BoolVar(&c.flagReadOnly, "read-only", false, i18n.G("Only allow read-only requests"))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthTokenCreate) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name != "" {
+		return fmt.Errorf(i18n.G("Invalid remote %q"), remote)
+	}
+
+	// Create the token
+	token := api.AuthTokensPost{
+		Description: c.flagDescription,
+		ReadOnly:    c.flagReadOnly,
+		Projects:    []string{},
+	}
+
+	if c.global.flagProject != "" {
+		token.Restricted = true
+		token.Projects = []string{c.global.flagProject}
+	}
+
+	token.ExpiresAt, err = shared.GetExpiry(time.Now(), c.flagExpiry)
+	if err != nil {
+		return err
+	}
+
+	secret, err := resource.server.CreateAuthToken(token)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("API bearer token %s created:")+"\n", secret.Fingerprint[:12])
+	}
+
+	fmt.Println(secret.Token)
+
+	return nil
+}
+
+// List.
+type cmdAuthTokenList struct {
+	global *cmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdAuthTokenList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List the API bearer tokens")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List the API bearer tokens`))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthTokenList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	tokens, err := resource.server.GetAuthTokens()
+	if err != nil {
+		return err
+	}
+
+	formatDate := func(date time.Time) string {
+		if date.IsZero() {
+			return ""
+		}
+
+		return date.Local().Format("2006/01/02 15:04 MST")
+	}
+
+	// Render the table
+	data := [][]string{}
+	for _, token := range tokens {
+		line := []string{
+			token.Fingerprint[:12],
+			token.Description,
+			strings.Join(token.Projects, "\n"),
+			fmt.Sprintf("%v", token.ReadOnly),
+			formatDate(token.ExpiresAt),
+			formatDate(token.LastUsedAt),
+		}
+
+		data = append(data, line)
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+
+	header := []string{
+		i18n.G("FINGERPRINT"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("PROJECTS"),
+		i18n.G("READ-ONLY"),
+		i18n.G("EXPIRES AT"),
+		i18n.G("LAST USED AT"),
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, tokens)
+}
+
+// Revoke.
+type cmdAuthTokenRevoke struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthTokenRevoke) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("revoke", i18n.G("[<remote>:]<fingerprint>"))
+	cmd.Aliases = []string{"delete", "rm"}
+	cmd.Short = i18n.G("Revoke an API bearer token")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Revoke an API bearer token`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthTokenRevoke) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing token fingerprint"))
+	}
+
+	// Revoke the token
+	err = resource.server.DeleteAuthToken(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("API bearer token %s revoked")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Show.
+type cmdAuthTokenShow struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthTokenShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<fingerprint>"))
+	cmd.Short = i18n.G("Show API bearer token details")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show API bearer token details`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthTokenShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing token fingerprint"))
+	}
+
+	// Show the token
+	token, err := resource.server.GetAuthToken(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Permission.
+type cmdAuthPermission struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthPermission) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("permission")
+	cmd.Short = i18n.G("Manage the permissions of authorization groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage the permissions of authorization groups
+
+Permissions grant an entitlement on the server, a project or an instance
+to the identities of a group. The entity name is omitted for the server.
+Instances are looked up in the current project.`))
+
+	// Add
+	authPermissionAddCmd := cmdAuthPermissionAdd{global: c.global}
+	cmd.AddCommand(authPermissionAddCmd.Command())
+
+	// Remove
+	authPermissionRemoveCmd := cmdAuthPermissionRemove{global: c.global}
+	cmd.AddCommand(authPermissionRemoveCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// parsePermission returns the permission described by the command arguments following the group name.
+func (c *cmdAuthPermission) parsePermission(resource remoteResource, args []string) (*api.AuthPermission, error) {
+	permission := api.AuthPermission{
+		EntityType:  args[0],
+		Entitlement: args[len(args)-1],
+	}
+
+	if permission.EntityType == api.AuthEntityTypeServer {
+		if len(args) != 2 {
+			return nil, fmt.Errorf(i18n.G("No entity name can be given for the server"))
+		}
+
+		permission.URL = api.NewURL().Path(version.APIVersion).String()
+
+		return &permission, nil
+	}
+
+	if len(args) != 3 {
+		return nil, fmt.Errorf(i18n.G("Missing entity name"))
+	}
+
+	switch permission.EntityType {
+	case api.AuthEntityTypeProject:
+		permission.URL = api.NewURL().Path(version.APIVersion, "projects", args[1]).String()
+	case api.AuthEntityTypeInstance:
+		info, err := resource.server.GetConnectionInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		permission.URL = api.NewURL().Path(version.APIVersion, "instances", args[1]).Project(info.Project).String()
+	default:
+		return nil, fmt.Errorf(i18n.G("Invalid entity type %q"), permission.EntityType)
+	}
+
+	return &permission, nil
+}
+
+// Add.
+type cmdAuthPermissionAdd struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthPermissionAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("add", i18n.G("[<remote>:]<group> <entity_type> [<entity_name>] <entitlement>"))
+	cmd.Short = i18n.G("Grant an entitlement to an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Grant an entitlement to an authorization group`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc auth permission add operators project prod operator
+    Grant the "operator" entitlement on the "prod" project to the "operators" group.
+
+lxc auth permission add support instance web can_exec --project prod
+    Grant the "can_exec" entitlement on the "web" instance of the "prod" project to the "support" group.
+
+lxc auth permission add auditors server viewer
+    Grant read access to the whole server to the "auditors" group.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthPermissionAdd) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 3, 4)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	parent := cmdAuthPermission{global: c.global}
+	permission, err := parent.parsePermission(resource, args[1:])
+	if err != nil {
+		return err
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range group.Permissions {
+		if p == *permission {
+			return fmt.Errorf(i18n.G("Group %s already has the permission"), resource.name)
+		}
+	}
+
+	group.Permissions = append(group.Permissions, *permission)
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
+
+// Remove.
+type cmdAuthPermissionRemove struct {
+	global *cmdGlobal
+}
+
+func (c *cmdAuthPermissionRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("remove", i18n.G("[<remote>:]<group> <entity_type> [<entity_name>] <entitlement>"))
+	cmd.Short = i18n.G("Revoke an entitlement from an authorization group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Revoke an entitlement from an authorization group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAuthPermissionRemove) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 3, 4)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing group name"))
+	}
+
+	parent := cmdAuthPermission{global: c.global}
+	permission, err := parent.parsePermission(resource, args[1:])
+	if err != nil {
+		return err
+	}
+
+	group, etag, err := resource.server.GetAuthGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	permissions := []api.AuthPermission{}
+	for _, p := range group.Permissions {
+		if p == *permission {
+			continue
+		}
+
+		permissions = append(permissions, p)
+	}
+
+	if len(permissions) == len(group.Permissions) {
+		return fmt.Errorf(i18n.G("Group %s doesn't have the permission"), resource.name)
+	}
+
+	group.Permissions = permissions
+
+	return resource.server.UpdateAuthGroup(resource.name, group.Writable(), etag)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/lxd/shared/api"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+)
+
+// configSourceLocal is the source of values set in the local configuration of an instance.
+const configSourceLocal = "local"
+
+// configValue is a value of the expanded configuration of an instance along with where it comes from.
+type configValue struct {
+	Value string `json:"value" yaml:"value"`
+
+	// Either "local" or the name of the profile providing the value.
+	Source string `json:"source" yaml:"source"`
+}
+
+// configDiffEntry is a key whose value differs between two instances.
+type configDiffEntry struct {
+	Key    string       `json:"key" yaml:"key"`
+	First  *configValue `json:"first" yaml:"first"`
+	Second *configValue `json:"second" yaml:"second"`
+}
+
+// configDriftEntry is a key whose local value on an instance differs from the value inherited from its profiles.
+type configDriftEntry struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+
+	// Value and name of the profile that would provide the key without the local value, if any.
+	// An empty value means that the key is only set by the profile.
+	ProfileValue string `json:"profile_value" yaml:"profile_value"`
+	Profile      string `json:"profile" yaml:"profile"`
+}
+
+// Diff.
+type cmdConfigDiff struct {
+	global *cmdGlobal
+	config *cmdConfig
+
+	flagFormat       string
+	flagProfileDrift bool
+}
+
+// Command creates a Cobra command to compare the expanded configuration of instances.
+func (c *cmdConfigDiff) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("diff", i18n.G("[<remote>:]<instance> [[<remote>:]<instance>]"))
+	cmd.Short = i18n.G("Compare the configuration of instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Compare the configuration of instances
+
+The expanded configuration and devices of two instances are compared, showing for each
+differing key whether its value is set locally on the instance or inherited from a profile.
+
+With --profile-drift, the keys that a single instance sets locally instead of inheriting
+them from its profiles are shown, along with the value the profiles would provide.
+
+Device keys are shown as devices.<name>.<key>. Volatile keys are ignored.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc config diff c1 c2
+    Show the configuration differences between instances "c1" and "c2".
+
+lxc config diff c1 --profile-drift
+    Show the configuration that instance "c1" overrides locally.`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.Flags().BoolVar(&c.flagProfileDrift, "profile-drift", false, i18n.G("Show the local overrides of profile values"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to compare the expanded configuration of instances.
+func (c *cmdConfigDiff) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	minArgs := 2
+	if c.flagProfileDrift {
+		minArgs = 1
+	}
+
+	exit, err := c.global.CheckArgs(cmd, args, minArgs, minArgs)
+	if exit {
+		return err
+	}
+
+	// Parse remotes
+	resources, err := c.global.ParseServers(args...)
+	if err != nil {
+		return err
+	}
+
+	insts := make([]*api.Instance, 0, len(resources))
+	values := make([]map[string]configValue, 0, len(resources))
+	profiles := make([][]api.Profile, 0, len(resources))
+	for _, resource := range resources {
+		if resource.name == "" {
+			return fmt.Errorf(i18n.G("Missing instance name"))
+		}
+
+		inst, _, err := resource.server.GetInstance(resource.name)
+		if err != nil {
+			return err
+		}
+
+		instProfiles := make([]api.Profile, 0, len(inst.Profiles))
+		for _, name := range inst.Profiles {
+			profile, _, err := resource.server.GetProfile(name)
+			if err != nil {
+				return err
+			}
+
+			instProfiles = append(instProfiles, *profile)
+		}
+
+		insts = append(insts, inst)
+		values = append(values, configValues(inst, instProfiles))
+		profiles = append(profiles, instProfiles)
+	}
+
+	if c.flagProfileDrift {
+		entries := configDrift(insts[0], profiles[0])
+
+		data := [][]string{}
+		for _, entry := range entries {
+			data = append(data, []string{entry.Key, entry.Value, entry.ProfileValue, entry.Profile})
+		}
+
+		header := []string{
+			i18n.G("KEY"),
+			i18n.G("LOCAL VALUE"),
+			i18n.G("PROFILE VALUE"),
+			i18n.G("PROFILE"),
+		}
+
+		return cli.RenderTable(c.flagFormat, header, data, entries)
+	}
+
+	entries := configDiff(values[0], values[1])
+
+	formatValue := func(value *configValue) string {
+		if value == nil {
+			return ""
+		}
+
+		if value.Source == configSourceLocal {
+			return fmt.Sprintf(i18n.G("%s (local)"), value.Value)
+		}
+
+		return fmt.Sprintf(i18n.G("%s (profile %s)"), value.Value, value.Source)
+	}
+
+	data := [][]string{}
+	for _, entry := range entries {
+		data = append(data, []string{entry.Key, formatValue(entry.First), formatValue(entry.Second)})
+	}
+
+	header := []string{
+		i18n.G("KEY"),
+		strings.ToUpper(resources[0].name),
+		strings.ToUpper(resources[1].name),
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, entries)
+}
+
+// configValues returns the non-volatile expanded configuration and device keys of an instance
+// along with their source, following the precedence used to expand the instance configuration:
+// local values override profile values, and later profiles override earlier ones.
+// Devices are inherited as a whole, so all the keys of a device share the same source.
+func configValues(inst *api.Instance, profiles []api.Profile) map[string]configValue {
+	values := map[string]configValue{}
+
+	for key, value := range inst.ExpandedConfig {
+		if strings.HasPrefix(key, "volatile.") {
+			continue
+		}
+
+		source := configSourceLocal
+		_, found := inst.Config[key]
+		if !found {
+			source, _ = configProfileValue(profiles, key)
+		}
+
+		values[key] = configValue{Value: value, Source: source}
+	}
+
+	for name, device := range inst.ExpandedDevices {
+		source := configSourceLocal
+		_, found := inst.Devices[name]
+		if !found {
+			source, _ = configProfileDevice(profiles, name)
+		}
+
+		for key, value := range device {
+			values[fmt.Sprintf("devices.%s.%s", name, key)] = configValue{Value: value, Source: source}
+		}
+	}
+
+	return values
+}
+
+// configProfileValue returns the name of the last profile setting the config key and its value there.
+func configProfileValue(profiles []api.Profile, key string) (string, string) {
+	for i := len(profiles) - 1; i >= 0; i-- {
+		value, found := profiles[i].Config[key]
+		if found {
+			return profiles[i].Name, value
+		}
+	}
+
+	return "", ""
+}
+
+// configProfileDevice returns the name of the last profile defining the device and the device there.
+func configProfileDevice(profiles []api.Profile, name string) (string, map[string]string) {
+	for i := len(profiles) - 1; i >= 0; i-- {
+		device, found := profiles[i].Devices[name]
+		if found {
+			return profiles[i].Name, device
+		}
+	}
+
+	return "", nil
+}
+
+// configDiff returns the keys whose value or source differs between two instances, sorted by key.
+func configDiff(first map[string]configValue, second map[string]configValue) []configDiffEntry {
+	entries := []configDiffEntry{}
+
+	for key, value := range first {
+		value := value
+
+		other, found := second[key]
+		if found && other == value {
+			continue
+		}
+
+		entry := configDiffEntry{Key: key, First: &value}
+		if found {
+			entry.Second = &other
+		}
+
+		entries = append(entries, entry)
+	}
+
+	for key, value := range second {
+		value := value
+
+		_, found := first[key]
+		if !found {
+			entries = append(entries, configDiffEntry{Key: key, Second: &value})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	return entries
+}
+
+// configDrift returns the non-volatile keys set locally on an instance that differ from what its profiles
+// provide, sorted by key. Local devices are compared key by key with the profile device they replace.
+func configDrift(inst *api.Instance, profiles []api.Profile) []configDriftEntry {
+	entries := []configDriftEntry{}
+
+	for key, value := range inst.Config {
+		if strings.HasPrefix(key, "volatile.") {
+			continue
+		}
+
+		profile, profileValue := configProfileValue(profiles, key)
+		if profile != "" && profileValue == value {
+			continue
+		}
+
+		entries = append(entries, configDriftEntry{Key: key, Value: value, ProfileValue: profileValue, Profile: profile})
+	}
+
+	for name, device := range inst.Devices {
+		profile, profileDevice := configProfileDevice(profiles, name)
+
+		for key, value := range device {
+			profileValue, found := profileDevice[key]
+			if found && profileValue == value {
+				continue
+			}
+
+			entries = append(entries, configDriftEntry{Key: fmt.Sprintf("devices.%s.%s", name, key), Value: value, ProfileValue: profileValue, Profile: profile})
+		}
+
+		// Keys of the profile device that the local device drops.
+		for key, profileValue := range profileDevice {
+			_, found := device[key]
+			if !found {
+				entries = append(entries, configDriftEntry{Key: fmt.Sprintf("devices.%s.%s", name, key), ProfileValue: profileValue, Profile: profile})
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	return entries
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+type configDiffTestSuite struct {
+	suite.Suite
+
+	profiles []api.Profile
+}
+
+func TestConfigDiffTestSuite(t *testing.T) {
+	suite.Run(t, new(configDiffTestSuite))
+}
+
+func (s *configDiffTestSuite) SetupTest() {
+	s.profiles = []api.Profile{
+		{
+			Name: "default",
+			ProfilePut: api.ProfilePut{
+				Config: map[string]string{"limits.cpu": "2", "limits.memory": "1GiB"},
+				Devices: map[string]map[string]string{
+					"eth0": {"type": "nic", "network": "lxdbr0"},
+					"root": {"type": "disk", "pool": "default", "path": "/"},
+				},
+			},
+		},
+		{
+			Name: "big",
+			ProfilePut: api.ProfilePut{
+				Config: map[string]string{"limits.cpu": "8"},
+			},
+		},
+	}
+}
+
+// instance returns an instance using the test profiles with the given local config and devices.
+func (s *configDiffTestSuite) instance(config map[string]string, devices map[string]map[string]string) *api.Instance {
+	inst := &api.Instance{
+		InstancePut: api.InstancePut{
+			Profiles: []string{"default", "big"},
+			Config:   config,
+			Devices:  devices,
+		},
+		ExpandedConfig:  map[string]string{"limits.cpu": "8", "limits.memory": "1GiB", "volatile.uuid": "x"},
+		ExpandedDevices: map[string]map[string]string{},
+	}
+
+	for k, v := range config {
+		inst.ExpandedConfig[k] = v
+	}
+
+	for name, device := range s.profiles[0].Devices {
+		inst.ExpandedDevices[name] = device
+	}
+
+	for name, device := range devices {
+		inst.ExpandedDevices[name] = device
+	}
+
+	return inst
+}
+
+func (s *configDiffTestSuite) TestConfigValues() {
+	inst := s.instance(map[string]string{"limits.memory": "2GiB"}, nil)
+
+	values := configValues(inst, s.profiles)
+
+	s.Equal(configValue{Value: "8", Source: "big"}, values["limits.cpu"])
+	s.Equal(configValue{Value: "2GiB", Source: configSourceLocal}, values["limits.memory"])
+	s.Equal(configValue{Value: "lxdbr0", Source: "default"}, values["devices.eth0.network"])
+	s.NotContains(values, "volatile.uuid")
+}
+
+func (s *configDiffTestSuite) TestConfigDiff() {
+	first := configValues(s.instance(map[string]string{"limits.memory": "2GiB"}, nil), s.profiles)
+	second := configValues(s.instance(map[string]string{"limits.cpu": "8", "user.foo": "bar"}, nil), s.profiles)
+
+	entries := configDiff(first, second)
+
+	s.Equal([]configDiffEntry{
+		{
+			Key:    "limits.cpu",
+			First:  &configValue{Value: "8", Source: "big"},
+			Second: &configValue{Value: "8", Source: configSourceLocal},
+		},
+		{
+			Key:    "limits.memory",
+			First:  &configValue{Value: "2GiB", Source: configSourceLocal},
+			Second: &configValue{Value: "1GiB", Source: "default"},
+		},
+		{
+			Key:    "user.foo",
+			Second: &configValue{Value: "bar", Source: configSourceLocal},
+		},
+	}, entries)
+}
+
+func (s *configDiffTestSuite) TestConfigDrift() {
+	inst := s.instance(map[string]string{"limits.cpu": "8", "limits.memory": "2GiB", "volatile.uuid": "x"}, map[string]map[string]string{
+		"eth0": {"type": "nic", "network": "lxdbr1"},
+		"data": {"type": "disk", "source": "/data", "path": "/data"},
+		"root": {"type": "disk", "path": "/"},
+	})
+
+	entries := configDrift(inst, s.profiles)
+
+	s.Equal([]configDriftEntry{
+		{Key: "devices.data.path", Value: "/data"},
+		{Key: "devices.data.source", Value: "/data"},
+		{Key: "devices.data.type", Value: "disk"},
+		{Key: "devices.eth0.network", Value: "lxdbr1", ProfileValue: "lxdbr0", Profile: "default"},
+		{Key: "devices.root.pool", ProfileValue: "default", Profile: "default"},
+		{Key: "limits.memory", Value: "2GiB", ProfileValue: "1GiB", Profile: "default"},
+	}, entries)
+}
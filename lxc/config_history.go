@@ -0,0 +1,377 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/canonical/lxd/shared/api"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+)
+
+// History.
+type cmdConfigHistory struct {
+	global *cmdGlobal
+	config *cmdConfig
+
+	flagFormat string
+}
+
+// Command creates a Cobra command to list the configuration revisions of an instance.
+func (c *cmdConfigHistory) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("history", i18n.G("[<remote>:]<instance> [<revision>]"))
+	cmd.Short = i18n.G("Show the configuration history of instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show the configuration history of instances
+
+Every change of the configuration, devices or profiles of an instance is recorded as a new revision.
+Without a revision, the list of revisions is shown. Otherwise the given revision is shown as YAML.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc config history c1
+    Show the configuration changes of instance "c1".
+
+lxc config history c1 3
+    Show revision 3 of the configuration of instance "c1".`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to show the configuration history of an instance.
+func (c *cmdConfigHistory) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	if len(args) > 1 {
+		revision, err := parseConfigRevision(args[1])
+		if err != nil {
+			return err
+		}
+
+		configRevision, err := resource.server.GetInstanceConfigRevision(resource.name, revision)
+		if err != nil {
+			return err
+		}
+
+		return showConfigRevision(configRevision)
+	}
+
+	revisions, err := resource.server.GetInstanceConfigRevisions(resource.name)
+	if err != nil {
+		return err
+	}
+
+	return renderConfigRevisions(c.flagFormat, revisions)
+}
+
+// Rollback.
+type cmdConfigRollback struct {
+	global *cmdGlobal
+	config *cmdConfig
+}
+
+// Command creates a Cobra command to restore a configuration revision of an instance.
+func (c *cmdConfigRollback) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("rollback", i18n.G("[<remote>:]<instance> <revision>"))
+	cmd.Short = i18n.G("Roll back the configuration of instances to a previous revision")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Roll back the configuration of instances to a previous revision
+
+The configuration, devices and profiles of the instance are restored from the revision,
+which is recorded as a new revision. Volatile keys are kept as they are.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc config rollback c1 3
+    Restore the configuration of instance "c1" from revision 3.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to restore a configuration revision of an instance.
+func (c *cmdConfigRollback) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	revision, err := parseConfigRevision(args[1])
+	if err != nil {
+		return err
+	}
+
+	configRevision, err := resource.server.GetInstanceConfigRevision(resource.name, revision)
+	if err != nil {
+		return err
+	}
+
+	inst, etag, err := resource.server.GetInstance(resource.name)
+	if err != nil {
+		return err
+	}
+
+	writable := inst.Writable()
+
+	// Keep the current volatile keys, which aren't part of the history.
+	config := map[string]string{}
+	for k, v := range writable.Config {
+		if strings.HasPrefix(k, "volatile.") {
+			config[k] = v
+		}
+	}
+
+	for k, v := range configRevision.Config {
+		config[k] = v
+	}
+
+	writable.Config = config
+	writable.Devices = configRevision.Devices
+	writable.Profiles = configRevision.Profiles
+
+	if writable.Devices == nil {
+		writable.Devices = map[string]map[string]string{}
+	}
+
+	op, err := resource.server.UpdateInstance(resource.name, writable, etag)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// History.
+type cmdProfileHistory struct {
+	global  *cmdGlobal
+	profile *cmdProfile
+
+	flagFormat string
+}
+
+// Command creates a Cobra command to list the configuration revisions of a profile.
+func (c *cmdProfileHistory) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("history", i18n.G("[<remote>:]<profile> [<revision>]"))
+	cmd.Short = i18n.G("Show the configuration history of profiles")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show the configuration history of profiles
+
+Every change of the configuration or devices of a profile is recorded as a new revision.
+Without a revision, the list of revisions is shown. Otherwise the given revision is shown as YAML.`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to show the configuration history of a profile.
+func (c *cmdProfileHistory) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing profile name"))
+	}
+
+	if len(args) > 1 {
+		revision, err := parseConfigRevision(args[1])
+		if err != nil {
+			return err
+		}
+
+		configRevision, err := resource.server.GetProfileConfigRevision(resource.name, revision)
+		if err != nil {
+			return err
+		}
+
+		return showConfigRevision(configRevision)
+	}
+
+	revisions, err := resource.server.GetProfileConfigRevisions(resource.name)
+	if err != nil {
+		return err
+	}
+
+	return renderConfigRevisions(c.flagFormat, revisions)
+}
+
+// Rollback.
+type cmdProfileRollback struct {
+	global  *cmdGlobal
+	profile *cmdProfile
+}
+
+// Command creates a Cobra command to restore a configuration revision of a profile.
+func (c *cmdProfileRollback) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("rollback", i18n.G("[<remote>:]<profile> <revision>"))
+	cmd.Short = i18n.G("Roll back the configuration of profiles to a previous revision")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Roll back the configuration of profiles to a previous revision
+
+The configuration and devices of the profile are restored from the revision,
+which is recorded as a new revision.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to restore a configuration revision of a profile.
+func (c *cmdProfileRollback) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing profile name"))
+	}
+
+	revision, err := parseConfigRevision(args[1])
+	if err != nil {
+		return err
+	}
+
+	configRevision, err := resource.server.GetProfileConfigRevision(resource.name, revision)
+	if err != nil {
+		return err
+	}
+
+	profile, etag, err := resource.server.GetProfile(resource.name)
+	if err != nil {
+		return err
+	}
+
+	writable := profile.Writable()
+	writable.Config = configRevision.Config
+	writable.Devices = configRevision.Devices
+
+	if writable.Config == nil {
+		writable.Config = map[string]string{}
+	}
+
+	if writable.Devices == nil {
+		writable.Devices = map[string]map[string]string{}
+	}
+
+	return resource.server.UpdateProfile(resource.name, writable, etag)
+}
+
+// parseConfigRevision parses a configuration revision number.
+func parseConfigRevision(value string) (int64, error) {
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 1 {
+		return -1, fmt.Errorf(i18n.G("Invalid revision %q"), value)
+	}
+
+	return revision, nil
+}
+
+// showConfigRevision prints a configuration revision as YAML.
+func showConfigRevision(revision *api.ConfigRevision) error {
+	data, err := yaml.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// renderConfigRevisions renders a list of configuration revisions in the given format.
+func renderConfigRevisions(format string, revisions []api.ConfigRevision) error {
+	data := [][]string{}
+	for _, revision := range revisions {
+		user := revision.Username
+		if user != "" && revision.Protocol != "" {
+			user = fmt.Sprintf("%s (%s)", user, revision.Protocol)
+		}
+
+		changes := make([]string, 0, len(revision.Changes))
+		for _, change := range revision.Changes {
+			changes = append(changes, formatConfigChange(change))
+		}
+
+		data = append(data, []string{strconv.FormatInt(revision.Revision, 10), revision.CreatedAt.UTC().Format("2006/01/02 15:04 UTC"), user, strings.Join(changes, "\n")})
+	}
+
+	header := []string{
+		i18n.G("REVISION"),
+		i18n.G("DATE"),
+		i18n.G("USER"),
+		i18n.G("CHANGES"),
+	}
+
+	return cli.RenderTable(format, header, data, revisions)
+}
+
+// formatConfigChange returns a short description of a configuration change.
+func formatConfigChange(change api.ConfigChange) string {
+	switch {
+	case change.Old == "":
+		return fmt.Sprintf("+%s=%s", change.Key, change.New)
+	case change.New == "":
+		return fmt.Sprintf("-%s=%s", change.Key, change.Old)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", change.Key, change.Old, change.New)
+	}
+}
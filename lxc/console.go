@@ -25,8 +25,9 @@ import (
 type cmdConsole struct {
 	global *cmdGlobal
 
-	flagShowLog bool
-	flagType    string
+	flagShowLog  bool
+	flagType     string
+	flagReadOnly bool
 }
 
 func (c *cmdConsole) Command() *cobra.Command {
@@ -37,11 +38,15 @@ func (c *cmdConsole) Command() *cobra.Command {
 		`Attach to instance consoles
 
 This command allows you to interact with the boot console of an instance
-as well as retrieve past log entries from it.`))
+as well as retrieve past log entries from it.
+
+Several clients can be attached to the console at the same time.
+With --read-only, the console is only viewed and no input is sent to it.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagShowLog, "show-log", false, i18n.G("Retrieve the instance's console log"))
 	cmd.Flags().StringVarP(&c.flagType, "type", "t", "console", i18n.G("Type of connection to establish: 'console' for serial console, 'vga' for SPICE graphical output")+"``")
+	cmd.Flags().BoolVar(&c.flagReadOnly, "read-only", false, i18n.G("Only view the console, without sending any input"))
 
 	return cmd
 }
@@ -119,6 +124,10 @@ func (c *cmdConsole) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagReadOnly && c.flagType != "console" {
+		return fmt.Errorf(i18n.G("The --read-only flag is only supported by the 'console' output type"))
+	}
+
 	// Show the current log if requested
 	if c.flagShowLog {
 		if c.flagType != "console" {
@@ -177,11 +186,19 @@ func (c *cmdConsole) console(d lxd.InstanceServer, name string) error {
 		return err
 	}
 
+	// Read-only clients don't resize the shared console.
+	if c.flagReadOnly {
+		handler = func(control *websocket.Conn) {}
+		width = 0
+		height = 0
+	}
+
 	// Prepare the remote console
 	req := api.InstanceConsolePost{
-		Width:  width,
-		Height: height,
-		Type:   "console",
+		Width:    width,
+		Height:   height,
+		Type:     "console",
+		ReadOnly: c.flagReadOnly,
 	}
 
 	consoleDisconnect := make(chan bool)
@@ -30,6 +30,7 @@ type cmdCopy struct {
 	flagTargetProject     string
 	flagRefresh           bool
 	flagAllowInconsistent bool
+	flagInstanceType      string
 }
 
 func (c *cmdCopy) Command() *cobra.Command {
@@ -62,6 +63,7 @@ The pull transfer mode is the default as it is compatible with all LXD versions.
 	cmd.Flags().BoolVar(&c.flagNoProfiles, "no-profiles", false, i18n.G("Create the instance with no profiles applied"))
 	cmd.Flags().BoolVar(&c.flagRefresh, "refresh", false, i18n.G("Perform an incremental copy"))
 	cmd.Flags().BoolVar(&c.flagAllowInconsistent, "allow-inconsistent", false, i18n.G("Ignore copy errors for volatile files"))
+	cmd.Flags().StringVar(&c.flagInstanceType, "instance-type", "", i18n.G("Convert the instance to this type (container or virtual-machine)")+"``")
 
 	return cmd
 }
@@ -94,6 +96,22 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 		return fmt.Errorf(i18n.G("--no-profiles cannot be used with --refresh"))
 	}
 
+	// Parse the instance type to convert to.
+	var instanceType api.InstanceType
+	switch c.flagInstanceType {
+	case "":
+	case "container":
+		instanceType = api.InstanceTypeContainer
+	case "virtual-machine", "vm":
+		instanceType = api.InstanceTypeVM
+	default:
+		return fmt.Errorf(i18n.G("Invalid instance type %q, must be one of container or virtual-machine"), c.flagInstanceType)
+	}
+
+	if instanceType != "" && c.flagRefresh {
+		return fmt.Errorf(i18n.G("--instance-type cannot be used with --refresh"))
+	}
+
 	// If no destination name was provided, use the same as the source
 	if destName == "" && destResource != "" {
 		destName = sourceName
@@ -153,6 +171,10 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			return fmt.Errorf(i18n.G("--instance-only can't be passed when the source is a snapshot"))
 		}
 
+		if instanceType != "" {
+			return fmt.Errorf(i18n.G("--instance-type can only be used with instances"))
+		}
+
 		// Prepare the instance creation request
 		args := lxd.InstanceSnapshotCopyArgs{
 			Name: destName,
@@ -248,6 +270,7 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			Mode:              mode,
 			Refresh:           c.flagRefresh,
 			AllowInconsistent: c.flagAllowInconsistent,
+			Type:              instanceType,
 		}
 
 		// Copy of an instance into a new instance
@@ -352,6 +375,20 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 
 	progress.Done("")
 
+	// Report the settings that were dropped by the instance type conversion.
+	if instanceType != "" && !c.global.flagQuiet {
+		opAPI, err := op.GetTarget()
+		if err == nil && opAPI.Metadata != nil {
+			incompatible, _ := opAPI.Metadata["incompatible"].([]any)
+			if len(incompatible) > 0 {
+				fmt.Println(i18n.G("The following settings aren't supported by the new instance type and were dropped:"))
+				for _, entry := range incompatible {
+					fmt.Printf(" - %v\n", entry)
+				}
+			}
+		}
+	}
+
 	if c.flagRefresh {
 		inst, etag, err := dest.GetInstance(destName)
 		if err != nil {
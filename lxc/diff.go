@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+)
+
+type cmdDiff struct {
+	global *cmdGlobal
+
+	flagFormat string
+}
+
+// Command creates a Cobra command to show the file changes of an instance.
+func (c *cmdDiff) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("diff", i18n.G("[<remote>:]<instance>"))
+	cmd.Short = i18n.G("Show the file changes of instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show the file changes of instances
+
+The root filesystem of the container is compared with the image it was created from,
+showing the files that were added, changed or removed since.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc diff c1
+    Show the files of container "c1" that differ from its image.`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run executes the command to show the file changes of an instance.
+func (c *cmdDiff) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	changes, err := resource.server.GetInstanceDiff(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, change := range changes {
+		data = append(data, []string{change.Change, change.Path})
+	}
+
+	header := []string{
+		i18n.G("CHANGE"),
+		i18n.G("PATH"),
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, changes)
+}
@@ -30,6 +30,9 @@ type cmdExec struct {
 	flagUser                uint32
 	flagGroup               uint32
 	flagCwd                 string
+	flagDetach              bool
+	flagAttach              string
+	flagSessions            bool
 
 	interactive bool
 }
@@ -37,6 +40,15 @@ type cmdExec struct {
 func (c *cmdExec) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = usage("exec", i18n.G("[<remote>:]<instance> [flags] [--] <command line>"))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc exec c1 --detach -- make -j4
+    Run "make -j4" in a detached session and print its ID.
+
+lxc exec c1 --sessions
+    List the detached sessions of instance c1.
+
+lxc exec c1 --attach <session>
+    Attach to a detached session, press <ctrl>+a q to detach again.`))
 	cmd.Short = i18n.G("Execute commands in instances")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Execute commands in instances
@@ -48,7 +60,10 @@ executable, passing the shell commands as arguments, for example:
 
   lxc exec <instance> -- sh -c "cd /tmp && pwd"
 
-Mode defaults to non-interactive, interactive mode is selected if both stdin AND stdout are terminals (stderr is ignored).`))
+Mode defaults to non-interactive, interactive mode is selected if both stdin AND stdout are terminals (stderr is ignored).
+
+Commands run with --detach keep running in a session when the client goes away.
+Their output is recorded and they can later be attached to with --attach.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().StringArrayVar(&c.flagEnvironment, "env", nil, i18n.G("Environment variable to set (e.g. HOME=/home/foo)")+"``")
@@ -59,6 +74,9 @@ Mode defaults to non-interactive, interactive mode is selected if both stdin AND
 	cmd.Flags().Uint32Var(&c.flagUser, "user", 0, i18n.G("User ID to run the command as (default 0)")+"``")
 	cmd.Flags().Uint32Var(&c.flagGroup, "group", 0, i18n.G("Group ID to run the command as (default 0)")+"``")
 	cmd.Flags().StringVar(&c.flagCwd, "cwd", "", i18n.G("Directory to run the command in (default /root)")+"``")
+	cmd.Flags().BoolVar(&c.flagDetach, "detach", false, i18n.G("Run the command in a detached session and print its ID"))
+	cmd.Flags().StringVar(&c.flagAttach, "attach", "", i18n.G("Attach to a detached session")+"``")
+	cmd.Flags().BoolVar(&c.flagSessions, "sessions", false, i18n.G("List the detached sessions of the instance"))
 
 	return cmd
 }
@@ -84,11 +102,20 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 	conf := c.global.conf
 
 	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	minArgs, maxArgs := 2, -1
+	if c.flagAttach != "" || c.flagSessions {
+		minArgs, maxArgs = 1, 1
+	}
+
+	exit, err := c.global.CheckArgs(cmd, args, minArgs, maxArgs)
 	if exit {
 		return err
 	}
 
+	if c.flagDetach && (c.flagAttach != "" || c.flagSessions) || c.flagAttach != "" && c.flagSessions {
+		return fmt.Errorf(i18n.G("Only one of --detach, --attach and --sessions can be used"))
+	}
+
 	if c.flagForceInteractive && c.flagForceNonInteractive {
 		return fmt.Errorf(i18n.G("You can't pass -t and -T at the same time"))
 	}
@@ -108,6 +135,14 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagSessions {
+		return c.listSessions(d, name)
+	}
+
+	if c.flagAttach != "" {
+		return c.attach(d, name, c.flagAttach)
+	}
+
 	// Set the environment
 	env := map[string]string{}
 	myTerm, ok := c.getTERM()
@@ -145,7 +180,7 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 
 	// Record terminal state
 	var oldttystate *termios.State
-	if c.interactive && stdinTerminal {
+	if c.interactive && stdinTerminal && !c.flagDetach {
 		oldttystate, err = termios.MakeRaw(stdinFd)
 		if err != nil {
 			return err
@@ -187,6 +222,19 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 		Cwd:         c.flagCwd,
 	}
 
+	if c.flagDetach {
+		req.WaitForWS = false
+		req.Detached = true
+
+		op, err := d.ExecInstance(name, req, nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(op.Get().ID)
+		return nil
+	}
+
 	execArgs := lxd.InstanceExecArgs{
 		Stdin:    stdin,
 		Stdout:   stdout,
@@ -220,3 +268,111 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// listSessions lists the detached exec sessions of the instance.
+func (c *cmdExec) listSessions(d lxd.InstanceServer, name string) error {
+	sessions, err := d.GetInstanceExecSessions(name)
+	if err != nil {
+		return err
+	}
+
+	const layout = "2006/01/02 15:04 MST"
+
+	data := [][]string{}
+	for _, session := range sessions {
+		exitCode := ""
+		if session.Status != "Running" {
+			exitCode = strconv.Itoa(session.ExitCode)
+		}
+
+		data = append(data, []string{session.ID, strings.Join(session.Command, " "), session.Status, exitCode, session.CreatedAt.Local().Format(layout)})
+	}
+
+	header := []string{
+		i18n.G("ID"),
+		i18n.G("COMMAND"),
+		i18n.G("STATUS"),
+		i18n.G("EXIT CODE"),
+		i18n.G("CREATED AT"),
+	}
+
+	return cli.RenderTable(cli.TableFormatTable, header, data, sessions)
+}
+
+// attach attaches the terminal to a detached exec session until detached or the command exits.
+func (c *cmdExec) attach(d lxd.InstanceServer, name string, id string) error {
+	session, err := d.GetInstanceExecSession(name, id)
+	if err != nil {
+		return err
+	}
+
+	c.interactive = session.Interactive
+
+	// Configure the terminal
+	stdinFd := getStdinFd()
+	if c.interactive && termios.IsTerminal(stdinFd) {
+		oldttystate, err := termios.MakeRaw(stdinFd)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = termios.Restore(stdinFd, oldttystate) }()
+	}
+
+	var width, height int
+	if termios.IsTerminal(getStdoutFd()) {
+		width, height, err = termios.GetSize(getStdoutFd())
+		if err != nil {
+			return err
+		}
+	}
+
+	consoleDisconnect := make(chan bool)
+	manualDisconnect := make(chan struct{})
+
+	sendDisconnect := make(chan struct{})
+	defer close(sendDisconnect)
+
+	// Window size changes are handled the same way as for the console.
+	console := cmdConsole{global: c.global}
+
+	attachArgs := lxd.InstanceConsoleArgs{
+		Terminal:          &readWriteCloser{stdinMirror{os.Stdin, manualDisconnect, new(bool)}, getStdout()},
+		Control:           console.controlSocketHandler,
+		ConsoleDisconnect: consoleDisconnect,
+	}
+
+	go func() {
+		select {
+		case <-sendDisconnect:
+		case <-manualDisconnect:
+		}
+
+		close(consoleDisconnect)
+	}()
+
+	if session.Status == "Running" && c.interactive {
+		fmt.Fprintf(os.Stderr, i18n.G("To detach from the session, press: <ctrl>+a q")+"\n\r")
+	}
+
+	op, err := d.AttachInstanceExecSession(name, id, api.InstanceExecSessionAttachPost{Width: width, Height: height}, &attachArgs)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the operation to complete
+	err = op.Wait()
+	opAPI := op.Get()
+	if opAPI.Metadata != nil {
+		exitStatusRaw, ok := opAPI.Metadata["return"].(float64)
+		if ok {
+			c.global.ret = int(exitStatusRaw)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/canonical/lxd/shared/i18n"
 	"github.com/canonical/lxd/shared/ioprogress"
 	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/tarstream"
 	"github.com/canonical/lxd/shared/termios"
 	"github.com/canonical/lxd/shared/units"
 )
@@ -98,6 +99,14 @@ func (c *cmdFile) Command() *cobra.Command {
 	fileMountCmd := cmdFileMount{global: c.global, file: c}
 	cmd.AddCommand(fileMountCmd.Command())
 
+	// Sync
+	fileSyncCmd := cmdFileSync{global: c.global, file: c}
+	cmd.AddCommand(fileSyncCmd.Command())
+
+	// Watch
+	fileWatchCmd := cmdFileWatch{global: c.global, file: c}
+	cmd.AddCommand(fileWatchCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -322,7 +331,12 @@ func (c *cmdFilePull) Run(cmd *cobra.Command, args []string) error {
 					targetIsDir = true
 				}
 
-				err := c.file.recursivePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				if resource.server.HasExtension("instance_file_archive") {
+					err = c.file.archivePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				} else {
+					err = c.file.recursivePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				}
+
 				if err != nil {
 					return err
 				}
@@ -561,7 +575,12 @@ func (c *cmdFilePush) Run(cmd *cobra.Command, args []string) error {
 
 		// Transfer the files
 		for _, fname := range sourcefilenames {
-			err := c.file.recursivePushFile(resource.server, resource.name, fname, targetPath)
+			if resource.server.HasExtension("instance_file_archive") && shared.IsDir(fname) {
+				err = c.file.archivePushFile(resource.server, resource.name, fname, targetPath)
+			} else {
+				err = c.file.recursivePushFile(resource.server, resource.name, fname, targetPath)
+			}
+
 			if err != nil {
 				return err
 			}
@@ -893,6 +912,88 @@ func (c *cmdFile) recursivePushFile(d lxd.InstanceServer, inst string, source st
 	return filepath.Walk(source, sendFile)
 }
 
+func (c *cmdFile) archivePullFile(d lxd.InstanceServer, inst string, p string, targetDir string) error {
+	target := filepath.Join(targetDir, filepath.Base(p))
+	logger.Infof("Pulling %s from %s (tar)", target, p)
+
+	buf, err := d.GetInstanceFileArchive(inst, p)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = buf.Close() }()
+
+	progress := cli.ProgressRenderer{
+		Format: fmt.Sprintf(i18n.G("Pulling %s from %s: %%s"), p, target),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	reader := &ioprogress.ProgressReader{
+		ReadCloser: buf,
+		Tracker: &ioprogress.ProgressTracker{
+			Handler: func(bytesReceived int64, speed int64) {
+				progress.UpdateProgress(ioprogress.ProgressData{
+					Text: fmt.Sprintf("%s (%s/s)",
+						units.GetByteSizeString(bytesReceived, 2),
+						units.GetByteSizeString(speed, 2))})
+			},
+		},
+	}
+
+	err = tarstream.Unpack(reader, target)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+	return nil
+}
+
+func (c *cmdFile) archivePushFile(d lxd.InstanceServer, inst string, source string, target string) error {
+	source = filepath.Clean(source)
+	targetPath := path.Join(target, filepath.Base(source))
+	logger.Infof("Pushing %s to %s (tar)", source, targetPath)
+
+	contentLength, err := tarstream.Size(source)
+	if err != nil {
+		return err
+	}
+
+	progress := cli.ProgressRenderer{
+		Format: fmt.Sprintf(i18n.G("Pushing %s to %s: %%s"), source, targetPath),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	// Stream the directory tree without buffering it.
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		_ = pipeWriter.CloseWithError(tarstream.Pack(pipeWriter, source))
+	}()
+
+	reader := &ioprogress.ProgressReader{
+		ReadCloser: pipeReader,
+		Tracker: &ioprogress.ProgressTracker{
+			Length: contentLength,
+			Handler: func(percent int64, speed int64) {
+				progress.UpdateProgress(ioprogress.ProgressData{
+					Text: fmt.Sprintf("%d%% (%s/s)", percent,
+						units.GetByteSizeString(speed, 2))})
+			},
+		},
+	}
+
+	err = d.CreateInstanceFileArchive(inst, targetPath, reader)
+	_ = pipeReader.Close()
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+	return nil
+}
+
 func (c *cmdFile) recursiveMkdir(d lxd.InstanceServer, inst string, p string, mode *os.FileMode, uid int64, gid int64) error {
 	/* special case, every instance has a /, we don't need to do anything */
 	if p == "/" {
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/lxd/shared"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+	"github.com/canonical/lxd/shared/ioprogress"
+	"github.com/canonical/lxd/shared/units"
+)
+
+// Sync.
+type cmdFileSync struct {
+	global *cmdGlobal
+	file   *cmdFile
+
+	flagChecksum bool
+	flagDelete   bool
+	flagDryRun   bool
+}
+
+func (c *cmdFileSync) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("sync", i18n.G("<source path> [<remote>:]<instance>/<path>"))
+	cmd.Short = i18n.G("Synchronize a local directory into instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Synchronize a local directory into instances
+
+The content of the local directory is copied into the target directory, which is created if missing.
+Only new files and files whose size or modification time differ are transferred,
+use --checksum to compare the file content instead.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc file sync ./src foo/srv/app
+   To copy the content of the local src directory into /srv/app in the instance "foo".
+
+lxc file sync --delete ./src foo/srv/app
+   To also delete the files from /srv/app which aren't in the local src directory.`))
+
+	cmd.Flags().BoolVarP(&c.flagChecksum, "checksum", "c", false, i18n.G("Compare files by checksum instead of size and modification time"))
+	cmd.Flags().BoolVar(&c.flagDelete, "delete", false, i18n.G("Delete files from the target that don't exist in the source"))
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, i18n.G("Only show what would be transferred or deleted"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// fileSyncAction describes a single change needed to synchronize a target path.
+type fileSyncAction struct {
+	kind string // One of "mkdir", "push", "symlink", "chmod" or "delete".
+	path string // Path relative to the root of the synchronization.
+	info os.FileInfo
+}
+
+func (c *cmdFileSync) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	source := shared.HostPathFollow(filepath.Clean(args[0]))
+	if !shared.IsDir(source) {
+		return fmt.Errorf(i18n.G("Source %q must be a directory"), args[0])
+	}
+
+	// Parse the destination.
+	pathSpec := strings.SplitN(args[1], "/", 2)
+	if len(pathSpec) != 2 {
+		return fmt.Errorf(i18n.G("Invalid target %s"), args[1])
+	}
+
+	targetPath := path.Clean("/" + pathSpec[1])
+
+	resources, err := c.global.ParseServers(pathSpec[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	client, err := resource.server.GetInstanceFileSFTP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = client.Close() }()
+
+	// Index the target.
+	remote := map[string]os.FileInfo{}
+	targetInfo, err := client.Lstat(targetPath)
+	if err == nil {
+		// Never synchronize through a symlink.
+		if !targetInfo.IsDir() {
+			return fmt.Errorf(i18n.G("Target %q isn't a directory"), targetPath)
+		}
+
+		walker := client.Walk(targetPath)
+		for walker.Step() {
+			err := walker.Err()
+			if err != nil {
+				return err
+			}
+
+			rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), targetPath), "/")
+			if rel == "" {
+				continue
+			}
+
+			remote[rel] = walker.Stat()
+		}
+	} else if !c.flagDryRun {
+		err = client.MkdirAll(targetPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Figure out what needs to change.
+	same := func(rel string, info os.FileInfo) (bool, error) {
+		localPath := filepath.Join(source, filepath.FromSlash(rel))
+		remotePath := path.Join(targetPath, rel)
+
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			localTarget, err := os.Readlink(localPath)
+			if err != nil {
+				return false, err
+			}
+
+			remoteTarget, err := client.ReadLink(remotePath)
+			if err != nil {
+				return false, err
+			}
+
+			return localTarget == remoteTarget, nil
+		}
+
+		if !c.flagChecksum {
+			return true, nil
+		}
+
+		return c.sameChecksum(client, localPath, remotePath)
+	}
+
+	actions, err := fileSyncPlan(source, remote, c.flagChecksum, c.flagDelete, same)
+	if err != nil {
+		return err
+	}
+
+	if c.flagDryRun {
+		for _, action := range actions {
+			fmt.Printf("%s %s\n", action.kind, path.Join(targetPath, action.path))
+		}
+
+		return nil
+	}
+
+	// Apply the changes.
+	var totalSize int64
+	for _, action := range actions {
+		if action.kind == "push" {
+			totalSize += action.info.Size()
+		}
+	}
+
+	progress := cli.ProgressRenderer{
+		Format: fmt.Sprintf(i18n.G("Syncing %s to %s: %%s"), args[0], args[1]),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	tracker := &ioprogress.ProgressTracker{
+		Length: totalSize,
+		Handler: func(percent int64, speed int64) {
+			progress.UpdateProgress(ioprogress.ProgressData{
+				Text: fmt.Sprintf("%d%% (%s/s)", percent,
+					units.GetByteSizeString(speed, 2))})
+		},
+	}
+
+	for _, action := range actions {
+		err = c.apply(client, source, targetPath, action, tracker)
+		if err != nil {
+			progress.Done("")
+			return fmt.Errorf(i18n.G("Failed to %s %q: %w"), action.kind, path.Join(targetPath, action.path), err)
+		}
+	}
+
+	progress.Done("")
+	return nil
+}
+
+// fileSyncPlan compares the local directory tree at source with the remote entries and returns the actions
+// needed to make the remote match. The same function is used to compare symlinks as well as regular files
+// whose size and modification time (or only size when compareContent is set) match.
+func fileSyncPlan(source string, remote map[string]os.FileInfo, compareContent bool, deleteExtra bool, same func(rel string, info os.FileInfo) (bool, error)) ([]fileSyncAction, error) {
+	actions := []fileSyncAction{}
+	seen := map[string]bool{}
+	replaced := map[string]bool{}
+
+	err := filepath.Walk(source, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		remoteInfo := remote[rel]
+
+		switch {
+		case info.IsDir():
+			if remoteInfo == nil || !remoteInfo.IsDir() {
+				if remoteInfo != nil {
+					replaced[rel] = true
+					actions = append(actions, fileSyncAction{kind: "delete", path: rel, info: remoteInfo})
+				}
+
+				actions = append(actions, fileSyncAction{kind: "mkdir", path: rel, info: info})
+			} else if remoteInfo.Mode().Perm() != info.Mode().Perm() {
+				actions = append(actions, fileSyncAction{kind: "chmod", path: rel, info: info})
+			}
+
+		case info.Mode()&os.ModeSymlink == os.ModeSymlink:
+			if remoteInfo != nil && remoteInfo.Mode()&os.ModeSymlink == os.ModeSymlink {
+				isSame, err := same(rel, info)
+				if err != nil {
+					return err
+				}
+
+				if isSame {
+					return nil
+				}
+			}
+
+			if remoteInfo != nil {
+				replaced[rel] = true
+				actions = append(actions, fileSyncAction{kind: "delete", path: rel, info: remoteInfo})
+			}
+
+			actions = append(actions, fileSyncAction{kind: "symlink", path: rel, info: info})
+
+		case info.Mode().IsRegular():
+			changed := remoteInfo == nil || !remoteInfo.Mode().IsRegular() || remoteInfo.Size() != info.Size()
+			if !changed && !compareContent {
+				// SFTP only carries modification times with a one second precision.
+				changed = remoteInfo.ModTime().Unix() != info.ModTime().Unix()
+			}
+
+			if !changed {
+				isSame, err := same(rel, info)
+				if err != nil {
+					return err
+				}
+
+				changed = !isSame
+			}
+
+			if changed {
+				// Anything but a regular file (including symlinks) is replaced rather than written through.
+				if remoteInfo != nil && !remoteInfo.Mode().IsRegular() {
+					replaced[rel] = true
+					actions = append(actions, fileSyncAction{kind: "delete", path: rel, info: remoteInfo})
+				}
+
+				actions = append(actions, fileSyncAction{kind: "push", path: rel, info: info})
+			} else if remoteInfo.Mode().Perm() != info.Mode().Perm() {
+				actions = append(actions, fileSyncAction{kind: "chmod", path: rel, info: info})
+			}
+
+		default:
+			// Devices, sockets and pipes can't be transferred.
+			return nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteExtra {
+		extra := []string{}
+		for rel := range remote {
+			if seen[rel] {
+				continue
+			}
+
+			// Skip the content of directories that were already replaced.
+			parentReplaced := false
+			for parent := path.Dir(rel); parent != "."; parent = path.Dir(parent) {
+				if replaced[parent] {
+					parentReplaced = true
+					break
+				}
+			}
+
+			if !parentReplaced {
+				extra = append(extra, rel)
+			}
+		}
+
+		// Delete children before their parents.
+		sort.Sort(sort.Reverse(sort.StringSlice(extra)))
+
+		for _, rel := range extra {
+			actions = append(actions, fileSyncAction{kind: "delete", path: rel, info: remote[rel]})
+		}
+	}
+
+	return actions, nil
+}
+
+// apply performs a single synchronization action.
+func (c *cmdFileSync) apply(client *sftp.Client, source string, target string, action fileSyncAction, tracker *ioprogress.ProgressTracker) error {
+	localPath := filepath.Join(source, filepath.FromSlash(action.path))
+	remotePath := path.Join(target, action.path)
+
+	switch action.kind {
+	case "delete":
+		if !action.info.IsDir() {
+			return client.Remove(remotePath)
+		}
+
+		// Remove the directory content first.
+		entries := []string{}
+		walker := client.Walk(remotePath)
+		for walker.Step() {
+			if walker.Err() != nil {
+				return walker.Err()
+			}
+
+			entries = append(entries, walker.Path())
+		}
+
+		sort.Sort(sort.Reverse(sort.StringSlice(entries)))
+		for _, entry := range entries {
+			err := client.Remove(entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case "mkdir":
+		err := client.Mkdir(remotePath)
+		if err != nil {
+			return err
+		}
+
+		return client.Chmod(remotePath, action.info.Mode().Perm())
+
+	case "chmod":
+		return client.Chmod(remotePath, action.info.Mode().Perm())
+
+	case "symlink":
+		linkTarget, err := os.Readlink(localPath)
+		if err != nil {
+			return err
+		}
+
+		return client.Symlink(linkTarget, remotePath)
+
+	case "push":
+		src, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = src.Close() }()
+
+		// Write to a new file and rename it over the target, so that whatever is at the target path is
+		// replaced rather than written through (such as a symlink created in the meantime).
+		tmpPath := path.Join(path.Dir(remotePath), fmt.Sprintf(".%s.lxc-sync-%d", path.Base(remotePath), os.Getpid()))
+		dst, err := client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+		if err != nil {
+			return err
+		}
+
+		revert := func() {
+			_ = dst.Close()
+			_ = client.Remove(tmpPath)
+		}
+
+		_, err = io.Copy(dst, &ioprogress.ProgressReader{ReadCloser: src, Tracker: tracker})
+		if err != nil {
+			revert()
+			return err
+		}
+
+		err = dst.Chmod(action.info.Mode().Perm())
+		if err != nil {
+			revert()
+			return err
+		}
+
+		err = dst.Close()
+		if err != nil {
+			_ = client.Remove(tmpPath)
+			return err
+		}
+
+		// Keep the modification time so unchanged files are skipped next time.
+		err = client.Chtimes(tmpPath, action.info.ModTime(), action.info.ModTime())
+		if err != nil {
+			_ = client.Remove(tmpPath)
+			return err
+		}
+
+		err = client.PosixRename(tmpPath, remotePath)
+		if err != nil {
+			_ = client.Remove(tmpPath)
+			return err
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Unknown action %q", action.kind)
+}
+
+// sameChecksum returns whether the local and remote files have the same content.
+func (c *cmdFileSync) sameChecksum(client *sftp.Client, localPath string, remotePath string) (bool, error) {
+	hash := func(r io.Reader) ([]byte, error) {
+		h := sha256.New()
+		_, err := io.Copy(h, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return h.Sum(nil), nil
+	}
+
+	local, err := os.Open(localPath)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() { _ = local.Close() }()
+
+	localSum, err := hash(local)
+	if err != nil {
+		return false, err
+	}
+
+	remote, err := client.Open(remotePath)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() { _ = remote.Close() }()
+
+	remoteSum, err := hash(remote)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(localSum, remoteSum), nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fileSyncTestSuite struct {
+	suite.Suite
+}
+
+func TestFileSyncTestSuite(t *testing.T) {
+	suite.Run(t, new(fileSyncTestSuite))
+}
+
+// index returns the entries below root keyed by their slash separated relative path.
+func (s *fileSyncTestSuite) index(root string) map[string]os.FileInfo {
+	entries := map[string]os.FileInfo{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		s.Require().NoError(err)
+
+		rel, err := filepath.Rel(root, p)
+		s.Require().NoError(err)
+
+		if rel != "." {
+			entries[filepath.ToSlash(rel)] = info
+		}
+
+		return nil
+	})
+	s.Require().NoError(err)
+
+	return entries
+}
+
+func (s *fileSyncTestSuite) writeFile(path string, content string, modTime time.Time) {
+	s.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+	s.Require().NoError(os.Chtimes(path, modTime, modTime))
+}
+
+func (s *fileSyncTestSuite) TestPlan() {
+	source := s.T().TempDir()
+	target := s.T().TempDir()
+	modTime := time.Now().Add(-time.Hour)
+
+	// Unchanged file.
+	s.writeFile(filepath.Join(source, "same"), "same", modTime)
+	s.writeFile(filepath.Join(target, "same"), "same", modTime)
+
+	// Modified file with the same size.
+	s.writeFile(filepath.Join(source, "dir", "modified"), "new", modTime)
+	s.writeFile(filepath.Join(target, "dir", "modified"), "old", modTime.Add(-time.Minute))
+
+	// New file.
+	s.writeFile(filepath.Join(source, "new", "file"), "new", modTime)
+
+	// Extra file.
+	s.writeFile(filepath.Join(target, "extra", "file"), "extra", modTime)
+
+	same := func(rel string, info os.FileInfo) (bool, error) { return true, nil }
+
+	actions, err := fileSyncPlan(source, s.index(target), false, true, same)
+	s.Require().NoError(err)
+
+	planned := []string{}
+	for _, action := range actions {
+		planned = append(planned, action.kind+" "+action.path)
+	}
+
+	s.Equal([]string{
+		"push dir/modified",
+		"mkdir new",
+		"push new/file",
+		"delete extra/file",
+		"delete extra",
+	}, planned)
+}
+
+func (s *fileSyncTestSuite) TestPlanChecksum() {
+	source := s.T().TempDir()
+	target := s.T().TempDir()
+
+	// Files with different modification times are only compared by content.
+	s.writeFile(filepath.Join(source, "file"), "same", time.Now())
+	s.writeFile(filepath.Join(target, "file"), "same", time.Now().Add(-time.Hour))
+
+	compared := []string{}
+	same := func(rel string, info os.FileInfo) (bool, error) {
+		compared = append(compared, rel)
+		return true, nil
+	}
+
+	actions, err := fileSyncPlan(source, s.index(target), true, false, same)
+	s.Require().NoError(err)
+	s.Empty(actions)
+	s.Equal([]string{"file"}, compared)
+}
+
+func (s *fileSyncTestSuite) TestPlanReplaceDirectory() {
+	source := s.T().TempDir()
+	target := s.T().TempDir()
+
+	// A local file replacing a remote directory.
+	s.writeFile(filepath.Join(source, "path"), "file", time.Now())
+	s.writeFile(filepath.Join(target, "path", "child"), "child", time.Now())
+
+	same := func(rel string, info os.FileInfo) (bool, error) { return true, nil }
+
+	actions, err := fileSyncPlan(source, s.index(target), false, true, same)
+	s.Require().NoError(err)
+
+	planned := []string{}
+	for _, action := range actions {
+		planned = append(planned, action.kind+" "+action.path)
+	}
+
+	s.Equal([]string{"delete path", "push path"}, planned)
+}
+
+func (s *fileSyncTestSuite) TestPlanReplaceSymlink() {
+	source := s.T().TempDir()
+	target := s.T().TempDir()
+
+	// Local files and directories replacing remote symlinks.
+	s.writeFile(filepath.Join(source, "file"), "file", time.Now())
+	s.Require().NoError(os.Mkdir(filepath.Join(source, "dir"), 0755))
+	s.Require().NoError(os.Symlink("/etc/passwd", filepath.Join(target, "file")))
+	s.Require().NoError(os.Symlink("/etc", filepath.Join(target, "dir")))
+
+	same := func(rel string, info os.FileInfo) (bool, error) { return true, nil }
+
+	actions, err := fileSyncPlan(source, s.index(target), false, false, same)
+	s.Require().NoError(err)
+
+	planned := []string{}
+	for _, action := range actions {
+		planned = append(planned, action.kind+" "+action.path)
+	}
+
+	s.Equal([]string{"delete dir", "mkdir dir", "delete file", "push file"}, planned)
+}
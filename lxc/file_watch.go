@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/lxd/shared/api"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+)
+
+// Watch.
+type cmdFileWatch struct {
+	global *cmdGlobal
+	file   *cmdFile
+}
+
+func (c *cmdFileWatch) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("watch", i18n.G("[<remote>:]<instance>/<path>"))
+	cmd.Short = i18n.G("Watch files in instances for changes")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Watch files in instances for changes
+
+Prints a line for every file or directory created, modified or deleted below the path.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc file watch foo/etc
+   To show the changes made to /etc in the instance "foo".`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdFileWatch) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	pathSpec := strings.SplitN(args[0], "/", 2)
+	if len(pathSpec) != 2 {
+		return fmt.Errorf(i18n.G("Invalid path %s"), args[0])
+	}
+
+	resources, err := c.global.ParseServers(pathSpec[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	conn, err := resource.server.GetInstanceFileWatch(resource.name, "/"+pathSpec[1])
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	for {
+		event := api.InstanceFileEvent{}
+		err := conn.ReadJSON(&event)
+		if err != nil {
+			closeErr, ok := err.(*websocket.CloseError)
+			if ok && closeErr.Code == websocket.CloseInternalServerErr {
+				return fmt.Errorf("%s", closeErr.Text)
+			}
+
+			return err
+		}
+
+		fmt.Printf("%s %s %s\n", event.Timestamp.Local().Format(time.RFC3339), event.Action, event.Path)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -22,6 +23,7 @@ type cmdInfo struct {
 	global *cmdGlobal
 
 	flagShowLog   bool
+	flagProcesses bool
 	flagResources bool
 	flagTarget    string
 }
@@ -33,7 +35,7 @@ func (c *cmdInfo) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show instance or server information`))
 	cmd.Example = cli.FormatSection("", i18n.G(
-		`lxc info [<remote>:]<instance> [--show-log]
+		`lxc info [<remote>:]<instance> [--show-log] [--processes]
     For instance information.
 
 lxc info [<remote>:] [--resources]
@@ -41,6 +43,7 @@ lxc info [<remote>:] [--resources]
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagShowLog, "show-log", false, i18n.G("Show the instance's last 100 log lines?"))
+	cmd.Flags().BoolVar(&c.flagProcesses, "processes", false, i18n.G("Show the processes running inside the instance"))
 	cmd.Flags().BoolVar(&c.flagResources, "resources", false, i18n.G("Show the resources available to the server"))
 	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 
@@ -686,6 +689,42 @@ func (c *cmdInfo) instanceInfo(d lxd.InstanceServer, remote config.Remote, name
 		_ = cli.RenderTable(cli.TableFormatTable, backupHeader, backupData, inst.Backups)
 	}
 
+	if c.flagProcesses {
+		processes, err := d.GetInstanceProcesses(name)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(processes, func(i, j int) bool { return processes[i].PID < processes[j].PID })
+
+		processData := [][]string{}
+		for _, process := range processes {
+			user := process.User
+			if user == "" {
+				user = fmt.Sprintf("%d", process.UID)
+			}
+
+			processData = append(processData, []string{
+				fmt.Sprintf("%d", process.PID),
+				user,
+				time.Duration(process.CPUUsage).Round(time.Second).String(),
+				units.GetByteSizeStringIEC(process.MemoryUsage, 2),
+				process.Command,
+			})
+		}
+
+		processHeader := []string{
+			i18n.G("PID"),
+			i18n.G("USER"),
+			i18n.G("CPU TIME"),
+			i18n.G("MEMORY"),
+			i18n.G("COMMAND"),
+		}
+
+		fmt.Println("\n" + i18n.G("Processes:"))
+		_ = cli.RenderTable(cli.TableFormatTable, processHeader, processData, processes)
+	}
+
 	if showLog {
 		var log io.Reader
 		if inst.Type == "container" {
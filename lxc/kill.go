@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+)
+
+type cmdKill struct {
+	global *cmdGlobal
+
+	flagSignal string
+}
+
+func (c *cmdKill) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("kill", i18n.G("[<remote>:]<instance> <pid>"))
+	cmd.Short = i18n.G("Send a signal to a process in an instance")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Send a signal to a process in an instance
+
+The PID is the one of the process inside the instance, as shown by "lxc info --processes".`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc kill foo 1234
+   Send SIGTERM to the process with PID 1234 in the instance "foo".
+
+lxc kill foo 1234 --signal KILL
+   Send SIGKILL to the process with PID 1234 in the instance "foo".`))
+
+	cmd.Flags().StringVarP(&c.flagSignal, "signal", "s", "SIGTERM", i18n.G("Signal to send, by name or number")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdKill) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	pid, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || pid <= 0 {
+		return fmt.Errorf(i18n.G("Invalid PID %q"), args[1])
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	return resource.server.SignalInstanceProcess(resource.name, pid, c.flagSignal)
+}
@@ -108,6 +108,10 @@ For help with any of those, simply call them with --help.`))
 	aliasCmd := cmdAlias{global: &globalCmd}
 	app.AddCommand(aliasCmd.Command())
 
+	// auth sub-command
+	authCmd := cmdAuth{global: &globalCmd}
+	app.AddCommand(authCmd.Command())
+
 	// cluster sub-command
 	clusterCmd := cmdCluster{global: &globalCmd}
 	app.AddCommand(clusterCmd.Command())
@@ -128,6 +132,10 @@ For help with any of those, simply call them with --help.`))
 	deleteCmd := cmdDelete{global: &globalCmd}
 	app.AddCommand(deleteCmd.Command())
 
+	// diff sub-command
+	diffCmd := cmdDiff{global: &globalCmd}
+	app.AddCommand(diffCmd.Command())
+
 	// exec sub-command
 	execCmd := cmdExec{global: &globalCmd}
 	app.AddCommand(execCmd.Command())
@@ -156,6 +164,10 @@ For help with any of those, simply call them with --help.`))
 	initCmd := cmdInit{global: &globalCmd}
 	app.AddCommand(initCmd.Command())
 
+	// kill sub-command
+	killCmd := cmdKill{global: &globalCmd}
+	app.AddCommand(killCmd.Command())
+
 	// launch sub-command
 	launchCmd := cmdLaunch{global: &globalCmd, init: &initCmd}
 	app.AddCommand(launchCmd.Command())
@@ -248,6 +260,10 @@ For help with any of those, simply call them with --help.`))
 	warningCmd := cmdWarning{global: &globalCmd}
 	app.AddCommand(warningCmd.Command())
 
+	// webhook sub-command
+	webhookCmd := cmdWebhook{global: &globalCmd}
+	app.AddCommand(webhookCmd.Command())
+
 	// Get help command
 	app.InitDefaultHelpCmd()
 	var help *cobra.Command
@@ -24,6 +24,11 @@ type cmdMonitor struct {
 	flagLogLevel    string
 	flagAllProjects bool
 	flagFormat      string
+	flagSince       uint64
+	flagAction      []string
+	flagEntity      string
+	flagLocation    []string
+	flagFilter      string
 }
 
 func (c *cmdMonitor) Command() *cobra.Command {
@@ -42,7 +47,13 @@ lxc monitor --pretty --type=logging --loglevel=info
     Show a pretty log of messages with info level or higher.
 
 lxc monitor --type=lifecycle
-    Only show lifecycle events.`))
+    Only show lifecycle events.
+
+lxc monitor --since=8796093022250
+    Show the logged lifecycle and operation events following the one with ID 8796093022250, then the new events.
+
+lxc monitor --filter 'action=instance-started AND project=prod' --all-projects
+    Only show the instance-started lifecycle events of the prod project.`))
 	cmd.Hidden = true
 
 	cmd.RunE = c.Run
@@ -51,6 +62,11 @@ lxc monitor --type=lifecycle
 	cmd.Flags().StringArrayVar(&c.flagType, "type", nil, i18n.G("Event type to listen for")+"``")
 	cmd.Flags().StringVar(&c.flagLogLevel, "loglevel", "", i18n.G("Minimum level for log messages (only available when using pretty format)")+"``")
 	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|pretty|yaml)")+"``")
+	cmd.Flags().Uint64Var(&c.flagSince, "since", 0, i18n.G("Replay the logged events following the one with this ID first")+"``")
+	cmd.Flags().StringArrayVar(&c.flagAction, "action", nil, i18n.G("Lifecycle action to listen for")+"``")
+	cmd.Flags().StringVar(&c.flagEntity, "entity", "", i18n.G("Only show the events related to entities whose URL starts with this prefix")+"``")
+	cmd.Flags().StringArrayVar(&c.flagLocation, "location", nil, i18n.G("Cluster member to show the events of")+"``")
+	cmd.Flags().StringVar(&c.flagFilter, "filter", "", i18n.G("Filter expression matched against the event fields")+"``")
 
 	return cmd
 }
@@ -98,8 +114,24 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	listenerArgs := &lxd.EventListenerArgs{
+		AllProjects: c.flagAllProjects,
+		Since:       c.flagSince,
+		Actions:     c.flagAction,
+		Entity:      c.flagEntity,
+		Locations:   c.flagLocation,
+		Filter:      c.flagFilter,
+	}
+
+	// Also filter the log messages server side when supported.
+	if c.flagLogLevel != "" && d.HasExtension("event_filter") {
+		listenerArgs.Level = c.flagLogLevel
+	}
+
 	var listener *lxd.EventListener
-	if c.flagAllProjects {
+	if listenerArgs.Since > 0 || len(listenerArgs.Actions) > 0 || listenerArgs.Entity != "" || len(listenerArgs.Locations) > 0 || listenerArgs.Level != "" || listenerArgs.Filter != "" {
+		listener, err = d.GetEventsWithArgs(listenerArgs)
+	} else if c.flagAllProjects {
 		listener, err = d.GetEventsAllProjects()
 	} else {
 		listener, err = d.GetEvents()
@@ -61,6 +61,10 @@ func (c *cmdProfile) Command() *cobra.Command {
 	profileGetCmd := cmdProfileGet{global: c.global, profile: c}
 	cmd.AddCommand(profileGetCmd.Command())
 
+	// History
+	profileHistoryCmd := cmdProfileHistory{global: c.global, profile: c}
+	cmd.AddCommand(profileHistoryCmd.Command())
+
 	// List
 	profileListCmd := cmdProfileList{global: c.global, profile: c}
 	cmd.AddCommand(profileListCmd.Command())
@@ -73,6 +77,10 @@ func (c *cmdProfile) Command() *cobra.Command {
 	profileRenameCmd := cmdProfileRename{global: c.global, profile: c}
 	cmd.AddCommand(profileRenameCmd.Command())
 
+	// Rollback
+	profileRollbackCmd := cmdProfileRollback{global: c.global, profile: c}
+	cmd.AddCommand(profileRollbackCmd.Command())
+
 	// Set
 	profileSetCmd := cmdProfileSet{global: c.global, profile: c}
 	cmd.AddCommand(profileSetCmd.Command())
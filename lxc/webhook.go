@@ -0,0 +1,430 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	cli "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/i18n"
+	"github.com/canonical/lxd/shared/termios"
+)
+
+type cmdWebhook struct {
+	global *cmdGlobal
+}
+
+func (c *cmdWebhook) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("webhook")
+	cmd.Short = i18n.G("Manage webhooks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage webhooks
+
+Webhooks deliver lifecycle and operation events to a remote URL as HTTP POST requests.`))
+
+	// Create
+	webhookCreateCmd := cmdWebhookCreate{global: c.global}
+	cmd.AddCommand(webhookCreateCmd.Command())
+
+	// Delete
+	webhookDeleteCmd := cmdWebhookDelete{global: c.global}
+	cmd.AddCommand(webhookDeleteCmd.Command())
+
+	// Edit
+	webhookEditCmd := cmdWebhookEdit{global: c.global}
+	cmd.AddCommand(webhookEditCmd.Command())
+
+	// List
+	webhookListCmd := cmdWebhookList{global: c.global}
+	cmd.AddCommand(webhookListCmd.Command())
+
+	// Show
+	webhookShowCmd := cmdWebhookShow{global: c.global}
+	cmd.AddCommand(webhookShowCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// Create.
+type cmdWebhookCreate struct {
+	global *cmdGlobal
+
+	flagDescription string
+	flagTypes       string
+	flagProjects    string
+	flagSecret      string
+}
+
+func (c *cmdWebhookCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("create", i18n.G("[<remote>:]<webhook> <URL>"))
+	cmd.Short = i18n.G("Create a webhook")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create a webhook`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc webhook create deploy https://hooks.example.com/lxd --types=lifecycle --projects=default --secret=s3cr3t
+    Deliver the lifecycle events of the default project, signed with the given secret`))
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Webhook description")+"``")
+	cmd.Flags().StringVar(&c.flagTypes, "types", "", i18n.G("Comma separated list of event types to deliver (lifecycle or operation)")+"``")
+	cmd.Flags().StringVar(&c.flagProjects, "projects", "", i18n.G("Comma separated list of projects whose events get delivered")+"``")
+	cmd.Flags().StringVar(&c.flagSecret, "secret", "", i18n.G("Secret used to sign the deliveries")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdWebhookCreate) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing webhook name"))
+	}
+
+	// Create the webhook
+	webhook := api.WebhooksPost{
+		Name: resource.name,
+	}
+
+	webhook.Description = c.flagDescription
+	webhook.URL = args[1]
+	if c.flagSecret != "" {
+		webhook.Secret = &c.flagSecret
+	}
+
+	if c.flagTypes != "" {
+		webhook.Types = strings.Split(c.flagTypes, ",")
+	}
+
+	if c.flagProjects != "" {
+		webhook.Projects = strings.Split(c.flagProjects, ",")
+	}
+
+	err = resource.server.CreateWebhook(webhook)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Webhook %s created")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Delete.
+type cmdWebhookDelete struct {
+	global *cmdGlobal
+}
+
+func (c *cmdWebhookDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("delete", i18n.G("[<remote>:]<webhook>"))
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete a webhook")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete a webhook`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdWebhookDelete) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing webhook name"))
+	}
+
+	// Delete the webhook
+	err = resource.server.DeleteWebhook(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Webhook %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Edit.
+type cmdWebhookEdit struct {
+	global *cmdGlobal
+}
+
+func (c *cmdWebhookEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("edit", i18n.G("[<remote>:]<webhook>"))
+	cmd.Short = i18n.G("Edit a webhook")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit a webhook`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc webhook edit <webhook> < webhook.yaml
+    Update a webhook using the content of webhook.yaml`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdWebhookEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the webhook.
+### Any line starting with a '# will be ignored.
+###
+### A sample webhook looks like:
+### name: deploy
+### description: Notify the deployment service
+### url: https://hooks.example.com/lxd
+### types:
+### - lifecycle
+### projects:
+### - default
+###
+### The secret isn't shown, it's only replaced if a new one is set
+### and removed if set to an empty string (secret: "").
+###
+### Note that the name is shown but cannot be changed`)
+}
+
+func (c *cmdWebhookEdit) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing webhook name"))
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.WebhookPut{}
+
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateWebhook(resource.name, newdata, "")
+	}
+
+	// Extract the current value
+	webhook, etag, err := resource.server.GetWebhook(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.WebhookPut{}
+
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateWebhook(resource.name, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again or ctrl+c to abort change"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// List.
+type cmdWebhookList struct {
+	global *cmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdWebhookList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List the webhooks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List the webhooks`))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdWebhookList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	webhooks, err := resource.server.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	// Render the table
+	data := [][]string{}
+	for _, webhook := range webhooks {
+		line := []string{webhook.Name, webhook.URL, strings.Join(webhook.Types, "\n"), strings.Join(webhook.Projects, "\n"), webhook.Description}
+		data = append(data, line)
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("URL"),
+		i18n.G("TYPES"),
+		i18n.G("PROJECTS"),
+		i18n.G("DESCRIPTION"),
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, webhooks)
+}
+
+// Show.
+type cmdWebhookShow struct {
+	global *cmdGlobal
+}
+
+func (c *cmdWebhookShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<webhook>"))
+	cmd.Short = i18n.G("Show webhook configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show webhook configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdWebhookShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing webhook name"))
+	}
+
+	// Show the webhook
+	webhook, _, err := resource.server.GetWebhook(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&webhook)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
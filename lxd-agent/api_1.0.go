@@ -29,10 +29,12 @@ var api10 = []APIEndpoint{
 	api10Cmd,
 	execCmd,
 	eventsCmd,
+	filesWatchCmd,
 	metricsCmd,
 	operationsCmd,
 	operationCmd,
 	operationWebsocket,
+	processesCmd,
 	sftpCmd,
 	stateCmd,
 }
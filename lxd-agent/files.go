@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/canonical/lxd/lxd/fsmonitor"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/ws"
+)
+
+var filesWatchCmd = APIEndpoint{
+	Name: "filesWatch",
+	Path: "files/watch",
+
+	Get: APIEndpointAction{Handler: filesWatchGet},
+}
+
+func filesWatchGet(d *Daemon, r *http.Request) response.Response {
+	path := r.FormValue("path")
+	if path == "" {
+		return response.BadRequest(fmt.Errorf("Missing path argument"))
+	}
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		conn, err := ws.Upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		// Stop watching once the client goes away.
+		go func() {
+			for {
+				_, _, err := conn.NextReader()
+				if err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		err = fsmonitor.WatchFiles(ctx, "", "/", path, func(event api.InstanceFileEvent) {
+			err := conn.WriteJSON(event)
+			if err != nil {
+				cancel()
+			}
+		})
+		if err != nil {
+			logger.Warn("Failed watching files", logger.Ctx{"path": path, "err": err})
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		}
+
+		return nil
+	})
+}
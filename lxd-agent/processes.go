@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared/api"
+)
+
+var processesCmd = APIEndpoint{
+	Name: "processes",
+	Path: "processes",
+
+	Get:  APIEndpointAction{Handler: processesGet},
+	Post: APIEndpointAction{Handler: processesPost},
+}
+
+func processesGet(d *Daemon, r *http.Request) response.Response {
+	processes, err := util.GetAllProcesses()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	usernames := util.GetUsernames("/etc/passwd")
+
+	result := make([]api.InstanceProcess, 0, len(processes))
+	for _, process := range processes {
+		process.User = usernames[process.UID]
+		result = append(result, process.InstanceProcess)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+func processesPost(d *Daemon, r *http.Request) response.Response {
+	req := api.InstanceProcessesPost{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	signal, err := util.ParseSignal(req.Signal)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.PID <= 0 {
+		return response.BadRequest(fmt.Errorf("Invalid PID %d", req.PID))
+	}
+
+	err = unix.Kill(int(req.PID), signal)
+	if err == unix.ESRCH {
+		return response.NotFound(fmt.Errorf("Process %d not found", req.PID))
+	} else if err != nil {
+		return response.InternalError(fmt.Errorf("Failed sending signal to process %d: %w", req.PID, err))
+	}
+
+	return response.EmptySyncResponse
+}
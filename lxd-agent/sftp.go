@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/pkg/sftp"
 
 	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/shared/tarstream"
 )
 
 var sftpCmd = APIEndpoint{
@@ -59,8 +62,17 @@ func (r *sftpServe) Render(w http.ResponseWriter) error {
 		return nil
 	}
 
+	// Handle tar stream transfers.
+	reader := bufio.NewReader(conn)
+	if tarstream.IsRequest(reader) {
+		return tarstream.Serve(reader, conn)
+	}
+
 	// Start sftp server.
-	server, err := sftp.NewServer(conn)
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{reader, conn})
 	if err != nil {
 		return nil
 	}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 
@@ -14,7 +17,9 @@ import (
 	"github.com/canonical/lxd/lxd/cluster/request"
 	"github.com/canonical/lxd/lxd/db"
 	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/metrics"
 	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/ratelimit"
 	lxdRequest "github.com/canonical/lxd/lxd/request"
 	"github.com/canonical/lxd/lxd/response"
 	storagePools "github.com/canonical/lxd/lxd/storage"
@@ -392,6 +397,131 @@ func setCORSHeaders(rw http.ResponseWriter, req *http.Request, config *clusterCo
 	}
 }
 
+// Classes of API endpoints that rate limits apply to.
+var apiRateLimitClasses = []string{"read", "write", "exec"}
+
+// apiRateLimits holds the rate limiters of the classes of API endpoints, keyed by class and limit so that the
+// identities subject to the same limit share a limiter, along with the number of requests rejected in each class.
+type apiRateLimits struct {
+	limiters  map[string]*ratelimit.Limiter
+	throttled map[string]uint64
+	lock      sync.Mutex
+}
+
+// newAPIRateLimits returns an apiRateLimits without any limiter.
+func newAPIRateLimits() *apiRateLimits {
+	return &apiRateLimits{
+		limiters:  map[string]*ratelimit.Limiter{},
+		throttled: map[string]uint64{},
+	}
+}
+
+// reset drops the limiters, so that they are created again from the current limits on their next use, and the
+// limiters of the limits that aren't used anymore are released. The number of rejected requests is kept.
+func (l *apiRateLimits) reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.limiters = map[string]*ratelimit.Limiter{}
+}
+
+// apiRateLimitClass returns the class of API endpoint the request is for: exec for the requests starting or
+// attaching to commands, consoles, file transfers or websockets, read for the other read-only requests and write
+// otherwise.
+func apiRateLimitClass(r *http.Request) string {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/1.0/events" {
+		return "exec"
+	}
+
+	for _, suffix := range []string{"/exec", "/attach", "/console", "/sftp", "/websocket"} {
+		if strings.HasSuffix(path, suffix) {
+			return "exec"
+		}
+	}
+
+	if shared.StringInSlice(r.Method, []string{http.MethodGet, http.MethodHead}) {
+		return "read"
+	}
+
+	return "write"
+}
+
+// rateLimitRequest enforces the rate limit of the requestor on the class of API endpoint of the request, which is
+// the one set by its auth groups if any, or the server's one otherwise. Limits are tracked by each cluster member
+// separately. It returns false once it rejected the request with a 429 response.
+func (d *Daemon) rateLimitRequest(w http.ResponseWriter, r *http.Request, username string, protocol string) bool {
+	// Local and internal cluster requests aren't limited.
+	if shared.StringInSlice(protocol, []string{"unix", "cluster"}) {
+		return true
+	}
+
+	class := apiRateLimitClass(r)
+
+	claims, _ := r.Context().Value(lxdRequest.CtxOIDCClaims).(map[string]any)
+	limit := d.identityRateLimit(protocol, username, claims, class)
+	if limit == "" {
+		d.globalConfigMu.Lock()
+		if d.globalConfig != nil {
+			limit = d.globalConfig.APIRateLimit(class)
+		}
+
+		d.globalConfigMu.Unlock()
+	}
+
+	if limit == "" {
+		return true
+	}
+
+	// Create the limiter on first use of the limit.
+	d.rateLimits.lock.Lock()
+	limiter := d.rateLimits.limiters[class+":"+limit]
+	if limiter == nil {
+		parsedLimit, err := ratelimit.ParseLimit(limit)
+		if err != nil {
+			d.rateLimits.lock.Unlock()
+			logger.Warn("Invalid API rate limit", logger.Ctx{"class": class, "err": err})
+
+			return true
+		}
+
+		limiter = ratelimit.NewLimiter(*parsedLimit)
+		d.rateLimits.limiters[class+":"+limit] = limiter
+	}
+
+	d.rateLimits.lock.Unlock()
+
+	allowed, retryAfter := limiter.Allow(protocol + "/" + username)
+	if allowed {
+		return true
+	}
+
+	d.rateLimits.lock.Lock()
+	d.rateLimits.throttled[class]++
+	d.rateLimits.lock.Unlock()
+
+	logger.Debug("Rate limiting API request", logger.Ctx{"class": class, "username": username, "protocol": protocol, "retryAfter": retryAfter})
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	_ = response.SmartError(api.StatusErrorf(http.StatusTooManyRequests, "Too many %s requests, rate limit is %s", class, limit)).Render(w)
+
+	return false
+}
+
+// apiRateLimitMetrics returns the number of requests rejected by the rate limits of each class of API endpoints.
+func (d *Daemon) apiRateLimitMetrics() *metrics.MetricSet {
+	out := metrics.NewMetricSet(nil)
+
+	d.rateLimits.lock.Lock()
+	defer d.rateLimits.lock.Unlock()
+
+	for _, class := range apiRateLimitClasses {
+		out.AddSamples(metrics.APIRequestsThrottledTotal, metrics.Sample{Labels: map[string]string{"class": class}, Value: float64(d.rateLimits.throttled[class])})
+	}
+
+	return out
+}
+
 // Return true if this an API request coming from a cluster node that is
 // notifying us of some user-initiated API request that needs some action to be
 // taken on this node as well.
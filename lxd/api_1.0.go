@@ -40,6 +40,10 @@ var api10Cmd = APIEndpoint{
 var api10 = []APIEndpoint{
 	api10Cmd,
 	api10ResourcesCmd,
+	authGroupCmd,
+	authGroupsCmd,
+	authTokenCmd,
+	authTokensCmd,
 	certificateCmd,
 	certificatesCmd,
 	clusterCmd,
@@ -54,7 +58,11 @@ var api10 = []APIEndpoint{
 	instanceBackupsCmd,
 	instanceCmd,
 	instanceConsoleCmd,
+	instanceDiffCmd,
 	instanceExecCmd,
+	instanceExecSessionAttachCmd,
+	instanceExecSessionCmd,
+	instanceExecSessionsCmd,
 	instanceFileCmd,
 	instanceExecOutputCmd,
 	instanceExecOutputsCmd,
@@ -62,8 +70,11 @@ var api10 = []APIEndpoint{
 	instanceLogsCmd,
 	instanceMetadataCmd,
 	instanceMetadataTemplatesCmd,
+	instanceProcessesCmd,
 	instancesCmd,
 	instanceRebuildCmd,
+	instanceRevisionCmd,
+	instanceRevisionsCmd,
 	instanceSFTPCmd,
 	instanceSnapshotCmd,
 	instanceSnapshotsCmd,
@@ -99,6 +110,8 @@ var api10 = []APIEndpoint{
 	operationWait,
 	operationWebsocket,
 	profileCmd,
+	profileRevisionCmd,
+	profileRevisionsCmd,
 	profilesCmd,
 	projectCmd,
 	projectsCmd,
@@ -121,6 +134,8 @@ var api10 = []APIEndpoint{
 	storagePoolVolumeTypeStateCmd,
 	warningsCmd,
 	warningCmd,
+	webhooksCmd,
+	webhookCmd,
 	metricsCmd,
 }
 
@@ -729,6 +744,8 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 	bgpChanged := false
 	dnsChanged := false
 	lokiChanged := false
+	tracingChanged := false
+	metricsExporterChanged := false
 	acmeDomainChanged := false
 	acmeCAURLChanged := false
 	oidcChanged := false
@@ -801,12 +818,18 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			fallthrough
 		case "loki.types":
 			lokiChanged = true
+		case "tracing.otlp.endpoint", "tracing.otlp.headers", "tracing.otlp.ca_cert":
+			tracingChanged = true
+		case "metrics.otlp.endpoint", "metrics.otlp.headers", "metrics.otlp.ca_cert", "metrics.otlp.interval":
+			metricsExporterChanged = true
 		case "acme.ca_url":
 			acmeCAURLChanged = true
 		case "acme.domain":
 			acmeDomainChanged = true
 		case "oidc.issuer", "oidc.client.id", "oidc.audience":
 			oidcChanged = true
+		case "core.rate_limit.read", "core.rate_limit.write", "core.rate_limit.exec":
+			d.rateLimits.reset()
 		}
 	}
 
@@ -941,6 +964,7 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 
 		if lokiURL == "" || lokiLoglevel == "" || len(lokiTypes) == 0 {
 			d.internalListener.RemoveHandler("loki")
+			d.audit.RemoveHandler("loki")
 		} else {
 			err := d.setupLoki(lokiURL, lokiUsername, lokiPassword, lokiCACert, lokiLabels, lokiLoglevel, lokiTypes)
 			if err != nil {
@@ -949,6 +973,24 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	if tracingChanged {
+		endpoint, headers, caCert := clusterConfig.TracingServer()
+
+		err := d.setupTracing(endpoint, headers, caCert)
+		if err != nil {
+			return err
+		}
+	}
+
+	if metricsExporterChanged {
+		endpoint, headers, caCert, interval := clusterConfig.MetricsOTLPServer()
+
+		err := d.setupMetricsExporter(endpoint, headers, caCert, interval)
+		if err != nil {
+			return err
+		}
+	}
+
 	if acmeCAURLChanged || acmeDomainChanged {
 		err := autoRenewCertificate(s.ShutdownCtx, d, acmeCAURLChanged)
 		if err != nil {
@@ -965,6 +1007,33 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	// Compile and load the instance admission scriptlet.
+	value, ok = clusterChanged["instances.admission.scriptlet"]
+	if ok {
+		err := scriptletLoad.InstanceAdmissionSet(value)
+		if err != nil {
+			return fmt.Errorf("Failed saving instance admission scriptlet: %w", err)
+		}
+	}
+
+	// Compile and load the authorization scriptlet.
+	value, ok = clusterChanged["authorization.scriptlet"]
+	if ok {
+		err := scriptletLoad.AuthorizationSet(value)
+		if err != nil {
+			return fmt.Errorf("Failed saving authorization scriptlet: %w", err)
+		}
+
+		// RBAC takes precedence over the authorization scriptlet.
+		rbacAPIURL, _, _, _, _, _, _ := clusterConfig.RBACServer()
+		if rbacAPIURL == "" {
+			err = d.setupAuthorizer(value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if oidcChanged {
 		oidcIssuer, oidcClientID, oidcAudience := clusterConfig.OIDCServer()
 
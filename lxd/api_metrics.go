@@ -18,6 +18,7 @@ import (
 	"github.com/canonical/lxd/lxd/locking"
 	"github.com/canonical/lxd/lxd/metrics"
 	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/logger"
@@ -91,15 +92,32 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	metricSet, err := getMetrics(r.Context(), d, projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponsePlain(true, compress, metricSet.String())
+}
+
+// getMetrics returns the metrics of the local member and of its instances in the given project, or in all
+// projects if empty.
+func getMetrics(ctx context.Context, d *Daemon, projectName string) (*metrics.MetricSet, error) {
+	s := d.State()
+
 	// Wait until daemon is fully started.
-	<-d.waitReady.Done()
+	select {
+	case <-d.waitReady.Done():
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
 	// Prepare response.
 	metricSet := metrics.NewMetricSet(nil)
 
 	var projectNames []string
 
-	err := s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
 		// Figure out the projects to retrieve.
 		if projectName != "" {
 			projectNames = []string{projectName}
@@ -118,11 +136,12 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 
 		// Add internal metrics.
 		metricSet.Merge(internalMetrics(ctx, s.StartTime, tx))
+		metricSet.Merge(d.apiRateLimitMetrics())
 
 		return nil
 	})
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
 	// invalidProjectFilters returns project filters which are either not in cache or have expired.
@@ -157,18 +176,18 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 
 	// If all valid, return immediately.
 	if len(projectsToFetch) == 0 {
-		return response.SyncResponsePlain(true, compress, metricSet.String())
+		return metricSet, nil
 	}
 
 	cacheDuration := time.Duration(8) * time.Second
 
 	// Acquire update lock.
-	lockCtx, lockCtxCancel := context.WithTimeout(r.Context(), cacheDuration)
+	lockCtx, lockCtxCancel := context.WithTimeout(ctx, cacheDuration)
 	defer lockCtxCancel()
 
 	unlock := locking.Lock(lockCtx, "metricsGet")
 	if unlock == nil {
-		return response.SmartError(api.StatusErrorf(http.StatusLocked, "Metrics are currently being built by another request"))
+		return nil, api.StatusErrorf(http.StatusLocked, "Metrics are currently being built by another request")
 	}
 
 	defer unlock()
@@ -182,14 +201,14 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 
 	// If all valid, return immediately.
 	if len(projectsToFetch) == 0 {
-		return response.SyncResponsePlain(true, compress, metricSet.String())
+		return metricSet, nil
 	}
 
 	// Gather information about host interfaces once.
 	hostInterfaces, _ := net.Interfaces()
 
 	var instances []instance.Instance
-	err = s.DB.Cluster.InstanceList(r.Context(), func(dbInst db.InstanceArgs, p api.Project) error {
+	err = s.DB.Cluster.InstanceList(ctx, func(dbInst db.InstanceArgs, p api.Project) error {
 		inst, err := instance.Load(s, dbInst, p)
 		if err != nil {
 			return fmt.Errorf("Failed loading instance %q in project %q: %w", dbInst.Name, dbInst.Project, err)
@@ -200,7 +219,7 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 		return nil
 	}, projectsToFetch...)
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
 	// Prepare temporary metrics storage.
@@ -228,6 +247,12 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 						logger.Warn("Failed getting instance metrics", logger.Ctx{"instance": inst.Name(), "project": projectName, "err": err})
 					}
 				} else {
+					// Counters of the instance restart along with it.
+					startTime, err := util.GetProcessStartTime(inst.InitPID(), s.OS.BootTime)
+					if err == nil {
+						instanceMetrics.SetStartTime(startTime)
+					}
+
 					// Add the metrics.
 					newMetricsLock.Lock()
 
@@ -285,7 +310,7 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 
 	metricsCacheLock.Unlock()
 
-	return response.SyncResponsePlain(true, compress, metricSet.String())
+	return metricSet, nil
 }
 
 func internalMetrics(ctx context.Context, daemonStartTime time.Time, tx *db.ClusterTx) *metrics.MetricSet {
@@ -1123,8 +1123,11 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"restricted.networks.subnets": validate.Optional(func(value string) error {
 			return projectValidateRestrictedSubnets(s, value)
 		}),
-		"restricted.networks.zones": validate.IsListOf(validate.IsAny),
-		"restricted.snapshots":      isEitherAllowOrBlock,
+		"restricted.networks.zones":           validate.IsListOf(validate.IsAny),
+		"restricted.snapshots":                isEitherAllowOrBlock,
+		"security.session_recording":          validate.Optional(validate.IsBool),
+		"security.session_recording.expiry":   validate.Optional(validate.IsUint32),
+		"security.session_recording.max_size": validate.Optional(validate.IsSize),
 	}
 
 	for k, v := range config {
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that the requests starting or attaching to commands, consoles and websockets are in the exec class.
+func TestAPIRateLimitClass(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		class  string
+	}{
+		{"GET", "/1.0/instances", "read"},
+		{"POST", "/1.0/instances", "write"},
+		{"POST", "/1.0/instances/c1/exec", "exec"},
+		{"POST", "/1.0/instances/c1/console", "exec"},
+		{"GET", "/1.0/instances/c1/sftp", "exec"},
+		{"GET", "/1.0/instances/c1/exec-sessions", "read"},
+		{"POST", "/1.0/instances/c1/exec-sessions/4f2a/attach", "exec"},
+		{"GET", "/1.0/operations/4f2a/websocket", "exec"},
+		{"GET", "/1.0/events", "exec"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		assert.Equal(t, test.class, apiRateLimitClass(r), test.method+" "+test.path)
+	}
+}
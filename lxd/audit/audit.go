@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// Entry represents an audited API request.
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Location   string    `json:"location"`
+	Username   string    `json:"username"`
+	Protocol   string    `json:"protocol"`
+	Address    string    `json:"address"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Project    string    `json:"project"`
+	Status     int       `json:"status"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Logger records audited API requests to a local log file, rotated once it reaches a maximum size, and
+// forwards them to its handlers.
+type Logger struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+	lock sync.Mutex
+
+	handlers     map[string]func(Entry)
+	handlersLock sync.Mutex
+	entries      chan Entry
+	dropped      uint64
+}
+
+// NewLogger returns a Logger writing to the given path. Once the file exceeds maxSize bytes it's rotated,
+// keeping at most maxFiles previous files (suffixed .1 for the most recent one).
+// The handlers are called in the background until the context is cancelled.
+func NewLogger(ctx context.Context, path string, maxSize int64, maxFiles int) *Logger {
+	l := &Logger{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		handlers: map[string]func(Entry){},
+		entries:  make(chan Entry, 1024),
+	}
+
+	go l.run(ctx)
+
+	return l
+}
+
+func (l *Logger) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-l.entries:
+			l.handlersLock.Lock()
+			handlers := make([]func(Entry), 0, len(l.handlers))
+			for _, handler := range l.handlers {
+				handlers = append(handlers, handler)
+			}
+
+			l.handlersLock.Unlock()
+
+			for _, handler := range handlers {
+				handler(entry)
+			}
+		}
+	}
+}
+
+// AddHandler adds a handler receiving the audited requests, replacing any existing handler with the same name.
+func (l *Logger) AddHandler(name string, handler func(Entry)) {
+	l.handlersLock.Lock()
+	defer l.handlersLock.Unlock()
+
+	l.handlers[name] = handler
+}
+
+// RemoveHandler removes the handler with the given name.
+func (l *Logger) RemoveHandler(name string) {
+	l.handlersLock.Lock()
+	defer l.handlersLock.Unlock()
+
+	delete(l.handlers, name)
+}
+
+// Log records an audited request.
+// The request is always written to the log file, but it's only forwarded to the handlers if they keep up, so that
+// slow handlers don't block the API requests. The entries they miss are counted and reported in the daemon log.
+func (l *Logger) Log(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	err = l.write(line)
+	if err != nil {
+		return err
+	}
+
+	l.handlersLock.Lock()
+	hasHandlers := len(l.handlers) > 0
+	l.handlersLock.Unlock()
+
+	if hasHandlers {
+		select {
+		case l.entries <- entry:
+		default:
+			dropped := atomic.AddUint64(&l.dropped, 1)
+			if dropped == 1 || dropped%1000 == 0 {
+				logger.Warn("Audit log handlers are falling behind, dropping entries", logger.Ctx{"dropped": dropped})
+			}
+		}
+	}
+
+	return nil
+}
+
+// Dropped returns the number of entries that weren't forwarded to the handlers because they were falling behind.
+func (l *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+func (l *Logger) write(line []byte) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.file != nil && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		err := l.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.file == nil {
+		err := l.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("Failed writing audit log %q: %w", l.path, err)
+	}
+
+	return nil
+}
+
+func (l *Logger) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed opening audit log %q: %w", l.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("Failed getting audit log %q size: %w", l.path, err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the current log file and shifts the previous ones, dropping the oldest.
+func (l *Logger) rotate() error {
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("Failed closing audit log %q: %w", l.path, err)
+	}
+
+	if l.maxFiles < 1 {
+		return os.Remove(l.path)
+	}
+
+	err = os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed removing old audit log: %w", err)
+	}
+
+	for i := l.maxFiles - 1; i > 0; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed rotating audit log: %w", err)
+		}
+	}
+
+	return os.Rename(l.path, l.path+".1")
+}
+
+// Close closes the log file.
+func (l *Logger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
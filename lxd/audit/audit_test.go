@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := Entry{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func TestLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entry := Entry{
+		Timestamp: time.Now().UTC(),
+		Username:  "jane",
+		Protocol:  "oidc",
+		Address:   "10.0.0.1:41234",
+		Method:    "POST",
+		Path:      "/1.0/instances",
+		Project:   "default",
+		Status:    202,
+	}
+
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	// Fit two entries in each file.
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := NewLogger(ctx, path, int64(2*(len(line)+1)), 2)
+	defer func() { _ = l.Close() }()
+
+	received := make(chan Entry, 10)
+	l.AddHandler("test", func(entry Entry) { received <- entry })
+
+	require.NoError(t, l.Log(entry))
+
+	select {
+	case got := <-received:
+		assert.Equal(t, entry.Path, got.Path)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handler wasn't called")
+	}
+
+	l.RemoveHandler("test")
+
+	// Every other entry rotates the file.
+	for i := 0; i < 7; i++ {
+		require.NoError(t, l.Log(entry))
+	}
+
+	assert.Len(t, readEntries(t, path), 2)
+	assert.Len(t, readEntries(t, path+".1"), 2)
+	assert.Len(t, readEntries(t, path+".2"), 2)
+	assert.NoFileExists(t, path+".3")
+	assert.Equal(t, "jane", readEntries(t, path)[0].Username)
+	assert.Len(t, received, 0)
+}
+
+func TestLoggerSlowHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := NewLogger(ctx, path, 1024*1024, 1)
+	defer func() { _ = l.Close() }()
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	l.AddHandler("test", func(entry Entry) { <-unblock })
+
+	// Logging doesn't block while the handler is stuck, and counts the entries it misses.
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, l.Log(Entry{Timestamp: time.Now().UTC(), Path: "/1.0"}))
+	}
+
+	assert.Greater(t, l.Dropped(), uint64(0))
+	assert.Len(t, readEntries(t, path), 2000)
+}
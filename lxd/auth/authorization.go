@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/logger"
 )
 
@@ -11,8 +12,10 @@ import (
 var ErrUnknownDriver = fmt.Errorf("Unknown driver")
 
 var authorizers = map[string]func() authorizer{
-	"tls":  func() authorizer { return &tls{} },
-	"rbac": func() authorizer { return &rbac{} },
+	"tls":       func() authorizer { return &tls{} },
+	"rbac":      func() authorizer { return &rbac{} },
+	"scriptlet": func() authorizer { return &scriptlet{} },
+	"embedded":  func() authorizer { return &embedded{} },
 }
 
 type authorizer interface {
@@ -38,6 +41,22 @@ type Authorizer interface {
 type UserAccess struct {
 	Admin    bool
 	Projects map[string][]string
+
+	// ReadOnly limits the access to the "view" permission, on all projects unless Projects is set.
+	ReadOnly bool
+}
+
+// HasPermission returns whether the access grants the permission on the project.
+func (ua *UserAccess) HasPermission(projectName string, permission string) bool {
+	if ua.ReadOnly && permission != "view" {
+		return false
+	}
+
+	if ua.Admin || (ua.ReadOnly && ua.Projects == nil) {
+		return true
+	}
+
+	return shared.StringInSlice(permission, ua.Projects[projectName])
 }
 
 func LoadAuthorizer(name string, config map[string]any, logger logger.Logger, projectsGetFunc func() (map[int64]string, error)) (Authorizer, error) {
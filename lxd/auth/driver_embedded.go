@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// Permission represents an entitlement on an entity granted to an identity through its auth groups.
+type Permission struct {
+	EntityType  string
+	Project     string
+	Name        string
+	Entitlement string
+}
+
+// IdentityPermissionsFunc returns the permissions granted to an identity through its auth groups, and whether
+// the identity belongs to any auth group. The claims are those of the OpenID Connect access token, if any.
+// It must be provided in the "permissions" config key of the embedded driver.
+type IdentityPermissionsFunc func(protocol string, username string, claims map[string]any) ([]Permission, bool)
+
+// AuthMethods lists the authentication methods of the identities that can be added to auth groups.
+var AuthMethods = []string{"tls", "oidc"}
+
+// Entitlements lists the entitlements that can be granted on each entity type.
+var Entitlements = map[string][]string{
+	api.AuthEntityTypeServer:   {"admin", "viewer"},
+	api.AuthEntityTypeProject:  {"operator", "viewer", "can_edit", "can_manage_instances", "can_operate_instances", "can_exec", "can_view_console", "can_manage_snapshots", "can_manage_images", "can_manage_networks", "can_manage_profiles", "can_manage_storage_volumes"},
+	api.AuthEntityTypeInstance: {"user", "viewer", "can_edit", "can_exec", "can_view_console", "can_manage_snapshots", "can_update_state"},
+}
+
+// projectEntitlementPermissions maps the entitlements on a project to the permissions they grant in it.
+var projectEntitlementPermissions = map[string][]string{
+	"operator":                   {"manage-containers", "operate-containers", "view-console", "manage-images", "manage-networks", "manage-profiles", "manage-storage-volumes", "operate-volumes"},
+	"can_edit":                   {"manage-projects"},
+	"can_manage_instances":       {"manage-containers", "operate-containers", "view-console"},
+	"can_operate_instances":      {"operate-containers", "view-console"},
+	"can_manage_images":          {"manage-images"},
+	"can_manage_networks":        {"manage-networks"},
+	"can_manage_profiles":        {"manage-profiles"},
+	"can_manage_storage_volumes": {"manage-storage-volumes", "operate-volumes"},
+}
+
+type embedded struct {
+	tls
+
+	permissions        IdentityPermissionsFunc
+	oidcGroupsRequired func() bool
+}
+
+func (a *embedded) load() error {
+	permissions, ok := a.config["permissions"].(IdentityPermissionsFunc)
+	if !ok || permissions == nil {
+		return fmt.Errorf("No identity permissions function provided")
+	}
+
+	a.permissions = permissions
+
+	// Optional function returning whether OpenID Connect identities must belong to an auth group.
+	a.oidcGroupsRequired, _ = a.config["oidc_groups_required"].(func() bool)
+
+	return nil
+}
+
+// UserIsAdmin checks whether the requestor is a global admin.
+// Identities that belong to auth groups are admins only if granted the admin entitlement on the server, while
+// OpenID Connect identities that don't belong to any auth group are denied if groups are required.
+func (a *embedded) UserIsAdmin(r *http.Request) bool {
+	permissions, found := a.identityPermissions(r)
+	if !found {
+		if a.denyWithoutGroups(r) {
+			return false
+		}
+
+		return a.tls.UserIsAdmin(r)
+	}
+
+	return isAdmin(permissions)
+}
+
+// UserHasPermission checks whether the requestor has a specific permission on a project.
+// Identities that belong to auth groups only get the permissions granted by their groups, in addition to the
+// projects a restricted TLS certificate gives access to. OpenID Connect identities that don't belong to any
+// auth group are denied if groups are required.
+func (a *embedded) UserHasPermission(r *http.Request, projectName string, permission string) bool {
+	permissions, found := a.identityPermissions(r)
+	if !found {
+		if a.denyWithoutGroups(r) {
+			return false
+		}
+
+		return a.tls.UserHasPermission(r, projectName, permission)
+	}
+
+	return hasPermission(r, permissions, projectName, permission)
+}
+
+// identityPermissions returns the permissions of the requestor and whether it belongs to any auth group.
+func (a *embedded) identityPermissions(r *http.Request) ([]Permission, bool) {
+	if a.permissions == nil {
+		return nil, false
+	}
+
+	ctx := r.Context()
+
+	protocol, _ := ctx.Value(request.CtxProtocol).(string)
+	if !shared.StringInSlice(protocol, AuthMethods) {
+		return nil, false
+	}
+
+	username, _ := ctx.Value(request.CtxUsername).(string)
+	claims, _ := ctx.Value(request.CtxOIDCClaims).(map[string]any)
+
+	return a.permissions(protocol, username, claims)
+}
+
+// isAdmin returns whether the permissions include the admin entitlement on the server.
+func isAdmin(permissions []Permission) bool {
+	for _, p := range permissions {
+		if p.EntityType == api.AuthEntityTypeServer && p.Entitlement == "admin" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPermission returns whether the permissions of the requestor, or the projects its restricted TLS certificate
+// gives access to, grant the permission on the project.
+func hasPermission(r *http.Request, permissions []Permission, projectName string, permission string) bool {
+	ua, ok := r.Context().Value(request.CtxAccess).(*UserAccess)
+	if ok && !ua.Admin && ua.HasPermission(projectName, permission) {
+		return true
+	}
+
+	instanceName, action := requestInstance(r)
+	for _, p := range permissions {
+		if permissionGrants(p, projectName, permission, instanceName, action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// denyWithoutGroups returns whether the requestor must be denied for not belonging to any auth group, which is
+// the case of OpenID Connect identities when the server requires them to belong to one.
+func (a *embedded) denyWithoutGroups(r *http.Request) bool {
+	return isOIDC(r) && a.oidcGroupsRequired != nil && a.oidcGroupsRequired()
+}
+
+// isOIDC returns whether the requestor authenticated through OpenID Connect.
+func isOIDC(r *http.Request) bool {
+	protocol, _ := r.Context().Value(request.CtxProtocol).(string)
+
+	return protocol == "oidc"
+}
+
+// ClaimMatches returns whether the OpenID Connect claims contain the claim with the given value.
+// List claims match if any of their elements is equal to the value. The "email_domain" claim matches the
+// domain of the "email" claim, provided the identity provider verified it ("email_verified" claim).
+func ClaimMatches(claims map[string]any, claim string, value string) bool {
+	if claim == "email_domain" {
+		verified, _ := claims["email_verified"].(bool)
+		if !verified {
+			return false
+		}
+
+		email, _ := claims["email"].(string)
+		_, domain, found := strings.Cut(email, "@")
+
+		return found && strings.EqualFold(domain, value)
+	}
+
+	switch v := claims[claim].(type) {
+	case nil:
+		return false
+	case []any:
+		for _, item := range v {
+			if fmt.Sprint(item) == value {
+				return true
+			}
+		}
+
+		return false
+	case []string:
+		return shared.StringInSlice(value, v)
+	default:
+		return fmt.Sprint(v) == value
+	}
+}
+
+// requestInstance returns the name of the instance targeted by the request and the instance sub-resource
+// (for example "exec" or "snapshots"), if any.
+func requestInstance(r *http.Request) (string, string) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != version.APIVersion || !shared.StringInSlice(parts[1], []string{"instances", "containers", "virtual-machines"}) {
+		return "", ""
+	}
+
+	if len(parts) == 3 {
+		return parts[2], ""
+	}
+
+	return parts[2], parts[3]
+}
+
+// instanceEntitlement returns the entitlement needed on an instance for an action requiring the permission.
+// Returns an empty string if the action isn't covered by a specific entitlement.
+func instanceEntitlement(permission string, action string) string {
+	if permission == "manage-containers" {
+		return "can_edit"
+	}
+
+	if permission == "view-console" && action == "console" {
+		return "can_view_console"
+	}
+
+	if permission != "operate-containers" {
+		return ""
+	}
+
+	switch action {
+	case "exec", "console", "files", "sftp":
+		return "can_exec"
+	case "snapshots":
+		return "can_manage_snapshots"
+	case "state":
+		return "can_update_state"
+	}
+
+	return ""
+}
+
+// permissionGrants returns whether p grants the permission on the project for a request targeting the instance
+// sub-resource, if any. Any permission on a project gives read access to the project and its instances, while a
+// permission on an instance only gives read access to that instance.
+func permissionGrants(p Permission, projectName string, permission string, instanceName string, action string) bool {
+	switch p.EntityType {
+	case api.AuthEntityTypeServer:
+		return p.Entitlement == "admin" || (p.Entitlement == "viewer" && permission == "view")
+	case api.AuthEntityTypeProject:
+		if p.Project != projectName {
+			return false
+		}
+
+		if permission == "view" || shared.StringInSlice(permission, projectEntitlementPermissions[p.Entitlement]) {
+			return true
+		}
+
+		return instanceName != "" && p.Entitlement == instanceEntitlement(permission, action)
+	case api.AuthEntityTypeInstance:
+		if p.Project != projectName || p.Name != instanceName {
+			return false
+		}
+
+		if permission == "view" {
+			return true
+		}
+
+		if p.Entitlement == "user" && shared.StringInSlice(permission, []string{"operate-containers", "view-console"}) {
+			return true
+		}
+
+		return p.Entitlement == instanceEntitlement(permission, action)
+	}
+
+	return false
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// newEmbeddedTestRequest returns a request to path from the given identity.
+func newEmbeddedTestRequest(method string, path string, protocol string, username string, ua *UserAccess) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+
+	ctx := context.WithValue(r.Context(), request.CtxProtocol, protocol)
+	ctx = context.WithValue(ctx, request.CtxUsername, username)
+	ctx = context.WithValue(ctx, request.CtxAccess, ua)
+
+	return r.WithContext(ctx)
+}
+
+// withClaims returns the request with the given OpenID Connect claims.
+func withClaims(r *http.Request, claims map[string]any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), request.CtxOIDCClaims, claims))
+}
+
+func TestEmbeddedAuthorizer(t *testing.T) {
+	permissions := map[string][]Permission{
+		"oidc/admin@example.com": {
+			{EntityType: api.AuthEntityTypeServer, Entitlement: "admin"},
+		},
+		"oidc/jane@example.com": {
+			{EntityType: api.AuthEntityTypeProject, Project: "dev", Entitlement: "operator"},
+			{EntityType: api.AuthEntityTypeProject, Project: "prod", Entitlement: "viewer"},
+			{EntityType: api.AuthEntityTypeInstance, Project: "prod", Name: "web", Entitlement: "can_exec"},
+			{EntityType: api.AuthEntityTypeInstance, Project: "prod", Name: "db", Entitlement: "can_view_console"},
+		},
+		"tls/restricted": {
+			{EntityType: api.AuthEntityTypeInstance, Project: "prod", Name: "db", Entitlement: "can_manage_snapshots"},
+		},
+	}
+
+	claimPermissions := map[string][]Permission{
+		"groups=lxd-operators": {
+			{EntityType: api.AuthEntityTypeProject, Project: "dev", Entitlement: "operator"},
+		},
+		"email_domain=example.org": {
+			{EntityType: api.AuthEntityTypeProject, Project: "prod", Entitlement: "viewer"},
+		},
+	}
+
+	config := map[string]any{
+		"permissions": IdentityPermissionsFunc(func(protocol string, username string, claims map[string]any) ([]Permission, bool) {
+			p, found := permissions[protocol+"/"+username]
+
+			for mapping, mappingPermissions := range claimPermissions {
+				claim, value, _ := strings.Cut(mapping, "=")
+				if ClaimMatches(claims, claim, value) {
+					p = append(p, mappingPermissions...)
+					found = true
+				}
+			}
+
+			return p, found
+		}),
+		"oidc_groups_required": func() bool { return true },
+	}
+
+	authorizer, err := LoadAuthorizer("embedded", config, logger.Log, nil)
+	require.NoError(t, err)
+
+	admin := &UserAccess{Admin: true}
+	restricted := &UserAccess{Projects: map[string][]string{"test": {"view", "operate-containers"}}}
+
+	tests := []struct {
+		name       string
+		request    *http.Request
+		project    string
+		permission string
+		allowed    bool
+	}{
+		{"Identity without groups", newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "john@example.com", admin), "prod", "view", false},
+		{"TLS identity without groups", newEmbeddedTestRequest("GET", "/1.0/instances", "tls", "unrestricted", admin), "prod", "manage-containers", true},
+		{"Server admin", newEmbeddedTestRequest("DELETE", "/1.0/projects/prod", "oidc", "admin@example.com", admin), "prod", "manage-projects", true},
+		{"Project operator", newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "jane@example.com", admin), "dev", "manage-containers", true},
+		{"Project operator can't edit the project", newEmbeddedTestRequest("PUT", "/1.0/projects/dev", "oidc", "jane@example.com", admin), "dev", "manage-projects", false},
+		{"Project viewer", newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "jane@example.com", admin), "prod", "view", true},
+		{"Project viewer can't create instances", newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "jane@example.com", admin), "prod", "manage-containers", false},
+		{"Instance exec", newEmbeddedTestRequest("POST", "/1.0/instances/web/exec", "oidc", "jane@example.com", admin), "prod", "operate-containers", true},
+		{"Instance exec on another instance", newEmbeddedTestRequest("POST", "/1.0/instances/db/exec", "oidc", "jane@example.com", admin), "prod", "operate-containers", false},
+		{"Console viewer", newEmbeddedTestRequest("POST", "/1.0/instances/db/console", "oidc", "jane@example.com", admin), "prod", "view-console", true},
+		{"Console viewer can't attach", newEmbeddedTestRequest("POST", "/1.0/instances/db/console", "oidc", "jane@example.com", admin), "prod", "operate-containers", false},
+		{"Console viewer on another instance", newEmbeddedTestRequest("POST", "/1.0/instances/app/console", "oidc", "jane@example.com", admin), "prod", "view-console", false},
+		{"Project operator can view the console", newEmbeddedTestRequest("POST", "/1.0/instances/app/console", "oidc", "jane@example.com", admin), "dev", "view-console", true},
+		{"Instance state without entitlement", newEmbeddedTestRequest("PUT", "/1.0/instances/web/state", "oidc", "jane@example.com", admin), "prod", "operate-containers", false},
+		{"Unknown project", newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "jane@example.com", admin), "other", "view", false},
+		{"Instance snapshots", newEmbeddedTestRequest("POST", "/1.0/containers/db/snapshots", "tls", "restricted", restricted), "prod", "operate-containers", true},
+		{"Instance entitlement gives read access", newEmbeddedTestRequest("GET", "/1.0/instances/db", "tls", "restricted", restricted), "prod", "view", true},
+		{"Instance entitlement doesn't give read access to other instances", newEmbeddedTestRequest("GET", "/1.0/instances/web", "tls", "restricted", restricted), "prod", "view", false},
+		{"Instance entitlement doesn't give read access to the project", newEmbeddedTestRequest("GET", "/1.0/instances", "tls", "restricted", restricted), "prod", "view", false},
+		{"Restricted certificate project", newEmbeddedTestRequest("PUT", "/1.0/instances/c1/state", "tls", "restricted", restricted), "test", "operate-containers", true},
+		{"Restricted certificate other project", newEmbeddedTestRequest("PUT", "/1.0/instances/c1/state", "tls", "restricted", restricted), "dev", "operate-containers", false},
+		{"Group claim", withClaims(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "bob@example.org", admin), map[string]any{"groups": []any{"lxd-operators", "staff"}}), "dev", "manage-containers", true},
+		{"Email domain claim", withClaims(newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "bob@example.org", admin), map[string]any{"email": "bob@example.org", "email_verified": true}), "prod", "view", true},
+		{"Unverified email domain claim", withClaims(newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "bob@example.org", admin), map[string]any{"email": "bob@example.org"}), "prod", "view", false},
+		{"Email domain claim can't create instances", withClaims(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "bob@example.org", admin), map[string]any{"email": "bob@example.org", "email_verified": true}), "prod", "manage-containers", false},
+		{"Unmapped claims", withClaims(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "bob@example.net", admin), map[string]any{"groups": []any{"staff"}, "email": "bob@example.net", "email_verified": true}), "prod", "view", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.allowed, authorizer.UserHasPermission(test.request, test.project, test.permission))
+		})
+	}
+
+	assert.True(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "oidc", "admin@example.com", admin)))
+	assert.False(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "oidc", "john@example.com", admin)))
+	assert.True(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "tls", "unrestricted", admin)))
+	assert.False(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "oidc", "jane@example.com", admin)))
+	assert.True(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "unix", "root", admin)))
+
+	// OpenID Connect identities without groups keep full access unless groups are required.
+	delete(config, "oidc_groups_required")
+	authorizer, err = LoadAuthorizer("embedded", config, logger.Log, nil)
+	require.NoError(t, err)
+
+	assert.True(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "oidc", "john@example.com", admin)))
+	assert.True(t, authorizer.UserHasPermission(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "john@example.com", admin), "prod", "manage-containers"))
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "jane@example.com", admin), "prod", "manage-containers"))
+}
+
+func TestClaimMatches(t *testing.T) {
+	claims := map[string]any{
+		"email":          "jane@Example.com",
+		"email_verified": true,
+		"groups":         []any{"lxd-admins", "staff"},
+		"roles":          []string{"operator"},
+		"tier":           float64(2),
+	}
+
+	assert.True(t, ClaimMatches(claims, "groups", "lxd-admins"))
+	assert.False(t, ClaimMatches(claims, "groups", "lxd"))
+	assert.True(t, ClaimMatches(claims, "roles", "operator"))
+	assert.True(t, ClaimMatches(claims, "tier", "2"))
+	assert.True(t, ClaimMatches(claims, "email_domain", "example.com"))
+	assert.False(t, ClaimMatches(claims, "email_domain", "example.org"))
+	assert.False(t, ClaimMatches(claims, "missing", ""))
+	assert.False(t, ClaimMatches(nil, "groups", "lxd-admins"))
+
+	// The email domain only matches verified emails.
+	assert.False(t, ClaimMatches(map[string]any{"email": "jane@example.com"}, "email_domain", "example.com"))
+	assert.False(t, ClaimMatches(map[string]any{"email": "jane@example.com", "email_verified": "true"}, "email_domain", "example.com"))
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/request"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// ScriptletAuthorizeFunc is the function used by the scriptlet authorizer to evaluate the authorization scriptlet.
+// It must be provided in the "authorize" config key of the scriptlet driver.
+type ScriptletAuthorizeFunc func(ctx context.Context, l logger.Logger, details *apiScriptlet.AuthorizationDetails, project string, permission string) (bool, error)
+
+// scriptlet is layered on top of the embedded driver: identities that belong to auth groups get the permissions
+// of their groups, while the authorization scriptlet decides for the others.
+type scriptlet struct {
+	embedded
+
+	authorize ScriptletAuthorizeFunc
+}
+
+func (a *scriptlet) load() error {
+	authorize, ok := a.config["authorize"].(ScriptletAuthorizeFunc)
+	if !ok || authorize == nil {
+		return fmt.Errorf("No authorization function provided")
+	}
+
+	a.authorize = authorize
+
+	// The identity permissions function is optional.
+	a.permissions, _ = a.config["permissions"].(IdentityPermissionsFunc)
+	a.oidcGroupsRequired, _ = a.config["oidc_groups_required"].(func() bool)
+
+	return nil
+}
+
+// AddProject is a no-op. It notifies the authorization service about new projects.
+func (a *scriptlet) AddProject(projectID int64, name string) error {
+	return nil
+}
+
+// DeleteProject is a no-op. It notifies the authorization service about deleted projects.
+func (a *scriptlet) DeleteProject(projectID int64) error {
+	return nil
+}
+
+// RenameProject is a no-op. It notifies the authorization service that a project has been renamed.
+func (a *scriptlet) RenameProject(projectID int64, newName string) error {
+	return nil
+}
+
+// StopStatusCheck is a no-op.
+func (a *scriptlet) StopStatusCheck() {
+}
+
+func (a *scriptlet) UserAccess(username string) (*UserAccess, error) {
+	return &UserAccess{Admin: true}, nil
+}
+
+// UserIsAdmin checks whether the requestor is a global admin.
+// Identities that belong to auth groups are admins only if granted the admin entitlement on the server.
+// For the others, the authorization scriptlet is called with an empty project and the "admin" permission, unless
+// they are OpenID Connect identities and groups are required.
+func (a *scriptlet) UserIsAdmin(r *http.Request) bool {
+	permissions, found := a.identityPermissions(r)
+	if found {
+		return isAdmin(permissions)
+	}
+
+	if a.denyWithoutGroups(r) {
+		return false
+	}
+
+	val := r.Context().Value(request.CtxAccess)
+	if val == nil {
+		return false
+	}
+
+	// Restricted TLS clients can't be admins.
+	ua := val.(*UserAccess)
+	if !ua.Admin {
+		return false
+	}
+
+	return a.check(r, "", "admin")
+}
+
+// UserHasPermission checks whether the requestor has a specific permission on a project.
+// Identities that belong to auth groups only get the permissions granted by their groups, while the others are
+// checked by the authorization scriptlet, unless they are OpenID Connect identities and groups are required.
+func (a *scriptlet) UserHasPermission(r *http.Request, projectName string, permission string) bool {
+	permissions, found := a.identityPermissions(r)
+	if found {
+		return hasPermission(r, permissions, projectName, permission)
+	}
+
+	if a.denyWithoutGroups(r) {
+		return false
+	}
+
+	val := r.Context().Value(request.CtxAccess)
+	if val == nil {
+		return false
+	}
+
+	// Restricted TLS clients remain limited to their projects.
+	ua := val.(*UserAccess)
+	if !ua.HasPermission(projectName, permission) {
+		return false
+	}
+
+	return a.check(r, projectName, permission)
+}
+
+// check runs the authorization scriptlet for the requestor.
+// Local and internal cluster requests are always allowed. Any scriptlet failure denies the request.
+func (a *scriptlet) check(r *http.Request, projectName string, permission string) bool {
+	ctx := r.Context()
+
+	protocol, _ := ctx.Value(request.CtxProtocol).(string)
+	if protocol == "unix" || protocol == "cluster" {
+		return true
+	}
+
+	details := &apiScriptlet.AuthorizationDetails{
+		Protocol: protocol,
+		Address:  r.RemoteAddr,
+	}
+
+	details.Username, _ = ctx.Value(request.CtxUsername).(string)
+	details.Claims, _ = ctx.Value(request.CtxOIDCClaims).(map[string]any)
+
+	allowed, err := a.authorize(ctx, a.logger, details, projectName, permission)
+	if err != nil {
+		a.logger.Error("Failed running authorization scriptlet", logger.Ctx{"username": details.Username, "protocol": protocol, "project": projectName, "permission": permission, "err": err})
+		return false
+	}
+
+	return allowed
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+func TestScriptletAuthorizer_Groups(t *testing.T) {
+	config := map[string]any{
+		"authorize": ScriptletAuthorizeFunc(func(ctx context.Context, l logger.Logger, details *apiScriptlet.AuthorizationDetails, project string, permission string) (bool, error) {
+			return project == "default", nil
+		}),
+		"permissions": IdentityPermissionsFunc(func(protocol string, username string, claims map[string]any) ([]Permission, bool) {
+			if protocol+"/"+username != "oidc/jane@example.com" {
+				return nil, false
+			}
+
+			return []Permission{{EntityType: api.AuthEntityTypeProject, Project: "dev", Entitlement: "operator"}}, true
+		}),
+	}
+
+	authorizer, err := LoadAuthorizer("scriptlet", config, logger.Log, nil)
+	require.NoError(t, err)
+
+	admin := &UserAccess{Admin: true}
+
+	// Identities that belong to auth groups get the permissions of their groups.
+	assert.True(t, authorizer.UserHasPermission(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "jane@example.com", admin), "dev", "manage-containers"))
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "jane@example.com", admin), "default", "view"))
+	assert.False(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "oidc", "jane@example.com", admin)))
+
+	// The scriptlet decides for the others.
+	assert.True(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances", "oidc", "john@example.com", admin), "default", "view"))
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("POST", "/1.0/instances", "oidc", "john@example.com", admin), "dev", "manage-containers"))
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/canonical/lxd/lxd/request"
-	"github.com/canonical/lxd/shared"
 )
 
 type tls struct {
@@ -57,9 +56,6 @@ func (a *tls) UserHasPermission(r *http.Request, projectName string, permission
 	}
 
 	ua := val.(*UserAccess)
-	if ua.Admin {
-		return true
-	}
 
-	return shared.StringInSlice(permission, ua.Projects[projectName])
+	return ua.HasPermission(projectName, permission)
 }
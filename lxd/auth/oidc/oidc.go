@@ -40,8 +40,8 @@ func (e AuthError) Unwrap() error {
 	return e.Err
 }
 
-// Auth extracts the token, validates it and returns the user information.
-func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, error) {
+// Auth extracts the token, validates it and returns the user information along with the token claims.
+func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, map[string]any, error) {
 	var token string
 
 	auth := r.Header.Get("Authorization")
@@ -54,7 +54,7 @@ func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		// Both returned errors contain information which are needed for the client to authenticate.
 		parts := strings.Split(auth, "Bearer ")
 		if len(parts) != 2 {
-			return "", &AuthError{fmt.Errorf("Bad authorization token, expected a Bearer token")}
+			return "", nil, &AuthError{fmt.Errorf("Bad authorization token, expected a Bearer token")}
 		}
 
 		token = parts[1]
@@ -62,7 +62,7 @@ func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		// When not using a Bearer token, fetch the equivalent from a cookie and move on with it.
 		cookie, err := r.Cookie("oidc_access")
 		if err != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 
 		token = cookie.Value
@@ -73,7 +73,7 @@ func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 		o.accessTokenVerifier, err = getAccessTokenVerifier(o.issuer)
 		if err != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 	}
 
@@ -82,25 +82,25 @@ func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		// See if we can refresh the access token.
 		cookie, cookieErr := r.Cookie("oidc_refresh")
 		if cookieErr != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 
 		// Get the provider.
 		provider, err := o.getProvider(r)
 		if err != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 
 		// Attempt the refresh.
 		tokens, err := rp.RefreshAccessToken(provider, cookie.Value, "", "")
 		if err != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 
 		// Validate the refreshed token.
 		claims, err = o.VerifyAccessToken(ctx, tokens.AccessToken)
 		if err != nil {
-			return "", &AuthError{err}
+			return "", nil, &AuthError{err}
 		}
 
 		// Update the access token cookie.
@@ -132,10 +132,10 @@ func (o *Verifier) Auth(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 	user, ok := claims.Claims["email"]
 	if ok && user != nil && user.(string) != "" {
-		return user.(string), nil
+		return user.(string), claims.Claims, nil
 	}
 
-	return claims.Subject, nil
+	return claims.Subject, claims.Claims, nil
 }
 
 func (o *Verifier) Login(w http.ResponseWriter, r *http.Request) {
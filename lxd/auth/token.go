@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TokenPrefix is the prefix of the API bearer tokens, distinguishing them from OpenID Connect access tokens.
+const TokenPrefix = "lxd_"
+
+// NewToken returns a new random API bearer token along with its fingerprint.
+func NewToken() (string, string, error) {
+	buf := make([]byte, 32)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed generating token: %w", err)
+	}
+
+	token := TokenPrefix + hex.EncodeToString(buf)
+
+	return token, TokenFingerprint(token), nil
+}
+
+// TokenFingerprint returns the SHA256 fingerprint of an API bearer token, which is what gets stored in the database.
+func TokenFingerprint(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// BearerToken returns the API bearer token from the value of an Authorization header, if it holds one.
+func BearerToken(authorization string) (string, bool) {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || !strings.HasPrefix(token, TokenPrefix) {
+		return "", false
+	}
+
+	return token, true
+}
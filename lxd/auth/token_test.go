@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/logger"
+)
+
+func TestNewToken(t *testing.T) {
+	token, fingerprint, err := NewToken()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, TokenFingerprint(token), fingerprint)
+	assert.Len(t, fingerprint, 64)
+
+	other, _, err := NewToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	found, ok := BearerToken("Bearer " + token)
+	assert.True(t, ok)
+	assert.Equal(t, token, found)
+
+	_, ok = BearerToken("Bearer eyJhbGciOiJSUzI1NiJ9")
+	assert.False(t, ok)
+
+	_, ok = BearerToken(token)
+	assert.False(t, ok)
+}
+
+func TestReadOnlyTokenAccess(t *testing.T) {
+	authorizer, err := LoadAuthorizer("tls", nil, logger.Log, nil)
+	require.NoError(t, err)
+
+	readOnly := &UserAccess{ReadOnly: true}
+	restricted := &UserAccess{ReadOnly: true, Projects: map[string][]string{"prod": {"view"}}}
+
+	// Read-only tokens can view all projects, but aren't admins.
+	assert.False(t, authorizer.UserIsAdmin(newEmbeddedTestRequest("GET", "/1.0", "token", "ro", readOnly)))
+	assert.True(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances", "token", "ro", readOnly), "prod", "view"))
+
+	// They can't open websockets requiring more than the view permission, like the SFTP one.
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances/c1/sftp", "token", "ro", readOnly), "prod", "operate-containers"))
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances/c1/sftp", "token", "ro", restricted), "prod", "operate-containers"))
+
+	// Restricted read-only tokens can only view their projects.
+	assert.True(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances", "token", "ro", restricted), "prod", "view"))
+	assert.False(t, authorizer.UserHasPermission(newEmbeddedTestRequest("GET", "/1.0/instances", "token", "ro", restricted), "dev", "view"))
+}
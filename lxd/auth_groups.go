@@ -0,0 +1,704 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/lxd/client"
+	"github.com/canonical/lxd/lxd/auth"
+	"github.com/canonical/lxd/lxd/cluster"
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/lxd/lifecycle"
+	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/ratelimit"
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/version"
+)
+
+type authGroupCache struct {
+	Permissions      map[string][]auth.Permission
+	ClaimPermissions map[string][]auth.Permission
+	Configs          map[string][]map[string]string
+	ClaimConfigs     map[string][]map[string]string
+	Lock             sync.Mutex
+}
+
+// authGroupConfigKeys are the configuration keys supported by auth groups, along with their validators.
+var authGroupConfigKeys = map[string]func(value string) error{
+	"limits.rate.exec":  authGroupRateLimitValidate,
+	"limits.rate.read":  authGroupRateLimitValidate,
+	"limits.rate.write": authGroupRateLimitValidate,
+}
+
+func authGroupRateLimitValidate(value string) error {
+	_, err := ratelimit.ParseLimit(value)
+
+	return err
+}
+
+var authGroupsCmd = APIEndpoint{
+	Path: "auth/groups",
+
+	Get:  APIEndpointAction{Handler: authGroupsGet},
+	Post: APIEndpointAction{Handler: authGroupsPost},
+}
+
+var authGroupCmd = APIEndpoint{
+	Path: "auth/groups/{name}",
+
+	Delete: APIEndpointAction{Handler: authGroupDelete},
+	Get:    APIEndpointAction{Handler: authGroupGet},
+	Post:   APIEndpointAction{Handler: authGroupPost},
+	Put:    APIEndpointAction{Handler: authGroupPut},
+}
+
+// updateAuthGroupCache loads the permissions granted to identities through auth groups into memory.
+func updateAuthGroupCache(d *Daemon) {
+	s := d.State()
+
+	logger.Debug("Refreshing auth group cache")
+
+	var permissions map[string][]auth.Permission
+	var claimPermissions map[string][]auth.Permission
+	var configs map[string][]map[string]string
+	var claimConfigs map[string][]map[string]string
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		permissions, err = tx.GetAuthIdentityPermissions(ctx)
+		if err != nil {
+			return err
+		}
+
+		claimPermissions, err = tx.GetAuthClaimPermissions(ctx)
+		if err != nil {
+			return err
+		}
+
+		configs, err = tx.GetAuthIdentityConfigs(ctx)
+		if err != nil {
+			return err
+		}
+
+		claimConfigs, err = tx.GetAuthClaimConfigs(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed reading auth groups from global database", logger.Ctx{"err": err})
+		return
+	}
+
+	d.authGroups.Lock.Lock()
+	d.authGroups.Permissions = permissions
+	d.authGroups.ClaimPermissions = claimPermissions
+	d.authGroups.Configs = configs
+	d.authGroups.ClaimConfigs = claimConfigs
+	d.authGroups.Lock.Unlock()
+
+	// The rate limits of the groups may have changed.
+	d.rateLimits.reset()
+}
+
+// identityPermissions returns the permissions granted to an identity through its auth groups, and whether the
+// identity belongs to any auth group. OpenID Connect users also get the permissions of the groups their claims
+// are mapped to.
+func (d *Daemon) identityPermissions(protocol string, username string, claims map[string]any) ([]auth.Permission, bool) {
+	d.authGroups.Lock.Lock()
+	defer d.authGroups.Lock.Unlock()
+
+	permissions, found := d.authGroups.Permissions[protocol+"/"+username]
+
+	if protocol == "oidc" && len(claims) > 0 {
+		// Copy the cached permissions before appending those of the claims.
+		permissions = append([]auth.Permission(nil), permissions...)
+
+		for mapping, claimPermissions := range d.authGroups.ClaimPermissions {
+			claim, value, _ := strings.Cut(mapping, "=")
+			if auth.ClaimMatches(claims, claim, value) {
+				permissions = append(permissions, claimPermissions...)
+				found = true
+			}
+		}
+	}
+
+	return permissions, found
+}
+
+// identityRateLimit returns the rate limit set by the auth groups of an identity on a class of API endpoints, or
+// an empty string if none of them sets one. If several groups set a limit, the one allowing the most requests
+// applies.
+func (d *Daemon) identityRateLimit(protocol string, username string, claims map[string]any, class string) string {
+	d.authGroups.Lock.Lock()
+	defer d.authGroups.Lock.Unlock()
+
+	configs := d.authGroups.Configs[protocol+"/"+username]
+
+	if protocol == "oidc" && len(claims) > 0 {
+		configs = append([]map[string]string(nil), configs...)
+
+		for mapping, claimConfigs := range d.authGroups.ClaimConfigs {
+			claim, value, _ := strings.Cut(mapping, "=")
+			if auth.ClaimMatches(claims, claim, value) {
+				configs = append(configs, claimConfigs...)
+			}
+		}
+	}
+
+	limit := ""
+	var rate float64
+	for _, config := range configs {
+		value := config["limits.rate."+class]
+		if value == "" {
+			continue
+		}
+
+		parsedLimit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			continue
+		}
+
+		parsedRate := float64(parsedLimit.Requests) / parsedLimit.Interval.Seconds()
+		if parsedRate > rate {
+			limit = value
+			rate = parsedRate
+		}
+	}
+
+	return limit
+}
+
+// authGroupsNotify refreshes the auth group cache of this member and notifies the other cluster members.
+func authGroupsNotify(d *Daemon, r *http.Request, hook func(client lxd.InstanceServer) error) error {
+	updateAuthGroupCache(d)
+
+	if isClusterNotification(r) {
+		return nil
+	}
+
+	s := d.State()
+
+	notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAlive)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// authGroupValidate validates the name, identities, OpenID Connect claims, permissions and configuration of an
+// auth group.
+func authGroupValidate(name string, req api.AuthGroupPut) error {
+	err := authGroupValidateName(name)
+	if err != nil {
+		return err
+	}
+
+	for _, identity := range req.Identities {
+		method, identifier, _ := strings.Cut(identity, "/")
+		if !shared.StringInSlice(method, auth.AuthMethods) || identifier == "" {
+			return fmt.Errorf("Invalid identity %q, must be one of %s followed by a slash and the identifier", identity, strings.Join(auth.AuthMethods, ", "))
+		}
+	}
+
+	for _, oidcClaim := range req.OIDCClaims {
+		claim, _, found := strings.Cut(oidcClaim, "=")
+		if !found || claim == "" {
+			return fmt.Errorf("Invalid OpenID Connect claim %q, must be the claim name followed by an equal sign and the value", oidcClaim)
+		}
+	}
+
+	for _, permission := range req.Permissions {
+		entitlements, found := auth.Entitlements[permission.EntityType]
+		if !found {
+			return fmt.Errorf("Invalid entity type %q", permission.EntityType)
+		}
+
+		if !shared.StringInSlice(permission.Entitlement, entitlements) {
+			return fmt.Errorf("Invalid entitlement %q for entity type %q", permission.Entitlement, permission.EntityType)
+		}
+	}
+
+	for key, value := range req.Config {
+		validator, found := authGroupConfigKeys[key]
+		if !found {
+			return fmt.Errorf("Invalid config key %q", key)
+		}
+
+		err := validator(value)
+		if err != nil {
+			return fmt.Errorf("Invalid value for config key %q: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func authGroupValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if strings.ContainsAny(name, `/ '"`) {
+		return fmt.Errorf("Auth group names may not contain slashes, spaces or quotes")
+	}
+
+	if shared.StringInSlice(name, []string{".", ".."}) {
+		return fmt.Errorf("Invalid auth group name %q", name)
+	}
+
+	return nil
+}
+
+// swagger:operation GET /1.0/auth/groups auth auth_groups_get
+//
+//	Get the authorization groups
+//
+//	Returns a list of authorization groups (URLs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of endpoints
+//	          items:
+//	            type: string
+//	          example: |-
+//	            [
+//	              "/1.0/auth/groups/operators",
+//	              "/1.0/auth/groups/viewers"
+//	            ]
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/auth/groups?recursion=1 auth auth_groups_get_recursion1
+//
+//	Get the authorization groups
+//
+//	Returns a list of authorization groups (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of authorization groups
+//	          items:
+//	            $ref: "#/definitions/AuthGroup"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupsGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	var groups []api.AuthGroup
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		groups, err = tx.GetAuthGroups(ctx)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		return response.SyncResponse(true, groups)
+	}
+
+	urls := make([]string, 0, len(groups))
+	for _, group := range groups {
+		urls = append(urls, api.NewURL().Path(version.APIVersion, "auth", "groups", group.Name).String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/auth/groups auth auth_groups_post
+//
+//	Create an authorization group
+//
+//	Creates a new authorization group.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: group
+//	    description: Authorization group to create
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/AuthGroupsPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupsPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	req := api.AuthGroupsPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Quick checks.
+		err = authGroupValidate(req.Name, req.AuthGroupPut)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			_, err := tx.GetAuthGroup(ctx, req.Name)
+			if err == nil {
+				return api.StatusErrorf(http.StatusConflict, "Auth group %q already exists", req.Name)
+			}
+
+			return tx.CreateAuthGroup(ctx, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = authGroupsNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.CreateAuthGroup(req)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	lc := lifecycle.AuthGroupCreated.Event(req.Name, request.CreateRequestor(r), nil)
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lc)
+	}
+
+	return response.SyncResponseLocation(true, nil, lc.Source)
+}
+
+// swagger:operation GET /1.0/auth/groups/{name} auth auth_group_get
+//
+//	Get the authorization group
+//
+//	Gets a specific authorization group.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Authorization group
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/AuthGroup"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var group *api.AuthGroup
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		group, err = tx.GetAuthGroup(ctx, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, group, group.Writable())
+}
+
+// swagger:operation PUT /1.0/auth/groups/{name} auth auth_group_put
+//
+//	Update the authorization group
+//
+//	Replaces the description, identities, OpenID Connect claims and permissions of the authorization group.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: group
+//	    description: Authorization group configuration
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/AuthGroupPut"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "412":
+//	    $ref: "#/responses/PreconditionFailed"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupPut(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.AuthGroupPut{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Quick checks.
+		err = authGroupValidate(name, req)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			group, err := tx.GetAuthGroup(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			// Validate the ETag.
+			err = util.EtagCheck(r, group.Writable())
+			if err != nil {
+				return err
+			}
+
+			return tx.UpdateAuthGroup(ctx, name, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = authGroupsNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.UpdateAuthGroup(name, req, "")
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lifecycle.AuthGroupUpdated.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation POST /1.0/auth/groups/{name} auth auth_group_post
+//
+//	Rename the authorization group
+//
+//	Renames an existing authorization group.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: name
+//	    description: Authorization group rename request
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/AuthGroupPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.AuthGroupPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Quick checks.
+		err = authGroupValidateName(req.Name)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			// Check that the name isn't already in use.
+			_, err := tx.GetAuthGroup(ctx, req.Name)
+			if err == nil {
+				return api.StatusErrorf(http.StatusConflict, "Name %q already in use", req.Name)
+			}
+
+			return tx.RenameAuthGroup(ctx, name, req.Name)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = authGroupsNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.RenameAuthGroup(name, req)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	lc := lifecycle.AuthGroupRenamed.Event(req.Name, request.CreateRequestor(r), logger.Ctx{"old_name": name})
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lc)
+	}
+
+	return response.SyncResponseLocation(true, nil, lc.Source)
+}
+
+// swagger:operation DELETE /1.0/auth/groups/{name} auth auth_group_delete
+//
+//	Delete the authorization group
+//
+//	Removes the authorization group along with its identities and permissions.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authGroupDelete(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return tx.DeleteAuthGroup(ctx, name)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = authGroupsNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.DeleteAuthGroup(name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lifecycle.AuthGroupDeleted.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/lxd/client"
+	"github.com/canonical/lxd/lxd/auth"
+	"github.com/canonical/lxd/lxd/cluster"
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/lxd/lifecycle"
+	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// authTokenLastUsedInterval is how often the last use of an API bearer token gets recorded in the database.
+const authTokenLastUsedInterval = time.Minute
+
+type authTokenCache struct {
+	Tokens map[string]api.AuthToken
+	Lock   sync.Mutex
+}
+
+var authTokensCmd = APIEndpoint{
+	Path: "auth/tokens",
+
+	Get:  APIEndpointAction{Handler: authTokensGet},
+	Post: APIEndpointAction{Handler: authTokensPost},
+}
+
+var authTokenCmd = APIEndpoint{
+	Path: "auth/tokens/{fingerprint}",
+
+	Delete: APIEndpointAction{Handler: authTokenDelete},
+	Get:    APIEndpointAction{Handler: authTokenGet},
+}
+
+// updateAuthTokenCache loads the API bearer tokens into memory.
+func updateAuthTokenCache(d *Daemon) {
+	s := d.State()
+
+	logger.Debug("Refreshing auth token cache")
+
+	var tokens []api.AuthToken
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		tokens, err = tx.GetAuthTokens(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed reading auth tokens from global database", logger.Ctx{"err": err})
+		return
+	}
+
+	newTokens := make(map[string]api.AuthToken, len(tokens))
+	for _, token := range tokens {
+		newTokens[token.Fingerprint] = token
+	}
+
+	d.authTokens.Lock.Lock()
+	d.authTokens.Tokens = newTokens
+	d.authTokens.Lock.Unlock()
+}
+
+// authenticateToken returns the fingerprint of an API bearer token and whether the token is valid.
+// The last use of the token is recorded in the background.
+func (d *Daemon) authenticateToken(token string) (string, bool) {
+	fingerprint := auth.TokenFingerprint(token)
+	now := time.Now()
+
+	d.authTokens.Lock.Lock()
+	cachedToken, found := d.authTokens.Tokens[fingerprint]
+	if !found || (!cachedToken.ExpiresAt.IsZero() && now.After(cachedToken.ExpiresAt)) {
+		d.authTokens.Lock.Unlock()
+		return "", false
+	}
+
+	recordUse := now.Sub(cachedToken.LastUsedAt) > authTokenLastUsedInterval
+	if recordUse {
+		cachedToken.LastUsedAt = now
+		d.authTokens.Tokens[fingerprint] = cachedToken
+	}
+
+	d.authTokens.Lock.Unlock()
+
+	if recordUse {
+		go func() {
+			err := d.db.Cluster.Transaction(d.shutdownCtx, func(ctx context.Context, tx *db.ClusterTx) error {
+				return tx.UpdateAuthTokenLastUsed(ctx, fingerprint, now)
+			})
+			if err != nil {
+				logger.Warn("Failed recording auth token use", logger.Ctx{"fingerprint": fingerprint, "err": err})
+			}
+		}()
+	}
+
+	return fingerprint, true
+}
+
+// tokenUserAccess returns the access granted by the API bearer token with the given fingerprint for a request
+// using the given method.
+func (d *Daemon) tokenUserAccess(fingerprint string, method string) (*auth.UserAccess, error) {
+	d.authTokens.Lock.Lock()
+	token, found := d.authTokens.Tokens[fingerprint]
+	d.authTokens.Lock.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf("Unknown auth token")
+	}
+
+	if token.ReadOnly && !shared.StringInSlice(method, []string{http.MethodGet, http.MethodHead}) {
+		return nil, fmt.Errorf("Read-only auth token can't be used for %s requests", method)
+	}
+
+	// Read-only tokens are never admins, and only get the view permission on their projects (or all of them).
+	ua := &auth.UserAccess{Admin: !token.Restricted && !token.ReadOnly, ReadOnly: token.ReadOnly}
+	if token.Restricted {
+		permissions := restrictedProjectPermissions
+		if token.ReadOnly {
+			permissions = []string{"view"}
+		}
+
+		ua.Projects = map[string][]string{}
+		for _, projectName := range token.Projects {
+			ua.Projects[projectName] = permissions
+		}
+	}
+
+	return ua, nil
+}
+
+// authTokensNotify refreshes the auth token cache of this member and notifies the other cluster members.
+func authTokensNotify(d *Daemon, r *http.Request, hook func(client lxd.InstanceServer) error) error {
+	updateAuthTokenCache(d)
+
+	if isClusterNotification(r) {
+		return nil
+	}
+
+	s := d.State()
+
+	notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAlive)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// swagger:operation GET /1.0/auth/tokens auth auth_tokens_get
+//
+//	Get the API bearer tokens
+//
+//	Returns a list of API bearer tokens (URLs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of endpoints
+//	          items:
+//	            type: string
+//	          example: |-
+//	            [
+//	              "/1.0/auth/tokens/0ff8b4c1c8ae6bb6b8d0a5a6f0f6c2b1e5d9c1e4c3a9d4e8f2a7b3c6d1e5f4a2"
+//	            ]
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/auth/tokens?recursion=1 auth auth_tokens_get_recursion1
+//
+//	Get the API bearer tokens
+//
+//	Returns a list of API bearer tokens (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of API bearer tokens
+//	          items:
+//	            $ref: "#/definitions/AuthToken"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authTokensGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	var tokens []api.AuthToken
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		tokens, err = tx.GetAuthTokens(ctx)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		return response.SyncResponse(true, tokens)
+	}
+
+	urls := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		urls = append(urls, token.URL(version.APIVersion).String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/auth/tokens auth auth_tokens_post
+//
+//	Create an API bearer token
+//
+//	Creates a new API bearer token and returns it.
+//	The token itself is only ever returned by this call, the server only stores its fingerprint.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: token
+//	    description: API bearer token to create
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/AuthTokensPost"
+//	responses:
+//	  "200":
+//	    description: API bearer token
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/AuthTokenSecret"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authTokensPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	// Cluster notifications only need the cache to be refreshed.
+	if isClusterNotification(r) {
+		updateAuthTokenCache(d)
+		return response.EmptySyncResponse
+	}
+
+	req := api.AuthTokensPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Quick checks.
+	if !req.ExpiresAt.IsZero() && req.ExpiresAt.Before(time.Now()) {
+		return response.BadRequest(fmt.Errorf("Expiry date is in the past"))
+	}
+
+	if !req.Restricted && len(req.Projects) > 0 {
+		return response.BadRequest(fmt.Errorf("Projects can only be set on restricted tokens"))
+	}
+
+	token, fingerprint, err := auth.NewToken()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.CreateAuthToken(ctx, fingerprint, req)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = authTokensNotify(d, r, func(client lxd.InstanceServer) error {
+		_, err := client.CreateAuthToken(req)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	lc := lifecycle.AuthTokenCreated.Event(fingerprint, request.CreateRequestor(r), nil)
+	s.Events.SendLifecycle(project.Default, lc)
+
+	return response.SyncResponseLocation(true, api.AuthTokenSecret{Fingerprint: fingerprint, Token: token}, lc.Source)
+}
+
+// swagger:operation GET /1.0/auth/tokens/{fingerprint} auth auth_token_get
+//
+//	Get the API bearer token
+//
+//	Gets a specific API bearer token.
+//	A unique prefix of the fingerprint is accepted.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API bearer token
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/AuthToken"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authTokenGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	fingerprint, err := url.PathUnescape(mux.Vars(r)["fingerprint"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var token *api.AuthToken
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		token, err = tx.GetAuthTokenByFingerprintPrefix(ctx, fingerprint)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, token)
+}
+
+// swagger:operation DELETE /1.0/auth/tokens/{fingerprint} auth auth_token_delete
+//
+//	Revoke the API bearer token
+//
+//	Removes the API bearer token, which can't be used anymore.
+//	A unique prefix of the fingerprint is accepted.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func authTokenDelete(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	fingerprint, err := url.PathUnescape(mux.Vars(r)["fingerprint"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			token, err := tx.GetAuthTokenByFingerprintPrefix(ctx, fingerprint)
+			if err != nil {
+				return err
+			}
+
+			fingerprint = token.Fingerprint
+
+			return tx.DeleteAuthToken(ctx, fingerprint)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = authTokensNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.DeleteAuthToken(fingerprint)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lifecycle.AuthTokenDeleted.Event(fingerprint, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
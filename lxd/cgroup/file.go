@@ -11,6 +11,42 @@ import (
 
 // NewFileReadWriter returns a CGroup instance using the filesystem as its backend.
 func NewFileReadWriter(pid int, unifiedCapable bool) (*CGroup, error) {
+	rw, err := newFileReadWriter(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	cg, err := New(rw)
+	if err != nil {
+		return nil, err
+	}
+
+	cg.UnifiedCapable = unifiedCapable
+	return cg, nil
+}
+
+// GetProcessCgroupPath returns the path of the cgroup of the process with the given PID in the hierarchy of the
+// controller, or in the unified hierarchy if the controller is handled by it.
+func GetProcessCgroupPath(pid int, controller string) (string, error) {
+	rw, err := newFileReadWriter(pid)
+	if err != nil {
+		return "", err
+	}
+
+	path := rw.paths[controller]
+	if cgLayout == CgroupsUnified || cgControllers[controller] == V2 {
+		path = rw.paths["unified"]
+	}
+
+	if path == "" {
+		return "", ErrControllerMissing
+	}
+
+	return path, nil
+}
+
+// newFileReadWriter returns a fileReadWriter for the cgroups of the process with the given PID.
+func newFileReadWriter(pid int) (*fileReadWriter, error) {
 	// Setup the read/writer struct.
 	rw := fileReadWriter{}
 
@@ -53,13 +89,7 @@ func NewFileReadWriter(pid int, unifiedCapable bool) (*CGroup, error) {
 		}
 	}
 
-	cg, err := New(&rw)
-	if err != nil {
-		return nil, err
-	}
-
-	cg.UnifiedCapable = unifiedCapable
-	return cg, nil
+	return &rw, nil
 }
 
 type fileReadWriter struct {
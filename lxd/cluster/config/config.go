@@ -15,6 +15,8 @@ import (
 
 	"github.com/canonical/lxd/lxd/config"
 	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/lxd/otlp"
+	"github.com/canonical/lxd/lxd/ratelimit"
 	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/validate"
@@ -43,6 +45,21 @@ func Load(ctx context.Context, tx *db.ClusterTx) (*Config, error) {
 	return &Config{tx: tx, m: m}, nil
 }
 
+// AuditEnabled returns whether authenticated API requests are recorded in the audit log.
+func (c *Config) AuditEnabled() bool {
+	return c.m.GetBool("audit.enabled")
+}
+
+// AuditReadOnly returns whether read-only API requests are recorded in the audit log too.
+func (c *Config) AuditReadOnly() bool {
+	return c.m.GetBool("audit.read_only")
+}
+
+// AuthorizationScriptlet returns the authorization scriptlet source code.
+func (c *Config) AuthorizationScriptlet() string {
+	return c.m.GetString("authorization.scriptlet")
+}
+
 // BackupsCompressionAlgorithm returns the compression algorithm to use for backups.
 func (c *Config) BackupsCompressionAlgorithm() string {
 	return c.m.GetString("backups.compression_algorithm")
@@ -207,6 +224,11 @@ func (c *Config) InstancesNICHostname() string {
 	return c.m.GetString("instances.nic.host_name")
 }
 
+// InstancesAdmissionScriptlet returns the instances admission scriptlet source code.
+func (c *Config) InstancesAdmissionScriptlet() string {
+	return c.m.GetString("instances.admission.scriptlet")
+}
+
 // InstancesPlacementScriptlet returns the instances placement scriptlet source code.
 func (c *Config) InstancesPlacementScriptlet() string {
 	return c.m.GetString("instances.placement.scriptlet")
@@ -228,6 +250,20 @@ func (c *Config) LokiServer() (string, string, string, string, []string, string,
 	return c.m.GetString("loki.api.url"), c.m.GetString("loki.auth.username"), c.m.GetString("loki.auth.password"), c.m.GetString("loki.api.ca_cert"), labels, c.m.GetString("loki.loglevel"), types
 }
 
+// TracingServer returns the OTLP collector endpoint the traces get exported to, along with the headers and CA
+// certificate used to connect to it.
+func (c *Config) TracingServer() (string, string, string) {
+	return c.m.GetString("tracing.otlp.endpoint"), c.m.GetString("tracing.otlp.headers"), c.m.GetString("tracing.otlp.ca_cert")
+}
+
+// MetricsOTLPServer returns the OTLP collector endpoint the metrics get pushed to, along with the headers and
+// CA certificate used to connect to it and the push interval.
+func (c *Config) MetricsOTLPServer() (string, string, string, time.Duration) {
+	interval := time.Duration(c.m.GetInt64("metrics.otlp.interval")) * time.Second
+
+	return c.m.GetString("metrics.otlp.endpoint"), c.m.GetString("metrics.otlp.headers"), c.m.GetString("metrics.otlp.ca_cert"), interval
+}
+
 // ACME returns all ACME settings needed for certificate renewal.
 func (c *Config) ACME() (string, string, string, bool) {
 	return c.m.GetString("acme.domain"), c.m.GetString("acme.email"), c.m.GetString("acme.ca_url"), c.m.GetBool("acme.agree_tos")
@@ -238,6 +274,11 @@ func (c *Config) ClusterJoinTokenExpiry() string {
 	return c.m.GetString("cluster.join_token_expiry")
 }
 
+// APIRateLimit returns the rate limit of each remote client on the given class of API endpoints (read, write or exec).
+func (c *Config) APIRateLimit(class string) string {
+	return c.m.GetString("core.rate_limit." + class)
+}
+
 // RemoteTokenExpiry returns the time after which a remote add token expires.
 func (c *Config) RemoteTokenExpiry() string {
 	return c.m.GetString("core.remote_token_expiry")
@@ -248,6 +289,11 @@ func (c *Config) OIDCServer() (string, string, string) {
 	return c.m.GetString("oidc.issuer"), c.m.GetString("oidc.client.id"), c.m.GetString("oidc.audience")
 }
 
+// OIDCGroupsRequired returns whether OpenID Connect users must belong to an authorization group to get access.
+func (c *Config) OIDCGroupsRequired() bool {
+	return c.m.GetBool("oidc.groups.required")
+}
+
 // ClusterHealingThreshold returns the configured healing threshold, i.e. the
 // number of seconds after which an offline node will be evacuated automatically. If the config key
 // is set but its value is lower than cluster.offline_threshold it returns
@@ -313,6 +359,9 @@ var ConfigSchema = config.Schema{
 	"acme.domain":                    {},
 	"acme.email":                     {},
 	"acme.agree_tos":                 {Type: config.Bool, Default: "false"},
+	"audit.enabled":                  {Type: config.Bool, Default: "false"},
+	"audit.read_only":                {Type: config.Bool, Default: "false"},
+	"authorization.scriptlet":        {Validator: validate.Optional(scriptletLoad.AuthorizationValidate)},
 	"backups.compression_algorithm":  {Default: "gzip", Validator: validate.IsCompressionAlgorithm},
 	"cluster.offline_threshold":      {Type: config.Int64, Default: offlineThresholdDefault(), Validator: offlineThresholdValidator},
 	"cluster.images_minimal_replica": {Type: config.Int64, Default: "3", Validator: imageMinimalReplicaValidator},
@@ -330,6 +379,9 @@ var ConfigSchema = config.Schema{
 	"core.proxy_http":                {},
 	"core.proxy_https":               {},
 	"core.proxy_ignore_hosts":        {},
+	"core.rate_limit.exec":           {Validator: validate.Optional(rateLimitValidator)},
+	"core.rate_limit.read":           {Validator: validate.Optional(rateLimitValidator)},
+	"core.rate_limit.write":          {Validator: validate.Optional(rateLimitValidator)},
 	"core.remote_token_expiry":       {Type: config.String, Validator: validate.Optional(expiryValidator)},
 	"core.shutdown_timeout":          {Type: config.Int64, Default: "5"},
 	"core.trust_password":            {Hidden: true, Setter: passwordSetter},
@@ -343,6 +395,7 @@ var ConfigSchema = config.Schema{
 	"images.compression_algorithm":   {Default: "gzip", Validator: validate.IsCompressionAlgorithm},
 	"images.default_architecture":    {Validator: validate.Optional(validate.IsArchitecture)},
 	"images.remote_cache_expiry":     {Type: config.Int64, Default: "10"},
+	"instances.admission.scriptlet":  {Validator: validate.Optional(scriptletLoad.InstanceAdmissionValidate)},
 	"instances.nic.host_name":        {Validator: validate.Optional(validate.IsOneOf("random", "mac"))},
 	"instances.placement.scriptlet":  {Validator: validate.Optional(scriptletLoad.InstancePlacementValidate)},
 	"loki.auth.username":             {},
@@ -351,12 +404,17 @@ var ConfigSchema = config.Schema{
 	"loki.api.url":                   {},
 	"loki.labels":                    {},
 	"loki.loglevel":                  {Validator: logLevelValidator, Default: logrus.InfoLevel.String()},
-	"loki.types":                     {Validator: validate.Optional(validate.IsListOf(validate.IsOneOf("lifecycle", "logging"))), Default: "lifecycle,logging"},
+	"loki.types":                     {Validator: validate.Optional(validate.IsListOf(validate.IsOneOf("audit", "lifecycle", "logging"))), Default: "lifecycle,logging"},
 	"maas.api.key":                   {},
 	"maas.api.url":                   {},
+	"metrics.otlp.ca_cert":           {Validator: validate.Optional(validate.IsX509Certificate)},
+	"metrics.otlp.endpoint":          {Validator: validate.Optional(validate.IsRequestURL)},
+	"metrics.otlp.headers":           {Hidden: true, Validator: validate.Optional(otlpHeadersValidator)},
+	"metrics.otlp.interval":          {Type: config.Int64, Default: "60", Validator: validate.Optional(validate.IsInRange(10, 86400))},
 	"oidc.client.id":                 {},
 	"oidc.issuer":                    {},
 	"oidc.audience":                  {},
+	"oidc.groups.required":           {Type: config.Bool, Default: "false"},
 	"rbac.agent.url":                 {},
 	"rbac.agent.username":            {},
 	"rbac.agent.private_key":         {},
@@ -365,6 +423,9 @@ var ConfigSchema = config.Schema{
 	"rbac.api.key":                   {},
 	"rbac.api.url":                   {},
 	"rbac.expiry":                    {Type: config.Int64, Default: "3600"},
+	"tracing.otlp.ca_cert":           {Validator: validate.Optional(validate.IsX509Certificate)},
+	"tracing.otlp.endpoint":          {Validator: validate.Optional(validate.IsRequestURL)},
+	"tracing.otlp.headers":           {Hidden: true, Validator: validate.Optional(otlpHeadersValidator)},
 
 	// OVN networking global keys.
 	"network.ovn.integration_bridge":    {Default: "br-int"},
@@ -380,6 +441,18 @@ func expiryValidator(value string) error {
 	return nil
 }
 
+func rateLimitValidator(value string) error {
+	_, err := ratelimit.ParseLimit(value)
+
+	return err
+}
+
+func otlpHeadersValidator(value string) error {
+	_, err := otlp.ParseHeaders(value)
+
+	return err
+}
+
 func logLevelValidator(value string) error {
 	if value == "" {
 		return nil
@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -77,6 +78,20 @@ func TestConfigLoad_MaxVotersValidator(t *testing.T) {
 	require.EqualError(t, err, "cannot set 'cluster.max_voters' to '4': Value must be an odd number equal to or higher than 3")
 }
 
+// The CA certificates of the OTLP collectors must be PEM encoded.
+func TestConfigLoad_OTLPCACertValidator(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	config, err := clusterConfig.Load(context.Background(), tx)
+	require.NoError(t, err)
+
+	for _, key := range []string{"metrics.otlp.ca_cert", "tracing.otlp.ca_cert"} {
+		_, err = config.Patch(map[string]any{key: "not-a-certificate"})
+		require.EqualError(t, err, fmt.Sprintf("cannot set '%s' to 'not-a-certificate': Invalid certificate", key))
+	}
+}
+
 // If some previously set values are missing from the ones passed to Replace(),
 // they are deleted from the configuration.
 func TestConfig_ReplaceDeleteValues(t *testing.T) {
@@ -16,6 +16,7 @@ import (
 	"github.com/canonical/lxd/lxd/request"
 	"github.com/canonical/lxd/lxd/state"
 	storagePools "github.com/canonical/lxd/lxd/storage"
+	"github.com/canonical/lxd/lxd/tracing"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/version"
@@ -66,6 +67,11 @@ func Connect(address string, networkCert *shared.CertInfo, serverCert *shared.Ce
 
 			req.Header.Add(request.HeaderForwardedAddress, r.RemoteAddr)
 
+			// Propagate the trace of the request to the notified members, the only ones honouring it.
+			if notify {
+				tracing.Inject(ctx, req.Header)
+			}
+
 			return shared.ProxyFromEnvironment(req)
 		}
 
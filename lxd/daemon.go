@@ -27,6 +27,7 @@ import (
 
 	"github.com/canonical/lxd/lxd/acme"
 	"github.com/canonical/lxd/lxd/apparmor"
+	"github.com/canonical/lxd/lxd/audit"
 	"github.com/canonical/lxd/lxd/auth"
 	"github.com/canonical/lxd/lxd/auth/candid"
 	"github.com/canonical/lxd/lxd/auth/oidc"
@@ -47,12 +48,15 @@ import (
 	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/loki"
 	"github.com/canonical/lxd/lxd/maas"
+	"github.com/canonical/lxd/lxd/metrics"
 	networkZone "github.com/canonical/lxd/lxd/network/zone"
 	"github.com/canonical/lxd/lxd/node"
+	"github.com/canonical/lxd/lxd/otlp"
 	"github.com/canonical/lxd/lxd/request"
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/lxd/lxd/revert"
 	"github.com/canonical/lxd/lxd/rsync"
+	"github.com/canonical/lxd/lxd/scriptlet"
 	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
 	"github.com/canonical/lxd/lxd/seccomp"
 	"github.com/canonical/lxd/lxd/state"
@@ -62,9 +66,11 @@ import (
 	"github.com/canonical/lxd/lxd/storage/s3/miniod"
 	"github.com/canonical/lxd/lxd/sys"
 	"github.com/canonical/lxd/lxd/task"
+	"github.com/canonical/lxd/lxd/tracing"
 	"github.com/canonical/lxd/lxd/ucred"
 	"github.com/canonical/lxd/lxd/util"
 	"github.com/canonical/lxd/lxd/warnings"
+	"github.com/canonical/lxd/lxd/webhooks"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/cancel"
 	"github.com/canonical/lxd/shared/idmap"
@@ -75,6 +81,8 @@ import (
 // A Daemon can respond to requests from a shared client.
 type Daemon struct {
 	clientCerts *certificateCache
+	authGroups  *authGroupCache
+	authTokens  *authTokenCache
 	os          *sys.OS
 	db          *db.DB
 	firewall    firewall.Firewall
@@ -87,6 +95,13 @@ type Daemon struct {
 	events           *events.Server
 	internalListener *events.InternalListener
 
+	// Event log the event streams can replay.
+	eventLog *events.Log
+
+	// Webhooks delivering the local lifecycle and operation events.
+	webhooks        *webhooks.Dispatcher
+	webhookListener *events.InternalListener
+
 	// Tasks registry for long-running background tasks
 	// Keep clustering tasks separate as they cause a lot of CPU wakeups
 	tasks        task.Group
@@ -145,6 +160,18 @@ type Daemon struct {
 
 	lokiClient *loki.Client
 
+	// OpenTelemetry traces.
+	tracingExporter *tracing.Exporter
+
+	// OTLP metrics exporter.
+	metricsExporter *metrics.Exporter
+
+	// Audit log.
+	audit *audit.Logger
+
+	// API rate limits.
+	rateLimits *apiRateLimits
+
 	// HTTP-01 challenge provider for ACME
 	http01Provider acme.HTTP01Provider
 
@@ -168,7 +195,11 @@ func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
 
 	d := &Daemon{
 		clientCerts:    &certificateCache{},
+		authGroups:     &authGroupCache{},
+		authTokens:     &authTokenCache{},
+		audit:          audit.NewLogger(shutdownCtx, shared.LogPath("audit.log"), auditLogMaxSize, auditLogMaxFiles),
 		config:         config,
+		rateLimits:     newAPIRateLimits(),
 		devlxdEvents:   devlxdEvents,
 		events:         lxdEvents,
 		db:             &db.DB{},
@@ -227,6 +258,18 @@ type APIEndpointAction struct {
 	AllowUntrusted bool
 }
 
+// restrictedProjectPermissions are the permissions granted on each of their projects to restricted clients.
+var restrictedProjectPermissions = []string{
+	"view",
+	"manage-containers",
+	"manage-images",
+	"manage-networks",
+	"manage-profiles",
+	"manage-storage-volumes",
+	"operate-containers",
+	"view-console",
+}
+
 // allowAuthenticated is an AccessHandler which allows all requests.
 // This function doesn't do anything itself, except return the EmptySyncResponse that allows the request to
 // proceed. However in order to access any API route you must be authenticated, unless the handler's AllowUntrusted
@@ -259,7 +302,7 @@ func allowProjectPermission(feature string, permission string) func(d *Daemon, r
 
 // Convenience function around Authenticate.
 func (d *Daemon) checkTrustedClient(r *http.Request) error {
-	trusted, _, _, err := d.Authenticate(nil, r)
+	trusted, _, _, _, err := d.Authenticate(nil, r)
 	if !trusted || err != nil {
 		if err != nil {
 			return err
@@ -284,9 +327,9 @@ func (d *Daemon) getTrustedCertificates() map[dbCluster.CertificateType]map[stri
 // will validate the TLS certificate or Macaroon.
 //
 // This does not perform authorization, only validates authentication.
-// Returns whether trusted or not, the username (or certificate fingerprint) of the trusted client, and the type of
-// client that has been authenticated (cluster, unix, candid or tls).
-func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, error) {
+// Returns whether trusted or not, the username (or certificate fingerprint) of the trusted client, the type of
+// client that has been authenticated (cluster, unix, oidc, candid or tls) and the token claims of OIDC clients.
+func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, map[string]any, error) {
 	trustedCerts := d.getTrustedCertificates()
 
 	// Allow internal cluster traffic by checking against the trusted certfificates.
@@ -294,7 +337,7 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		for _, i := range r.TLS.PeerCertificates {
 			trusted, fingerprint := util.CheckTrustState(*i, trustedCerts[dbCluster.CertificateTypeServer], d.endpoints.NetworkCert(), false)
 			if trusted {
-				return true, fingerprint, "cluster", nil
+				return true, fingerprint, "cluster", nil, nil
 			}
 		}
 	}
@@ -304,55 +347,66 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		if w != nil {
 			cred, err := ucred.GetCredFromContext(r.Context())
 			if err != nil {
-				return false, "", "", err
+				return false, "", "", nil, err
 			}
 
 			u, err := user.LookupId(fmt.Sprintf("%d", cred.Uid))
 			if err != nil {
-				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil
+				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil, nil
 			}
 
-			return true, u.Username, "unix", nil
+			return true, u.Username, "unix", nil, nil
 		}
 
-		return true, "", "unix", nil
+		return true, "", "unix", nil, nil
 	}
 
 	// Devlxd unix socket credentials on main API.
 	if r.RemoteAddr == "@devlxd" {
-		return false, "", "", fmt.Errorf("Main API query can't come from /dev/lxd socket")
+		return false, "", "", nil, fmt.Errorf("Main API query can't come from /dev/lxd socket")
 	}
 
 	// Cluster notification with wrong certificate.
 	if isClusterNotification(r) {
-		return false, "", "", fmt.Errorf("Cluster notification isn't using trusted server certificate")
+		return false, "", "", nil, fmt.Errorf("Cluster notification isn't using trusted server certificate")
 	}
 
 	// Bad query, no TLS found.
 	if r.TLS == nil {
-		return false, "", "", fmt.Errorf("Bad/missing TLS on network query")
+		return false, "", "", nil, fmt.Errorf("Bad/missing TLS on network query")
+	}
+
+	// API bearer tokens.
+	token, ok := auth.BearerToken(r.Header.Get("Authorization"))
+	if ok {
+		fingerprint, trusted := d.authenticateToken(token)
+		if !trusted {
+			return false, "", "", nil, nil
+		}
+
+		return true, fingerprint, "token", nil, nil
 	}
 
 	if d.oidcVerifier != nil && d.oidcVerifier.IsRequest(r) {
-		userName, err := d.oidcVerifier.Auth(d.shutdownCtx, w, r)
+		userName, claims, err := d.oidcVerifier.Auth(d.shutdownCtx, w, r)
 		if err != nil {
-			return false, "", "", err
+			return false, "", "", nil, err
 		}
 
-		return true, userName, "oidc", nil
+		return true, userName, "oidc", claims, nil
 	} else if d.candidVerifier != nil && d.candidVerifier.IsRequest(r) {
 		info, err := d.candidVerifier.Auth(r)
 		if err != nil {
-			return false, "", "", err
+			return false, "", "", nil, err
 		}
 
 		if info != nil && info.Identity != nil {
 			// Valid identity macaroon found.
-			return true, info.Identity.Id(), "candid", nil
+			return true, info.Identity.Id(), "candid", nil, nil
 		}
 
 		// Valid macaroon with no identity information.
-		return true, "", "candid", nil
+		return true, "", "candid", nil, nil
 	}
 
 	// Validate normal TLS access.
@@ -363,7 +417,7 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		for _, i := range r.TLS.PeerCertificates {
 			trusted, username := util.CheckTrustState(*i, trustedCerts[dbCluster.CertificateTypeMetrics], d.endpoints.NetworkCert(), trustCACertificates)
 			if trusted {
-				return true, username, "tls", nil
+				return true, username, "tls", nil, nil
 			}
 		}
 	}
@@ -371,12 +425,12 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 	for _, i := range r.TLS.PeerCertificates {
 		trusted, username := util.CheckTrustState(*i, trustedCerts[dbCluster.CertificateTypeClient], d.endpoints.NetworkCert(), trustCACertificates)
 		if trusted {
-			return true, username, "tls", nil
+			return true, username, "tls", nil, nil
 		}
 	}
 
 	// Reject unauthorized.
-	return false, "", "", nil
+	return false, "", "", nil, nil
 }
 
 // State creates a new State instance linked to our internal db and os.
@@ -457,8 +511,38 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			}
 		}
 
+		// Trace the request (except the long lived event streams), continuing the trace of the cluster
+		// member that notified us if any. The traceparent header of other clients is ignored so that they
+		// can't attach spans to arbitrary traces.
+		if tracing.Enabled() && c.Path != "events" {
+			ctx := r.Context()
+			if isClusterNotification(r) {
+				ctx = tracing.Extract(ctx, r.Header)
+			}
+
+			ctx, span := tracing.StartServer(ctx, fmt.Sprintf("%s %s", r.Method, uri), map[string]any{
+				"http.request.method": r.Method,
+				"http.route":          uri,
+				"url.path":            r.URL.Path,
+				"client.address":      r.RemoteAddr,
+			})
+
+			statusWriter := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			w = statusWriter
+			r = r.WithContext(ctx)
+
+			defer func() {
+				span.SetAttribute("http.response.status_code", statusWriter.status)
+				if statusWriter.status >= http.StatusInternalServerError {
+					span.SetError(fmt.Errorf("%s", http.StatusText(statusWriter.status)))
+				}
+
+				span.End()
+			}()
+		}
+
 		// Authentication
-		trusted, username, protocol, err := d.Authenticate(w, r)
+		trusted, username, protocol, claims, err := d.Authenticate(w, r)
 		if err != nil {
 			_, ok := err.(*oidc.AuthError)
 			if ok {
@@ -500,6 +584,14 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		if trusted {
 			logger.Debug("Handling API request", logCtx)
 
+			// Record the request in the audit log once handled.
+			if d.auditRequest(r, protocol) {
+				auditWriter := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+				w = auditWriter
+
+				defer d.auditRecord(r, auditWriter, username, protocol, time.Now())
+			}
+
 			// Get user access data.
 			userAccess, err := func() (*auth.UserAccess, error) {
 				ua := &auth.UserAccess{}
@@ -523,15 +615,7 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 							ua.Admin = false
 							ua.Projects = map[string][]string{}
 							for _, projectName := range projects {
-								ua.Projects[projectName] = []string{
-									"view",
-									"manage-containers",
-									"manage-images",
-									"manage-networks",
-									"manage-profiles",
-									"manage-storage-volumes",
-									"operate-containers",
-								}
+								ua.Projects[projectName] = restrictedProjectPermissions
 							}
 						}
 					}
@@ -539,6 +623,11 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 					return ua, nil
 				}
 
+				// API bearer tokens.
+				if protocol == "token" {
+					return d.tokenUserAccess(username, r.Method)
+				}
+
 				// If no external authentication configured, we're done now.
 				if d.candidVerifier == nil || r.RemoteAddr == "@" {
 					return ua, nil
@@ -564,6 +653,10 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			ctx = context.WithValue(ctx, request.CtxProtocol, protocol)
 			ctx = context.WithValue(ctx, request.CtxAccess, userAccess)
 
+			if claims != nil {
+				ctx = context.WithValue(ctx, request.CtxOIDCClaims, claims)
+			}
+
 			// Add forwarded requestor data.
 			if protocol == "cluster" {
 				// Add authentication/authorization context data.
@@ -573,6 +666,11 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			}
 
 			r = r.WithContext(ctx)
+
+			// Enforce the API rate limits.
+			if !d.rateLimitRequest(w, r, username, protocol) {
+				return
+			}
 		} else if untrustedOk && r.Header.Get("X-LXD-authenticated") == "" {
 			logger.Debug(fmt.Sprintf("Allowing untrusted %s", r.Method), logger.Ctx{"url": r.URL.RequestURI(), "ip": r.RemoteAddr})
 		} else if derr, ok := err.(*bakery.DischargeRequiredError); ok {
@@ -762,6 +860,61 @@ func (d *Daemon) setupLoki(URL string, cert string, key string, caCert string, l
 	d.lokiClient = loki.NewClient(d.shutdownCtx, u, cert, key, caCert, labels, logLevel, types)
 
 	d.internalListener.AddHandler("loki", d.lokiClient.HandleEvent)
+	d.audit.AddHandler("loki", d.lokiClient.HandleAudit)
+
+	return nil
+}
+
+func (d *Daemon) setupTracing(endpoint string, headers string, caCert string) error {
+	if d.tracingExporter != nil {
+		tracing.SetExporter(nil)
+		d.tracingExporter.Stop()
+		d.tracingExporter = nil
+	}
+
+	if endpoint == "" {
+		return nil
+	}
+
+	headerMap, err := otlp.ParseHeaders(headers)
+	if err != nil {
+		return err
+	}
+
+	client, err := otlp.NewClient(endpoint, headerMap, caCert)
+	if err != nil {
+		return err
+	}
+
+	d.tracingExporter = tracing.NewExporter(d.shutdownCtx, client, d.serverName)
+	tracing.SetExporter(d.tracingExporter)
+
+	return nil
+}
+
+func (d *Daemon) setupMetricsExporter(endpoint string, headers string, caCert string, interval time.Duration) error {
+	if d.metricsExporter != nil {
+		d.metricsExporter.Stop()
+		d.metricsExporter = nil
+	}
+
+	if endpoint == "" {
+		return nil
+	}
+
+	headerMap, err := otlp.ParseHeaders(headers)
+	if err != nil {
+		return err
+	}
+
+	client, err := otlp.NewClient(endpoint, headerMap, caCert)
+	if err != nil {
+		return err
+	}
+
+	d.metricsExporter = metrics.NewExporter(d.shutdownCtx, client, d.serverName, interval, d.startTime, func(ctx context.Context) (*metrics.MetricSet, error) {
+		return getMetrics(ctx, d, "")
+	})
 
 	return nil
 }
@@ -772,7 +925,7 @@ func (d *Daemon) init() error {
 	var dbWarnings []dbCluster.Warning
 
 	// Set default authorizer.
-	d.authorizer, err = auth.LoadAuthorizer("tls", nil, logger.Log, nil)
+	err = d.setupAuthorizer("")
 	if err != nil {
 		return err
 	}
@@ -783,12 +936,24 @@ func (d *Daemon) init() error {
 	// Setup internal event listener
 	d.internalListener = events.NewInternalListener(d.shutdownCtx, d.events)
 
+	// Setup webhooks
+	d.webhooks = webhooks.NewDispatcher(d.shutdownCtx, shared.LogPath("webhooks.log"))
+	d.webhookListener = events.NewLocalInternalListener(d.shutdownCtx, d.events, webhooks.EventTypes)
+
 	// Lets check if there's an existing LXD running
 	err = endpoints.CheckAlreadyRunning(d.UnixSocket())
 	if err != nil {
 		return err
 	}
 
+	// Setup the event log
+	d.eventLog, err = events.OpenLog(shared.LogPath("events.log"), eventLogMaxSize, eventLogMaxFiles)
+	if err != nil {
+		return err
+	}
+
+	d.events.SetLog(d.eventLog)
+
 	/* Set the LVM environment */
 	err = os.Setenv("LVM_SUPPRESS_FD_WARNINGS", "1")
 	if err != nil {
@@ -1320,9 +1485,13 @@ func (d *Daemon) init() error {
 	rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey = d.globalConfig.RBACServer()
 	d.gateway.HeartbeatOfflineThreshold = d.globalConfig.OfflineThreshold()
 	lokiURL, lokiUsername, lokiPassword, lokiCACert, lokiLabels, lokiLoglevel, lokiTypes := d.globalConfig.LokiServer()
+	tracingEndpoint, tracingHeaders, tracingCACert := d.globalConfig.TracingServer()
+	metricsEndpoint, metricsHeaders, metricsCACert, metricsInterval := d.globalConfig.MetricsOTLPServer()
 	oidcIssuer, oidcClientID, oidcAudience := d.globalConfig.OIDCServer()
 
 	instancePlacementScriptlet := d.globalConfig.InstancesPlacementScriptlet()
+	instanceAdmissionScriptlet := d.globalConfig.InstancesAdmissionScriptlet()
+	authorizationScriptlet := d.globalConfig.AuthorizationScriptlet()
 
 	d.endpoints.NetworkUpdateTrustedProxy(d.globalConfig.HTTPSTrustedProxy())
 	d.globalConfigMu.Unlock()
@@ -1335,6 +1504,22 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Setup OpenTelemetry tracing, which isn't worth failing the daemon startup for.
+	if tracingEndpoint != "" {
+		err = d.setupTracing(tracingEndpoint, tracingHeaders, tracingCACert)
+		if err != nil {
+			logger.Warn("Failed setting up OpenTelemetry tracing", logger.Ctx{"err": err})
+		}
+	}
+
+	// Setup OTLP metrics exporter, which isn't worth failing the daemon startup for.
+	if metricsEndpoint != "" {
+		err = d.setupMetricsExporter(metricsEndpoint, metricsHeaders, metricsCACert, metricsInterval)
+		if err != nil {
+			logger.Warn("Failed setting up OTLP metrics exporter", logger.Ctx{"err": err})
+		}
+	}
+
 	// Setup RBAC authentication.
 	if rbacAPIURL != "" {
 		err = d.setupRBACServer(rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey)
@@ -1343,6 +1528,22 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Load authorization scriptlet.
+	if authorizationScriptlet != "" {
+		err = scriptletLoad.AuthorizationSet(authorizationScriptlet)
+		if err != nil {
+			logger.Warn("Failed loading authorization scriptlet", logger.Ctx{"err": err})
+		}
+
+		// RBAC takes precedence over the authorization scriptlet.
+		if rbacAPIURL == "" {
+			err = d.setupAuthorizer(authorizationScriptlet)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Setup Candid authentication.
 	if candidAPIURL != "" {
 		d.candidVerifier, err = candid.NewVerifier(candidAPIURL, candidAPIKey, candidExpiry, candidDomains)
@@ -1444,6 +1645,14 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Load instance admission scriptlet.
+	if instanceAdmissionScriptlet != "" {
+		err = scriptletLoad.InstanceAdmissionSet(instanceAdmissionScriptlet)
+		if err != nil {
+			logger.Warn("Failed loading instance admission scriptlet", logger.Ctx{"err": err})
+		}
+	}
+
 	// Apply all patches that need to be run after networks are initialised.
 	err = patchesApply(d, patchPostNetworks)
 	if err != nil {
@@ -1497,6 +1706,15 @@ func (d *Daemon) init() error {
 		// Read the trusted certificates
 		updateCertificateCache(d)
 
+		// Read the auth groups
+		updateAuthGroupCache(d)
+
+		// Read the API bearer tokens
+		updateAuthTokenCache(d)
+
+		// Read the webhooks
+		updateWebhookCache(d)
+
 		// Connect to MAAS
 		if maasAPIURL != "" {
 			go func() {
@@ -1591,6 +1809,9 @@ func (d *Daemon) init() error {
 
 		// Remove expired tokens (hourly)
 		d.tasks.Add(autoRemoveExpiredTokensTask(d))
+
+		// Remove expired exec sessions (hourly)
+		d.tasks.Add(pruneExitedExecSessionsTask(d))
 	}
 
 	// Start all background tasks
@@ -1791,6 +2012,12 @@ func (d *Daemon) Stop(ctx context.Context, sig os.Signal) error {
 		trackError(d.endpoints.Down(), "Shutdown endpoints")
 	}
 
+	trackError(d.audit.Close(), "Close audit log")
+
+	if d.eventLog != nil {
+		trackError(d.eventLog.Close(), "Close event log")
+	}
+
 	if shouldUnmount {
 		logger.Info("Unmounting temporary filesystems")
 
@@ -1823,6 +2050,39 @@ func (d *Daemon) Stop(ctx context.Context, sig os.Signal) error {
 	return err
 }
 
+// setupAuthorizer loads the default authorizer used when RBAC isn't configured.
+// This is the scriptlet authorizer if an authorization scriptlet is set, and the embedded authorizer otherwise.
+func (d *Daemon) setupAuthorizer(authorizationScriptlet string) error {
+	var err error
+
+	oidcGroupsRequired := func() bool {
+		d.globalConfigMu.Lock()
+		defer d.globalConfigMu.Unlock()
+
+		return d.globalConfig != nil && d.globalConfig.OIDCGroupsRequired()
+	}
+
+	if authorizationScriptlet == "" {
+		config := map[string]any{
+			"permissions":          auth.IdentityPermissionsFunc(d.identityPermissions),
+			"oidc_groups_required": oidcGroupsRequired,
+		}
+
+		d.authorizer, err = auth.LoadAuthorizer("embedded", config, logger.Log, nil)
+		return err
+	}
+
+	// The authorization scriptlet only applies to identities that don't belong to any auth group.
+	config := map[string]any{
+		"authorize":            auth.ScriptletAuthorizeFunc(scriptlet.AuthorizationRun),
+		"permissions":          auth.IdentityPermissionsFunc(d.identityPermissions),
+		"oidc_groups_required": oidcGroupsRequired,
+	}
+
+	d.authorizer, err = auth.LoadAuthorizer("scriptlet", config, logger.Log, nil)
+	return err
+}
+
 // Setup RBAC.
 func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int64, rbacAgentURL string, rbacAgentUsername string, rbacAgentPrivateKey string, rbacAgentPublicKey string) error {
 	var err error
@@ -1831,16 +2091,15 @@ func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int6
 		d.authorizer.StopStatusCheck()
 	}
 
+	d.globalConfigMu.Lock()
+	authorizationScriptlet := d.globalConfig.AuthorizationScriptlet()
+	d.globalConfigMu.Unlock()
+
 	if rbacURL == "" || rbacAgentURL == "" || rbacAgentUsername == "" || rbacAgentPrivateKey == "" || rbacAgentPublicKey == "" {
 		d.candidVerifier = nil
 
 		// Reset to default authorizer.
-		d.authorizer, err = auth.LoadAuthorizer("tls", nil, logger.Log, nil)
-		if err != nil {
-			return err
-		}
-
-		return nil
+		return d.setupAuthorizer(authorizationScriptlet)
 	}
 
 	revert := revert.New()
@@ -1872,7 +2131,7 @@ func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int6
 		d.candidVerifier = nil
 
 		// Reset to default authorizer.
-		d.authorizer, _ = auth.LoadAuthorizer("tls", nil, logger.Log, nil)
+		_ = d.setupAuthorizer(authorizationScriptlet)
 	})
 
 	// Load RBAC authorizer
@@ -2118,6 +2377,15 @@ func (d *Daemon) nodeRefreshTask(heartbeatData *cluster.APIHeartbeat, isLeader b
 		// Refresh cluster certificates cached.
 		updateCertificateCache(d)
 
+		// Refresh auth groups cached.
+		updateAuthGroupCache(d)
+
+		// Refresh API bearer tokens cached.
+		updateAuthTokenCache(d)
+
+		// Refresh webhooks cached.
+		updateWebhookCache(d)
+
 		// Refresh forkdns peers.
 		err := networkUpdateForkdnsServersTask(s, heartbeatData)
 		if err != nil {
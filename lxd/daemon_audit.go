@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/canonical/lxd/lxd/audit"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// Size after which the audit log gets rotated, and number of rotated audit logs to keep.
+const (
+	auditLogMaxSize  = 100 * 1024 * 1024
+	auditLogMaxFiles = 5
+)
+
+// statusResponseWriter records the status code of the responses to audited and traced requests.
+type statusResponseWriter struct {
+	http.ResponseWriter
+
+	status int
+}
+
+// WriteHeader records the status code and writes it.
+func (w *statusResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush sends any buffered data to the client.
+func (w *statusResponseWriter) Flush() {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, which is recorded as a protocol switch.
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Response writer doesn't support hijacking")
+	}
+
+	w.status = http.StatusSwitchingProtocols
+
+	return hijacker.Hijack()
+}
+
+// auditRequest returns whether the request should be recorded in the audit log.
+// Internal cluster requests aren't, the member that received the original request records it.
+func (d *Daemon) auditRequest(r *http.Request, protocol string) bool {
+	if protocol == "cluster" {
+		return false
+	}
+
+	d.globalConfigMu.Lock()
+	globalConfig := d.globalConfig
+	d.globalConfigMu.Unlock()
+
+	if globalConfig == nil || !globalConfig.AuditEnabled() {
+		return false
+	}
+
+	if shared.StringInSlice(r.Method, []string{http.MethodGet, http.MethodHead}) {
+		return globalConfig.AuditReadOnly()
+	}
+
+	return true
+}
+
+// auditRecord records a handled request in the audit log.
+func (d *Daemon) auditRecord(r *http.Request, w *statusResponseWriter, username string, protocol string, start time.Time) {
+	entry := audit.Entry{
+		Timestamp:  start.UTC(),
+		Location:   d.serverName,
+		Username:   username,
+		Protocol:   protocol,
+		Address:    r.RemoteAddr,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Project:    projectParam(r),
+		Status:     w.status,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	err := d.audit.Log(entry)
+	if err != nil {
+		logger.Warn("Failed recording API request in audit log", logger.Ctx{"err": err})
+	}
+}
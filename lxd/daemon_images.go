@@ -57,8 +57,7 @@ func imageOperationLock(fingerprint string) locking.UnlockFunc {
 }
 
 // ImageDownload resolves the image fingerprint and if not in the database, downloads it.
-func ImageDownload(r *http.Request, s *state.State, op *operations.Operation, args *ImageDownloadArgs) (*api.Image, error) {
-	var err error
+func ImageDownload(r *http.Request, s *state.State, op *operations.Operation, args *ImageDownloadArgs) (_ *api.Image, err error) {
 	var ctxMap logger.Ctx
 
 	var remote lxd.ImageServer
@@ -70,6 +69,14 @@ func ImageDownload(r *http.Request, s *state.State, op *operations.Operation, ar
 		protocol = "lxd"
 	}
 
+	span := op.StartSpan("image.Download", map[string]any{
+		"lxd.image.alias":    args.Alias,
+		"lxd.image.server":   args.Server,
+		"lxd.image.protocol": protocol,
+	})
+
+	defer func() { span.EndWithError(err) }()
+
 	// Copy so that local modifications aren't propgated to args.
 	alias := args.Alias
 
@@ -0,0 +1,503 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/auth"
+	"github.com/canonical/lxd/lxd/db/query"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// authPermissionsQuery selects the permissions of the auth groups along with the names of their entities.
+const authPermissionsQuery = `
+SELECT auth_groups_permissions.auth_group_id, auth_groups_permissions.entity_type, auth_groups_permissions.entitlement,
+       COALESCE(projects.name, instances_projects.name, ''), COALESCE(instances.name, '')
+  FROM auth_groups_permissions
+  LEFT JOIN projects ON auth_groups_permissions.entity_type = 'project' AND projects.id = auth_groups_permissions.entity_id
+  LEFT JOIN instances ON auth_groups_permissions.entity_type = 'instance' AND instances.id = auth_groups_permissions.entity_id
+  LEFT JOIN projects AS instances_projects ON instances_projects.id = instances.project_id
+ ORDER BY auth_groups_permissions.id
+`
+
+// GetAuthGroups returns all the auth groups, sorted by name.
+func (c *ClusterTx) GetAuthGroups(ctx context.Context) ([]api.AuthGroup, error) {
+	return c.getAuthGroups(ctx, "")
+}
+
+// GetAuthGroup returns the auth group with the given name.
+func (c *ClusterTx) GetAuthGroup(ctx context.Context, name string) (*api.AuthGroup, error) {
+	groups, err := c.getAuthGroups(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Auth group not found")
+	}
+
+	return &groups[0], nil
+}
+
+// getAuthGroups returns all the auth groups, or only the one with the given name if not empty.
+func (c *ClusterTx) getAuthGroups(ctx context.Context, name string) ([]api.AuthGroup, error) {
+	stmt := "SELECT id, name, description FROM auth_groups"
+	args := []any{}
+
+	if name != "" {
+		stmt += " WHERE name = ?"
+		args = append(args, name)
+	}
+
+	stmt += " ORDER BY name"
+
+	groups := []api.AuthGroup{}
+	indexes := map[int64]int{}
+
+	err := query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		group := api.AuthGroup{}
+
+		err := scan(&id, &group.Name, &group.Description)
+		if err != nil {
+			return err
+		}
+
+		group.Identities = []string{}
+		group.OIDCClaims = []string{}
+		group.Permissions = []api.AuthPermission{}
+		group.Config = map[string]string{}
+
+		indexes[id] = len(groups)
+		groups = append(groups, group)
+
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth groups: %w", err)
+	}
+
+	stmt = "SELECT auth_group_id, auth_method, identifier FROM auth_groups_identities ORDER BY auth_method, identifier"
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var method, identifier string
+
+		err := scan(&id, &method, &identifier)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			groups[i].Identities = append(groups[i].Identities, method+"/"+identifier)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group identities: %w", err)
+	}
+
+	stmt = "SELECT auth_group_id, claim, value FROM auth_groups_oidc_claims ORDER BY claim, value"
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var claim, value string
+
+		err := scan(&id, &claim, &value)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			groups[i].OIDCClaims = append(groups[i].OIDCClaims, claim+"="+value)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group OpenID Connect claims: %w", err)
+	}
+
+	err = query.Scan(ctx, c.tx, authPermissionsQuery, func(scan func(dest ...any) error) error {
+		var id int64
+		var entityType, entitlement, projectName, entityName string
+
+		err := scan(&id, &entityType, &entitlement, &projectName, &entityName)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			groups[i].Permissions = append(groups[i].Permissions, api.AuthPermission{
+				EntityType:  entityType,
+				URL:         authPermissionURL(entityType, projectName, entityName),
+				Entitlement: entitlement,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group permissions: %w", err)
+	}
+
+	stmt = "SELECT auth_group_id, key, value FROM auth_groups_config"
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var key, value string
+
+		err := scan(&id, &key, &value)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			groups[i].Config[key] = value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group config: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetAuthIdentityPermissions returns the permissions granted to identities through their auth groups.
+// The result is keyed by the authentication method and the identifier of the identities ("<method>/<identifier>").
+// Identities that belong to groups without permissions are included with no permissions.
+func (c *ClusterTx) GetAuthIdentityPermissions(ctx context.Context) (map[string][]auth.Permission, error) {
+	stmt := "SELECT auth_group_id, auth_method || '/' || identifier FROM auth_groups_identities"
+
+	return c.getAuthGroupMemberPermissions(ctx, stmt)
+}
+
+// GetAuthClaimPermissions returns the permissions granted through auth groups to OpenID Connect users with claims.
+// The result is keyed by the claim name and value ("<claim>=<value>").
+func (c *ClusterTx) GetAuthClaimPermissions(ctx context.Context) (map[string][]auth.Permission, error) {
+	stmt := "SELECT auth_group_id, claim || '=' || value FROM auth_groups_oidc_claims"
+
+	return c.getAuthGroupMemberPermissions(ctx, stmt)
+}
+
+// GetAuthIdentityConfigs returns the configurations of the auth groups of the identities, keyed by the
+// authentication method and the identifier of the identities ("<method>/<identifier>").
+func (c *ClusterTx) GetAuthIdentityConfigs(ctx context.Context) (map[string][]map[string]string, error) {
+	stmt := "SELECT auth_group_id, auth_method || '/' || identifier FROM auth_groups_identities"
+
+	return c.getAuthGroupMemberConfigs(ctx, stmt)
+}
+
+// GetAuthClaimConfigs returns the configurations of the auth groups OpenID Connect claims are mapped to, keyed by
+// the claim name and value ("<claim>=<value>").
+func (c *ClusterTx) GetAuthClaimConfigs(ctx context.Context) (map[string][]map[string]string, error) {
+	stmt := "SELECT auth_group_id, claim || '=' || value FROM auth_groups_oidc_claims"
+
+	return c.getAuthGroupMemberConfigs(ctx, stmt)
+}
+
+// getAuthGroupMemberConfigs returns the configurations of the auth groups keyed by the members returned by stmt,
+// which must select the group ID and the member. Groups without configuration are left out.
+func (c *ClusterTx) getAuthGroupMemberConfigs(ctx context.Context, stmt string) (map[string][]map[string]string, error) {
+	groupConfigs := map[int64]map[string]string{}
+
+	err := query.Scan(ctx, c.tx, "SELECT auth_group_id, key, value FROM auth_groups_config", func(scan func(dest ...any) error) error {
+		var id int64
+		var key, value string
+
+		err := scan(&id, &key, &value)
+		if err != nil {
+			return err
+		}
+
+		if groupConfigs[id] == nil {
+			groupConfigs[id] = map[string]string{}
+		}
+
+		groupConfigs[id][key] = value
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group config: %w", err)
+	}
+
+	configs := map[string][]map[string]string{}
+
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var member string
+
+		err := scan(&id, &member)
+		if err != nil {
+			return err
+		}
+
+		config, found := groupConfigs[id]
+		if found {
+			configs[member] = append(configs[member], config)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group members: %w", err)
+	}
+
+	return configs, nil
+}
+
+// getAuthGroupMemberPermissions returns the permissions of the auth groups keyed by the members returned by stmt,
+// which must select the group ID and the member.
+func (c *ClusterTx) getAuthGroupMemberPermissions(ctx context.Context, stmt string) (map[string][]auth.Permission, error) {
+	groupPermissions := map[int64][]auth.Permission{}
+
+	err := query.Scan(ctx, c.tx, authPermissionsQuery, func(scan func(dest ...any) error) error {
+		var id int64
+		p := auth.Permission{}
+
+		err := scan(&id, &p.EntityType, &p.Entitlement, &p.Project, &p.Name)
+		if err != nil {
+			return err
+		}
+
+		groupPermissions[id] = append(groupPermissions[id], p)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group permissions: %w", err)
+	}
+
+	permissions := map[string][]auth.Permission{}
+
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var member string
+
+		err := scan(&id, &member)
+		if err != nil {
+			return err
+		}
+
+		permissions[member] = append(permissions[member], groupPermissions[id]...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching auth group members: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// CreateAuthGroup creates a new auth group.
+func (c *ClusterTx) CreateAuthGroup(ctx context.Context, group api.AuthGroupsPost) error {
+	result, err := c.tx.ExecContext(ctx, "INSERT INTO auth_groups (name, description) VALUES (?, ?)", group.Name, group.Description)
+	if err != nil {
+		return fmt.Errorf("Failed creating auth group: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.setAuthGroupMembers(ctx, id, group.AuthGroupPut)
+}
+
+// UpdateAuthGroup replaces the description, identities, OpenID Connect claims, permissions and configuration of an
+// auth group.
+func (c *ClusterTx) UpdateAuthGroup(ctx context.Context, name string, group api.AuthGroupPut) error {
+	id, err := c.getAuthGroupID(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.ExecContext(ctx, "UPDATE auth_groups SET description = ? WHERE id = ?", group.Description, id)
+	if err != nil {
+		return fmt.Errorf("Failed updating auth group: %w", err)
+	}
+
+	for _, table := range []string{"auth_groups_identities", "auth_groups_oidc_claims", "auth_groups_permissions", "auth_groups_config"} {
+		_, err = c.tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE auth_group_id = ?", table), id)
+		if err != nil {
+			return fmt.Errorf("Failed clearing auth group: %w", err)
+		}
+	}
+
+	return c.setAuthGroupMembers(ctx, id, group)
+}
+
+// RenameAuthGroup renames an auth group.
+func (c *ClusterTx) RenameAuthGroup(ctx context.Context, name string, newName string) error {
+	id, err := c.getAuthGroupID(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.ExecContext(ctx, "UPDATE auth_groups SET name = ? WHERE id = ?", newName, id)
+	if err != nil {
+		return fmt.Errorf("Failed renaming auth group: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAuthGroup deletes an auth group along with its members and permissions.
+func (c *ClusterTx) DeleteAuthGroup(ctx context.Context, name string) error {
+	id, err := c.getAuthGroupID(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.ExecContext(ctx, "DELETE FROM auth_groups WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("Failed deleting auth group: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ClusterTx) getAuthGroupID(ctx context.Context, name string) (int64, error) {
+	var id int64
+
+	err := c.tx.QueryRowContext(ctx, "SELECT id FROM auth_groups WHERE name = ?", name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, api.StatusErrorf(http.StatusNotFound, "Auth group not found")
+		}
+
+		return -1, fmt.Errorf("Failed fetching auth group: %w", err)
+	}
+
+	return id, nil
+}
+
+// setAuthGroupMembers adds the identities, OpenID Connect claims, permissions and configuration to an auth group.
+func (c *ClusterTx) setAuthGroupMembers(ctx context.Context, id int64, group api.AuthGroupPut) error {
+	for _, identity := range group.Identities {
+		method, identifier, found := strings.Cut(identity, "/")
+		if !found {
+			return api.StatusErrorf(http.StatusBadRequest, "Invalid identity %q", identity)
+		}
+
+		_, err := c.tx.ExecContext(ctx, "INSERT OR IGNORE INTO auth_groups_identities (auth_group_id, auth_method, identifier) VALUES (?, ?, ?)", id, method, identifier)
+		if err != nil {
+			return fmt.Errorf("Failed adding identity %q to auth group: %w", identity, err)
+		}
+	}
+
+	for _, oidcClaim := range group.OIDCClaims {
+		claim, value, found := strings.Cut(oidcClaim, "=")
+		if !found {
+			return api.StatusErrorf(http.StatusBadRequest, "Invalid OpenID Connect claim %q", oidcClaim)
+		}
+
+		_, err := c.tx.ExecContext(ctx, "INSERT OR IGNORE INTO auth_groups_oidc_claims (auth_group_id, claim, value) VALUES (?, ?, ?)", id, claim, value)
+		if err != nil {
+			return fmt.Errorf("Failed adding OpenID Connect claim %q to auth group: %w", oidcClaim, err)
+		}
+	}
+
+	for _, permission := range group.Permissions {
+		entityID, err := c.authPermissionEntityID(ctx, permission)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.tx.ExecContext(ctx, "INSERT OR IGNORE INTO auth_groups_permissions (auth_group_id, entity_type, entity_id, entitlement) VALUES (?, ?, ?, ?)", id, permission.EntityType, entityID, permission.Entitlement)
+		if err != nil {
+			return fmt.Errorf("Failed adding permission to auth group: %w", err)
+		}
+	}
+
+	for key, value := range group.Config {
+		_, err := c.tx.ExecContext(ctx, "INSERT INTO auth_groups_config (auth_group_id, key, value) VALUES (?, ?, ?)", id, key, value)
+		if err != nil {
+			return fmt.Errorf("Failed adding config %q to auth group: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// authPermissionEntityID returns the ID of the entity the permission is granted on, 0 for the server.
+func (c *ClusterTx) authPermissionEntityID(ctx context.Context, permission api.AuthPermission) (int64, error) {
+	u, err := url.Parse(permission.URL)
+	if err != nil {
+		return -1, api.StatusErrorf(http.StatusBadRequest, "Invalid entity URL %q: %v", permission.URL, err)
+	}
+
+	projectName := u.Query().Get("project")
+	if projectName == "" {
+		projectName = "default"
+	}
+
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+
+	var stmt string
+	var args []any
+
+	switch permission.EntityType {
+	case api.AuthEntityTypeServer:
+		if u.Path == "/"+version.APIVersion {
+			return 0, nil
+		}
+
+	case api.AuthEntityTypeProject:
+		if len(parts) == 3 && parts[0] == version.APIVersion && parts[1] == "projects" {
+			stmt = "SELECT id FROM projects WHERE name = ?"
+			args = []any{parts[2]}
+		}
+
+	case api.AuthEntityTypeInstance:
+		if len(parts) == 3 && parts[0] == version.APIVersion && parts[1] == "instances" {
+			stmt = "SELECT instances.id FROM instances JOIN projects ON projects.id = instances.project_id WHERE projects.name = ? AND instances.name = ?"
+			args = []any{projectName, parts[2]}
+		}
+
+	default:
+		return -1, api.StatusErrorf(http.StatusBadRequest, "Invalid entity type %q", permission.EntityType)
+	}
+
+	if stmt == "" {
+		return -1, api.StatusErrorf(http.StatusBadRequest, "Invalid URL %q for entity type %q", permission.URL, permission.EntityType)
+	}
+
+	var id int64
+	err = c.tx.QueryRowContext(ctx, stmt, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, api.StatusErrorf(http.StatusBadRequest, "Entity %q not found", permission.URL)
+		}
+
+		return -1, fmt.Errorf("Failed fetching entity %q: %w", permission.URL, err)
+	}
+
+	return id, nil
+}
+
+// authPermissionURL returns the URL of the entity of a permission.
+func authPermissionURL(entityType string, projectName string, name string) string {
+	switch entityType {
+	case api.AuthEntityTypeProject:
+		return api.NewURL().Path(version.APIVersion, "projects", projectName).String()
+	case api.AuthEntityTypeInstance:
+		return api.NewURL().Path(version.APIVersion, "instances", name).Project(projectName).String()
+	}
+
+	return api.NewURL().Path(version.APIVersion).String()
+}
@@ -0,0 +1,124 @@
+//go:build linux && cgo && !agent
+
+package db_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/auth"
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Create, update, rename and delete auth groups.
+func TestAuthGroups(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	addContainer(t, tx, 1, "c1")
+
+	ctx := context.Background()
+
+	group := api.AuthGroupsPost{
+		Name: "operators",
+		AuthGroupPut: api.AuthGroupPut{
+			Description: "Operators",
+			Identities:  []string{"oidc/jane@example.com", "tls/abcdef"},
+			OIDCClaims:  []string{"groups=lxd-operators"},
+			Permissions: []api.AuthPermission{
+				{EntityType: api.AuthEntityTypeServer, URL: "/1.0", Entitlement: "viewer"},
+				{EntityType: api.AuthEntityTypeProject, URL: "/1.0/projects/default", Entitlement: "operator"},
+				{EntityType: api.AuthEntityTypeInstance, URL: "/1.0/instances/c1", Entitlement: "can_exec"},
+			},
+			Config: map[string]string{"limits.rate.read": "100/m"},
+		},
+	}
+
+	err := tx.CreateAuthGroup(ctx, group)
+	require.NoError(t, err)
+
+	groups, err := tx.GetAuthGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "operators", groups[0].Name)
+	assert.Equal(t, []string{"oidc/jane@example.com", "tls/abcdef"}, groups[0].Identities)
+	assert.Equal(t, []api.AuthPermission{
+		{EntityType: api.AuthEntityTypeServer, URL: "/1.0", Entitlement: "viewer"},
+		{EntityType: api.AuthEntityTypeProject, URL: "/1.0/projects/default", Entitlement: "operator"},
+		{EntityType: api.AuthEntityTypeInstance, URL: "/1.0/instances/c1", Entitlement: "can_exec"},
+	}, groups[0].Permissions)
+	assert.Equal(t, map[string]string{"limits.rate.read": "100/m"}, groups[0].Config)
+
+	permissions, err := tx.GetAuthIdentityPermissions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []auth.Permission{
+		{EntityType: api.AuthEntityTypeServer, Entitlement: "viewer"},
+		{EntityType: api.AuthEntityTypeProject, Project: "default", Entitlement: "operator"},
+		{EntityType: api.AuthEntityTypeInstance, Project: "default", Name: "c1", Entitlement: "can_exec"},
+	}, permissions["oidc/jane@example.com"])
+
+	claimPermissions, err := tx.GetAuthClaimPermissions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, permissions["oidc/jane@example.com"], claimPermissions["groups=lxd-operators"])
+
+	configs, err := tx.GetAuthIdentityConfigs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"limits.rate.read": "100/m"}}, configs["tls/abcdef"])
+
+	claimConfigs, err := tx.GetAuthClaimConfigs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, configs["tls/abcdef"], claimConfigs["groups=lxd-operators"])
+
+	// Permissions on unknown entities are rejected.
+	put := groups[0].Writable()
+	put.Permissions = append(put.Permissions, api.AuthPermission{EntityType: api.AuthEntityTypeInstance, URL: "/1.0/instances/c2", Entitlement: "can_exec"})
+	err = tx.UpdateAuthGroup(ctx, "operators", put)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusBadRequest))
+
+	put = groups[0].Writable()
+	put.Identities = []string{"tls/abcdef"}
+	put.Config = map[string]string{"limits.rate.write": "5/s"}
+	err = tx.UpdateAuthGroup(ctx, "operators", put)
+	require.NoError(t, err)
+
+	err = tx.RenameAuthGroup(ctx, "operators", "ops")
+	require.NoError(t, err)
+
+	group2, err := tx.GetAuthGroup(ctx, "ops")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tls/abcdef"}, group2.Identities)
+	assert.Equal(t, []string{"groups=lxd-operators"}, group2.OIDCClaims)
+	assert.Len(t, group2.Permissions, 3)
+	assert.Equal(t, map[string]string{"limits.rate.write": "5/s"}, group2.Config)
+
+	// Deleting the instance removes the permissions granted on it.
+	_, err = tx.Tx().Exec("DELETE FROM instances WHERE name = 'c1'")
+	require.NoError(t, err)
+
+	group2, err = tx.GetAuthGroup(ctx, "ops")
+	require.NoError(t, err)
+	assert.Len(t, group2.Permissions, 2)
+
+	err = tx.DeleteAuthGroup(ctx, "ops")
+	require.NoError(t, err)
+
+	_, err = tx.GetAuthGroup(ctx, "ops")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+
+	permissions, err = tx.GetAuthIdentityPermissions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	claimPermissions, err = tx.GetAuthClaimPermissions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, claimPermissions)
+
+	configs, err = tx.GetAuthIdentityConfigs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+}
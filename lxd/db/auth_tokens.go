@@ -0,0 +1,176 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/canonical/lxd/lxd/db/query"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// GetAuthTokens returns all the API bearer tokens.
+func (c *ClusterTx) GetAuthTokens(ctx context.Context) ([]api.AuthToken, error) {
+	return c.getAuthTokens(ctx, "")
+}
+
+// GetAuthTokenByFingerprintPrefix returns the API bearer token whose fingerprint starts with the given prefix.
+func (c *ClusterTx) GetAuthTokenByFingerprintPrefix(ctx context.Context, fingerprintPrefix string) (*api.AuthToken, error) {
+	tokens, err := c.getAuthTokens(ctx, fingerprintPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) > 1 {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "More than one token matches")
+	}
+
+	if len(tokens) == 0 {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Token not found")
+	}
+
+	return &tokens[0], nil
+}
+
+// getAuthTokens returns the API bearer tokens, only those whose fingerprint starts with the prefix if not empty.
+func (c *ClusterTx) getAuthTokens(ctx context.Context, fingerprintPrefix string) ([]api.AuthToken, error) {
+	stmt := `
+SELECT id, fingerprint, description, restricted, read_only, creation_date, expiry_date, last_used_date
+  FROM auth_tokens
+ WHERE fingerprint LIKE ?
+ ORDER BY creation_date, fingerprint
+`
+
+	tokens := []api.AuthToken{}
+	indexes := map[int64]int{}
+
+	err := query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var expiryDate, lastUsedDate sql.NullTime
+		token := api.AuthToken{}
+
+		err := scan(&id, &token.Fingerprint, &token.Description, &token.Restricted, &token.ReadOnly, &token.CreatedAt, &expiryDate, &lastUsedDate)
+		if err != nil {
+			return err
+		}
+
+		token.ExpiresAt = expiryDate.Time
+		token.LastUsedAt = lastUsedDate.Time
+		token.Projects = []string{}
+
+		indexes[id] = len(tokens)
+		tokens = append(tokens, token)
+
+		return nil
+	}, fingerprintPrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching tokens: %w", err)
+	}
+
+	stmt = `
+SELECT auth_tokens_projects.auth_token_id, projects.name
+  FROM auth_tokens_projects
+  JOIN projects ON projects.id = auth_tokens_projects.project_id
+ ORDER BY projects.name
+`
+
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var projectName string
+
+		err := scan(&id, &projectName)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			tokens[i].Projects = append(tokens[i].Projects, projectName)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching token projects: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CreateAuthToken stores a new API bearer token with the given fingerprint.
+func (c *ClusterTx) CreateAuthToken(ctx context.Context, fingerprint string, token api.AuthTokensPost) error {
+	projectIDs := make([]int64, 0, len(token.Projects))
+	for _, projectName := range token.Projects {
+		var projectID int64
+
+		err := c.tx.QueryRowContext(ctx, "SELECT id FROM projects WHERE name = ?", projectName).Scan(&projectID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return api.StatusErrorf(http.StatusBadRequest, "Project %q not found", projectName)
+			}
+
+			return fmt.Errorf("Failed fetching project %q: %w", projectName, err)
+		}
+
+		projectIDs = append(projectIDs, projectID)
+	}
+
+	var expiryDate any
+	if !token.ExpiresAt.IsZero() {
+		expiryDate = token.ExpiresAt.UTC()
+	}
+
+	stmt := "INSERT INTO auth_tokens (fingerprint, description, restricted, read_only, creation_date, expiry_date) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := c.tx.ExecContext(ctx, stmt, fingerprint, token.Description, token.Restricted, token.ReadOnly, time.Now().UTC(), expiryDate)
+	if err != nil {
+		return fmt.Errorf("Failed creating token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, projectID := range projectIDs {
+		_, err = c.tx.ExecContext(ctx, "INSERT OR IGNORE INTO auth_tokens_projects (auth_token_id, project_id) VALUES (?, ?)", id, projectID)
+		if err != nil {
+			return fmt.Errorf("Failed adding project to token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteAuthToken deletes the API bearer token with the given fingerprint.
+func (c *ClusterTx) DeleteAuthToken(ctx context.Context, fingerprint string) error {
+	result, err := c.tx.ExecContext(ctx, "DELETE FROM auth_tokens WHERE fingerprint = ?", fingerprint)
+	if err != nil {
+		return fmt.Errorf("Failed deleting token: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return api.StatusErrorf(http.StatusNotFound, "Token not found")
+	}
+
+	return nil
+}
+
+// UpdateAuthTokenLastUsed records when the API bearer token with the given fingerprint was last used.
+func (c *ClusterTx) UpdateAuthTokenLastUsed(ctx context.Context, fingerprint string, date time.Time) error {
+	_, err := c.tx.ExecContext(ctx, "UPDATE auth_tokens SET last_used_date = ? WHERE fingerprint = ?", date.UTC(), fingerprint)
+	if err != nil {
+		return fmt.Errorf("Failed updating token: %w", err)
+	}
+
+	return nil
+}
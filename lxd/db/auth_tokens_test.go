@@ -0,0 +1,74 @@
+//go:build linux && cgo && !agent
+
+package db_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Create, get, use and delete API bearer tokens.
+func TestAuthTokens(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	err := tx.CreateAuthToken(ctx, "abcdef", api.AuthTokensPost{Description: "CI", Restricted: true, Projects: []string{"default"}, ReadOnly: true, ExpiresAt: expiry})
+	require.NoError(t, err)
+
+	err = tx.CreateAuthToken(ctx, "abc123", api.AuthTokensPost{})
+	require.NoError(t, err)
+
+	// Projects must exist.
+	err = tx.CreateAuthToken(ctx, "fedcba", api.AuthTokensPost{Restricted: true, Projects: []string{"missing"}})
+	assert.True(t, api.StatusErrorCheck(err, http.StatusBadRequest))
+
+	tokens, err := tx.GetAuthTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	token, err := tx.GetAuthTokenByFingerprintPrefix(ctx, "abcd")
+	require.NoError(t, err)
+	assert.Equal(t, "CI", token.Description)
+	assert.True(t, token.Restricted)
+	assert.True(t, token.ReadOnly)
+	assert.Equal(t, []string{"default"}, token.Projects)
+	assert.True(t, expiry.Equal(token.ExpiresAt))
+	assert.True(t, token.LastUsedAt.IsZero())
+
+	_, err = tx.GetAuthTokenByFingerprintPrefix(ctx, "abc")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusBadRequest))
+
+	token, err = tx.GetAuthTokenByFingerprintPrefix(ctx, "abc1")
+	require.NoError(t, err)
+	assert.False(t, token.Restricted)
+	assert.Empty(t, token.Projects)
+	assert.True(t, token.ExpiresAt.IsZero())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err = tx.UpdateAuthTokenLastUsed(ctx, "abc123", now)
+	require.NoError(t, err)
+
+	token, err = tx.GetAuthTokenByFingerprintPrefix(ctx, "abc123")
+	require.NoError(t, err)
+	assert.True(t, now.Equal(token.LastUsedAt))
+
+	err = tx.DeleteAuthToken(ctx, "abc123")
+	require.NoError(t, err)
+
+	err = tx.DeleteAuthToken(ctx, "abc123")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+
+	_, err = tx.GetAuthTokenByFingerprintPrefix(ctx, "abc1")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+}
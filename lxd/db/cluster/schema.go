@@ -6,6 +6,73 @@ package cluster
 // modify the database schema, please add a new schema update to update.go
 // and the run 'make update-schema'.
 const freshSchema = `
+CREATE TABLE auth_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE auth_groups_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (auth_group_id, key),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TABLE auth_groups_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    auth_method TEXT NOT NULL,
+    identifier TEXT NOT NULL,
+    UNIQUE (auth_group_id, auth_method, identifier),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TABLE auth_groups_oidc_claims (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    claim TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (auth_group_id, claim, value),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TABLE auth_groups_permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    entitlement TEXT NOT NULL,
+    UNIQUE (auth_group_id, entity_type, entity_id, entitlement),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TRIGGER auth_groups_permissions_on_instance_delete
+  AFTER DELETE ON instances
+  BEGIN
+    DELETE FROM auth_groups_permissions WHERE entity_type = 'instance' AND entity_id = OLD.id;
+  END;
+CREATE TRIGGER auth_groups_permissions_on_project_delete
+  AFTER DELETE ON projects
+  BEGIN
+    DELETE FROM auth_groups_permissions WHERE entity_type = 'project' AND entity_id = OLD.id;
+  END;
+CREATE TABLE auth_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    description TEXT NOT NULL,
+    restricted INTEGER NOT NULL DEFAULT 0,
+    read_only INTEGER NOT NULL DEFAULT 0,
+    creation_date DATETIME NOT NULL,
+    expiry_date DATETIME,
+    last_used_date DATETIME,
+    UNIQUE (fingerprint)
+);
+CREATE TABLE auth_tokens_projects (
+    auth_token_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    FOREIGN KEY (auth_token_id) REFERENCES auth_tokens (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (auth_token_id, project_id)
+);
 CREATE TABLE certificates (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     fingerprint TEXT NOT NULL,
@@ -166,6 +233,20 @@ CREATE INDEX instances_project_id_and_node_id_and_name_idx ON instances (project
 CREATE INDEX instances_project_id_and_node_id_idx ON instances (project_id,
     node_id);
 CREATE INDEX instances_project_id_idx ON instances (project_id);
+CREATE TABLE instances_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    username TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    config TEXT NOT NULL,
+    devices TEXT NOT NULL,
+    profiles TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    UNIQUE (instance_id, revision),
+    FOREIGN KEY (instance_id) REFERENCES "instances" (id) ON DELETE CASCADE
+);
 CREATE TABLE "instances_snapshots" (
     id INTEGER primary key AUTOINCREMENT NOT NULL,
     instance_id INTEGER NOT NULL,
@@ -426,6 +507,19 @@ CREATE TABLE "profiles_devices_config" (
     FOREIGN KEY (profile_device_id) REFERENCES "profiles_devices" (id) ON DELETE CASCADE
 );
 CREATE INDEX profiles_project_id_idx ON profiles (project_id);
+CREATE TABLE profiles_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    profile_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    username TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    config TEXT NOT NULL,
+    devices TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    UNIQUE (profile_id, revision),
+    FOREIGN KEY (profile_id) REFERENCES "profiles" (id) ON DELETE CASCADE
+);
 CREATE TABLE "projects" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -620,6 +714,22 @@ CREATE TABLE "warnings" (
 	FOREIGN KEY (project_id) REFERENCES "projects" (id) ON DELETE CASCADE
 );
 CREATE UNIQUE INDEX warnings_unique_node_id_project_id_entity_type_code_entity_id_type_code ON warnings(IFNULL(node_id, -1), IFNULL(project_id, -1), entity_type_code, entity_id, type_code);
+CREATE TABLE webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    url TEXT NOT NULL,
+    types TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE webhooks_projects (
+    webhook_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (webhook_id, project_id)
+);
 
-INSERT INTO schema (version, updated_at) VALUES (69, strftime("%s"))
+INSERT INTO schema (version, updated_at) VALUES (75, strftime("%s"))
 `
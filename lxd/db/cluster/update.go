@@ -106,6 +106,189 @@ var updates = map[int]schema.Update{
 	67: updateFromV66,
 	68: updateFromV67,
 	69: updateFromV68,
+	70: updateFromV69,
+	71: updateFromV70,
+	72: updateFromV71,
+	73: updateFromV72,
+	74: updateFromV73,
+	75: updateFromV74,
+}
+
+// updateFromV74 adds the table holding the configuration of the authorization groups.
+func updateFromV74(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE auth_groups_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (auth_group_id, key),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding authorization groups configuration table: %w", err)
+	}
+
+	return nil
+}
+
+// updateFromV73 adds the tables holding the webhooks and the projects they apply to.
+func updateFromV73(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    url TEXT NOT NULL,
+    types TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE webhooks_projects (
+    webhook_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (webhook_id, project_id)
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding webhooks tables: %w", err)
+	}
+
+	return nil
+}
+
+// updateFromV72 adds the tables holding the API bearer tokens and the projects they are restricted to.
+func updateFromV72(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE auth_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    description TEXT NOT NULL,
+    restricted INTEGER NOT NULL DEFAULT 0,
+    read_only INTEGER NOT NULL DEFAULT 0,
+    creation_date DATETIME NOT NULL,
+    expiry_date DATETIME,
+    last_used_date DATETIME,
+    UNIQUE (fingerprint)
+);
+CREATE TABLE auth_tokens_projects (
+    auth_token_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    FOREIGN KEY (auth_token_id) REFERENCES auth_tokens (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (auth_token_id, project_id)
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding authentication tokens tables: %w", err)
+	}
+
+	return nil
+}
+
+// updateFromV71 adds the table holding the OpenID Connect claims granting membership of authorization groups.
+func updateFromV71(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE auth_groups_oidc_claims (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    claim TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (auth_group_id, claim, value),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding authorization groups OpenID Connect claims table: %w", err)
+	}
+
+	return nil
+}
+
+// updateFromV70 adds the tables holding the authorization groups, their identities and their permissions.
+// Permissions on instances and projects are removed along with them.
+func updateFromV70(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE auth_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE auth_groups_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    auth_method TEXT NOT NULL,
+    identifier TEXT NOT NULL,
+    UNIQUE (auth_group_id, auth_method, identifier),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TABLE auth_groups_permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    auth_group_id INTEGER NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    entitlement TEXT NOT NULL,
+    UNIQUE (auth_group_id, entity_type, entity_id, entitlement),
+    FOREIGN KEY (auth_group_id) REFERENCES auth_groups (id) ON DELETE CASCADE
+);
+CREATE TRIGGER auth_groups_permissions_on_instance_delete
+  AFTER DELETE ON instances
+  BEGIN
+    DELETE FROM auth_groups_permissions WHERE entity_type = 'instance' AND entity_id = OLD.id;
+  END;
+CREATE TRIGGER auth_groups_permissions_on_project_delete
+  AFTER DELETE ON projects
+  BEGIN
+    DELETE FROM auth_groups_permissions WHERE entity_type = 'project' AND entity_id = OLD.id;
+  END;
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding authorization groups tables: %w", err)
+	}
+
+	return nil
+}
+
+// updateFromV69 adds the tables holding the configuration revisions of instances and profiles.
+func updateFromV69(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE instances_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    username TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    config TEXT NOT NULL,
+    devices TEXT NOT NULL,
+    profiles TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    UNIQUE (instance_id, revision),
+    FOREIGN KEY (instance_id) REFERENCES "instances" (id) ON DELETE CASCADE
+);
+CREATE TABLE profiles_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    profile_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    username TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    config TEXT NOT NULL,
+    devices TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    UNIQUE (profile_id, revision),
+    FOREIGN KEY (profile_id) REFERENCES "profiles" (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding config revisions tables: %w", err)
+	}
+
+	return nil
 }
 
 // updateFromV68 fixes unique index for record name to make it zone specific.
@@ -0,0 +1,287 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// ConfigRevisionsMax is the number of configuration revisions kept for each instance and profile.
+const ConfigRevisionsMax = 100
+
+// configRevisionsTable describes the table holding the configuration revisions of an entity type.
+type configRevisionsTable struct {
+	name     string
+	column   string
+	profiles bool
+}
+
+var instancesRevisions = configRevisionsTable{name: "instances_revisions", column: "instance_id", profiles: true}
+var profilesRevisions = configRevisionsTable{name: "profiles_revisions", column: "profile_id"}
+
+// ConfigChanges returns the changes between two revisions of a configuration, sorted by key.
+// Volatile keys are ignored.
+func ConfigChanges(old api.ConfigRevision, new api.ConfigRevision) []api.ConfigChange {
+	changes := []api.ConfigChange{}
+
+	diff := func(key string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, api.ConfigChange{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+
+	diffMaps := func(prefix string, oldMap map[string]string, newMap map[string]string) {
+		for k, v := range oldMap {
+			diff(prefix+k, v, newMap[k])
+		}
+
+		for k, v := range newMap {
+			_, found := oldMap[k]
+			if !found {
+				diff(prefix+k, "", v)
+			}
+		}
+	}
+
+	diffMaps("", configRevisionConfig(old.Config), configRevisionConfig(new.Config))
+
+	for name, device := range old.Devices {
+		diffMaps("devices."+name+".", device, new.Devices[name])
+	}
+
+	for name, device := range new.Devices {
+		_, found := old.Devices[name]
+		if !found {
+			diffMaps("devices."+name+".", nil, device)
+		}
+	}
+
+	diff("profiles", strings.Join(old.Profiles, ","), strings.Join(new.Profiles, ","))
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	return changes
+}
+
+// configRevisionConfig returns a copy of the config without its volatile keys.
+func configRevisionConfig(config map[string]string) map[string]string {
+	result := make(map[string]string, len(config))
+	for k, v := range config {
+		if strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// CreateInstanceConfigRevision records the change of the configuration of an instance from old to new.
+// If the instance has no revision yet, the old configuration is recorded first as its baseline.
+// Returns the new revision, or nil if the configuration didn't change.
+func (c *ClusterTx) CreateInstanceConfigRevision(ctx context.Context, instanceID int, old api.ConfigRevision, new api.ConfigRevision) (*api.ConfigRevision, error) {
+	return c.createConfigRevision(ctx, instancesRevisions, instanceID, old, new)
+}
+
+// CreateProfileConfigRevision records the change of the configuration of a profile from old to new.
+// If the profile has no revision yet, the old configuration is recorded first as its baseline.
+// Returns the new revision, or nil if the configuration didn't change.
+func (c *ClusterTx) CreateProfileConfigRevision(ctx context.Context, profileID int, old api.ConfigRevision, new api.ConfigRevision) (*api.ConfigRevision, error) {
+	old.Profiles = nil
+	new.Profiles = nil
+
+	return c.createConfigRevision(ctx, profilesRevisions, profileID, old, new)
+}
+
+// GetInstanceConfigRevisions returns the configuration revisions of an instance, oldest first.
+func (c *ClusterTx) GetInstanceConfigRevisions(ctx context.Context, instanceID int) ([]api.ConfigRevision, error) {
+	return c.getConfigRevisions(ctx, instancesRevisions, instanceID, -1)
+}
+
+// GetInstanceConfigRevision returns a configuration revision of an instance.
+func (c *ClusterTx) GetInstanceConfigRevision(ctx context.Context, instanceID int, revision int64) (*api.ConfigRevision, error) {
+	return c.getConfigRevision(ctx, instancesRevisions, instanceID, revision)
+}
+
+// GetProfileConfigRevisions returns the configuration revisions of a profile, oldest first.
+func (c *ClusterTx) GetProfileConfigRevisions(ctx context.Context, profileID int) ([]api.ConfigRevision, error) {
+	return c.getConfigRevisions(ctx, profilesRevisions, profileID, -1)
+}
+
+// GetProfileConfigRevision returns a configuration revision of a profile.
+func (c *ClusterTx) GetProfileConfigRevision(ctx context.Context, profileID int, revision int64) (*api.ConfigRevision, error) {
+	return c.getConfigRevision(ctx, profilesRevisions, profileID, revision)
+}
+
+func (c *ClusterTx) createConfigRevision(ctx context.Context, table configRevisionsTable, id int, old api.ConfigRevision, new api.ConfigRevision) (*api.ConfigRevision, error) {
+	changes := ConfigChanges(old, new)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var last int64
+	err := c.tx.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(MAX(revision), 0) FROM %s WHERE %s = ?", table.name, table.column), id).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching last config revision: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	// Record the configuration prior to the first recorded change, so that it can be rolled back to.
+	if last == 0 {
+		last++
+
+		baseline := api.ConfigRevision{
+			Revision:  last,
+			CreatedAt: now,
+			Config:    old.Config,
+			Devices:   old.Devices,
+			Profiles:  old.Profiles,
+			Changes:   []api.ConfigChange{},
+		}
+
+		err = c.insertConfigRevision(ctx, table, id, baseline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revision := api.ConfigRevision{
+		Revision:  last + 1,
+		CreatedAt: now,
+		Username:  new.Username,
+		Protocol:  new.Protocol,
+		Config:    configRevisionConfig(new.Config),
+		Devices:   new.Devices,
+		Profiles:  new.Profiles,
+		Changes:   changes,
+	}
+
+	err = c.insertConfigRevision(ctx, table, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expire the oldest revisions.
+	_, err = c.tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND revision <= ?", table.name, table.column), id, revision.Revision-ConfigRevisionsMax)
+	if err != nil {
+		return nil, fmt.Errorf("Failed expiring config revisions: %w", err)
+	}
+
+	return &revision, nil
+}
+
+func (c *ClusterTx) insertConfigRevision(ctx context.Context, table configRevisionsTable, id int, revision api.ConfigRevision) error {
+	config, err := json.Marshal(configRevisionConfig(revision.Config))
+	if err != nil {
+		return err
+	}
+
+	devices, err := json.Marshal(revision.Devices)
+	if err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{table.column, "revision", "date", "username", "protocol", "config", "devices", "changes"}
+	args := []any{id, revision.Revision, revision.CreatedAt, revision.Username, revision.Protocol, string(config), string(devices), string(changes)}
+
+	if table.profiles {
+		profiles, err := json.Marshal(revision.Profiles)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, "profiles")
+		args = append(args, string(profiles))
+	}
+
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table.name, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	_, err = c.tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("Failed recording config revision: %w", err)
+	}
+
+	return nil
+}
+
+// getConfigRevisions returns the revisions of an entity, or only the given revision if not negative.
+func (c *ClusterTx) getConfigRevisions(ctx context.Context, table configRevisionsTable, id int, revision int64) ([]api.ConfigRevision, error) {
+	profilesColumn := "'[]'"
+	if table.profiles {
+		profilesColumn = "profiles"
+	}
+
+	stmt := fmt.Sprintf("SELECT revision, date, username, protocol, config, devices, %s, changes FROM %s WHERE %s = ?", profilesColumn, table.name, table.column)
+	args := []any{id}
+
+	if revision >= 0 {
+		stmt += " AND revision = ?"
+		args = append(args, revision)
+	}
+
+	stmt += " ORDER BY revision"
+
+	rows, err := c.tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching config revisions: %w", err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	revisions := []api.ConfigRevision{}
+	for rows.Next() {
+		var config, devices, profiles, changes string
+
+		r := api.ConfigRevision{}
+		err := rows.Scan(&r.Revision, &r.CreatedAt, &r.Username, &r.Protocol, &config, &devices, &profiles, &changes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range []struct {
+			data   string
+			target any
+		}{{config, &r.Config}, {devices, &r.Devices}, {profiles, &r.Profiles}, {changes, &r.Changes}} {
+			err = json.Unmarshal([]byte(field.data), field.target)
+			if err != nil {
+				return nil, fmt.Errorf("Failed parsing config revision %d: %w", r.Revision, err)
+			}
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (c *ClusterTx) getConfigRevision(ctx context.Context, table configRevisionsTable, id int, revision int64) (*api.ConfigRevision, error) {
+	revisions, err := c.getConfigRevisions(ctx, table, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Config revision not found")
+	}
+
+	return &revisions[0], nil
+}
@@ -0,0 +1,82 @@
+//go:build linux && cgo && !agent
+
+package db_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/shared/api"
+)
+
+func TestConfigChanges(t *testing.T) {
+	old := api.ConfigRevision{
+		Config:   map[string]string{"limits.cpu": "2", "user.foo": "bar", "volatile.uuid": "1"},
+		Devices:  map[string]map[string]string{"eth0": {"type": "nic", "network": "lxdbr0"}},
+		Profiles: []string{"default"},
+	}
+
+	new := api.ConfigRevision{
+		Config:   map[string]string{"limits.cpu": "4", "limits.memory": "1GiB", "volatile.uuid": "2"},
+		Devices:  map[string]map[string]string{"root": {"type": "disk", "path": "/"}},
+		Profiles: []string{"default", "extra"},
+	}
+
+	assert.Equal(t, []api.ConfigChange{
+		{Key: "devices.eth0.network", Old: "lxdbr0"},
+		{Key: "devices.eth0.type", Old: "nic"},
+		{Key: "devices.root.path", New: "/"},
+		{Key: "devices.root.type", New: "disk"},
+		{Key: "limits.cpu", Old: "2", New: "4"},
+		{Key: "limits.memory", New: "1GiB"},
+		{Key: "profiles", Old: "default", New: "default,extra"},
+		{Key: "user.foo", Old: "bar"},
+	}, db.ConfigChanges(old, new))
+}
+
+// Record and get the configuration revisions of an instance.
+func TestInstanceConfigRevisions(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	addContainer(t, tx, 1, "c1")
+	id := int(getContainerID(t, tx, "c1"))
+
+	ctx := context.Background()
+
+	rev1 := api.ConfigRevision{Config: map[string]string{"limits.cpu": "2"}, Profiles: []string{"default"}}
+	rev2 := api.ConfigRevision{Config: map[string]string{"limits.cpu": "4", "volatile.uuid": "1"}, Profiles: []string{"default"}, Username: "foo", Protocol: "tls"}
+
+	// No revision is recorded if nothing changed.
+	revision, err := tx.CreateInstanceConfigRevision(ctx, id, rev1, rev1)
+	require.NoError(t, err)
+	assert.Nil(t, revision)
+
+	// The first change also records the previous configuration.
+	revision, err = tx.CreateInstanceConfigRevision(ctx, id, rev1, rev2)
+	require.NoError(t, err)
+	require.NotNil(t, revision)
+	assert.Equal(t, int64(2), revision.Revision)
+	assert.Equal(t, []api.ConfigChange{{Key: "limits.cpu", Old: "2", New: "4"}}, revision.Changes)
+
+	revisions, err := tx.GetInstanceConfigRevisions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, map[string]string{"limits.cpu": "2"}, revisions[0].Config)
+	assert.Empty(t, revisions[0].Changes)
+	assert.Equal(t, map[string]string{"limits.cpu": "4"}, revisions[1].Config)
+	assert.Equal(t, []string{"default"}, revisions[1].Profiles)
+	assert.Equal(t, "foo", revisions[1].Username)
+
+	revision, err = tx.GetInstanceConfigRevision(ctx, id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "2", revision.Config["limits.cpu"])
+
+	_, err = tx.GetInstanceConfigRevision(ctx, id, 3)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+}
@@ -71,6 +71,7 @@ const (
 	RenewServerCertificate
 	RemoveExpiredTokens
 	ClusterHeal
+	InstanceDiff
 )
 
 // Description return a human-readable description of the operation type.
@@ -194,6 +195,8 @@ func (t Type) Description() string {
 		return "Remove expired tokens"
 	case ClusterHeal:
 		return "Healing cluster"
+	case InstanceDiff:
+		return "Comparing instance with its image"
 	default:
 		return "Executing operation"
 	}
@@ -249,6 +252,8 @@ func (t Type) Permission() string {
 		return "manage-containers"
 	case InstanceRebuild:
 		return "operate-containers"
+	case InstanceDiff:
+		return "operate-containers"
 	case SnapshotRestore:
 		return "manage-containers"
 
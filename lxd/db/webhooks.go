@@ -0,0 +1,212 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/db/query"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// GetWebhooks returns all the webhooks, including their secrets.
+func (c *ClusterTx) GetWebhooks(ctx context.Context) ([]api.Webhook, error) {
+	return c.getWebhooks(ctx, "")
+}
+
+// GetWebhook returns the webhook with the given name, including its secret.
+func (c *ClusterTx) GetWebhook(ctx context.Context, name string) (*api.Webhook, error) {
+	webhooks, err := c.getWebhooks(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(webhooks) == 0 {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Webhook not found")
+	}
+
+	return &webhooks[0], nil
+}
+
+// getWebhooks returns the webhooks, only the one with the given name if not empty.
+func (c *ClusterTx) getWebhooks(ctx context.Context, name string) ([]api.Webhook, error) {
+	stmt := "SELECT id, name, description, url, types, secret FROM webhooks"
+	args := []any{}
+	if name != "" {
+		stmt += " WHERE name = ?"
+		args = append(args, name)
+	}
+
+	stmt += " ORDER BY name"
+
+	webhooks := []api.Webhook{}
+	indexes := map[int64]int{}
+
+	err := query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var types string
+		var secret string
+		webhook := api.Webhook{}
+
+		err := scan(&id, &webhook.Name, &webhook.Description, &webhook.URL, &types, &secret)
+		if err != nil {
+			return err
+		}
+
+		webhook.Secret = &secret
+
+		webhook.Types = []string{}
+		if types != "" {
+			webhook.Types = strings.Split(types, ",")
+		}
+
+		webhook.Projects = []string{}
+
+		indexes[id] = len(webhooks)
+		webhooks = append(webhooks, webhook)
+
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching webhooks: %w", err)
+	}
+
+	stmt = `
+SELECT webhooks_projects.webhook_id, projects.name
+  FROM webhooks_projects
+  JOIN projects ON projects.id = webhooks_projects.project_id
+ ORDER BY projects.name
+`
+
+	err = query.Scan(ctx, c.tx, stmt, func(scan func(dest ...any) error) error {
+		var id int64
+		var projectName string
+
+		err := scan(&id, &projectName)
+		if err != nil {
+			return err
+		}
+
+		i, found := indexes[id]
+		if found {
+			webhooks[i].Projects = append(webhooks[i].Projects, projectName)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed fetching webhook projects: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhook stores a new webhook.
+func (c *ClusterTx) CreateWebhook(ctx context.Context, webhook api.WebhooksPost) error {
+	var count int
+
+	err := c.tx.QueryRowContext(ctx, "SELECT count(*) FROM webhooks WHERE name = ?", webhook.Name).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("Failed checking for existing webhook: %w", err)
+	}
+
+	if count > 0 {
+		return api.StatusErrorf(http.StatusConflict, "Webhook %q already exists", webhook.Name)
+	}
+
+	stmt := "INSERT INTO webhooks (name, description, url, types, secret) VALUES (?, ?, ?, ?, ?)"
+	result, err := c.tx.ExecContext(ctx, stmt, webhook.Name, webhook.Description, webhook.URL, strings.Join(webhook.Types, ","), webhookSecret(webhook.WebhookPut))
+	if err != nil {
+		return fmt.Errorf("Failed creating webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.setWebhookProjects(ctx, id, webhook.Projects)
+}
+
+// UpdateWebhook updates the webhook with the given name.
+func (c *ClusterTx) UpdateWebhook(ctx context.Context, name string, webhook api.WebhookPut) error {
+	var id int64
+
+	err := c.tx.QueryRowContext(ctx, "SELECT id FROM webhooks WHERE name = ?", name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.StatusErrorf(http.StatusNotFound, "Webhook not found")
+		}
+
+		return fmt.Errorf("Failed fetching webhook: %w", err)
+	}
+
+	stmt := "UPDATE webhooks SET description = ?, url = ?, types = ?, secret = ? WHERE id = ?"
+	_, err = c.tx.ExecContext(ctx, stmt, webhook.Description, webhook.URL, strings.Join(webhook.Types, ","), webhookSecret(webhook), id)
+	if err != nil {
+		return fmt.Errorf("Failed updating webhook: %w", err)
+	}
+
+	_, err = c.tx.ExecContext(ctx, "DELETE FROM webhooks_projects WHERE webhook_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("Failed clearing webhook projects: %w", err)
+	}
+
+	return c.setWebhookProjects(ctx, id, webhook.Projects)
+}
+
+// webhookSecret returns the secret of the webhook, empty if unset.
+func webhookSecret(webhook api.WebhookPut) string {
+	if webhook.Secret == nil {
+		return ""
+	}
+
+	return *webhook.Secret
+}
+
+// setWebhookProjects adds the given projects to the webhook with the given ID.
+func (c *ClusterTx) setWebhookProjects(ctx context.Context, id int64, projects []string) error {
+	for _, projectName := range projects {
+		var projectID int64
+
+		err := c.tx.QueryRowContext(ctx, "SELECT id FROM projects WHERE name = ?", projectName).Scan(&projectID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return api.StatusErrorf(http.StatusBadRequest, "Project %q not found", projectName)
+			}
+
+			return fmt.Errorf("Failed fetching project %q: %w", projectName, err)
+		}
+
+		_, err = c.tx.ExecContext(ctx, "INSERT OR IGNORE INTO webhooks_projects (webhook_id, project_id) VALUES (?, ?)", id, projectID)
+		if err != nil {
+			return fmt.Errorf("Failed adding project to webhook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes the webhook with the given name.
+func (c *ClusterTx) DeleteWebhook(ctx context.Context, name string) error {
+	result, err := c.tx.ExecContext(ctx, "DELETE FROM webhooks WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("Failed deleting webhook: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return api.StatusErrorf(http.StatusNotFound, "Webhook not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+//go:build linux && cgo && !agent
+
+package db_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Create, get, update and delete webhooks.
+func TestWebhooks(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	secret := "s3cr3t"
+	webhook := api.WebhooksPost{
+		Name: "deploy",
+		WebhookPut: api.WebhookPut{
+			Description: "Deployments",
+			URL:         "https://hooks.example.com/lxd",
+			Types:       []string{"lifecycle", "operation"},
+			Projects:    []string{"default"},
+			Secret:      &secret,
+		},
+	}
+
+	err := tx.CreateWebhook(ctx, webhook)
+	require.NoError(t, err)
+
+	err = tx.CreateWebhook(ctx, webhook)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusConflict))
+
+	// Projects must exist.
+	err = tx.CreateWebhook(ctx, api.WebhooksPost{Name: "other", WebhookPut: api.WebhookPut{Projects: []string{"missing"}}})
+	assert.True(t, api.StatusErrorCheck(err, http.StatusBadRequest))
+
+	webhooks, err := tx.GetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, webhook.WebhookPut, webhooks[0].WebhookPut)
+
+	err = tx.UpdateWebhook(ctx, "deploy", api.WebhookPut{URL: "https://hooks.example.com/other"})
+	require.NoError(t, err)
+
+	updated, err := tx.GetWebhook(ctx, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/other", updated.URL)
+	assert.Empty(t, updated.Types)
+	assert.Empty(t, updated.Projects)
+	assert.Empty(t, *updated.Secret)
+
+	err = tx.UpdateWebhook(ctx, "missing", api.WebhookPut{})
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+
+	err = tx.DeleteWebhook(ctx, "deploy")
+	require.NoError(t, err)
+
+	err = tx.DeleteWebhook(ctx, "deploy")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+
+	_, err = tx.GetWebhook(ctx, "deploy")
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+}
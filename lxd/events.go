@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/canonical/lxd/lxd/db"
@@ -22,6 +23,12 @@ import (
 var eventTypes = []string{api.EventTypeLogging, api.EventTypeOperation, api.EventTypeLifecycle}
 var privilegedEventTypes = []string{api.EventTypeLogging}
 
+// Size after which the event log gets rotated, and number of rotated event logs to keep.
+const (
+	eventLogMaxSize  = 10 * 1024 * 1024
+	eventLogMaxFiles = 5
+)
+
 var eventsCmd = APIEndpoint{
 	Path: "events",
 
@@ -82,6 +89,43 @@ func eventsSocket(s *state.State, r *http.Request, w http.ResponseWriter) error
 		return api.StatusErrorf(http.StatusForbidden, "Forbidden")
 	}
 
+	// Replay the logged events more recent than the given ID if requested.
+	var since *uint64
+	if queryParam(r, "since") != "" {
+		id, err := strconv.ParseUint(queryParam(r, "since"), 10, 64)
+		if err != nil {
+			return api.StatusErrorf(http.StatusBadRequest, "Invalid event ID %q", queryParam(r, "since"))
+		}
+
+		// Event IDs are assigned by each member, reject those that came from another one.
+		err = s.Events.CheckReplay(id)
+		if err != nil {
+			return err
+		}
+
+		since = &id
+	}
+
+	// Filter the events server side if requested.
+	var eventFilter *events.Filter
+	if queryParam(r, "action") != "" || queryParam(r, "entity") != "" || queryParam(r, "location") != "" || queryParam(r, "level") != "" || queryParam(r, "filter") != "" {
+		var actions []string
+		if queryParam(r, "action") != "" {
+			actions = strings.Split(queryParam(r, "action"), ",")
+		}
+
+		var locations []string
+		if queryParam(r, "location") != "" {
+			locations = strings.Split(queryParam(r, "location"), ",")
+		}
+
+		var err error
+		eventFilter, err = events.NewFilter(actions, queryParam(r, "entity"), locations, queryParam(r, "level"), queryParam(r, "filter"))
+		if err != nil {
+			return api.StatusErrorf(http.StatusBadRequest, "%v", err)
+		}
+	}
+
 	l := logger.AddContext(logger.Ctx{"remote": r.RemoteAddr})
 
 	// Upgrade the connection to websocket
@@ -140,7 +184,7 @@ func eventsSocket(s *state.State, r *http.Request, w http.ResponseWriter) error
 
 	listenerConnection := events.NewWebsocketListenerConnection(conn)
 
-	listener, err := s.Events.AddListener(projectName, allProjects, listenerConnection, types, excludeSources, recvFunc, excludeLocations)
+	listener, err := s.Events.AddListenerWithOptions(projectName, allProjects, listenerConnection, types, excludeSources, recvFunc, excludeLocations, events.ListenerOptions{Since: since, Filter: eventFilter})
 	if err != nil {
 		l.Warn("Failed to add event listener", logger.Ctx{"err": err})
 		return nil
@@ -175,11 +219,43 @@ func eventsSocket(s *state.State, r *http.Request, w http.ResponseWriter) error
 //	    name: all-projects
 //	    description: Retrieve instances from all projects
 //	    type: boolean
+//	  - in: query
+//	    name: since
+//	    description: Replay the logged lifecycle and operation events with a greater ID before the live events (the ID must come from the same server)
+//	    type: integer
+//	    example: 8796093022250
+//	  - in: query
+//	    name: action
+//	    description: Lifecycle action(s) to deliver, comma separated
+//	    type: string
+//	    example: instance-started,instance-stopped
+//	  - in: query
+//	    name: entity
+//	    description: Prefix of the URL of the entity the lifecycle and operation events relate to
+//	    type: string
+//	    example: /1.0/instances/c1
+//	  - in: query
+//	    name: location
+//	    description: Cluster member(s) the events originate from, comma separated
+//	    type: string
+//	    example: lxd01
+//	  - in: query
+//	    name: level
+//	    description: Minimum level of the logging events
+//	    type: string
+//	    example: warning
+//	  - in: query
+//	    name: filter
+//	    description: Collection filter matched against the event fields
+//	    type: string
+//	    example: action eq instance-started and project eq prod
 //	responses:
 //	  "200":
 //	    description: Websocket message (JSON)
 //	    schema:
 //	      $ref: "#/definitions/Event"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
 //	  "403":
 //	    $ref: "#/responses/Forbidden"
 //	  "500":
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/pborman/uuid"
@@ -39,6 +40,7 @@ type Server struct {
 	listeners map[string]*Listener
 	notify    NotifyFunc
 	location  string
+	log       *Log
 }
 
 // NewServer returns a new event server.
@@ -64,8 +66,47 @@ func (s *Server) SetLocalLocation(location string) {
 	s.location = location
 }
 
+// SetLog sets the log recording the lifecycle and operation events, from which listeners can replay them.
+func (s *Server) SetLog(log *Log) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.log = log
+}
+
 // AddListener creates and returns a new event listener.
 func (s *Server) AddListener(projectName string, allProjects bool, connection EventListenerConnection, messageTypes []string, excludeSources []EventSource, recvFunc EventHandler, excludeLocations []string) (*Listener, error) {
+	return s.addListener(projectName, allProjects, connection, messageTypes, excludeSources, recvFunc, excludeLocations, ListenerOptions{})
+}
+
+// ListenerOptions represents the optional settings of an event listener.
+type ListenerOptions struct {
+	// Replay the logged events whose ID is greater than this one before the live events.
+	Since *uint64
+
+	// Only deliver the events passing this filter.
+	Filter *Filter
+}
+
+// CheckReplay returns an error if the logged events can't be replayed from the given ID.
+func (s *Server) CheckReplay(since uint64) error {
+	s.lock.Lock()
+	log := s.log
+	s.lock.Unlock()
+
+	if log == nil {
+		return fmt.Errorf("Event log isn't available")
+	}
+
+	return log.Check(since)
+}
+
+// AddListenerWithOptions creates and returns a new event listener with the given options.
+func (s *Server) AddListenerWithOptions(projectName string, allProjects bool, connection EventListenerConnection, messageTypes []string, excludeSources []EventSource, recvFunc EventHandler, excludeLocations []string, options ListenerOptions) (*Listener, error) {
+	return s.addListener(projectName, allProjects, connection, messageTypes, excludeSources, recvFunc, excludeLocations, options)
+}
+
+func (s *Server) addListener(projectName string, allProjects bool, connection EventListenerConnection, messageTypes []string, excludeSources []EventSource, recvFunc EventHandler, excludeLocations []string, options ListenerOptions) (*Listener, error) {
 	if allProjects && projectName != "" {
 		return nil, fmt.Errorf("Cannot specify project name when listening for events on all projects")
 	}
@@ -83,6 +124,7 @@ func (s *Server) AddListener(projectName string, allProjects bool, connection Ev
 		projectName:      projectName,
 		excludeSources:   excludeSources,
 		excludeLocations: excludeLocations,
+		filter:           options.Filter,
 	}
 
 	s.lock.Lock()
@@ -92,6 +134,17 @@ func (s *Server) AddListener(projectName string, allProjects bool, connection Ev
 		return nil, fmt.Errorf("A listener with ID %q already exists", listener.id)
 	}
 
+	if options.Since != nil {
+		if s.log == nil {
+			return nil, fmt.Errorf("Event log isn't available")
+		}
+
+		// The live events are held back until the logged ones have been sent. As event IDs are assigned with
+		// the server lock held, the ones up to the current last ID are exactly those not delivered live.
+		listener.replaying = true
+		go listener.replay(s.log, *options.Since, s.log.LastID())
+	}
+
 	s.listeners[listener.id] = listener
 
 	go listener.start()
@@ -148,16 +201,6 @@ func (s *Server) Inject(event api.Event, eventSource EventSource) {
 }
 
 func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
-	sourceInSlice := func(source EventSource, sources []EventSource) bool {
-		for _, i := range sources {
-			if source == i {
-				return true
-			}
-		}
-
-		return false
-	}
-
 	s.lock.Lock()
 
 	// Set the Location for local events to the local serverName if not already populated (do it here rather
@@ -166,6 +209,14 @@ func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
 		event.Location = s.location
 	}
 
+	// Assign the ID of the event in the event log, it's only written once the lock is released.
+	log := s.log
+	if log != nil && shouldLog(event) {
+		log.Reserve(&event)
+	} else {
+		log = nil
+	}
+
 	// If a notifcation hook is present, then call it for locally produced events.
 	// This can be used to send local events to another target (such as an event-hub member).
 	if s.notify != nil && eventSource == EventSourceLocal {
@@ -174,21 +225,7 @@ func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
 
 	listeners := s.listeners
 	for _, listener := range listeners {
-		// If the event is project specific, check if the listener is requesting events from that project.
-		if event.Project != "" && !listener.allProjects && event.Project != listener.projectName {
-			continue
-		}
-
-		if sourceInSlice(eventSource, listener.excludeSources) {
-			continue
-		}
-
-		if !shared.StringInSlice(event.Type, listener.messageTypes) {
-			continue
-		}
-
-		// If the event doesn't come from this member and has been excluded by listener, don't deliver it.
-		if eventSource != EventSourceLocal && shared.StringInSlice(event.Location, listener.excludeLocations) {
+		if !listener.accepts(event, eventSource) {
 			continue
 		}
 
@@ -207,7 +244,7 @@ func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
 				return
 			}
 
-			err := listener.WriteJSON(event)
+			err := listener.send(event)
 			if err != nil {
 				// Remove the listener from the list
 				s.lock.Lock()
@@ -221,6 +258,14 @@ func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
 
 	s.lock.Unlock()
 
+	// Record the event once the lock is released, and only log a failure then as logging emits an event.
+	if log != nil {
+		err := log.Write(event, eventSource)
+		if err != nil {
+			logger.Warn("Failed recording event in event log", logger.Ctx{"err": err})
+		}
+	}
+
 	return nil
 }
 
@@ -232,4 +277,97 @@ type Listener struct {
 	projectName      string
 	excludeSources   []EventSource
 	excludeLocations []string
+	filter           *Filter
+
+	// Live events held back while the logged ones are being replayed.
+	replaying  bool
+	pending    []api.Event
+	replayLock sync.Mutex
+}
+
+// maxPendingEvents is the maximum number of live events held back during a replay, after which the listener is
+// closed. The client can then resume from the last event it received.
+const maxPendingEvents = 4096
+
+// accepts returns whether the listener requested the event, received from the given source.
+func (l *Listener) accepts(event api.Event, eventSource EventSource) bool {
+	// If the event is project specific, check if the listener is requesting events from that project.
+	if event.Project != "" && !l.allProjects && event.Project != l.projectName {
+		return false
+	}
+
+	for _, source := range l.excludeSources {
+		if source == eventSource {
+			return false
+		}
+	}
+
+	if !shared.StringInSlice(event.Type, l.messageTypes) {
+		return false
+	}
+
+	// If the event doesn't come from this member and has been excluded by listener, don't deliver it.
+	if eventSource != EventSourceLocal && shared.StringInSlice(event.Location, l.excludeLocations) {
+		return false
+	}
+
+	return true
+}
+
+// send writes an event to the listener, or holds it back if the logged events are still being replayed.
+// Events not passing the listener filter are dropped.
+func (l *Listener) send(event api.Event) error {
+	if l.filter != nil && !l.filter.Match(event) {
+		return nil
+	}
+
+	l.replayLock.Lock()
+	defer l.replayLock.Unlock()
+
+	if l.replaying {
+		if len(l.pending) >= maxPendingEvents {
+			return fmt.Errorf("Too many events held back while replaying the event log")
+		}
+
+		l.pending = append(l.pending, event)
+		return nil
+	}
+
+	return l.WriteJSON(event)
+}
+
+// replay writes the logged events whose ID is in the (since, until] range and matching the listener, followed
+// by the live events that were held back in the meantime. The listener is closed if the log can't be read,
+// so that the client doesn't miss any event without noticing.
+func (l *Listener) replay(log *Log, since uint64, until uint64) {
+	err := log.Since(since, until, func(event api.Event, eventSource EventSource) error {
+		if !l.accepts(event, eventSource) {
+			return nil
+		}
+
+		if l.filter != nil && !l.filter.Match(event) {
+			return nil
+		}
+
+		return l.WriteJSON(event)
+	})
+	if err != nil {
+		logger.Warn("Failed replaying event log", logger.Ctx{"listener": l.ID(), "err": err})
+		l.Close()
+		return
+	}
+
+	l.replayLock.Lock()
+	defer l.replayLock.Unlock()
+
+	for _, event := range l.pending {
+		err := l.WriteJSON(event)
+		if err != nil {
+			l.Close()
+			return
+		}
+	}
+
+	l.pending = nil
+	l.replaying = false
 }
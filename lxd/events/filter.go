@@ -0,0 +1,283 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/filter"
+)
+
+// Filter restricts the events delivered to a listener beyond their type and project.
+// The empty fields don't restrict the events.
+type Filter struct {
+	// Lifecycle actions to deliver (only lifecycle events get delivered if set).
+	Actions []string
+
+	// Prefix of the URL of the entity the lifecycle or operation events relate to (logging events don't get
+	// delivered if set).
+	Entity string
+
+	// Cluster members the events originate from.
+	Locations []string
+
+	// Minimum level of the logging events.
+	Level string
+
+	// Filter expression matched against the event fields (see FilterFields).
+	Clauses *filter.ClauseSet
+}
+
+// FilterFields represents the fields of an event available to filter expressions. The fields that don't apply
+// to the type of the event are left empty.
+type FilterFields struct {
+	ID       uint64 `yaml:"id"`
+	Type     string `yaml:"type"`
+	Project  string `yaml:"project"`
+	Location string `yaml:"location"`
+
+	// Lifecycle events.
+	Action    string `yaml:"action"`
+	Source    string `yaml:"source"`
+	Requestor string `yaml:"requestor"`
+
+	// Operation events.
+	Class       string `yaml:"class"`
+	Status      string `yaml:"status"`
+	Description string `yaml:"description"`
+
+	// Logging events.
+	Level   string `yaml:"level"`
+	Message string `yaml:"message"`
+}
+
+// NewFilter returns a Filter after validating its settings. The filter expression uses the API filtering
+// language, matched against the event fields (see FilterFields).
+func NewFilter(actions []string, entity string, locations []string, level string, expression string) (*Filter, error) {
+	f := &Filter{
+		Actions:   actions,
+		Entity:    entity,
+		Locations: locations,
+		Level:     level,
+	}
+
+	if level != "" {
+		_, err := logrus.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid log level %q", level)
+		}
+	}
+
+	if expression != "" {
+		ops := filter.QueryOperatorSet()
+
+		expression, err := expandFilterExpression(expression, ops)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter: %w", err)
+		}
+
+		clauses, err := filter.Parse(expression, ops)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter: %w", err)
+		}
+
+		// Catch unknown fields and invalid values upfront rather than when matching the events.
+		_, err = filter.Match(FilterFields{}, *clauses)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter: %w", err)
+		}
+
+		f.Clauses = clauses
+	}
+
+	return f, nil
+}
+
+// expandFilterExpression rewrites the shorthands accepted in event filter expressions into the API filtering
+// language: "field=value" and "field!=value" become "field eq value" and "field ne value", and the logical
+// operators are made case insensitive.
+func expandFilterExpression(expression string, ops filter.OperatorSet) (string, error) {
+	const (
+		stateField = iota
+		stateOperator
+		stateValue
+		stateLogical
+	)
+
+	parts := strings.Fields(expression)
+	expanded := make([]string, 0, len(parts))
+	state := stateField
+
+	for index := 0; index < len(parts); index++ {
+		part := parts[index]
+
+		switch state {
+		case stateField:
+			if strings.EqualFold(part, ops.Negate) {
+				expanded = append(expanded, part)
+				continue
+			}
+
+			field, value, compact := strings.Cut(part, "=")
+			if !compact || strings.TrimSuffix(field, "!") == "" {
+				expanded = append(expanded, part)
+				state = stateOperator
+				continue
+			}
+
+			if value == "" {
+				return "", fmt.Errorf("clause has no value")
+			}
+
+			if strings.HasSuffix(field, "!") {
+				expanded = append(expanded, strings.TrimSuffix(field, "!"), ops.NotEquals)
+			} else {
+				expanded = append(expanded, field, ops.Equals)
+			}
+
+			// Handle the value glued to the field as if it were a separate part.
+			parts[index] = value
+			index--
+			state = stateValue
+
+		case stateOperator:
+			expanded = append(expanded, part)
+			state = stateValue
+
+		case stateValue:
+			expanded = append(expanded, part)
+			state = stateLogical
+
+			// Values with spaces are quoted, keep their parts untouched.
+			for _, symbol := range ops.Quote {
+				if !strings.HasPrefix(part, symbol) {
+					continue
+				}
+
+				for index+1 < len(parts) {
+					index++
+					expanded = append(expanded, parts[index])
+					if strings.HasSuffix(parts[index], symbol) {
+						break
+					}
+				}
+			}
+
+		case stateLogical:
+			if strings.EqualFold(part, ops.And) {
+				part = ops.And
+			} else if strings.EqualFold(part, ops.Or) {
+				part = ops.Or
+			}
+
+			expanded = append(expanded, part)
+			state = stateField
+		}
+	}
+
+	return strings.Join(expanded, " "), nil
+}
+
+// Match returns whether the event passes the filter.
+func (f *Filter) Match(event api.Event) bool {
+	if len(f.Locations) > 0 && !shared.StringInSlice(event.Location, f.Locations) {
+		return false
+	}
+
+	fields, entities := filterFieldsOf(event)
+
+	if len(f.Actions) > 0 && (event.Type != api.EventTypeLifecycle || !shared.StringInSlice(fields.Action, f.Actions)) {
+		return false
+	}
+
+	if f.Entity != "" {
+		found := false
+		for _, entity := range entities {
+			if strings.HasPrefix(entity, f.Entity) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.Level != "" && event.Type == api.EventTypeLogging {
+		minLevel, _ := logrus.ParseLevel(f.Level)
+		level, err := logrus.ParseLevel(fields.Level)
+		if err == nil && level > minLevel {
+			return false
+		}
+	}
+
+	if f.Clauses != nil {
+		match, err := filter.Match(fields, *f.Clauses)
+		if err != nil || !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterFieldsOf returns the filter fields of an event along with the URLs of the entities it relates to.
+func filterFieldsOf(event api.Event) (FilterFields, []string) {
+	fields := FilterFields{
+		ID:       event.ID,
+		Type:     event.Type,
+		Project:  event.Project,
+		Location: event.Location,
+	}
+
+	var entities []string
+
+	switch event.Type {
+	case api.EventTypeLifecycle:
+		lifecycle := api.EventLifecycle{}
+		err := json.Unmarshal(event.Metadata, &lifecycle)
+		if err != nil {
+			break
+		}
+
+		fields.Action = lifecycle.Action
+		fields.Source = lifecycle.Source
+		if lifecycle.Requestor != nil {
+			fields.Requestor = lifecycle.Requestor.Username
+		}
+
+		entities = append(entities, lifecycle.Source)
+
+	case api.EventTypeOperation:
+		op := api.Operation{}
+		err := json.Unmarshal(event.Metadata, &op)
+		if err != nil {
+			break
+		}
+
+		fields.Class = op.Class
+		fields.Status = op.Status
+		fields.Description = op.Description
+
+		for _, urls := range op.Resources {
+			entities = append(entities, urls...)
+		}
+
+	case api.EventTypeLogging:
+		logging := api.EventLogging{}
+		err := json.Unmarshal(event.Metadata, &logging)
+		if err != nil {
+			break
+		}
+
+		fields.Level = logging.Level
+		fields.Message = logging.Message
+	}
+
+	return fields, entities
+}
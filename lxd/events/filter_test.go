@@ -0,0 +1,100 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/filter"
+)
+
+func newTestEvent(t *testing.T, eventType string, projectName string, location string, metadata any) api.Event {
+	encoded, err := json.Marshal(metadata)
+	require.NoError(t, err)
+
+	return api.Event{Type: eventType, Project: projectName, Location: location, Metadata: encoded}
+}
+
+func TestNewFilter_Error(t *testing.T) {
+	cases := map[string]string{
+		"foo=bar":        `Invalid filter: Invalid type "invalid" for field "foo"`,
+		"id=abc":         `Invalid filter: Failed to parse value: strconv.ParseUint: parsing "abc": invalid syntax`,
+		"action eq":      "Invalid filter: clause has no value",
+		"action=a XOR b": "Invalid filter: invalid clause composition",
+	}
+
+	for expression, message := range cases {
+		t.Run(expression, func(t *testing.T) {
+			f, err := NewFilter(nil, "", nil, "", expression)
+			assert.Nil(t, f)
+			assert.EqualError(t, err, message)
+		})
+	}
+
+	_, err := NewFilter(nil, "", nil, "loud", "")
+	assert.EqualError(t, err, `Invalid log level "loud"`)
+}
+
+func TestFilter_Match(t *testing.T) {
+	started := newTestEvent(t, api.EventTypeLifecycle, "prod", "lxd01", api.EventLifecycle{Action: "instance-started", Source: "/1.0/instances/c1?project=prod"})
+	stopped := newTestEvent(t, api.EventTypeLifecycle, "default", "lxd02", api.EventLifecycle{Action: "instance-stopped", Source: "/1.0/instances/c2"})
+	operation := newTestEvent(t, api.EventTypeOperation, "default", "lxd01", api.Operation{Class: "task", Status: "Running", Resources: map[string][]string{"instances": {"/1.0/instances/c2"}}})
+	debug := newTestEvent(t, api.EventTypeLogging, "", "lxd01", api.EventLogging{Level: "debug", Message: "Hello"})
+	warning := newTestEvent(t, api.EventTypeLogging, "", "lxd01", api.EventLogging{Level: "warning", Message: "Oops"})
+
+	events := []api.Event{started, stopped, operation, debug, warning}
+
+	cases := []struct {
+		name       string
+		actions    []string
+		entity     string
+		locations  []string
+		level      string
+		expression string
+		matches    []bool
+	}{
+		{name: "none", matches: []bool{true, true, true, true, true}},
+		{name: "actions", actions: []string{"instance-started"}, matches: []bool{true, false, false, false, false}},
+		{name: "entity", entity: "/1.0/instances/c2", matches: []bool{false, true, true, false, false}},
+		{name: "locations", locations: []string{"lxd02"}, matches: []bool{false, true, false, false, false}},
+		{name: "level", level: "info", matches: []bool{true, true, true, false, true}},
+		{name: "expression", expression: "action=instance-started AND project=prod", matches: []bool{true, false, false, false, false}},
+		{name: "expression regexp", expression: "type eq lifecycle or status=running", matches: []bool{true, true, true, false, false}},
+		{name: "expression negate", expression: "not type=logging and location!=lxd02", matches: []bool{true, false, true, false, false}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := NewFilter(c.actions, c.entity, c.locations, c.level, c.expression)
+			require.NoError(t, err)
+
+			for i, event := range events {
+				assert.Equal(t, c.matches[i], f.Match(event), "event %d", i)
+			}
+		})
+	}
+}
+
+func TestExpandFilterExpression(t *testing.T) {
+	cases := map[string]string{
+		"action=instance-started AND project=prod": "action eq instance-started and project eq prod",
+		"not type=logging Or location!=lxd02":      "not type eq logging or location ne lxd02",
+		`message!="a=b and c" and level eq info`:   `message ne "a=b and c" and level eq info`,
+		"message eq a=b":                           "message eq a=b",
+		"type eq lifecycle":                        "type eq lifecycle",
+	}
+
+	for expression, expected := range cases {
+		t.Run(expression, func(t *testing.T) {
+			expanded, err := expandFilterExpression(expression, filter.QueryOperatorSet())
+			require.NoError(t, err)
+			assert.Equal(t, expected, expanded)
+		})
+	}
+
+	_, err := expandFilterExpression("action=", filter.QueryOperatorSet())
+	assert.EqualError(t, err, "clause has no value")
+}
@@ -17,15 +17,31 @@ type InternalListener struct {
 	ctx            context.Context
 	listenerCtx    context.Context
 	listenerCancel context.CancelFunc
+	messageTypes   []string
+	excludeSources []EventSource
 	lock           sync.Mutex
 }
 
 // NewInternalListener returns an InternalListener.
 func NewInternalListener(ctx context.Context, server *Server) *InternalListener {
 	return &InternalListener{
-		ctx:      ctx,
-		handlers: map[string]EventHandler{},
-		server:   server,
+		ctx:            ctx,
+		handlers:       map[string]EventHandler{},
+		server:         server,
+		messageTypes:   []string{api.EventTypeLifecycle, api.EventTypeLogging},
+		excludeSources: []EventSource{EventSourcePull},
+	}
+}
+
+// NewLocalInternalListener returns an InternalListener only receiving the events of the given types that were
+// generated by this member.
+func NewLocalInternalListener(ctx context.Context, server *Server, messageTypes []string) *InternalListener {
+	return &InternalListener{
+		ctx:            ctx,
+		handlers:       map[string]EventHandler{},
+		server:         server,
+		messageTypes:   messageTypes,
+		excludeSources: []EventSource{EventSourcePull, EventSourcePush},
 	}
 }
 
@@ -38,16 +54,15 @@ func (l *InternalListener) startListener() {
 	aEnd, bEnd := memorypipe.NewPipePair(l.listenerCtx)
 	listenerConnection := NewSimpleListenerConnection(aEnd)
 
-	l.listener, err = l.server.AddListener("", true, listenerConnection, []string{"lifecycle", "logging"}, []EventSource{EventSourcePull}, nil, nil)
+	l.listener, err = l.server.AddListener("", true, listenerConnection, l.messageTypes, l.excludeSources, nil, nil)
 	if err != nil {
 		return
 	}
 
-	go func(ctx context.Context) {
-		l.listener.Wait(ctx)
-		l.listener.Close()
-		l.listener = nil
-	}(l.listenerCtx)
+	go func(ctx context.Context, listener *Listener) {
+		listener.Wait(ctx)
+		listener.Close()
+	}(l.listenerCtx, l.listener)
 
 	go func(ctx context.Context, handlers map[string]EventHandler) {
 		for {
@@ -76,6 +91,9 @@ func (l *InternalListener) stopListener() {
 	if l.listenerCancel != nil {
 		l.listenerCancel()
 	}
+
+	// Forget about the listener right away so that it gets recreated if a handler is added again.
+	l.listener = nil
 }
 
 // AddHandler adds a new event handler.
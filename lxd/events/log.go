@@ -0,0 +1,358 @@
+package events
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// LogTypes lists the types of events recorded in the event log.
+var LogTypes = []string{api.EventTypeLifecycle, api.EventTypeOperation}
+
+// logSequenceBits is the number of low bits of the event IDs holding their sequence number, the higher ones hold
+// the identity of the log. IDs stay below 2^53 so that they can be represented exactly in JSON.
+const logSequenceBits = 37
+
+// logMaxIdentity is the maximum identity of a log.
+const logMaxIdentity = 1<<(53-logSequenceBits) - 1
+
+// Log records events to a local log file, assigning them monotonically increasing IDs. Once the file reaches
+// a maximum size it's rotated, keeping a bounded number of previous files.
+//
+// The IDs are assigned by Reserve, which is cheap enough to be called with the event server lock held, while
+// Write records the events in ID order, waiting for the events with a lower ID to be written first.
+//
+// Each log assigns its own IDs to the events it records, including those received from other cluster members.
+// The IDs are prefixed with a random identity picked when the log is created, so that Check can reject the IDs
+// assigned by the log of another member.
+type Log struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	identity uint64
+
+	// Last assigned ID, accessed atomically.
+	lastID uint64
+
+	file    *os.File
+	size    int64
+	written uint64
+	lock    sync.Mutex
+	cond    *sync.Cond
+}
+
+// logEntry represents an event recorded in the log, along with where it was received from.
+type logEntry struct {
+	api.Event
+
+	Source EventSource `json:"source"`
+}
+
+// OpenLog returns a Log writing to the given path, resuming from the last ID found in the existing files or
+// picking a new identity if there's none. Once the file exceeds maxSize bytes it's rotated, keeping at most
+// maxFiles previous files (suffixed .1 for the most recent one).
+func OpenLog(path string, maxSize int64, maxFiles int) (*Log, error) {
+	l := &Log{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	l.cond = sync.NewCond(&l.lock)
+
+	// Find the last ID, looking at the previous files if the current one is empty.
+	for _, path := range l.paths() {
+		file, err := openLogFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if file == nil {
+			continue
+		}
+
+		err = readLogFile(file, 0, ^uint64(0), func(entry logEntry) error {
+			l.lastID = entry.ID
+			return nil
+		})
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l.identity = l.lastID >> logSequenceBits
+	if l.identity == 0 {
+		identity, err := rand.Int(rand.Reader, big.NewInt(logMaxIdentity))
+		if err != nil {
+			return nil, fmt.Errorf("Failed generating event log identity: %w", err)
+		}
+
+		l.identity = identity.Uint64() + 1
+		l.lastID = l.identity << logSequenceBits
+	}
+
+	l.written = l.lastID
+
+	return l, nil
+}
+
+// paths returns the paths of the log files, from the oldest to the most recent one.
+func (l *Log) paths() []string {
+	paths := make([]string, 0, l.maxFiles+1)
+	for i := l.maxFiles; i > 0; i-- {
+		paths = append(paths, fmt.Sprintf("%s.%d", l.path, i))
+	}
+
+	return append(paths, l.path)
+}
+
+// openLogFile opens a log file for reading, returning nil if it doesn't exist.
+func openLogFile(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("Failed opening event log %q: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// readLogFile calls fn for the entries of a log file whose ID is greater than since and lower or equal to until.
+func readLogFile(file *os.File, since uint64, until uint64, fn func(entry logEntry) error) error {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		entry := logEntry{}
+
+		// Skip any partially written entry.
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			continue
+		}
+
+		// The entries are written in ID order.
+		if entry.ID > until {
+			return nil
+		}
+
+		if entry.ID <= since {
+			continue
+		}
+
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("Failed reading event log %q: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+// Reserve assigns the next ID to the event, which must then be recorded with Write.
+func (l *Log) Reserve(event *api.Event) {
+	event.ID = atomic.AddUint64(&l.lastID, 1)
+}
+
+// Write records an event whose ID was assigned by Reserve, received from the given source. It waits for the
+// events with a lower ID to be written first.
+func (l *Log) Write(event api.Event, source EventSource) error {
+	line, err := json.Marshal(logEntry{Event: event, Source: source})
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for l.written+1 < event.ID {
+		l.cond.Wait()
+	}
+
+	// Let the following events be written whatever the outcome.
+	defer func() {
+		l.written = event.ID
+		l.cond.Broadcast()
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	if l.file != nil && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		err := l.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.file == nil {
+		err := l.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("Failed writing event log %q: %w", l.path, err)
+	}
+
+	return nil
+}
+
+// Append assigns the next ID to a local event and records it.
+func (l *Log) Append(event *api.Event) error {
+	l.Reserve(event)
+
+	return l.Write(*event, EventSourceLocal)
+}
+
+// LastID returns the ID of the last reserved event.
+func (l *Log) LastID() uint64 {
+	return atomic.LoadUint64(&l.lastID)
+}
+
+// Check returns an error if the given ID wasn't assigned by this log, such as an ID received from another cluster
+// member. Zero is accepted to replay all the recorded events.
+func (l *Log) Check(id uint64) error {
+	if id == 0 {
+		return nil
+	}
+
+	if id>>logSequenceBits != l.identity || id > l.LastID() {
+		return api.StatusErrorf(http.StatusBadRequest, "Event ID %d wasn't assigned by this server", id)
+	}
+
+	return nil
+}
+
+// Since calls fn for the recorded events whose ID is greater than since and lower or equal to until, from the
+// oldest to the most recent one, along with the source they were received from. It first waits for the events
+// up to until to be written. The events that were rotated out of the log aren't returned.
+//
+// The log files are opened with the lock held, so that a rotation doesn't affect the replay, but are read
+// without it so that recording new events isn't delayed.
+func (l *Log) Since(since uint64, until uint64, fn func(event api.Event, source EventSource) error) error {
+	files := []*os.File{}
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	l.lock.Lock()
+
+	for l.written < until {
+		l.cond.Wait()
+	}
+
+	for _, path := range l.paths() {
+		file, err := openLogFile(path)
+		if err != nil {
+			l.lock.Unlock()
+			return err
+		}
+
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+
+	l.lock.Unlock()
+
+	for _, file := range files {
+		err := readLogFile(file, since, until, func(entry logEntry) error {
+			return fn(entry.Event, entry.Source)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed opening event log %q: %w", l.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("Failed getting event log %q size: %w", l.path, err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the current log file and shifts the previous ones, dropping the oldest.
+func (l *Log) rotate() error {
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("Failed closing event log %q: %w", l.path, err)
+	}
+
+	if l.maxFiles < 1 {
+		return os.Remove(l.path)
+	}
+
+	err = os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed removing old event log: %w", err)
+	}
+
+	for i := l.maxFiles - 1; i > 0; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed rotating event log: %w", err)
+		}
+	}
+
+	return os.Rename(l.path, l.path+".1")
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
+
+// shouldLog returns whether an event gets recorded in the event log.
+func shouldLog(event api.Event) bool {
+	return shared.StringInSlice(event.Type, LogTypes)
+}
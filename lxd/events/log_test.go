@@ -0,0 +1,204 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	event := func() *api.Event {
+		return &api.Event{Type: api.EventTypeLifecycle, Metadata: json.RawMessage(`{"action":"instance-created"}`)}
+	}
+
+	l, err := OpenLog(path, 1024*1024, 1)
+	require.NoError(t, err)
+
+	// The IDs are prefixed with the identity of the log.
+	base := l.LastID()
+	assert.NotZero(t, base>>logSequenceBits)
+	assert.Zero(t, base&(1<<logSequenceBits-1))
+
+	line, err := json.Marshal(logEntry{Event: api.Event{ID: base + 1, Type: api.EventTypeLifecycle, Metadata: event().Metadata}})
+	require.NoError(t, err)
+
+	// Rotate every two events, keeping a single previous file.
+	l.maxSize = int64(2 * (len(line) + 1))
+
+	for i := 0; i < 5; i++ {
+		e := event()
+		require.NoError(t, l.Append(e))
+		assert.Equal(t, base+uint64(i+1), e.ID)
+	}
+
+	assert.Equal(t, base+5, l.LastID())
+
+	since := func(since uint64, until uint64) []uint64 {
+		ids := []uint64{}
+		err := l.Since(since, until, func(event api.Event, source EventSource) error {
+			assert.Equal(t, EventSource(EventSourceLocal), source)
+			ids = append(ids, event.ID-base)
+			return nil
+		})
+		require.NoError(t, err)
+
+		return ids
+	}
+
+	// The first two events were rotated out.
+	assert.Equal(t, []uint64{3, 4, 5}, since(0, base+5))
+	assert.Equal(t, []uint64{4}, since(base+3, base+4))
+
+	// Only the IDs assigned by the log can be replayed from.
+	assert.NoError(t, l.Check(0))
+	assert.NoError(t, l.Check(base+5))
+	assert.Error(t, l.Check(base+6))
+	assert.Error(t, l.Check(3))
+	assert.Error(t, l.Check(base+3+(1<<logSequenceBits)))
+
+	require.NoError(t, l.Close())
+
+	// IDs keep increasing once reopened.
+	l, err = OpenLog(path, int64(2*(len(line)+1)), 1)
+	require.NoError(t, err)
+	assert.Equal(t, base+5, l.LastID())
+
+	e := event()
+	require.NoError(t, l.Append(e))
+	assert.Equal(t, base+6, e.ID)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_WriteOrder(t *testing.T) {
+	l, err := OpenLog(filepath.Join(t.TempDir(), "events.log"), 1024*1024, 1)
+	require.NoError(t, err)
+
+	defer func() { _ = l.Close() }()
+
+	first := api.Event{Type: api.EventTypeLifecycle}
+	second := api.Event{Type: api.EventTypeLifecycle}
+	l.Reserve(&first)
+	l.Reserve(&second)
+
+	// The second event waits for the first one to be written.
+	done := make(chan error)
+	go func() { done <- l.Write(second, EventSourcePull) }()
+
+	select {
+	case <-done:
+		t.Fatal("Event written before the previous one")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, l.Write(first, EventSourceLocal))
+	require.NoError(t, <-done)
+
+	sources := []EventSource{}
+	err = l.Since(0, l.LastID(), func(event api.Event, source EventSource) error {
+		sources = append(sources, source)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []EventSource{EventSourceLocal, EventSourcePull}, sources)
+}
+
+// testListenerConnection records the events written to it.
+type testListenerConnection struct {
+	events []api.Event
+	lock   sync.Mutex
+}
+
+func (c *testListenerConnection) Reader(ctx context.Context, recvFunc EventHandler) {
+	<-ctx.Done()
+}
+
+func (c *testListenerConnection) WriteJSON(event any) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.events = append(c.events, event.(api.Event))
+
+	return nil
+}
+
+func (c *testListenerConnection) Close() error {
+	return nil
+}
+
+func (c *testListenerConnection) LocalAddr() net.Addr {
+	return nil
+}
+
+func (c *testListenerConnection) RemoteAddr() net.Addr {
+	return nil
+}
+
+// ids returns the IDs of the recorded events, relative to the given base.
+func (c *testListenerConnection) ids(base uint64) []uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ids := []uint64{}
+	for _, event := range c.events {
+		ids = append(ids, event.ID-base)
+	}
+
+	return ids
+}
+
+func TestServerReplay(t *testing.T) {
+	l, err := OpenLog(filepath.Join(t.TempDir(), "events.log"), 1024*1024, 1)
+	require.NoError(t, err)
+
+	defer func() { _ = l.Close() }()
+
+	s := NewServer(false, false, nil)
+	s.SetLog(l)
+
+	base := l.LastID()
+
+	for _, projectName := range []string{"default", "foo", "default"} {
+		s.SendLifecycle(projectName, api.EventLifecycle{Action: "instance-created"})
+	}
+
+	conn := &testListenerConnection{}
+	since := base + 1
+	require.NoError(t, s.CheckReplay(since))
+
+	listener, err := s.AddListenerWithOptions("default", false, conn, []string{api.EventTypeLifecycle}, nil, nil, nil, ListenerOptions{Since: &since})
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	s.SendLifecycle("default", api.EventLifecycle{Action: "instance-deleted"})
+
+	// The logged events of the project come first, followed by the live ones.
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]uint64{3, 4}, conn.ids(base))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The replay excludes the same sources as the live events.
+	s.Inject(api.Event{Type: api.EventTypeLifecycle, Project: "default", Location: "lxd02", Metadata: json.RawMessage(`{}`)}, EventSourcePull)
+
+	conn = &testListenerConnection{}
+	since = uint64(0)
+	listener, err = s.AddListenerWithOptions("default", false, conn, []string{api.EventTypeLifecycle}, []EventSource{EventSourcePull}, nil, nil, ListenerOptions{Since: &since})
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]uint64{1, 3, 4}, conn.ids(base))
+	}, 5*time.Second, 10*time.Millisecond)
+}
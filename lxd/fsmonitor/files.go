@@ -0,0 +1,58 @@
+package fsmonitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// WatchFiles calls f for every file created, modified or deleted below path until ctx is cancelled.
+// The path is resolved inside the root filesystem at rootfsPath and the reported paths are relative to it.
+// All the watches share a single inotify instance. The number of directories watched is limited per watch and
+// per instance (identified by instanceKey), and watches not keeping up with the changes are stopped.
+func WatchFiles(ctx context.Context, instanceKey string, rootfsPath string, path string, f func(event api.InstanceFileEvent)) error {
+	watchPath, err := util.ResolveRootfsPath(rootfsPath, path)
+	if err != nil {
+		return err
+	}
+
+	c := &treeClient{
+		key:     instanceKey,
+		path:    filepath.Clean(watchPath),
+		watches: map[string]struct{}{},
+		events:  make(chan treeEvent, treeClientQueueSize),
+		done:    make(chan struct{}),
+	}
+
+	err = treeAddClient(c)
+	if err != nil {
+		return err
+	}
+
+	defer treeRemoveClient(c)
+
+	rootfsPath = filepath.Clean(rootfsPath)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.done:
+			return c.err
+		case event := <-c.events:
+			relPath := event.path
+			if rootfsPath != "/" {
+				relPath = strings.TrimPrefix(event.path, rootfsPath)
+			}
+
+			f(api.InstanceFileEvent{
+				Path:      filepath.Join("/", relPath),
+				Action:    event.action,
+				Timestamp: event.timestamp,
+			})
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package fsmonitor
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	in "k8s.io/utils/inotify"
+
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// treeWatchMask is the set of inotify events watched on every directory of a watched tree.
+const treeWatchMask = in.InCreate | in.InDelete | in.InCloseWrite | in.InMovedFrom | in.InMovedTo | in.InDontFollow | in.InOnlydir
+
+// treeMaxWatchesPerClient is the maximum number of directories a single tree watch can monitor.
+var treeMaxWatchesPerClient = 8192
+
+// treeMaxWatchesPerInstance is the maximum number of directories monitored by all the tree watches of an instance.
+var treeMaxWatchesPerInstance = 16384
+
+// treeClientQueueSize is the number of events queued for a tree watch before it's considered too slow.
+var treeClientQueueSize = 1024
+
+// ErrTooManyWatches is returned when a tree watch would exceed the number of directories it can monitor.
+var ErrTooManyWatches = errors.New("Too many directories to watch")
+
+// ErrWatchOverflow is returned when a tree watch doesn't consume its events fast enough.
+var ErrWatchOverflow = errors.New("Too many pending events")
+
+// treeEvent is a change reported to a tree watch.
+type treeEvent struct {
+	path      string
+	action    string
+	timestamp time.Time
+}
+
+// treeClient is a single tree watch.
+type treeClient struct {
+	key     string // Identifies the instance the watch is for.
+	path    string
+	watches map[string]struct{}
+	events  chan treeEvent
+	done    chan struct{}
+	err     error
+}
+
+// treeMonitor multiplexes all the tree watches on a single inotify instance.
+// Directories are watched once and reference counted across the tree watches covering them.
+type treeMonitor struct {
+	watcher         *in.Watcher
+	refs            map[string]int
+	clients         map[*treeClient]struct{}
+	instanceWatches map[string]int
+}
+
+// treeMu protects tree and all of its content.
+var treeMu sync.Mutex
+var tree *treeMonitor
+
+// covers returns whether path is the watched directory of the client or below it.
+func (c *treeClient) covers(path string) bool {
+	return c.path == "/" || path == c.path || strings.HasPrefix(path, c.path+"/")
+}
+
+// drop stops the client with err. The caller must hold treeMu.
+func (c *treeClient) drop(err error) {
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	close(c.done)
+}
+
+// treeAddClient registers the client and watches its directory tree, starting the shared inotify instance if
+// needed.
+func treeAddClient(c *treeClient) error {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+
+	if tree == nil {
+		watcher, err := in.NewWatcher()
+		if err != nil {
+			return err
+		}
+
+		tree = &treeMonitor{
+			watcher:         watcher,
+			refs:            map[string]int{},
+			clients:         map[*treeClient]struct{}{},
+			instanceWatches: map[string]int{},
+		}
+
+		go tree.run()
+	}
+
+	tree.clients[c] = struct{}{}
+
+	err := tree.addWatches(c, c.path)
+	if err != nil {
+		tree.removeClient(c)
+		return err
+	}
+
+	return nil
+}
+
+// treeRemoveClient unregisters the client and removes the watches only it was using.
+func treeRemoveClient(c *treeClient) {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+
+	if tree != nil {
+		tree.removeClient(c)
+	}
+}
+
+// removeClient unregisters the client. The caller must hold treeMu.
+func (t *treeMonitor) removeClient(c *treeClient) {
+	for path := range c.watches {
+		t.removeWatch(c, path)
+	}
+
+	delete(t.clients, c)
+}
+
+// addWatches watches the directory tree at root on behalf of the client. The caller must hold treeMu.
+func (t *treeMonitor) addWatches(c *treeClient, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The tree may be changing while it's walked, only the root must be there.
+			if path == root {
+				return err
+			}
+
+			return nil
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		_, ok := c.watches[path]
+		if ok {
+			return nil
+		}
+
+		if len(c.watches) >= treeMaxWatchesPerClient || t.instanceWatches[c.key] >= treeMaxWatchesPerInstance {
+			return ErrTooManyWatches
+		}
+
+		if t.refs[path] == 0 {
+			err := t.watcher.AddWatch(path, treeWatchMask)
+			if err != nil {
+				logger.Debug("Failed to watch path", logger.Ctx{"path": path, "err": err})
+				return nil
+			}
+		}
+
+		t.refs[path]++
+		t.instanceWatches[c.key]++
+		c.watches[path] = struct{}{}
+
+		return nil
+	})
+}
+
+// removeWatch drops the watch on path held by the client. The caller must hold treeMu.
+func (t *treeMonitor) removeWatch(c *treeClient, path string) {
+	delete(c.watches, path)
+
+	t.instanceWatches[c.key]--
+	if t.instanceWatches[c.key] <= 0 {
+		delete(t.instanceWatches, c.key)
+	}
+
+	t.refs[path]--
+	if t.refs[path] <= 0 {
+		delete(t.refs, path)
+		_ = t.watcher.RemoveWatch(path)
+	}
+}
+
+// run dispatches the inotify events to the tree watches.
+func (t *treeMonitor) run() {
+	go func() {
+		for err := range t.watcher.Error {
+			logger.Warn("Received file watch error", logger.Ctx{"err": err})
+		}
+	}()
+
+	for event := range t.watcher.Event {
+		t.handle(event)
+	}
+}
+
+// handle queues an inotify event for the tree watches covering it.
+func (t *treeMonitor) handle(event *in.Event) {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+
+	path := filepath.Clean(event.Name)
+
+	// The kernel removed the watch as the directory is gone.
+	if event.Mask&in.InIgnored != 0 {
+		_, ok := t.refs[path]
+		if !ok {
+			return
+		}
+
+		// Dropping the last reference also cleans up the watcher's state in case the path gets watched again.
+		for c := range t.clients {
+			_, ok := c.watches[path]
+			if ok {
+				t.removeWatch(c, path)
+			}
+		}
+
+		return
+	}
+
+	var action string
+	if event.Mask&(in.InCreate|in.InMovedTo) != 0 {
+		action = "create"
+	} else if event.Mask&(in.InDelete|in.InMovedFrom) != 0 {
+		action = "delete"
+	} else if event.Mask&in.InCloseWrite != 0 {
+		action = "modify"
+	} else {
+		return
+	}
+
+	timestamp := time.Now().UTC()
+
+	for c := range t.clients {
+		if c.err != nil || !c.covers(path) {
+			continue
+		}
+
+		// Watch new directories.
+		if action == "create" && event.Mask&in.InIsdir != 0 {
+			err := t.addWatches(c, path)
+			if err != nil {
+				c.drop(err)
+				continue
+			}
+		}
+
+		// Queue the event so that slow clients don't hold the lock.
+		select {
+		case c.events <- treeEvent{path: path, action: action, timestamp: timestamp}:
+		default:
+			c.drop(ErrWatchOverflow)
+		}
+	}
+}
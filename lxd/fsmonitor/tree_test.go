@@ -0,0 +1,88 @@
+package fsmonitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// watchFiles runs WatchFiles in the background and returns the channels its events and result are sent to.
+func watchFiles(ctx context.Context, t *testing.T, key string, rootfs string, path string) (chan api.InstanceFileEvent, chan error) {
+	events := make(chan api.InstanceFileEvent, 100)
+	result := make(chan error, 1)
+
+	go func() {
+		result <- WatchFiles(ctx, key, rootfs, path, func(event api.InstanceFileEvent) { events <- event })
+	}()
+
+	// Wait for the watch to be set up.
+	require.Eventually(t, func() bool {
+		treeMu.Lock()
+		defer treeMu.Unlock()
+
+		return tree != nil && tree.instanceWatches[key] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return events, result
+}
+
+func TestWatchFiles(t *testing.T) {
+	rootfs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "srv"), 0755))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, result := watchFiles(ctx, t, "test-events", rootfs, "/srv")
+
+	// Files in new directories are reported too.
+	require.NoError(t, os.Mkdir(filepath.Join(rootfs, "srv", "dir"), 0755))
+
+	event := <-events
+	assert.Equal(t, "/srv/dir", event.Path)
+	assert.Equal(t, "create", event.Action)
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "srv", "dir", "file"), []byte("foo"), 0644))
+
+	event = <-events
+	assert.Equal(t, "/srv/dir/file", event.Path)
+	assert.Equal(t, "create", event.Action)
+
+	event = <-events
+	assert.Equal(t, "/srv/dir/file", event.Path)
+	assert.Equal(t, "modify", event.Action)
+
+	cancel()
+	require.NoError(t, <-result)
+
+	// All the watches are gone with the client.
+	treeMu.Lock()
+	assert.Empty(t, tree.refs)
+	assert.Empty(t, tree.instanceWatches)
+	treeMu.Unlock()
+}
+
+func TestWatchFilesLimits(t *testing.T) {
+	rootfs := t.TempDir()
+	for _, dir := range []string{"a", "b", "c"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "srv", dir), 0755))
+	}
+
+	maxWatches := treeMaxWatchesPerClient
+	treeMaxWatchesPerClient = 4
+	defer func() { treeMaxWatchesPerClient = maxWatches }()
+
+	// The tree fits, but growing it beyond the limit stops the watch.
+	_, result := watchFiles(context.Background(), t, "test-limits", rootfs, "/srv")
+	require.NoError(t, os.Mkdir(filepath.Join(rootfs, "srv", "d"), 0755))
+	assert.ErrorIs(t, <-result, ErrTooManyWatches)
+
+	// Watching a tree which is already too large fails.
+	err := WatchFiles(context.Background(), "test-limits", rootfs, "/", func(event api.InstanceFileEvent) {})
+	assert.ErrorIs(t, err, ErrTooManyWatches)
+}
@@ -496,6 +496,16 @@ func pruneExpiredInstanceSnapshots(ctx context.Context, s *state.State, snapshot
 			return err
 		}
 
+		// Keep the snapshot the instance is restored to on stop.
+		isBaseline, err := snapshotIsRestoreOnStopBaseline(s, snapshot)
+		if err != nil {
+			return fmt.Errorf("Failed checking expired instance snapshot %q in project %q: %w", snapshot.Name(), snapshot.Project().Name, err)
+		}
+
+		if isBaseline {
+			continue
+		}
+
 		_, loaded := instSnapshotsPruneRunning.LoadOrStore(snapshot.ID(), struct{}{})
 		if loaded {
 			continue // Deletion of this snapshot is already running, skip.
@@ -29,9 +29,12 @@ import (
 	"github.com/canonical/lxd/lxd/maas"
 	"github.com/canonical/lxd/lxd/operations"
 	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/request"
 	"github.com/canonical/lxd/lxd/revert"
 	"github.com/canonical/lxd/lxd/state"
 	storagePools "github.com/canonical/lxd/lxd/storage"
+	storageDrivers "github.com/canonical/lxd/lxd/storage/drivers"
+	"github.com/canonical/lxd/lxd/tracing"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/logger"
@@ -56,8 +59,9 @@ type deviceManager interface {
 
 // common provides structure common to all instance types.
 type common struct {
-	op    *operations.Operation
-	state *state.State
+	op        *operations.Operation
+	requestor *api.EventLifecycleRequestor
+	state     *state.State
 
 	architecture    int
 	creationDate    time.Time
@@ -248,6 +252,31 @@ func (d *common) SetOperation(op *operations.Operation) {
 	d.op = op
 }
 
+// SetRequestor sets the requestor of the changes made outside of an operation from an http.Request.
+func (d *common) SetRequestor(r *http.Request) {
+	d.requestor = request.CreateRequestor(r)
+}
+
+// startSpan starts a span for an action on the instance, as part of the trace of its current operation.
+func (d *common) startSpan(action string, attributes map[string]any) *tracing.Span {
+	// Only trace the actions performed by operations, rather than those of the background tasks.
+	if d.op == nil || !tracing.Enabled() {
+		return nil
+	}
+
+	spanAttributes := map[string]any{
+		"lxd.project":       d.project.Name,
+		"lxd.instance":      d.name,
+		"lxd.instance.type": d.dbType.String(),
+	}
+
+	for key, value := range attributes {
+		spanAttributes[key] = value
+	}
+
+	return d.op.StartSpan("instance."+action, spanAttributes)
+}
+
 // Snapshots returns a list of snapshots.
 func (d *common) Snapshots() ([]instance.Instance, error) {
 	if d.isSnapshot {
@@ -683,6 +712,18 @@ func (d *common) snapshotCommon(inst instance.Instance, name string, expiry time
 	revert := revert.New()
 	defer revert.Fail()
 
+	// Restoring the baseline on stop would delete the new snapshot if the storage can't keep it.
+	if d.expandedConfig["snapshots.restore_on_stop"] != "" {
+		keepsSnapshots, err := d.restoreOnStopKeepsSnapshots()
+		if err != nil {
+			return err
+		}
+
+		if !keepsSnapshots {
+			return fmt.Errorf("Snapshots can't be created while snapshots.restore_on_stop is set as the storage pool can only restore the most recent snapshot")
+		}
+	}
+
 	// Setup the arguments.
 	args := db.InstanceArgs{
 		Project:      inst.Project().Name,
@@ -1052,6 +1093,107 @@ func (d *common) onStopOperationSetup(target string) (*operationlock.InstanceOpe
 	return op, nil
 }
 
+// restoreOnStopSnapshot loads the snapshot configured in snapshots.restore_on_stop.
+func (d *common) restoreOnStopSnapshot() (instance.Instance, error) {
+	snapName := d.expandedConfig["snapshots.restore_on_stop"]
+
+	snap, err := instance.LoadByProjectAndName(d.state, d.project.Name, d.name+shared.SnapshotDelimiter+snapName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading snapshot %q for restore on stop: %w", snapName, err)
+	}
+
+	return snap, nil
+}
+
+// restoreOnStopKeepsSnapshots returns whether the instance's storage pool can restore a snapshot while keeping
+// the more recent ones. ZFS can only roll back to the most recent snapshot.
+func (d *common) restoreOnStopKeepsSnapshots() (bool, error) {
+	pool, err := d.getStoragePool()
+	if err != nil {
+		return false, err
+	}
+
+	return pool.Driver().Info().Name != "zfs", nil
+}
+
+// restoreOnStopValidate checks that the snapshot configured in snapshots.restore_on_stop exists and can be
+// restored on stop without deleting any of the other snapshots of the instance.
+func (d *common) restoreOnStopValidate() error {
+	snap, err := d.restoreOnStopSnapshot()
+	if err != nil {
+		return err
+	}
+
+	keepsSnapshots, err := d.restoreOnStopKeepsSnapshots()
+	if err != nil {
+		return err
+	}
+
+	if keepsSnapshots {
+		return nil
+	}
+
+	snaps, err := d.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snaps) > 0 && snaps[len(snaps)-1].Name() != snap.Name() {
+		return fmt.Errorf("Snapshot %q can't be used by snapshots.restore_on_stop as it isn't the most recent snapshot of the instance", d.expandedConfig["snapshots.restore_on_stop"])
+	}
+
+	return nil
+}
+
+// restoreOnStop resets the instance's root volume to the snapshot configured in snapshots.restore_on_stop.
+// It must be called from the stop path once the instance's volume has been unmounted and while the stop
+// operation lock is still held, so that conflicting operations are refused until the restore has completed.
+// If the volume couldn't be unmounted because it is still in use, the restore fails rather than rolling back a
+// volume that is still being accessed, and so does the stop.
+func (d *common) restoreOnStop(inst instance.Instance, op *operationlock.InstanceOperation, volumeInUse bool) error {
+	snapName := d.expandedConfig["snapshots.restore_on_stop"]
+	if snapName == "" || d.ephemeral {
+		return nil
+	}
+
+	// Skip if the instance is being stopped as part of a snapshot restore, as it's about to be restored anyway.
+	if op.ActionMatch(operationlock.ActionRestore) {
+		return nil
+	}
+
+	err := d.restoreOnStopSnapshotVolume(inst, volumeInUse)
+	if err != nil {
+		d.logger.Error("Failed restoring instance on stop", logger.Ctx{"snapshot": snapName, "err": err})
+		return fmt.Errorf("Failed restoring snapshot %q on stop: %w", snapName, err)
+	}
+
+	d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceRestored.Event(inst, map[string]any{"snapshot": snapName}))
+
+	return nil
+}
+
+// restoreOnStopSnapshotVolume restores the instance's root volume from the snapshot configured in
+// snapshots.restore_on_stop.
+func (d *common) restoreOnStopSnapshotVolume(inst instance.Instance, volumeInUse bool) error {
+	if volumeInUse {
+		return fmt.Errorf("Instance volume is still mounted: %w", storageDrivers.ErrInUse)
+	}
+
+	snap, err := d.restoreOnStopSnapshot()
+	if err != nil {
+		return err
+	}
+
+	pool, err := d.getStoragePool()
+	if err != nil {
+		return err
+	}
+
+	d.logger.Debug("Restoring instance on stop", logger.Ctx{"snapshot": snap.Name()})
+
+	return pool.RestoreInstanceSnapshotOnStop(inst, snap, nil)
+}
+
 // warningsDelete deletes any persistent warnings for the instance.
 func (d *common) warningsDelete() error {
 	err := d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
@@ -1137,6 +1279,43 @@ func (d *common) recordLastState() error {
 	})
 }
 
+// recordConfigRevision records the change of the local configuration of the instance from the old one
+// into its configuration history, along with the requestor of the current operation.
+func (d *common) recordConfigRevision(ctx context.Context, tx *db.ClusterTx, oldConfig map[string]string, oldDevices deviceConfig.Devices, oldProfiles []api.Profile) (*api.ConfigRevision, error) {
+	profileNames := func(profiles []api.Profile) []string {
+		names := make([]string, 0, len(profiles))
+		for _, profile := range profiles {
+			names = append(names, profile.Name)
+		}
+
+		return names
+	}
+
+	old := api.ConfigRevision{
+		Config:   oldConfig,
+		Devices:  oldDevices.CloneNative(),
+		Profiles: profileNames(oldProfiles),
+	}
+
+	new := api.ConfigRevision{
+		Config:   d.localConfig,
+		Devices:  d.localDevices.CloneNative(),
+		Profiles: profileNames(d.profiles),
+	}
+
+	requestor := d.requestor
+	if d.op != nil && d.op.Requestor() != nil {
+		requestor = d.op.Requestor()
+	}
+
+	if requestor != nil {
+		new.Username = requestor.Username
+		new.Protocol = requestor.Protocol
+	}
+
+	return tx.CreateInstanceConfigRevision(ctx, d.id, old, new)
+}
+
 func (d *common) setCoreSched(pids []int) error {
 	if !d.state.OS.CoreScheduling {
 		return nil
@@ -0,0 +1,52 @@
+package drivers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/canonical/lxd/lxd/instance/operationlock"
+	storageDrivers "github.com/canonical/lxd/lxd/storage/drivers"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+func TestRestoreOnStop_VolumeInUse(t *testing.T) {
+	d := &common{
+		name:           "c1",
+		expandedConfig: map[string]string{"snapshots.restore_on_stop": "base"},
+		logger:         logger.AddContext(logger.Ctx{"instance": "c1", "project": "default"}),
+	}
+
+	op, err := operationlock.Create("default", "c1", operationlock.ActionStop, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer op.Done(nil)
+
+	// A volume still in use fails the restore, and so the stop, rather than skipping it.
+	err = d.restoreOnStop(nil, op, true)
+	if !errors.Is(err, storageDrivers.ErrInUse) {
+		t.Fatalf("Expected the restore to fail with %q, got %v", storageDrivers.ErrInUse, err)
+	}
+
+	// Nothing gets restored without a baseline snapshot.
+	d.expandedConfig = map[string]string{}
+	err = d.restoreOnStop(nil, op, true)
+	if err != nil {
+		t.Fatalf("Expected no restore without a baseline snapshot, got %v", err)
+	}
+
+	// Nor when stopping the instance to restore one of its snapshots.
+	d.expandedConfig = map[string]string{"snapshots.restore_on_stop": "base"}
+	restoreOp, err := operationlock.Create("default", "c2", operationlock.ActionRestore, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer restoreOp.Done(nil)
+
+	err = d.restoreOnStop(nil, restoreOp, true)
+	if err != nil {
+		t.Fatalf("Expected no restore when stopping for a snapshot restore, got %v", err)
+	}
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/canonical/lxd/lxd/device"
 	deviceConfig "github.com/canonical/lxd/lxd/device/config"
 	"github.com/canonical/lxd/lxd/device/nictype"
+	"github.com/canonical/lxd/lxd/fsmonitor"
 	"github.com/canonical/lxd/lxd/instance"
 	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/instance/operationlock"
@@ -1931,7 +1932,10 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 	d.stopForkfile(false)
 
 	// Mount instance root volume.
+	mountSpan := d.startSpan("Mount", nil)
 	mountInfo, err := d.mount()
+	mountSpan.SetError(err)
+	mountSpan.End()
 	if err != nil {
 		return "", nil, err
 	}
@@ -2053,7 +2057,10 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		dev := startDevices[i] // Local var for revert.
 
 		// Start the device.
+		deviceSpan := d.startSpan("StartDevice", map[string]any{"lxd.device.name": dev.Name(), "lxd.device.type": dev.Config()["type"]})
 		runConf, err := d.deviceStart(dev, false)
+		deviceSpan.SetError(err)
+		deviceSpan.End()
 		if err != nil {
 			return "", nil, fmt.Errorf("Failed to start device %q: %w", dev.Name(), err)
 		}
@@ -2093,6 +2100,25 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 				return "", nil, fmt.Errorf("Unable to resolve container rootfs: %w", err)
 			}
 
+			// Assemble a writable overlay on top of the rootfs if it must be kept read-only.
+			if shared.IsTrue(d.expandedConfig["security.rootfs.readonly"]) {
+				if idmapType == idmap.IdmapStorageShiftfs && !d.IsPrivileged() {
+					return "", nil, fmt.Errorf("Read-only rootfs isn't supported with shiftfs")
+				}
+
+				absoluteRootfs, err = d.mountRootfsOverlay(absoluteRootfs)
+				if err != nil {
+					return "", nil, err
+				}
+
+				revert.Add(func() { _ = d.unmountRootfsOverlay() })
+
+				// The idmapped mount of the rootfs is stacked on top of the overlay.
+				if idmapType == idmap.IdmapStorageIdmapped && !d.IsPrivileged() && !idmap.CanIdmapMount(absoluteRootfs, "none") {
+					return "", nil, fmt.Errorf("Read-only rootfs requires a kernel supporting idmapped mounts of overlayfs")
+				}
+			}
+
 			if liblxc.RuntimeLiblxcVersionAtLeast(liblxc.Version(), 2, 1, 0) {
 				rootfsPath := fmt.Sprintf("dir:%s", absoluteRootfs)
 				err = lxcSetConfigItem(cc, "lxc.rootfs.path", rootfsPath)
@@ -2342,6 +2368,7 @@ func (d *lxc) Start(stateful bool) error {
 
 	d.logger.Debug("Start started", logger.Ctx{"stateful": stateful})
 	defer d.logger.Debug("Start finished", logger.Ctx{"stateful": stateful})
+	defer d.startSpan("Start", map[string]any{"lxd.instance.stateful": stateful}).End()
 
 	// Check that we are startable before creating an operation lock.
 	// Must happen before creating operation Start lock to avoid the status check returning Stopped due to the
@@ -2951,9 +2978,17 @@ func (d *lxc) onStop(args map[string]string) error {
 			return
 		}
 
+		// Discard the rootfs overlay (if any) before unmounting the rootfs.
+		err = d.unmountRootfsOverlay()
+		if err != nil {
+			op.Done(err)
+			return
+		}
+
 		// Stop the storage for this container
 		err = d.unmount()
-		if err != nil && !errors.Is(err, storageDrivers.ErrInUse) {
+		volumeInUse := errors.Is(err, storageDrivers.ErrInUse)
+		if err != nil && !volumeInUse {
 			err = fmt.Errorf("Failed unmounting instance: %w", err)
 			op.Done(err)
 			return
@@ -2980,6 +3015,15 @@ func (d *lxc) onStop(args map[string]string) error {
 			return
 		}
 
+		// Reset the root volume to its baseline snapshot if requested.
+		if target == "stop" {
+			err = d.restoreOnStop(d, op, volumeInUse)
+			if err != nil {
+				op.Done(err)
+				return
+			}
+		}
+
 		// Log and emit lifecycle if not user triggered
 		if op.GetInstanceInitiated() {
 			ctxMap := logger.Ctx{
@@ -4234,6 +4278,24 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		if newErr != nil {
 			return fmt.Errorf("Invalid root disk device: %w", newErr)
 		}
+
+		// Ensure the snapshot used to restore the instance on stop exists and can be restored.
+		if shared.StringInSlice("snapshots.restore_on_stop", changedConfig) && d.expandedConfig["snapshots.restore_on_stop"] != "" {
+			err = d.restoreOnStopValidate()
+			if err != nil {
+				return err
+			}
+		}
+
+		// Ensure the custom volume used as rootfs overlay can be used as such.
+		overlayVolume := d.expandedConfig["security.rootfs.readonly.overlay.volume"]
+		overlayChanged := shared.StringInSlice("security.rootfs.readonly", changedConfig) || shared.StringInSlice("security.rootfs.readonly.overlay", changedConfig) || shared.StringInSlice("security.rootfs.readonly.overlay.volume", changedConfig)
+		if overlayChanged && shared.IsTrue(d.expandedConfig["security.rootfs.readonly"]) && d.expandedConfig["security.rootfs.readonly.overlay"] == "volume" && overlayVolume != "" {
+			err = d.checkRootfsOverlayVolume(overlayVolume)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Run through initLXC to catch anything we missed
@@ -4662,6 +4724,7 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	// Finally, apply the changes to the database
+	var revision *api.ConfigRevision
 	err = d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		// Snapshots should update only their descriptions and expiry date.
 		if d.IsSnapshot() {
@@ -4703,7 +4766,14 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 			profileNames = append(profileNames, profile.Name)
 		}
 
-		return cluster.UpdateInstanceProfiles(ctx, tx.Tx(), object.ID, object.Project, profileNames)
+		err = cluster.UpdateInstanceProfiles(ctx, tx.Tx(), object.ID, object.Project, profileNames)
+		if err != nil {
+			return err
+		}
+
+		revision, err = d.recordConfigRevision(ctx, tx, oldLocalConfig, oldLocalDevices, oldProfiles)
+
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("Failed to update database: %w", err)
@@ -4782,7 +4852,7 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		if d.isSnapshot {
 			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceSnapshotUpdated.Event(d, nil))
 		} else {
-			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceUpdated.Event(d, nil))
+			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceUpdated.Event(d, lifecycle.ConfigRevisionContext(revision)))
 		}
 	}
 
@@ -6859,6 +6929,16 @@ func (d *lxc) FileSFTP() (*sftp.Client, error) {
 	return client, nil
 }
 
+// FileWatch calls f for every file created, modified or deleted below path until ctx is cancelled.
+// Only changes to the root filesystem are reported, not those on other mounts inside the container.
+func (d *lxc) FileWatch(ctx context.Context, path string, f func(event api.InstanceFileEvent)) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	return fsmonitor.WatchFiles(ctx, project.Instance(d.Project().Name, d.Name()), d.RootfsPath(), path, f)
+}
+
 // stopForkFile attempts to send SIGTERM (if force is true) or SIGINT to forkfile then waits for it to exit.
 func (d *lxc) stopForkfile(force bool) {
 	// Make sure that when the function exits, no forkfile is running by acquiring the lock (which indicates
@@ -7289,6 +7369,91 @@ func (d *lxc) networkState(hostInterfaces []net.Interface) map[string]api.Instan
 	return result
 }
 
+// processes returns the processes running in the container's cgroup, including those of nested cgroups.
+func (d *lxc) processes() ([]util.Process, error) {
+	pid := d.InitPID()
+	if pid <= 0 {
+		return nil, fmt.Errorf("Instance is not running")
+	}
+
+	cgroupPath, err := cgroup.GetProcessCgroupPath(pid, "pids")
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting cgroup of instance: %w", err)
+	}
+
+	processes, err := util.GetProcesses(cgroupPath, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	idmapset, err := d.CurrentIdmap()
+	if err != nil {
+		return nil, err
+	}
+
+	passwdPath, err := util.ResolveRootfsPath(d.RootfsPath(), "/etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := util.GetUsernames(passwdPath)
+
+	// Report the user IDs as seen inside the container.
+	for i := range processes {
+		if idmapset != nil {
+			processes[i].UID, _ = idmapset.ShiftFromNs(processes[i].UID, -1)
+		}
+
+		processes[i].User = usernames[processes[i].UID]
+	}
+
+	return processes, nil
+}
+
+// Processes returns the processes running inside the container.
+func (d *lxc) Processes() ([]api.InstanceProcess, error) {
+	processes, err := d.processes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]api.InstanceProcess, 0, len(processes))
+	for _, process := range processes {
+		result = append(result, process.InstanceProcess)
+	}
+
+	return result, nil
+}
+
+// SignalProcess sends a signal to the process with the given PID inside the container.
+func (d *lxc) SignalProcess(pid int64, signal unix.Signal) error {
+	processes, err := d.processes()
+	if err != nil {
+		return err
+	}
+
+	for _, process := range processes {
+		if process.PID != pid {
+			continue
+		}
+
+		err = util.SignalProcess(process, signal)
+		if err != nil {
+			if errors.Is(err, unix.ESRCH) {
+				return api.StatusErrorf(http.StatusNotFound, "Process %d not found", pid)
+			}
+
+			return fmt.Errorf("Failed sending signal to process %d: %w", pid, err)
+		}
+
+		d.logger.Debug("Sent signal to process", logger.Ctx{"pid": pid, "signal": signal})
+
+		return nil
+	}
+
+	return api.StatusErrorf(http.StatusNotFound, "Process %d not found", pid)
+}
+
 func (d *lxc) processesState(pid int) (int64, error) {
 	// Return 0 if not running
 	if pid == -1 {
@@ -7347,6 +7512,243 @@ func (d *lxc) getStorageType() (string, error) {
 	return pool.Driver().Info().Name, nil
 }
 
+// rootfsOverlayPath returns the path used to assemble the overlay of a read-only rootfs.
+func (d *lxc) rootfsOverlayPath() string {
+	return filepath.Join(d.DevicesPath(), "rootfs.overlay")
+}
+
+// checkRootfsOverlayVolume checks that the custom volume can be used as the upper layer of the rootfs overlay.
+// As the volume is wiped on every start, it must be a filesystem volume which nothing else is using.
+func (d *lxc) checkRootfsOverlayVolume(volName string) error {
+	pool, err := d.getStoragePool()
+	if err != nil {
+		return err
+	}
+
+	storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.project.Name, db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	var dbVolume *db.StorageVolume
+	err = d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		dbVolume, err = tx.GetStoragePoolVolume(ctx, pool.ID(), storageProjectName, db.StoragePoolVolumeTypeCustom, volName, true)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed loading rootfs overlay volume %q: %w", volName, err)
+	}
+
+	if dbVolume.ContentType != db.StoragePoolVolumeContentTypeNameFS {
+		return fmt.Errorf("Rootfs overlay volume %q must have content type %q", volName, db.StoragePoolVolumeContentTypeNameFS)
+	}
+
+	err = storagePools.VolumeUsedByInstanceDevices(d.state, pool.Name(), storageProjectName, &dbVolume.StorageVolume, true, func(inst db.InstanceArgs, p api.Project, usedByDevices []string) error {
+		return fmt.Errorf("Rootfs overlay volume %q is attached to instance %q in project %q", volName, inst.Name, inst.Project)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Check that no other instance uses the same volume as its rootfs overlay.
+	return d.state.DB.Cluster.InstanceList(context.TODO(), func(inst db.InstanceArgs, p api.Project) error {
+		if inst.ID == d.id {
+			return nil
+		}
+
+		// Volumes on local pools are only shared with instances on the same cluster member.
+		if dbVolume.Location != "" && inst.Node != dbVolume.Location {
+			return nil
+		}
+
+		if project.StorageVolumeProjectFromRecord(&p, db.StoragePoolVolumeTypeCustom) != storageProjectName {
+			return nil
+		}
+
+		expandedConfig := db.ExpandInstanceConfig(inst.Config, inst.Profiles)
+		if shared.IsFalseOrEmpty(expandedConfig["security.rootfs.readonly"]) || expandedConfig["security.rootfs.readonly.overlay"] != "volume" || expandedConfig["security.rootfs.readonly.overlay.volume"] != volName {
+			return nil
+		}
+
+		_, rootDiskDevice, err := shared.GetRootDiskDevice(db.ExpandInstanceDevices(inst.Devices.Clone(), inst.Profiles).CloneNative())
+		if err != nil || rootDiskDevice["pool"] != pool.Name() {
+			return nil
+		}
+
+		return fmt.Errorf("Rootfs overlay volume %q is already used by instance %q in project %q", volName, inst.Name, inst.Project)
+	})
+}
+
+// mountRootfsOverlay mounts a writable overlay on top of the read-only rootfs at lowerPath.
+// The upper layer lives either on a tmpfs or on a dedicated custom volume and is always started empty so that
+// any changes made to the rootfs are discarded when the container restarts.
+// Returns the path of the merged rootfs.
+func (d *lxc) mountRootfsOverlay(lowerPath string) (string, error) {
+	revert := revert.New()
+	defer revert.Fail()
+
+	overlayPath := d.rootfsOverlayPath()
+	err := os.MkdirAll(overlayPath, 0711)
+	if err != nil {
+		return "", err
+	}
+
+	revert.Add(func() { _ = os.Remove(overlayPath) })
+
+	if d.expandedConfig["security.rootfs.readonly.overlay"] == "volume" {
+		volName := d.expandedConfig["security.rootfs.readonly.overlay.volume"]
+		if volName == "" {
+			return "", fmt.Errorf("%q must be set when using a volume overlay", "security.rootfs.readonly.overlay.volume")
+		}
+
+		pool, err := d.getStoragePool()
+		if err != nil {
+			return "", err
+		}
+
+		storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.project.Name, db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return "", err
+		}
+
+		err = d.checkRootfsOverlayVolume(volName)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = pool.MountCustomVolume(storageProjectName, volName, nil)
+		if err != nil {
+			return "", fmt.Errorf("Failed mounting rootfs overlay volume %q: %w", volName, err)
+		}
+
+		revert.Add(func() { _, _ = pool.UnmountCustomVolume(storageProjectName, volName, nil) })
+
+		volPath := storageDrivers.GetVolumeMountPath(pool.Name(), storageDrivers.VolumeTypeCustom, project.StorageVolume(storageProjectName, volName))
+		err = unix.Mount(volPath, overlayPath, "none", unix.MS_BIND, "")
+		if err != nil {
+			return "", fmt.Errorf("Failed bind mounting rootfs overlay volume %q: %w", volName, err)
+		}
+
+		revert.Add(func() { _ = unix.Unmount(overlayPath, unix.MNT_DETACH) })
+
+		// Discard any changes left over from the previous run.
+		for _, dir := range []string{"upper", "work"} {
+			err = os.RemoveAll(filepath.Join(overlayPath, dir))
+			if err != nil {
+				return "", fmt.Errorf("Failed resetting rootfs overlay: %w", err)
+			}
+		}
+
+		err = d.VolatileSet(map[string]string{"volatile.rootfs.overlay.volume": volName})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		opts := "mode=0711"
+		if d.expandedConfig["security.rootfs.readonly.overlay.size"] != "" {
+			size, err := units.ParseByteSizeString(d.expandedConfig["security.rootfs.readonly.overlay.size"])
+			if err != nil {
+				return "", err
+			}
+
+			opts = fmt.Sprintf("%s,size=%d", opts, size)
+		}
+
+		err = unix.Mount("tmpfs", overlayPath, "tmpfs", 0, opts)
+		if err != nil {
+			return "", fmt.Errorf("Failed mounting rootfs overlay tmpfs: %w", err)
+		}
+
+		revert.Add(func() { _ = unix.Unmount(overlayPath, unix.MNT_DETACH) })
+	}
+
+	// The root of the merged filesystem takes its ownership and mode from the upper layer, so copy them
+	// from the lower layer to keep the container's view of / unchanged.
+	lowerInfo, err := os.Stat(lowerPath)
+	if err != nil {
+		return "", err
+	}
+
+	upperPath := filepath.Join(overlayPath, "upper")
+	workPath := filepath.Join(overlayPath, "work")
+	mergedPath := filepath.Join(overlayPath, "merged")
+	for _, dir := range []string{upperPath, workPath, mergedPath} {
+		err = os.Mkdir(dir, 0711)
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
+	}
+
+	lowerStat, ok := lowerInfo.Sys().(*syscall.Stat_t)
+	if ok {
+		err = os.Chown(upperPath, int(lowerStat.Uid), int(lowerStat.Gid))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = os.Chmod(upperPath, lowerInfo.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	err = unix.Mount("overlay", mergedPath, "overlay", 0, fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lowerPath, upperPath, workPath))
+	if err != nil {
+		return "", fmt.Errorf("Failed mounting rootfs overlay: %w", err)
+	}
+
+	revert.Success()
+	return mergedPath, nil
+}
+
+// unmountRootfsOverlay unmounts the overlay set up by mountRootfsOverlay, if any.
+func (d *lxc) unmountRootfsOverlay() error {
+	overlayPath := d.rootfsOverlayPath()
+	if !shared.PathExists(overlayPath) {
+		return nil
+	}
+
+	mergedPath := filepath.Join(overlayPath, "merged")
+	err := unix.Unmount(mergedPath, unix.MNT_DETACH)
+	if err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("Failed unmounting rootfs overlay: %w", err)
+	}
+
+	err = unix.Unmount(overlayPath, unix.MNT_DETACH)
+	if err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("Failed unmounting rootfs overlay upper layer: %w", err)
+	}
+
+	volName := d.localConfig["volatile.rootfs.overlay.volume"]
+	if volName != "" {
+		pool, err := d.getStoragePool()
+		if err != nil {
+			return err
+		}
+
+		storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.project.Name, db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return err
+		}
+
+		_, err = pool.UnmountCustomVolume(storageProjectName, volName, nil)
+		if err != nil && !errors.Is(err, storageDrivers.ErrInUse) {
+			return fmt.Errorf("Failed unmounting rootfs overlay volume %q: %w", volName, err)
+		}
+
+		err = d.VolatileSet(map[string]string{"volatile.rootfs.overlay.volume": ""})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only remove the now empty mount points, never recurse into a path that may still be mounted.
+	_ = os.Remove(mergedPath)
+
+	return os.Remove(overlayPath)
+}
+
 // mount the instance's rootfs volume if needed.
 func (d *lxc) mount() (*storagePools.MountInfo, error) {
 	pool, err := d.getStoragePool()
@@ -635,7 +635,8 @@ func (d *qemu) onStop(target string) error {
 
 	// Stop the storage for the instance.
 	err = d.unmount()
-	if err != nil && !errors.Is(err, storageDrivers.ErrInUse) {
+	volumeInUse := errors.Is(err, storageDrivers.ErrInUse)
+	if err != nil && !volumeInUse {
 		err = fmt.Errorf("Failed unmounting instance: %w", err)
 		op.Done(err)
 		return err
@@ -648,6 +649,15 @@ func (d *qemu) onStop(target string) error {
 		return err
 	}
 
+	// Reset the root volume to its baseline snapshot if requested.
+	if target == "stop" {
+		err = d.restoreOnStop(d, op, volumeInUse)
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	// Log and emit lifecycle if not user triggered.
 	if op.GetInstanceInitiated() {
 		d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceShutdown.Event(d, nil))
@@ -1071,6 +1081,7 @@ func (d *qemu) Start(stateful bool) error {
 func (d *qemu) start(stateful bool, op *operationlock.InstanceOperation) error {
 	d.logger.Debug("Start started", logger.Ctx{"stateful": stateful})
 	defer d.logger.Debug("Start finished", logger.Ctx{"stateful": stateful})
+	defer d.startSpan("Start", map[string]any{"lxd.instance.stateful": stateful}).End()
 
 	// Check that we are startable before creating an operation lock.
 	// Must happen before creating operation Start lock to avoid the status check returning Stopped due to the
@@ -1136,7 +1147,10 @@ func (d *qemu) start(stateful bool, op *operationlock.InstanceOperation) error {
 	}
 
 	// Mount the instance's config volume.
+	mountSpan := d.startSpan("Mount", nil)
 	mountInfo, err := d.mount()
+	mountSpan.SetError(err)
+	mountSpan.End()
 	if err != nil {
 		op.Done(err)
 		return err
@@ -1270,7 +1284,10 @@ func (d *qemu) start(stateful bool, op *operationlock.InstanceOperation) error {
 		dev := startDevices[i] // Local var for revert.
 
 		// Start the device.
+		deviceSpan := d.startSpan("StartDevice", map[string]any{"lxd.device.name": dev.Name(), "lxd.device.type": dev.Config()["type"]})
 		runConf, err := d.deviceStart(dev, false)
+		deviceSpan.SetError(err)
+		deviceSpan.End()
 		if err != nil {
 			err = fmt.Errorf("Failed to start device %q: %w", dev.Name(), err)
 			op.Done(err)
@@ -2382,6 +2399,98 @@ func (d *qemu) spiceCmdlineConfig() string {
 	return fmt.Sprintf("unix=on,disable-ticketing=on,addr=%s", d.spicePath())
 }
 
+// lxdAgentServiceUnit is the systemd unit used to start the lxd-agent inside virtual machines.
+const lxdAgentServiceUnit = `[Unit]
+Description=LXD - agent
+Documentation=https://documentation.ubuntu.com/lxd/en/latest/
+ConditionPathExists=/dev/virtio-ports/org.linuxcontainers.lxd
+Before=cloud-init.target cloud-init.service cloud-init-local.service
+DefaultDependencies=no
+
+[Service]
+Type=notify
+WorkingDirectory=-/run/lxd_agent
+ExecStartPre=/lib/systemd/lxd-agent-setup
+ExecStart=/run/lxd_agent/lxd-agent
+Restart=on-failure
+RestartSec=5s
+StartLimitInterval=60
+StartLimitBurst=10
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// lxdAgentSetupScript is run by lxdAgentServiceUnit to copy the lxd-agent from the config share.
+const lxdAgentSetupScript = `#!/bin/sh
+set -eu
+PREFIX="/run/lxd_agent"
+
+# Functions.
+mount_virtiofs() {
+    mount -t virtiofs config "${PREFIX}/.mnt" >/dev/null 2>&1
+}
+
+mount_9p() {
+    /sbin/modprobe 9pnet_virtio >/dev/null 2>&1 || true
+    /bin/mount -t 9p config "${PREFIX}/.mnt" -o access=0,trans=virtio,size=1048576 >/dev/null 2>&1
+}
+
+fail() {
+    umount -l "${PREFIX}" >/dev/null 2>&1 || true
+    rmdir "${PREFIX}" >/dev/null 2>&1 || true
+    echo "${1}"
+    exit 1
+}
+
+# Setup the mount target.
+umount -l "${PREFIX}" >/dev/null 2>&1 || true
+mkdir -p "${PREFIX}"
+mount -t tmpfs tmpfs "${PREFIX}" -o mode=0700,size=50M
+mkdir -p "${PREFIX}/.mnt"
+
+# Try virtiofs first.
+mount_virtiofs || mount_9p || fail "Couldn't mount virtiofs or 9p, failing."
+
+# Copy the data.
+cp -Ra "${PREFIX}/.mnt/"* "${PREFIX}"
+
+# Unmount the temporary mount.
+umount "${PREFIX}/.mnt"
+rmdir "${PREFIX}/.mnt"
+
+# Fix up permissions.
+chown -R root:root "${PREFIX}"
+`
+
+// lxdAgentRules is the udev rule starting the lxd-agent once its virtio port shows up.
+const lxdAgentRules = `ACTION=="add", SYMLINK=="virtio-ports/org.linuxcontainers.lxd", TAG+="systemd", ACTION=="add", RUN+="/bin/systemctl start lxd-agent.service"`
+
+// InstallAgentFiles installs the lxd-agent systemd unit, setup script and udev rule into the root filesystem at
+// rootfsPath, so that the agent is started automatically when the filesystem is booted as a virtual machine.
+func InstallAgentFiles(rootfsPath string) error {
+	files := []struct {
+		path    string
+		content string
+		mode    os.FileMode
+	}{
+		{path: "lib/systemd/system/lxd-agent.service", content: lxdAgentServiceUnit, mode: 0644},
+		{path: "lib/systemd/lxd-agent-setup", content: lxdAgentSetupScript, mode: 0755},
+		{path: "lib/udev/rules.d/99-lxd-agent.rules", content: lxdAgentRules, mode: 0644},
+	}
+
+	// The root filesystem is guest controlled, so paths are resolved within it.
+	for _, file := range files {
+		err := util.WriteFileInRoot(rootfsPath, file.path, []byte(file.content), file.mode)
+		if err != nil {
+			return fmt.Errorf("Failed writing %q: %w", file.path, err)
+		}
+	}
+
+	// Enable the unit.
+	return util.SymlinkInRoot(rootfsPath, "etc/systemd/system/multi-user.target.wants/lxd-agent.service", "/lib/systemd/system/lxd-agent.service")
+}
+
 // generateConfigShare generates the config share directory that will be exported to the VM via
 // a 9P share. Due to the unknown size of templates inside the images this directory is created
 // inside the VM's config volume so that it can be restricted by quota.
@@ -2481,73 +2590,11 @@ func (d *qemu) generateConfigShare() error {
 		return err
 	}
 
-	lxdAgentServiceUnit := `[Unit]
-Description=LXD - agent
-Documentation=https://documentation.ubuntu.com/lxd/en/latest/
-ConditionPathExists=/dev/virtio-ports/org.linuxcontainers.lxd
-Before=cloud-init.target cloud-init.service cloud-init-local.service
-DefaultDependencies=no
-
-[Service]
-Type=notify
-WorkingDirectory=-/run/lxd_agent
-ExecStartPre=/lib/systemd/lxd-agent-setup
-ExecStart=/run/lxd_agent/lxd-agent
-Restart=on-failure
-RestartSec=5s
-StartLimitInterval=60
-StartLimitBurst=10
-
-[Install]
-WantedBy=multi-user.target
-`
-
 	err = os.WriteFile(filepath.Join(configDrivePath, "systemd", "lxd-agent.service"), []byte(lxdAgentServiceUnit), 0400)
 	if err != nil {
 		return err
 	}
 
-	lxdAgentSetupScript := `#!/bin/sh
-set -eu
-PREFIX="/run/lxd_agent"
-
-# Functions.
-mount_virtiofs() {
-    mount -t virtiofs config "${PREFIX}/.mnt" >/dev/null 2>&1
-}
-
-mount_9p() {
-    /sbin/modprobe 9pnet_virtio >/dev/null 2>&1 || true
-    /bin/mount -t 9p config "${PREFIX}/.mnt" -o access=0,trans=virtio,size=1048576 >/dev/null 2>&1
-}
-
-fail() {
-    umount -l "${PREFIX}" >/dev/null 2>&1 || true
-    rmdir "${PREFIX}" >/dev/null 2>&1 || true
-    echo "${1}"
-    exit 1
-}
-
-# Setup the mount target.
-umount -l "${PREFIX}" >/dev/null 2>&1 || true
-mkdir -p "${PREFIX}"
-mount -t tmpfs tmpfs "${PREFIX}" -o mode=0700,size=50M
-mkdir -p "${PREFIX}/.mnt"
-
-# Try virtiofs first.
-mount_virtiofs || mount_9p || fail "Couldn't mount virtiofs or 9p, failing."
-
-# Copy the data.
-cp -Ra "${PREFIX}/.mnt/"* "${PREFIX}"
-
-# Unmount the temporary mount.
-umount "${PREFIX}/.mnt"
-rmdir "${PREFIX}/.mnt"
-
-# Fix up permissions.
-chown -R root:root "${PREFIX}"
-`
-
 	err = os.WriteFile(filepath.Join(configDrivePath, "systemd", "lxd-agent-setup"), []byte(lxdAgentSetupScript), 0500)
 	if err != nil {
 		return err
@@ -2559,7 +2606,6 @@ chown -R root:root "${PREFIX}"
 		return err
 	}
 
-	lxdAgentRules := `ACTION=="add", SYMLINK=="virtio-ports/org.linuxcontainers.lxd", TAG+="systemd", ACTION=="add", RUN+="/bin/systemctl start lxd-agent.service"`
 	err = os.WriteFile(filepath.Join(configDrivePath, "udev", "99-lxd-agent.rules"), []byte(lxdAgentRules), 0400)
 	if err != nil {
 		return err
@@ -5090,6 +5136,14 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		if newErr != nil {
 			return fmt.Errorf("Invalid root disk device: %w", newErr)
 		}
+
+		// Ensure the snapshot used to restore the instance on stop exists and can be restored.
+		if shared.StringInSlice("snapshots.restore_on_stop", changedConfig) && d.expandedConfig["snapshots.restore_on_stop"] != "" {
+			err = d.restoreOnStopValidate()
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// If apparmor changed, re-validate the apparmor profile (even if not running).
@@ -5117,6 +5171,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 			"security.csm",
 			"security.devlxd",
 			"security.secureboot",
+			"security.session_recording",
 		}
 
 		liveUpdateKeyPrefixes := []string{
@@ -5241,6 +5296,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	// Finally, apply the changes to the database.
+	var revision *api.ConfigRevision
 	err = d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		// Snapshots should update only their descriptions and expiry date.
 		if d.IsSnapshot() {
@@ -5282,7 +5338,14 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 			profileNames = append(profileNames, profile.Name)
 		}
 
-		return dbCluster.UpdateInstanceProfiles(ctx, tx.Tx(), object.ID, object.Project, profileNames)
+		err = dbCluster.UpdateInstanceProfiles(ctx, tx.Tx(), object.ID, object.Project, profileNames)
+		if err != nil {
+			return err
+		}
+
+		revision, err = d.recordConfigRevision(ctx, tx, oldLocalConfig, oldLocalDevices, oldProfiles)
+
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("Failed to update database: %w", err)
@@ -5320,7 +5383,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		if d.isSnapshot {
 			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceSnapshotUpdated.Event(d, nil))
 		} else {
-			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceUpdated.Event(d, nil))
+			d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceUpdated.Event(d, lifecycle.ConfigRevisionContext(revision)))
 		}
 	}
 
@@ -6991,6 +7054,53 @@ func (d *qemu) FileSFTP() (*sftp.Client, error) {
 	return client, nil
 }
 
+// FileWatch calls f for every file created, modified or deleted below path until ctx is cancelled.
+// The changes are monitored by the lxd-agent inside the instance.
+func (d *qemu) FileWatch(ctx context.Context, path string, f func(event api.InstanceFileEvent)) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to lxd-agent", logger.Ctx{"err": err})
+		return fmt.Errorf("Failed to connect to lxd-agent")
+	}
+
+	defer agent.Disconnect()
+
+	conn, err := agent.RawWebsocket(fmt.Sprintf("/files/watch?path=%s", url.QueryEscape(path)))
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	for {
+		event := api.InstanceFileEvent{}
+		err := conn.ReadJSON(&event)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("Failed reading file event from lxd-agent: %w", err)
+		}
+
+		f(event)
+	}
+}
+
 // Console gets access to the instance's console.
 func (d *qemu) Console(protocol string) (*os.File, chan error, error) {
 	var path string
@@ -7351,6 +7461,55 @@ func (d *qemu) agentGetState() (*api.InstanceState, error) {
 	return status, nil
 }
 
+// Processes returns the processes running inside the VM as reported by the agent.
+func (d *qemu) Processes() ([]api.InstanceProcess, error) {
+	if !d.IsRunning() {
+		return nil, fmt.Errorf("Instance is not running")
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		return nil, fmt.Errorf("Failed connecting to agent: %w", err)
+	}
+
+	defer agent.Disconnect()
+
+	return agent.GetInstanceProcesses("")
+}
+
+// SignalProcess sends a signal to the process with the given PID inside the VM through the agent.
+func (d *qemu) SignalProcess(pid int64, signal unix.Signal) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to agent: %w", err)
+	}
+
+	defer agent.Disconnect()
+
+	err = agent.SignalInstanceProcess("", pid, strconv.Itoa(int(signal)))
+	if err != nil {
+		return err
+	}
+
+	d.logger.Debug("Sent signal to process", logger.Ctx{"pid": pid, "signal": signal})
+
+	return nil
+}
+
 // IsRunning returns whether or not the instance is running.
 func (d *qemu) IsRunning() bool {
 	return d.isRunningStatusCode(d.statusCode())
@@ -5,11 +5,13 @@ import (
 	"crypto/x509"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
 	liblxc "github.com/lxc/go-lxc"
 	"github.com/pkg/sftp"
+	"golang.org/x/sys/unix"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/canonical/lxd/lxd/backup"
@@ -106,11 +108,16 @@ type Instance interface {
 	// File handling.
 	FileSFTPConn() (net.Conn, error)
 	FileSFTP() (*sftp.Client, error)
+	FileWatch(ctx context.Context, path string, f func(event api.InstanceFileEvent)) error
 
 	// Console - Allocate and run a console tty or a spice Unix socket.
 	Console(protocol string) (*os.File, chan error, error)
 	Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (Cmd, error)
 
+	// Processes.
+	Processes() ([]api.InstanceProcess, error)
+	SignalProcess(pid int64, signal unix.Signal) error
+
 	// Status
 	Render(options ...func(response any) error) (any, any, error)
 	RenderFull(hostInterfaces []net.Interface) (*api.InstanceFull, any, error)
@@ -163,6 +170,7 @@ type Instance interface {
 	// Progress reporting.
 	SetOperation(op *operations.Operation)
 	Operation() *operations.Operation
+	SetRequestor(r *http.Request)
 
 	DeferTemplateApply(trigger TemplateTrigger) error
 
@@ -149,6 +149,10 @@ func ValidConfig(sysOS *sys.OS, config map[string]string, expanded bool, instanc
 		return fmt.Errorf("nvidia.runtime is incompatible with privileged containers")
 	}
 
+	if expanded && shared.IsTrue(config["security.rootfs.readonly"]) && config["security.rootfs.readonly.overlay"] == "volume" && config["security.rootfs.readonly.overlay.volume"] == "" {
+		return fmt.Errorf("security.rootfs.readonly.overlay.volume must be set when security.rootfs.readonly.overlay is volume")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/lxd/scriptlet"
+	"github.com/canonical/lxd/lxd/state"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/osarch"
+)
+
+// instanceAdmission runs the instance admission scriptlet on a request to create or update an instance.
+// The request is modified in place by the scriptlet, and an error is returned if the scriptlet rejects it.
+func instanceAdmission(s *state.State, r *http.Request, req *apiScriptlet.InstanceAdmission) error {
+	req.Requestor = *request.CreateRequestor(r)
+
+	err := scriptlet.InstanceAdmissionRun(r.Context(), logger.Log, req)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusForbidden) {
+			return err
+		}
+
+		return fmt.Errorf("Failed instance admission scriptlet: %w", err)
+	}
+
+	return nil
+}
+
+// instanceUpdateAdmission runs the instance admission scriptlet (if set) on a request to update an instance.
+func instanceUpdateAdmission(s *state.State, r *http.Request, inst instance.Instance, req *api.InstancePut) error {
+	if s.GlobalConfig.InstancesAdmissionScriptlet() == "" {
+		return nil
+	}
+
+	admission := apiScriptlet.InstanceAdmission{
+		InstancePut: *req,
+		Action:      apiScriptlet.InstanceAdmissionActionUpdate,
+		Project:     inst.Project().Name,
+		Name:        inst.Name(),
+		Type:        inst.Type().String(),
+	}
+
+	err := instanceAdmission(s, r, &admission)
+	if err != nil {
+		return err
+	}
+
+	*req = admission.InstancePut
+
+	return nil
+}
+
+// instanceRenameAdmission runs the instance admission scriptlet (if set) on a request to rename an instance.
+// Returns the new name of the instance, which the scriptlet may have changed.
+func instanceRenameAdmission(s *state.State, r *http.Request, inst instance.Instance, newName string) (string, error) {
+	if s.GlobalConfig.InstancesAdmissionScriptlet() == "" {
+		return newName, nil
+	}
+
+	admission := apiScriptlet.InstanceAdmission{
+		InstancePut: instanceAdmissionPut(inst),
+		Action:      apiScriptlet.InstanceAdmissionActionRename,
+		Project:     inst.Project().Name,
+		Name:        newName,
+		Type:        inst.Type().String(),
+	}
+
+	err := instanceAdmission(s, r, &admission)
+	if err != nil {
+		return "", err
+	}
+
+	return admission.Name, nil
+}
+
+// instanceRestoreAdmission runs the instance admission scriptlet (if set) on a request to restore an instance
+// from a snapshot, with the configuration the instance gets from the snapshot.
+func instanceRestoreAdmission(s *state.State, r *http.Request, inst instance.Instance, snapName string) error {
+	if s.GlobalConfig.InstancesAdmissionScriptlet() == "" {
+		return nil
+	}
+
+	if !shared.IsSnapshot(snapName) {
+		snapName = inst.Name() + shared.SnapshotDelimiter + snapName
+	}
+
+	snap, err := instance.LoadByProjectAndName(s, inst.Project().Name, snapName)
+	if err != nil {
+		return err
+	}
+
+	admission := apiScriptlet.InstanceAdmission{
+		InstancePut: instanceAdmissionPut(snap),
+		Action:      apiScriptlet.InstanceAdmissionActionRestore,
+		Project:     inst.Project().Name,
+		Name:        inst.Name(),
+		Type:        inst.Type().String(),
+	}
+
+	return instanceAdmission(s, r, &admission)
+}
+
+// instanceImportAdmission runs the instance admission scriptlet (if set) on a request to import an instance
+// from a backup, with the configuration stored in the backup.
+// Returns the name of the imported instance, which the scriptlet may have changed.
+func instanceImportAdmission(s *state.State, r *http.Request, projectName string, name string, inst *api.Instance) (string, error) {
+	if s.GlobalConfig.InstancesAdmissionScriptlet() == "" || inst == nil {
+		return name, nil
+	}
+
+	admission := apiScriptlet.InstanceAdmission{
+		InstancePut: inst.InstancePut,
+		Action:      apiScriptlet.InstanceAdmissionActionImport,
+		Project:     projectName,
+		Name:        name,
+		Type:        inst.Type,
+	}
+
+	err := instanceAdmission(s, r, &admission)
+	if err != nil {
+		return "", err
+	}
+
+	return admission.Name, nil
+}
+
+// instanceAdmissionPut returns a copy of the local configuration, devices and profiles of the instance to
+// pass to the instance admission scriptlet.
+func instanceAdmissionPut(inst instance.Instance) api.InstancePut {
+	config := make(map[string]string, len(inst.LocalConfig()))
+	for k, v := range inst.LocalConfig() {
+		config[k] = v
+	}
+
+	profiles := make([]string, 0, len(inst.Profiles()))
+	for _, profile := range inst.Profiles() {
+		profiles = append(profiles, profile.Name)
+	}
+
+	architecture, _ := osarch.ArchitectureName(inst.Architecture())
+
+	return api.InstancePut{
+		Architecture: architecture,
+		Config:       config,
+		Devices:      inst.LocalDevices().CloneNative(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     profiles,
+		Description:  inst.Description(),
+	}
+}
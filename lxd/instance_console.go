@@ -23,7 +23,9 @@ import (
 	"github.com/canonical/lxd/lxd/instance"
 	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/operations"
+	"github.com/canonical/lxd/lxd/project"
 	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/state"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/logger"
@@ -61,6 +63,157 @@ type consoleWs struct {
 
 	// channel type (either console or vga)
 	protocol string
+
+	// daemon state
+	s *state.State
+
+	// whether the client can only view the console
+	readOnly bool
+}
+
+// consoleBufferSize is the amount of recent console output replayed to attaching clients.
+const consoleBufferSize = 64 * 1024
+
+// consoleHubs holds the shared consoles keyed by project and instance name.
+// A hub is removed once its last client detached, which happens at the latest when the instance stops.
+var consoleHubs = map[string]*consoleHub{}
+var consoleHubsLock sync.Mutex
+
+// consoleHub shares the console of an instance between all the clients attached to it.
+type consoleHub struct {
+	mu sync.Mutex
+
+	// recent and live output of the console
+	output *streamBroadcaster
+
+	// key of the hub in consoleHubs
+	key string
+
+	// console handle and its disconnect channel, nil when no client is attached
+	console      *os.File
+	disconnectCh chan error
+
+	// closed when the current console handle is done
+	closedCh chan struct{}
+
+	// number of attached clients
+	clients int
+}
+
+// consoleHubAttach attaches a client to the shared console of the instance, getting the console
+// from the instance if needed. The returned channel is closed when the console is done.
+func consoleHubAttach(inst instance.Instance) (*consoleHub, chan struct{}, error) {
+	key := project.Instance(inst.Project().Name, inst.Name())
+
+	// Count the client in while holding the hubs lock so that the hub can't be removed in the meantime.
+	consoleHubsLock.Lock()
+	hub, found := consoleHubs[key]
+	if !found {
+		hub = &consoleHub{key: key, output: newStreamBroadcaster(consoleBufferSize)}
+		consoleHubs[key] = hub
+	}
+
+	hub.mu.Lock()
+	hub.clients++
+	hub.mu.Unlock()
+
+	consoleHubsLock.Unlock()
+
+	hub.mu.Lock()
+	if hub.console == nil {
+		console, disconnectCh, err := inst.Console(instance.ConsoleTypeConsole)
+		if err != nil {
+			hub.mu.Unlock()
+			hub.detach()
+
+			return nil, nil, err
+		}
+
+		hub.console = console
+		hub.disconnectCh = disconnectCh
+		hub.closedCh = make(chan struct{})
+
+		go hub.read(console, hub.closedCh)
+	}
+
+	closedCh := hub.closedCh
+	hub.mu.Unlock()
+
+	return hub, closedCh, nil
+}
+
+// read records the output of the console until it is done.
+func (h *consoleHub) read(console *os.File, closedCh chan struct{}) {
+	_, _ = io.Copy(h.output, console)
+
+	h.mu.Lock()
+	if h.console == console {
+		h.close()
+	}
+
+	h.mu.Unlock()
+
+	close(closedCh)
+}
+
+// close disconnects from the console. It must be called with the lock held.
+func (h *consoleHub) close() {
+	close(h.disconnectCh)
+
+	// Write a reset escape sequence to the console to cancel any ongoing reads to the handle
+	// and then close it.
+	_, _ = h.console.Write([]byte("\x1bc"))
+	_ = h.console.Close()
+
+	h.console = nil
+	h.disconnectCh = nil
+}
+
+// detach detaches a client. When it was the last one, it disconnects from the console and removes the hub
+// along with the recent output of the console.
+func (h *consoleHub) detach() {
+	consoleHubsLock.Lock()
+	defer consoleHubsLock.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.clients--
+	if h.clients > 0 {
+		return
+	}
+
+	if h.console != nil {
+		h.close()
+	}
+
+	if consoleHubs[h.key] == h {
+		delete(consoleHubs, h.key)
+	}
+}
+
+// Write writes input to the console.
+func (h *consoleHub) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.console == nil {
+		return 0, os.ErrClosed
+	}
+
+	return h.console.Write(p)
+}
+
+// setSize sets the size of the console.
+func (h *consoleHub) setSize(width int, height int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.console == nil {
+		return os.ErrClosed
+	}
+
+	return shared.SetSize(int(h.console.Fd()), width, height)
 }
 
 func (s *consoleWs) Metadata() any {
@@ -204,17 +357,24 @@ func (s *consoleWs) doConsole(op *operations.Operation) error {
 	defer logger.Debug("Console websocket finished")
 	<-s.allConnected
 
-	// Get console from instance.
-	console, consoleDisconnectCh, err := s.instance.Console(s.protocol)
+	rec, err := sessionRecordingStart(s.instance, op, s.width, s.height, nil)
+	if err != nil {
+		return err
+	}
+
+	defer sessionRecordingEnd(s.s, s.instance, op, rec, sessionTypeConsole, nil)
+
+	// Attach to the console of the instance, shared with any other client.
+	hub, consoleClosedCh, err := consoleHubAttach(s.instance)
 	if err != nil {
 		return err
 	}
 
-	defer func() { _ = console.Close() }()
+	defer hub.detach()
 
-	// Detect size of window and set it into console.
-	if s.width > 0 && s.height > 0 {
-		_ = shared.SetSize(int(console.Fd()), s.width, s.height)
+	// Detect size of window and set it into console, unless the client is read-only.
+	if !s.readOnly && s.width > 0 && s.height > 0 {
+		_ = hub.setSize(s.width, s.height)
 	}
 
 	consoleDoneCh := make(chan struct{})
@@ -245,6 +405,12 @@ func (s *consoleWs) doConsole(op *operations.Operation) error {
 				break
 			}
 
+			// Read-only clients can't send any control message to the shared console.
+			if s.readOnly {
+				logger.Debug("Ignoring control message from read-only console client")
+				continue
+			}
+
 			command := api.InstanceConsoleControl{}
 
 			err = json.Unmarshal(buf, &command)
@@ -266,68 +432,95 @@ func (s *consoleWs) doConsole(op *operations.Operation) error {
 					continue
 				}
 
-				err = shared.SetSize(int(console.Fd()), winchWidth, winchHeight)
+				err = hub.setSize(winchWidth, winchHeight)
 				if err != nil {
 					logger.Debugf("Failed to set window size to: %dx%d", winchWidth, winchHeight)
 					continue
 				}
 
 				logger.Debugf("Set window size to: %dx%d", winchWidth, winchHeight)
+				rec.Resize(winchWidth, winchHeight)
 			}
 		}
 	}()
 
-	// Mirror the console and websocket.
+	s.connsLock.Lock()
+	consoleConn := s.conns[0]
+	ctrlConn := s.conns[-1]
+	s.connsLock.Unlock()
+
+	l := logger.AddContext(logger.Ctx{"address": consoleConn.RemoteAddr().String(), "readOnly": s.readOnly})
+	l.Debug("Started mirroring websocket")
+	defer l.Debug("Finished mirroring websocket to console")
+
+	// Forward input from the websocket to the console, unless read-only.
 	mirrorDoneCh := make(chan struct{})
 	go func() {
-		s.connsLock.Lock()
-		conn := s.conns[0]
-		s.connsLock.Unlock()
+		defer close(mirrorDoneCh)
+
+		for {
+			_, r, err := consoleConn.NextReader()
+			if err != nil {
+				return
+			}
+
+			buf, err := io.ReadAll(r)
+			if err != nil {
+				return
+			}
 
-		l := logger.AddContext(logger.Ctx{"address": conn.RemoteAddr().String()})
-		defer l.Debug("Finished mirroring websocket to console")
+			if s.readOnly {
+				continue
+			}
 
-		l.Debug("Started mirroring websocket")
-		readDone, writeDone := ws.Mirror(context.Background(), conn, console)
+			rec.Input(buf)
 
-		<-readDone
-		l.Debug("Finished mirroring console to websocket")
-		<-writeDone
-		close(mirrorDoneCh)
+			_, err = hub.Write(buf)
+			if err != nil {
+				l.Debug("Failed writing to console", logger.Ctx{"err": err})
+			}
+		}
 	}()
 
-	// Wait until either the console or the websocket is done.
-	select {
-	case <-mirrorDoneCh:
-		close(consoleDisconnectCh)
-	case <-consoleDoneCh:
-		close(consoleDisconnectCh)
+	// Send the recent output of the console followed by its live output to the websocket,
+	// until either the console or the websocket is done.
+	history, output := hub.output.Subscribe()
+	defer hub.output.Unsubscribe(output)
+
+	if len(history) > 0 {
+		rec.Output(history)
+		_ = consoleConn.WriteMessage(websocket.BinaryMessage, history)
 	}
 
-	// Get the console and control websockets.
-	s.connsLock.Lock()
-	consoleConn := s.conns[0]
-	ctrlConn := s.conns[-1]
-	s.connsLock.Unlock()
+	for done := false; !done; {
+		select {
+		case buf, ok := <-output:
+			if !ok {
+				done = true
+				break
+			}
 
-	defer func() {
-		_ = consoleConn.WriteMessage(websocket.BinaryMessage, []byte("\n\r"))
-		_ = consoleConn.Close()
-		_ = ctrlConn.Close()
-	}()
+			rec.Output(buf)
 
-	// Write a reset escape sequence to the console to cancel any ongoing reads to the handle
-	// and then close it. This ordering is important, close the console before closing the
-	// websocket to ensure console doesn't get stuck reading.
-	_, err = console.Write([]byte("\x1bc"))
-	if err != nil {
-		_ = console.Close()
-		return err
+			err := consoleConn.WriteMessage(websocket.BinaryMessage, buf)
+			if err != nil {
+				done = true
+			}
+
+		case <-mirrorDoneCh:
+			done = true
+		case <-consoleDoneCh:
+			done = true
+		case <-consoleClosedCh:
+			done = true
+		}
 	}
 
-	err = console.Close()
-	if err != nil {
-		return err
+	_ = consoleConn.WriteMessage(websocket.BinaryMessage, []byte("\n\r"))
+	_ = consoleConn.Close()
+
+	if ctrlConn != nil {
+		_ = ctrlConn.Close()
 	}
 
 	// Indicate to the control socket go routine to end if not already.
@@ -381,6 +574,25 @@ func (s *consoleWs) doVGA(op *operations.Operation) error {
 	return err
 }
 
+// allowInstanceConsole checks access to the console of an instance.
+// Attaching to the console requires the operate-containers permission, while read-only viewers only need the
+// view-console permission. The handler checks which of the two the request needs.
+func allowInstanceConsole(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	// Shortcut for speed
+	if s.Authorizer.UserIsAdmin(r) {
+		return response.EmptySyncResponse
+	}
+
+	projectName := projectParam(r)
+	if !s.Authorizer.UserHasPermission(r, projectName, "operate-containers") && !s.Authorizer.UserHasPermission(r, projectName, "view-console") {
+		return response.Forbidden(nil)
+	}
+
+	return response.EmptySyncResponse
+}
+
 // swagger:operation POST /1.0/instances/{name}/console instances instance_console_post
 //
 //	Connect to console
@@ -443,6 +655,11 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	// Read-only viewers only need the view-console permission checked by the access handler.
+	if !post.ReadOnly && !s.Authorizer.UserIsAdmin(r) && !s.Authorizer.UserHasPermission(r, projectName, "operate-containers") {
+		return response.Forbidden(nil)
+	}
+
 	// Forward the request if the container is remote.
 	client, err := cluster.ConnectIfInstanceIsRemote(s.DB.Cluster, projectName, name, s.Endpoints.NetworkCert(), s.ServerCert(), r, instanceType)
 	if err != nil {
@@ -482,6 +699,10 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("VGA console is only supported by virtual machines"))
 	}
 
+	if post.ReadOnly && post.Type != instance.ConsoleTypeConsole {
+		return response.BadRequest(fmt.Errorf("Read-only mode is only supported by the console type"))
+	}
+
 	if !inst.IsRunning() {
 		return response.BadRequest(fmt.Errorf("Instance is not running"))
 	}
@@ -509,6 +730,8 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 	ws.width = post.Width
 	ws.height = post.Height
 	ws.protocol = post.Type
+	ws.s = s
+	ws.readOnly = post.ReadOnly
 
 	resources := map[string][]api.URL{}
 	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", ws.instance.Name())}
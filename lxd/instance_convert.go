@@ -0,0 +1,636 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/lxd/apparmor"
+	"github.com/canonical/lxd/lxd/archive"
+	"github.com/canonical/lxd/lxd/backup"
+	"github.com/canonical/lxd/lxd/db"
+	deviceConfig "github.com/canonical/lxd/lxd/device/config"
+	"github.com/canonical/lxd/lxd/instance"
+	instanceDrivers "github.com/canonical/lxd/lxd/instance/drivers"
+	"github.com/canonical/lxd/lxd/instance/instancetype"
+	"github.com/canonical/lxd/lxd/instance/operationlock"
+	"github.com/canonical/lxd/lxd/operations"
+	"github.com/canonical/lxd/lxd/revert"
+	"github.com/canonical/lxd/lxd/rsync"
+	"github.com/canonical/lxd/lxd/state"
+	storagePools "github.com/canonical/lxd/lxd/storage"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/osarch"
+	"github.com/canonical/lxd/shared/units"
+)
+
+// instanceConvertESPSize is the size of the EFI system partition created when converting a container to a VM.
+const instanceConvertESPSize = 100 * 1024 * 1024
+
+// instanceConvertConfig translates the config and devices of an instance to the target instance type.
+// The check covers the config and devices inherited from profiles. Config keys and devices that aren't supported by
+// the target instance type are dropped from the instance (or masked when they come from a profile device) and
+// returned as a list of human readable descriptions so they can be reported to the user.
+// Config keys coming from a profile can't be dropped and are only reported.
+func instanceConvertConfig(s *state.State, p api.Project, targetType instancetype.Type, config map[string]string, devices deviceConfig.Devices, profiles []api.Profile) (map[string]string, deviceConfig.Devices, []string) {
+	newConfig := make(map[string]string, len(config))
+	for key, value := range config {
+		newConfig[key] = value
+	}
+
+	newDevices := devices.Clone()
+	incompatible := []string{}
+
+	for key, value := range db.ExpandInstanceConfig(config, profiles) {
+		// Volatile keys are internal state which is carried over as is.
+		if strings.HasPrefix(key, shared.ConfigVolatilePrefix) {
+			continue
+		}
+
+		checker, err := shared.ConfigKeyChecker(key, targetType)
+		if err == nil {
+			err = checker(value)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		_, isLocal := config[key]
+		if !isLocal {
+			incompatible = append(incompatible, fmt.Sprintf("config %q (from profile, not dropped): %v", key, err))
+			continue
+		}
+
+		incompatible = append(incompatible, fmt.Sprintf("config %q: %v", key, err))
+		delete(newConfig, key)
+	}
+
+	for name, dev := range db.ExpandInstanceDevices(devices.Clone(), profiles) {
+		err := instance.ValidDevices(s, p, targetType, deviceConfig.Devices{name: dev}, nil)
+		if err == nil {
+			continue
+		}
+
+		_, isLocal := devices[name]
+		if !isLocal {
+			incompatible = append(incompatible, fmt.Sprintf("device %q (from profile, masked): %v", name, errors.Unwrap(err)))
+			newDevices[name] = deviceConfig.Device{"type": "none"}
+			continue
+		}
+
+		incompatible = append(incompatible, fmt.Sprintf("device %q: %v", name, errors.Unwrap(err)))
+		delete(newDevices, name)
+	}
+
+	sort.Strings(incompatible)
+
+	return newConfig, newDevices, incompatible
+}
+
+// instanceCreateAsConversion creates a new instance of a different type than the source instance by converting
+// its root filesystem. Snapshots aren't converted.
+func instanceCreateAsConversion(s *state.State, opts instanceCreateAsCopyOpts, op *operations.Operation) (instance.Instance, error) {
+	revert := revert.New()
+	defer revert.Fail()
+
+	var convert func(srcPath string, targetPath string, targetSize int64) error
+	switch opts.targetInstance.Type {
+	case instancetype.VM:
+		// The bootloader installed on the converted disk isn't signed.
+		if shared.IsTrue(opts.targetInstance.Config["security.secureboot"]) {
+			return nil, fmt.Errorf("Converted virtual machines don't support secure boot, unset %q", "security.secureboot")
+		}
+
+		if opts.targetInstance.Config == nil {
+			opts.targetInstance.Config = map[string]string{}
+		}
+
+		opts.targetInstance.Config["security.secureboot"] = "false"
+
+		convert = func(srcPath string, targetPath string, targetSize int64) error {
+			return instanceConvertRootfsToDisk(opts.sourceInstance, srcPath, targetPath, targetSize)
+		}
+
+	case instancetype.Container:
+		convert = func(srcPath string, targetPath string, targetSize int64) error {
+			return instanceConvertDiskToRootfs(s, srcPath, targetPath)
+		}
+
+	default:
+		return nil, fmt.Errorf("Unsupported instance type %q", opts.targetInstance.Type)
+	}
+
+	// Keep the source instance from being started, modified or deleted for the whole conversion.
+	if !opts.sourceInstance.IsSnapshot() {
+		srcOp, err := operationlock.Create(opts.sourceInstance.Project().Name, opts.sourceInstance.Name(), operationlock.ActionConvert, false, false)
+		if err != nil {
+			return nil, fmt.Errorf("Failed locking source instance: %w", err)
+		}
+
+		defer srcOp.Done(nil)
+	}
+
+	// Create the instance record.
+	inst, instOp, cleanup, err := instance.CreateInternal(s, opts.targetInstance, true)
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating instance record: %w", err)
+	}
+
+	revert.Add(cleanup)
+	defer instOp.Done(err)
+
+	pool, err := storagePools.LoadByInstance(s, inst)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading instance storage pool: %w", err)
+	}
+
+	err = pool.CreateInstanceFromConversion(inst, opts.sourceInstance, convert, op)
+	if err != nil {
+		return nil, fmt.Errorf("Create instance from conversion: %w", err)
+	}
+
+	revert.Add(func() { _ = inst.Delete(true) })
+
+	err = inst.UpdateBackupFile()
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Success()
+	return inst, nil
+}
+
+// instanceConvertRootfsToDisk builds a bootable disk at diskPath from the container root filesystem at rootfsPath.
+// The disk gets an EFI system partition and an ext4 root partition and the lxd-agent units are injected.
+// The root filesystem is never executed: the bootloader is a standalone GRUB image built with the host's GRUB
+// tooling, which boots the kernel found in the container's /boot.
+func instanceConvertRootfsToDisk(src instance.Instance, rootfsPath string, diskPath string, diskSize int64) error {
+	var grubTarget string
+	var efiBinary string
+	switch src.Architecture() {
+	case osarch.ARCH_64BIT_INTEL_X86:
+		grubTarget = "x86_64-efi"
+		efiBinary = "BOOTX64.EFI"
+	case osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN:
+		grubTarget = "arm64-efi"
+		efiBinary = "BOOTAA64.EFI"
+	default:
+		return fmt.Errorf("Converting instances of this architecture to virtual machines isn't supported")
+	}
+
+	// Check the root filesystem can boot before doing any work.
+	kernel, initrd, err := instanceConvertFindKernel(rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	for _, tool := range []string{"sgdisk", "losetup", "mkfs.vfat", "mkfs.ext4", "grub-mkstandalone"} {
+		_, err := exec.LookPath(tool)
+		if err != nil {
+			return fmt.Errorf("Required tool %q is missing", tool)
+		}
+	}
+
+	if !shared.PathExists(filepath.Join("/usr/lib/grub", grubTarget)) {
+		return fmt.Errorf("The GRUB %q modules are missing on the host", grubTarget)
+	}
+
+	// Check the root filesystem fits on the new disk.
+	var usedSize int64
+	err = filepath.WalkDir(rootfsPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		usedSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed calculating root filesystem size: %w", err)
+	}
+
+	// Leave some room for filesystem metadata.
+	minSize := usedSize + usedSize/10 + instanceConvertESPSize
+	if diskSize < minSize {
+		return fmt.Errorf("Root disk is too small for the converted root filesystem (needs at least %s), increase the root disk size", units.GetByteSizeStringIEC(minSize, 2))
+	}
+
+	// File backed disks may not exist yet.
+	if !shared.IsBlockdevPath(diskPath) {
+		f, err := os.OpenFile(diskPath, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+
+		err = f.Truncate(diskSize)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Partition the disk.
+	_, err = shared.RunCommand("sgdisk", "--zap-all", diskPath)
+	if err != nil {
+		return fmt.Errorf("Failed wiping partition table: %w", err)
+	}
+
+	_, err = shared.RunCommand("sgdisk",
+		"-n", fmt.Sprintf("1:0:+%dK", instanceConvertESPSize/1024), "-t", "1:ef00", "-c", "1:UEFI",
+		"-n", "2:0:0", "-t", "2:8300", "-c", "2:rootfs",
+		diskPath)
+	if err != nil {
+		return fmt.Errorf("Failed partitioning disk: %w", err)
+	}
+
+	loopDev, err := instanceConvertLoopSetup(diskPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _, _ = shared.RunCommand("losetup", "--detach", loopDev) }()
+
+	espDev := loopDev + "p1"
+	rootDev := loopDev + "p2"
+	rootUUID := uuid.New()
+
+	_, err = shared.RunCommand("mkfs.vfat", "-F", "32", "-n", "UEFI", espDev)
+	if err != nil {
+		return fmt.Errorf("Failed formatting EFI system partition: %w", err)
+	}
+
+	_, err = shared.RunCommand("mkfs.ext4", "-q", "-L", "rootfs", "-U", rootUUID, rootDev)
+	if err != nil {
+		return fmt.Errorf("Failed formatting root partition: %w", err)
+	}
+
+	cleanup := revert.New()
+	defer cleanup.Fail()
+
+	// Both file systems were just created, so only their content comes from the container.
+	mountPath, err := os.MkdirTemp("", "lxd_convert_")
+	if err != nil {
+		return err
+	}
+
+	cleanup.Add(func() { _ = os.Remove(mountPath) })
+
+	err = unix.Mount(rootDev, mountPath, "ext4", 0, "")
+	if err != nil {
+		return fmt.Errorf("Failed mounting root partition: %w", err)
+	}
+
+	cleanup.Add(func() { _ = unix.Unmount(mountPath, unix.MNT_DETACH) })
+
+	espPath, err := os.MkdirTemp("", "lxd_convert_esp_")
+	if err != nil {
+		return err
+	}
+
+	cleanup.Add(func() { _ = os.Remove(espPath) })
+
+	err = unix.Mount(espDev, espPath, "vfat", 0, "umask=0077")
+	if err != nil {
+		return fmt.Errorf("Failed mounting EFI system partition: %w", err)
+	}
+
+	cleanup.Add(func() { _ = unix.Unmount(espPath, unix.MNT_DETACH) })
+
+	// Copy the root filesystem.
+	_, err = rsync.LocalCopy(rootfsPath, mountPath, "", true)
+	if err != nil {
+		return fmt.Errorf("Failed copying root filesystem: %w", err)
+	}
+
+	// Unshift the copy if the container's root filesystem is shifted on disk.
+	ct, ok := src.(instance.Container)
+	if ok {
+		diskIdmap, err := ct.DiskIdmap()
+		if err != nil {
+			return err
+		}
+
+		if diskIdmap != nil {
+			err = diskIdmap.UnshiftRootfs(mountPath, nil)
+			if err != nil {
+				return fmt.Errorf("Failed unshifting root filesystem: %w", err)
+			}
+		}
+	}
+
+	// The copy is guest controlled, so all paths are resolved within it.
+	err = util.MkdirAllInRoot(mountPath, "boot/efi", 0755)
+	if err != nil {
+		return err
+	}
+
+	err = util.WriteFileInRoot(mountPath, "etc/fstab", []byte(fmt.Sprintf(`# Generated by LXD when converting the instance to a virtual machine.
+UUID=%s	/	ext4	defaults	0	1
+LABEL=UEFI	/boot/efi	vfat	umask=0077	0	1
+`, rootUUID)), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = instanceDrivers.InstallAgentFiles(mountPath)
+	if err != nil {
+		return fmt.Errorf("Failed installing lxd-agent: %w", err)
+	}
+
+	// Build the bootloader on the host, with its configuration embedded.
+	grubConfig := fmt.Sprintf(`insmod part_gpt
+insmod ext2
+search --no-floppy --fs-uuid --set=root %s
+linux /boot/%s root=UUID=%s ro console=tty1 console=ttyS0
+`, rootUUID, kernel, rootUUID)
+
+	if initrd != "" {
+		grubConfig += fmt.Sprintf("initrd /boot/%s\n", initrd)
+	}
+
+	grubConfig += "boot\n"
+
+	grubConfigFile, err := os.CreateTemp("", "lxd_convert_grub_")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(grubConfigFile.Name()) }()
+
+	_, err = grubConfigFile.WriteString(grubConfig)
+	_ = grubConfigFile.Close()
+	if err != nil {
+		return err
+	}
+
+	efiPath := filepath.Join(espPath, "EFI", "BOOT")
+	err = os.MkdirAll(efiPath, 0700)
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("grub-mkstandalone", "--format="+grubTarget, "--output="+filepath.Join(efiPath, efiBinary), "boot/grub/grub.cfg="+grubConfigFile.Name())
+	if err != nil {
+		return fmt.Errorf("Failed installing bootloader: %w", err)
+	}
+
+	// Unmount everything in reverse order before detaching the loop device.
+	cleanup.Fail()
+	cleanup.Success()
+
+	return nil
+}
+
+// instanceConvertFindKernel returns the names of the kernel and initrd (if any) to boot from the /boot directory
+// of the root filesystem at rootfsPath. The distribution's symlinks to the latest kernel are preferred, so that
+// kernel updates are picked up.
+func instanceConvertFindKernel(rootfsPath string) (string, string, error) {
+	entries, err := util.ReadDirInRoot(rootfsPath, "boot")
+	if err != nil && !errors.Is(err, unix.ENOENT) {
+		return "", "", fmt.Errorf("Failed listing the instance's /boot: %w", err)
+	}
+
+	names := make(map[string]bool, len(entries))
+	kernels := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+
+		// The names end up in the bootloader configuration.
+		if strings.ContainsAny(name, " \t\n\"'\\$;") {
+			continue
+		}
+
+		names[name] = true
+		if strings.HasPrefix(name, "vmlinuz-") || strings.HasPrefix(name, "vmlinux-") {
+			kernels = append(kernels, name)
+		}
+	}
+
+	if names["vmlinuz"] {
+		if names["initrd.img"] {
+			return "vmlinuz", "initrd.img", nil
+		}
+
+		return "vmlinuz", "", nil
+	}
+
+	if len(kernels) == 0 {
+		return "", "", fmt.Errorf("No kernel found in the instance's /boot, install one before converting to a virtual machine")
+	}
+
+	sort.Strings(kernels)
+	kernel := kernels[len(kernels)-1]
+	version := kernel[len("vmlinuz-"):]
+
+	for _, initrd := range []string{"initrd.img-" + version, "initramfs-" + version + ".img", "initrd-" + version} {
+		if names[initrd] {
+			return kernel, initrd, nil
+		}
+	}
+
+	return kernel, "", nil
+}
+
+// instanceConvertPartition is a partition of a virtual machine disk as reported by sfdisk.
+type instanceConvertPartition struct {
+	Start int64  `json:"start"`
+	Size  int64  `json:"size"`
+	Type  string `json:"type"`
+}
+
+// instanceConvertSkippedPartitionTypes are the partition types which never hold a root filesystem.
+var instanceConvertSkippedPartitionTypes = []string{
+	"C12A7328-F81F-11D2-BA4B-00A0C93EC93B", // EFI system partition.
+	"21686148-6449-6E6F-744E-656564454649", // BIOS boot partition.
+	"0657FD6D-A4AB-43C4-84E5-0933C84B4F4F", // Linux swap.
+	"EF", "82",                             // Their MBR counterparts.
+}
+
+// instanceConvertDiskToRootfs extracts the root filesystem of the virtual machine disk at diskPath into the
+// container root filesystem at rootfsPath.
+// The disk is never mounted. Each candidate partition is copied out with qemu-img and its content extracted with
+// debugfs, both confined by AppArmor like when unpacking images. Only ext2/3/4 root filesystems are supported.
+func instanceConvertDiskToRootfs(s *state.State, diskPath string, rootfsPath string) error {
+	for _, tool := range []string{"sfdisk", "qemu-img", "debugfs"} {
+		_, err := exec.LookPath(tool)
+		if err != nil {
+			return fmt.Errorf("Required tool %q is missing", tool)
+		}
+	}
+
+	out, err := shared.RunCommand("sfdisk", "--json", diskPath)
+	if err != nil {
+		return fmt.Errorf("Failed reading partition table: %w", err)
+	}
+
+	table := struct {
+		PartitionTable struct {
+			SectorSize int64                      `json:"sectorsize"`
+			Partitions []instanceConvertPartition `json:"partitions"`
+		} `json:"partitiontable"`
+	}{}
+
+	err = json.Unmarshal([]byte(out), &table)
+	if err != nil {
+		return fmt.Errorf("Failed parsing partition table: %w", err)
+	}
+
+	sectorSize := table.PartitionTable.SectorSize
+	if sectorSize <= 0 {
+		sectorSize = 512
+	}
+
+	// Try the largest partitions first.
+	candidates := []instanceConvertPartition{}
+	for _, partition := range table.PartitionTable.Partitions {
+		if !shared.StringInSlice(strings.ToUpper(partition.Type), instanceConvertSkippedPartitionTypes) {
+			candidates = append(candidates, partition)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Size > candidates[j].Size })
+
+	err = os.MkdirAll(rootfsPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		err = instanceConvertExtractPartition(s, diskPath, candidate.Start*sectorSize, candidate.Size*sectorSize, rootfsPath)
+		if err != nil {
+			logger.Debug("Skipping partition for conversion", logger.Ctx{"disk": diskPath, "start": candidate.Start, "err": err})
+			continue
+		}
+
+		_, errEtc := util.ReadFileInRoot(rootfsPath, "etc/os-release")
+		_, errUsr := util.ReadFileInRoot(rootfsPath, "usr/lib/os-release")
+		if errEtc == nil || errUsr == nil {
+			return instanceConvertDisableFstab(rootfsPath)
+		}
+
+		// Not a root filesystem, clear what was extracted.
+		entries, err := os.ReadDir(rootfsPath)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			err = os.RemoveAll(filepath.Join(rootfsPath, entry.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("Couldn't find an ext4 root filesystem on the instance's disk")
+}
+
+// instanceConvertExtractPartition copies the partition at offset of the disk at diskPath out with qemu-img and
+// extracts the content of its file system into rootfsPath with debugfs. The extracted tree is left unshifted and
+// gets shifted on first start like a new container.
+func instanceConvertExtractPartition(s *state.State, diskPath string, offset int64, size int64, rootfsPath string) error {
+	imgFile, err := os.CreateTemp(shared.VarPath("backups"), fmt.Sprintf("%s_convert_", backup.WorkingDirPrefix))
+	if err != nil {
+		return err
+	}
+
+	imgPath := imgFile.Name()
+	_ = imgFile.Close()
+	defer func() { _ = os.Remove(imgPath) }()
+
+	fileDriver := "file"
+	if shared.IsBlockdevPath(diskPath) {
+		fileDriver = "host_device"
+	}
+
+	cmd := []string{
+		"nice", "-n19", // Run with low priority to reduce CPU impact on other processes.
+		"qemu-img", "convert",
+		"--image-opts", fmt.Sprintf("driver=raw,offset=%d,size=%d,file.driver=%s,file.filename=%s", offset, size, fileDriver, strings.ReplaceAll(diskPath, ",", ",,")),
+		"-O", "raw", imgPath,
+	}
+
+	_, err = apparmor.QemuImg(s.OS, cmd, diskPath, imgPath)
+	if err != nil {
+		return fmt.Errorf("Failed copying partition: %w", err)
+	}
+
+	outputDir, err := os.OpenFile(rootfsPath, os.O_RDONLY, 0)
+	if err != nil {
+		return fmt.Errorf("Error opening directory: %w", err)
+	}
+
+	defer func() { _ = outputDir.Close() }()
+
+	err = archive.ExtractWithFds("debugfs", []string{"-R", "rdump / " + rootfsPath, imgPath}, nil, nil, s.OS, outputDir)
+	if err != nil {
+		return fmt.Errorf("Failed extracting file system: %w", err)
+	}
+
+	return nil
+}
+
+// instanceConvertDisableFstab comments out the entries of /etc/fstab in the root filesystem at rootfsPath, as disk
+// mounts make no sense in a container.
+func instanceConvertDisableFstab(rootfsPath string) error {
+	fstab, err := util.ReadFileInRoot(rootfsPath, "etc/fstab")
+	if err != nil && !errors.Is(err, unix.ENOENT) {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Entries disabled by LXD when converting the instance to a container.\n")
+	for _, line := range strings.Split(strings.TrimSpace(string(fstab)), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			sb.WriteString(line + "\n")
+			continue
+		}
+
+		sb.WriteString("# " + line + "\n")
+	}
+
+	return util.WriteFileInRoot(rootfsPath, "etc/fstab", []byte(sb.String()), 0644)
+}
+
+// instanceConvertLoopSetup attaches the disk at diskPath to a loop device with partition scanning enabled and
+// waits for the partitions to show up.
+func instanceConvertLoopSetup(diskPath string) (string, error) {
+	out, err := shared.RunCommand("losetup", "--find", "--show", "--partscan", diskPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed setting up loop device for %q: %w", diskPath, err)
+	}
+
+	loopDev := strings.TrimSpace(out)
+
+	for i := 0; i < 50; i++ {
+		partitions, _ := filepath.Glob(loopDev + "p*")
+		if len(partitions) > 0 {
+			break
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	logger.Debug("Attached disk for conversion", logger.Ctx{"disk": diskPath, "dev": loopDev})
+
+	return loopDev, nil
+}
@@ -84,7 +84,14 @@ func instanceDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	rmct := func(op *operations.Operation) error {
-		return inst.Delete(false)
+		err := inst.Delete(false)
+		if err != nil {
+			return err
+		}
+
+		execSessionsRemoveInstance(projectName, name)
+
+		return nil
 	}
 
 	resources := map[string][]api.URL{}
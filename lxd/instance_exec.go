@@ -25,6 +25,7 @@ import (
 	"github.com/canonical/lxd/lxd/instance"
 	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/operations"
+	"github.com/canonical/lxd/lxd/recording"
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/lxd/lxd/state"
 	"github.com/canonical/lxd/shared"
@@ -173,7 +174,11 @@ func (s *execWs) Do(op *operations.Operation) error {
 		return fmt.Errorf("Timed out waiting for websockets to connect")
 	}
 
-	var err error
+	rec, err := sessionRecordingStart(s.instance, op, s.req.Width, s.req.Height, s.req.Command)
+	if err != nil {
+		return err
+	}
+
 	var ttys []*os.File
 	var ptys []*os.File
 
@@ -187,40 +192,14 @@ func (s *execWs) Do(op *operations.Operation) error {
 			ttys = make([]*os.File, 1)
 			ptys = make([]*os.File, 1)
 
-			var rootUID, rootGID int64
-			var devptsFd *os.File
-
-			c := s.instance.(instance.Container)
-			idmapset, err := c.CurrentIdmap()
+			ptys[0], ttys[0], err = execOpenPty(s.s, s.instance, s.req.Width, s.req.Height)
 			if err != nil {
 				return err
 			}
 
-			if idmapset != nil {
-				rootUID, rootGID = idmapset.ShiftIntoNs(0, 0)
-			}
-
-			devptsFd, _ = c.DevptsFd()
-
-			if devptsFd != nil && s.s.OS.NativeTerminals {
-				ptys[0], ttys[0], err = shared.OpenPtyInDevpts(int(devptsFd.Fd()), rootUID, rootGID)
-				_ = devptsFd.Close()
-				devptsFd = nil
-			} else {
-				ptys[0], ttys[0], err = shared.OpenPty(rootUID, rootGID)
-			}
-
-			if err != nil {
-				return fmt.Errorf("Unable to open the PTY device: %w", err)
-			}
-
 			stdin = ttys[0]
 			stdout = ttys[0]
 			stderr = ttys[0]
-
-			if s.req.Width > 0 && s.req.Height > 0 {
-				_ = shared.SetSize(int(ptys[0].Fd()), s.req.Width, s.req.Height)
-			}
 		} else {
 			// For VMs we rely on the lxd-agent PTY running inside the VM guest.
 			ttys = make([]*os.File, 2)
@@ -281,6 +260,8 @@ func (s *execWs) Do(op *operations.Operation) error {
 			_ = pty.Close()
 		}
 
+		sessionRecordingEnd(s.s, s.instance, op, rec, sessionTypeExec, s.req.Command)
+
 		metadata := shared.Jmap{"return": cmdResult}
 		err = op.ExtendMetadata(metadata)
 		if err != nil {
@@ -386,6 +367,8 @@ func (s *execWs) Do(op *operations.Operation) error {
 					l.Debug("Failed to set window size", logger.Ctx{"err": err, "width": winchWidth, "height": winchHeight})
 					continue
 				}
+
+				rec.Resize(winchWidth, winchHeight)
 			} else if command.Command == "signal" {
 				err := cmd.Signal(unix.Signal(command.Signal))
 				if err != nil {
@@ -413,10 +396,10 @@ func (s *execWs) Do(op *operations.Operation) error {
 			if s.instance.Type() == instancetype.Container {
 				// For containers, we are running the command via the local LXD managed PTY and so
 				// need to use the same PTY handle for both read and write.
-				readDone, writeDone = ws.Mirror(context.Background(), conn, ptys[0])
+				readDone, writeDone = ws.Mirror(context.Background(), conn, rec.ReadWriteCloser(ptys[0]))
 			} else {
-				readDone = ws.MirrorRead(context.Background(), conn, ptys[execWSStdout])
-				writeDone = ws.MirrorWrite(context.Background(), conn, ttys[execWSStdin])
+				readDone = ws.MirrorRead(context.Background(), conn, rec.Reader(ptys[execWSStdout]))
+				writeDone = ws.MirrorWrite(context.Background(), conn, rec.Writer(ttys[execWSStdin]))
 			}
 
 			<-readDone
@@ -458,10 +441,10 @@ func (s *execWs) Do(op *operations.Operation) error {
 				}
 
 				if i == execWSStdin {
-					<-ws.MirrorWrite(context.Background(), conn, ttys[i])
+					<-ws.MirrorWrite(context.Background(), conn, rec.Writer(ttys[i]))
 					_ = ttys[i].Close()
 				} else {
-					<-ws.MirrorRead(context.Background(), conn, ptys[i])
+					<-ws.MirrorRead(context.Background(), conn, rec.Reader(ptys[i]))
 					_ = ptys[i].Close()
 					wgEOF.Done()
 				}
@@ -475,6 +458,66 @@ func (s *execWs) Do(op *operations.Operation) error {
 	return finisher(exitStatus, err)
 }
 
+// execRecordOutput returns a pipe for the output of a command whose content is recorded in the session recording
+// and copied to file if not nil. The returned channel is closed once the pipe was closed and its content copied.
+func execRecordOutput(rec *recording.Recorder, file *os.File) (*os.File, chan struct{}, error) {
+	read, write, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { _ = read.Close() }()
+
+		var w io.Writer = rec
+		if file != nil {
+			w = io.MultiWriter(file, rec)
+		}
+
+		_, _ = io.Copy(w, read)
+	}()
+
+	return write, done, nil
+}
+
+// execOpenPty allocates a PTY on the LXD server for running an interactive command in a container.
+// It returns the PTY master and the terminal to pass to the command.
+func execOpenPty(s *state.State, inst instance.Instance, width int, height int) (*os.File, *os.File, error) {
+	var rootUID, rootGID int64
+
+	c := inst.(instance.Container)
+	idmapset, err := c.CurrentIdmap()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if idmapset != nil {
+		rootUID, rootGID = idmapset.ShiftIntoNs(0, 0)
+	}
+
+	var pty, tty *os.File
+
+	devptsFd, _ := c.DevptsFd()
+	if devptsFd != nil && s.OS.NativeTerminals {
+		pty, tty, err = shared.OpenPtyInDevpts(int(devptsFd.Fd()), rootUID, rootGID)
+		_ = devptsFd.Close()
+	} else {
+		pty, tty, err = shared.OpenPty(rootUID, rootGID)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to open the PTY device: %w", err)
+	}
+
+	if width > 0 && height > 0 {
+		_ = shared.SetSize(int(pty.Fd()), width, height)
+	}
+
+	return pty, tty, nil
+}
+
 // swagger:operation POST /1.0/instances/{name}/exec instances instance_exec_post
 //
 //	Run a command
@@ -637,6 +680,14 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		post.Environment["LANG"] = "C.UTF-8"
 	}
 
+	if post.Detached {
+		if post.WaitForWS || post.RecordOutput {
+			return response.BadRequest(fmt.Errorf("Detached sessions cannot be combined with wait-for-websocket or record-output"))
+		}
+
+		return instanceExecSessionCreate(s, r, inst, post)
+	}
+
 	if post.WaitForWS {
 		ws := &execWs{}
 		ws.s = d.State()
@@ -714,9 +765,52 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 			}
 		}
 
+		rec, err := sessionRecordingStart(inst, op, post.Width, post.Height, post.Command)
+		if err != nil {
+			return err
+		}
+
+		defer sessionRecordingEnd(s, inst, op, rec, sessionTypeExec, post.Command)
+
+		// Pass the output through the session recording, if any.
+		cmdStdout, cmdStderr := stdout, stderr
+		var outputDone []chan struct{}
+		if rec != nil {
+			var done chan struct{}
+			cmdStdout, done, err = execRecordOutput(rec, stdout)
+			if err != nil {
+				return err
+			}
+
+			outputDone = append(outputDone, done)
+
+			cmdStderr, done, err = execRecordOutput(rec, stderr)
+			if err != nil {
+				_ = cmdStdout.Close()
+				return err
+			}
+
+			outputDone = append(outputDone, done)
+		}
+
+		// Close the command side of the recorded output and wait for it to be fully recorded.
+		waitOutput := func() {
+			if rec == nil {
+				return
+			}
+
+			_ = cmdStdout.Close()
+			_ = cmdStderr.Close()
+
+			for _, done := range outputDone {
+				<-done
+			}
+		}
+
 		// Run the command.
-		cmd, err := inst.Exec(post, nil, stdout, stderr)
+		cmd, err := inst.Exec(post, nil, cmdStdout, cmdStderr)
 		if err != nil {
+			waitOutput()
 			return err
 		}
 
@@ -726,6 +820,8 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		exitStatus, cmdErr := cmd.Wait()
 		l.Debug("Instance process stopped", logger.Ctx{"err": cmdErr, "exitStatus": exitStatus})
 
+		waitOutput()
+
 		metadata["return"] = exitStatus
 		err = op.ExtendMetadata(metadata)
 		if err != nil {
@@ -0,0 +1,1177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/lxd/cluster"
+	"github.com/canonical/lxd/lxd/db/operationtype"
+	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/instance/instancetype"
+	"github.com/canonical/lxd/lxd/operations"
+	"github.com/canonical/lxd/lxd/recording"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/state"
+	"github.com/canonical/lxd/lxd/task"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/cancel"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/version"
+	"github.com/canonical/lxd/shared/ws"
+)
+
+// execSessionBufferSize is the amount of recent output kept in memory and replayed to attaching clients.
+const execSessionBufferSize = 64 * 1024
+
+// Detached exec session states.
+const (
+	execSessionStatusRunning = "Running"
+	execSessionStatusExited  = "Exited"
+)
+
+// execSessionExpiry is how long exited sessions are kept before being removed along with their output log.
+const execSessionExpiry = 24 * time.Hour
+
+// execSessions holds the detached exec sessions of this member keyed by session ID.
+// Sessions are kept in memory only and are lost when LXD restarts.
+var execSessions = map[string]*execSession{}
+var execSessionsLock sync.Mutex
+
+// streamBroadcaster fans out a stream of output to any number of subscribers.
+// It keeps a bounded history of the most recent output which is replayed to new subscribers.
+type streamBroadcaster struct {
+	mu          sync.Mutex
+	size        int
+	history     []byte
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+// newStreamBroadcaster returns a new streamBroadcaster keeping up to size bytes of history.
+func newStreamBroadcaster(size int) *streamBroadcaster {
+	return &streamBroadcaster{
+		size:        size,
+		subscribers: map[chan []byte]struct{}{},
+	}
+}
+
+// Write records p in the history and sends it to all subscribers.
+// Subscribers which aren't keeping up are disconnected rather than blocking the writer.
+func (b *streamBroadcaster) Write(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	copy(buf, p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.history = append(b.history, buf...)
+	if len(b.history) > 2*b.size {
+		b.history = append([]byte(nil), b.history[len(b.history)-b.size:]...)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- buf:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return len(p), nil
+}
+
+// Subscribe returns the buffered history and a channel receiving any further output.
+// The channel is closed when the broadcaster is closed or when the subscriber falls behind.
+func (b *streamBroadcaster) Subscribe() ([]byte, chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	history := b.history
+	if len(history) > b.size {
+		history = history[len(history)-b.size:]
+	}
+
+	ch := make(chan []byte, 128)
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+
+	return append([]byte(nil), history...), ch
+}
+
+// Unsubscribe stops sending output to ch.
+func (b *streamBroadcaster) Unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, found := b.subscribers[ch]
+	if found {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Close disconnects all subscribers. The history remains available to later subscribers.
+func (b *streamBroadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		close(ch)
+	}
+
+	b.subscribers = map[chan []byte]struct{}{}
+	b.closed = true
+}
+
+// execSession represents a command running in an instance independently of any client connection.
+type execSession struct {
+	id        string
+	project   string
+	instance  instance.Instance
+	req       api.InstanceExecPost
+	createdAt time.Time
+	logPath   string
+
+	// Command output, replayed to attaching clients.
+	output *streamBroadcaster
+
+	mu sync.Mutex
+
+	// Running command, nil until started.
+	cmd instance.Cmd
+
+	// Input of the command, nil for non-interactive sessions.
+	stdin io.WriteCloser
+
+	// File passed to Cmd.WindowResize, nil for non-interactive sessions.
+	pty *os.File
+
+	// Session recording, nil when recording is disabled.
+	rec *recording.Recorder
+
+	exitCode int
+	exitedAt time.Time
+	done     chan struct{}
+}
+
+// outputURL returns the URL of the session output log.
+func (e *execSession) outputURL() string {
+	return fmt.Sprintf("/%s/instances/%s/logs/exec-output/%s", version.APIVersion, e.instance.Name(), filepath.Base(e.logPath))
+}
+
+// running returns whether the command is still running.
+func (e *execSession) running() bool {
+	select {
+	case <-e.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// render returns the API representation of the session.
+func (e *execSession) render() api.InstanceExecSession {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	session := api.InstanceExecSession{
+		ID:          e.id,
+		Command:     e.req.Command,
+		Interactive: e.req.Interactive,
+		Status:      execSessionStatusRunning,
+		ExitCode:    -1,
+		CreatedAt:   e.createdAt,
+		Output:      e.outputURL(),
+	}
+
+	if !e.running() {
+		session.Status = execSessionStatusExited
+		session.ExitCode = e.exitCode
+	}
+
+	return session
+}
+
+// command returns the running command along with its input and terminal files.
+// The command is nil if it hasn't started yet or has already exited.
+func (e *execSession) command() (instance.Cmd, io.WriteCloser, *os.File) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cmd == nil || !e.running() {
+		return nil, nil, nil
+	}
+
+	return e.cmd, e.stdin, e.pty
+}
+
+// signal sends a signal to the command if it is running.
+func (e *execSession) signal(sig unix.Signal) error {
+	cmd, _, _ := e.command()
+	if cmd == nil {
+		return nil
+	}
+
+	return cmd.Signal(sig)
+}
+
+// resize sets the terminal size of interactive commands.
+func (e *execSession) resize(width int, height int) error {
+	cmd, _, pty := e.command()
+	if cmd == nil || pty == nil {
+		return nil
+	}
+
+	err := cmd.WindowResize(int(pty.Fd()), width, height)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.rec.Resize(width, height)
+	e.mu.Unlock()
+
+	return nil
+}
+
+// run starts the command, records its output and waits for it to exit.
+func (e *execSession) run(s *state.State, op *operations.Operation) error {
+	// Mark the session as ended however this function returns.
+	defer func() {
+		e.mu.Lock()
+		e.exitedAt = time.Now()
+		e.mu.Unlock()
+
+		close(e.done)
+		e.output.Close()
+	}()
+
+	// Ensure exec-output directory exists.
+	err := os.Mkdir(e.instance.ExecOutputPath(), 0600)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	logFile, err := os.OpenFile(e.logPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = logFile.Close() }()
+
+	rec, err := sessionRecordingStart(e.instance, op, e.req.Width, e.req.Height, e.req.Command)
+	if err != nil {
+		return err
+	}
+
+	defer sessionRecordingEnd(s, e.instance, op, rec, sessionTypeExec, e.req.Command)
+
+	// The files passed to the command and the files LXD uses to talk to it.
+	var stdin, stdout, stderr *os.File
+	var output, input, pty *os.File
+	var cmdFiles, lxdFiles []*os.File
+
+	defer func() {
+		for _, f := range append(cmdFiles, lxdFiles...) {
+			_ = f.Close()
+		}
+	}()
+
+	if e.req.Interactive && e.instance.Type() == instancetype.Container {
+		ptyFile, tty, err := execOpenPty(s, e.instance, e.req.Width, e.req.Height)
+		if err != nil {
+			return err
+		}
+
+		cmdFiles = append(cmdFiles, tty)
+		lxdFiles = append(lxdFiles, ptyFile)
+
+		stdin = tty
+		stdout = tty
+		stderr = tty
+		output = ptyFile
+		input = ptyFile
+		pty = ptyFile
+	} else {
+		outputRead, outputWrite, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+
+		cmdFiles = append(cmdFiles, outputWrite)
+		lxdFiles = append(lxdFiles, outputRead)
+
+		stdout = outputWrite
+		output = outputRead
+
+		if e.req.Interactive {
+			// For VMs we rely on the lxd-agent PTY running inside the VM guest.
+			inputRead, inputWrite, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+
+			cmdFiles = append(cmdFiles, inputRead)
+			lxdFiles = append(lxdFiles, inputWrite)
+
+			stdin = inputRead
+			input = inputWrite
+			pty = inputRead
+		} else {
+			// Non-interactive sessions get no input and both output streams are recorded together.
+			stderr = outputWrite
+		}
+	}
+
+	// Detaching is handled by LXD so the instance driver runs a regular command.
+	req := e.req
+	req.Detached = false
+
+	cmd, err := e.instance.Exec(req, stdin, stdout, stderr)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.cmd = cmd
+	e.pty = pty
+	e.rec = rec
+	if input != nil {
+		e.stdin = rec.Writer(input)
+	}
+
+	e.mu.Unlock()
+
+	l := logger.AddContext(logger.Ctx{"project": e.project, "instance": e.instance.Name(), "PID": cmd.PID(), "session": e.id})
+	l.Debug("Detached instance process started")
+
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+
+		_, err := io.Copy(io.MultiWriter(logFile, e.output, rec), output)
+		if err != nil && !errors.Is(err, unix.EIO) && !errors.Is(err, os.ErrClosed) {
+			l.Debug("Failed recording exec session output", logger.Ctx{"err": err})
+		}
+	}()
+
+	exitStatus, cmdErr := cmd.Wait()
+	l.Debug("Detached instance process stopped", logger.Ctx{"err": cmdErr, "exitStatus": exitStatus})
+
+	// Close the command side of the files so that the output recording reaches the end.
+	for _, f := range cmdFiles {
+		_ = f.Close()
+	}
+
+	<-outputDone
+
+	e.mu.Lock()
+	e.exitCode = exitStatus
+	e.mu.Unlock()
+
+	err = op.ExtendMetadata(shared.Jmap{"return": exitStatus})
+	if err != nil {
+		l.Error("Error updating metadata for cmd", logger.Ctx{"err": err, "cmd": e.req.Command})
+	}
+
+	return cmdErr
+}
+
+// instanceExecSessionCreate starts a detached exec session in the instance.
+// The ID of the session is the ID of the returned operation, which ends when the command exits.
+func instanceExecSessionCreate(s *state.State, r *http.Request, inst instance.Instance, post api.InstanceExecPost) response.Response {
+	session := &execSession{
+		project:   inst.Project().Name,
+		instance:  inst,
+		req:       post,
+		createdAt: time.Now().UTC(),
+		output:    newStreamBroadcaster(execSessionBufferSize),
+		exitCode:  -1,
+		done:      make(chan struct{}),
+	}
+
+	run := func(op *operations.Operation) error {
+		return session.run(s, op)
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", inst.Name())}
+
+	if inst.Type() == instancetype.Container {
+		resources["containers"] = resources["instances"]
+	}
+
+	op, err := operations.OperationCreate(s, session.project, operations.OperationClassTask, operationtype.CommandExec, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	session.id = op.ID()
+	session.logPath = filepath.Join(inst.ExecOutputPath(), fmt.Sprintf("exec_%s.log", op.ID()))
+
+	err = op.UpdateMetadata(shared.Jmap{
+		"command":     post.Command,
+		"interactive": post.Interactive,
+		"output":      session.outputURL(),
+	})
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	execSessionsLock.Lock()
+	execSessions[session.id] = session
+	execSessionsLock.Unlock()
+
+	return operations.OperationResponse(op)
+}
+
+// execSessionLoad returns the session of the request, or the response to send instead.
+// When forward is true, requests for instances on other cluster members are forwarded there.
+func execSessionLoad(s *state.State, r *http.Request, forward bool) (*execSession, response.Response) {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return nil, response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	if forward {
+		// Handle requests targeted to an instance on a different node.
+		resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+		if err != nil {
+			return nil, response.SmartError(err)
+		}
+
+		if resp != nil {
+			return nil, resp
+		}
+	}
+
+	id, err := url.PathUnescape(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	execSessionsLock.Lock()
+	session, found := execSessions[id]
+	execSessionsLock.Unlock()
+
+	if !found || session.project != projectName || session.instance.Name() != name {
+		return nil, response.NotFound(fmt.Errorf("Exec session not found"))
+	}
+
+	return session, nil
+}
+
+// swagger:operation GET /1.0/instances/{name}/exec-sessions instances instance_exec-sessions_get
+//
+//	Get the detached exec sessions
+//
+//	Returns a list of detached exec sessions (URLs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of endpoints
+//	          items:
+//	            type: string
+//	          example: |-
+//	            [
+//	              "/1.0/instances/foo/exec-sessions/6916c8a6-9b7d-4abd-90b3-aedfec7ec7da"
+//	            ]
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/instances/{name}/exec-sessions?recursion=1 instances instance_exec-sessions_get_recursion1
+//
+//	Get the detached exec sessions
+//
+//	Returns a list of detached exec sessions (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of exec sessions
+//	          items:
+//	            $ref: "#/definitions/InstanceExecSession"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceExecSessionsGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	_, err = instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	sessions := []api.InstanceExecSession{}
+
+	execSessionsLock.Lock()
+	for _, session := range execSessions {
+		if session.project != projectName || session.instance.Name() != name {
+			continue
+		}
+
+		sessions = append(sessions, session.render())
+	}
+
+	execSessionsLock.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	if util.IsRecursionRequest(r) {
+		return response.SyncResponse(true, sessions)
+	}
+
+	urls := []string{}
+	for _, session := range sessions {
+		urls = append(urls, api.NewURL().Path(version.APIVersion, "instances", name, "exec-sessions", session.ID).String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation GET /1.0/instances/{name}/exec-sessions/{id} instances instance_exec-session_get
+//
+//	Get the detached exec session
+//
+//	Gets the status of a detached exec session.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: Exec session
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/InstanceExecSession"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceExecSessionGet(d *Daemon, r *http.Request) response.Response {
+	session, resp := execSessionLoad(d.State(), r, true)
+	if resp != nil {
+		return resp
+	}
+
+	return response.SyncResponse(true, session.render())
+}
+
+// swagger:operation DELETE /1.0/instances/{name}/exec-sessions/{id} instances instance_exec-session_delete
+//
+//	Delete the detached exec session
+//
+//	Kills the command if it is still running and removes the session and its output log.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceExecSessionDelete(d *Daemon, r *http.Request) response.Response {
+	session, resp := execSessionLoad(d.State(), r, true)
+	if resp != nil {
+		return resp
+	}
+
+	if session.running() {
+		err := session.signal(unix.SIGKILL)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		select {
+		case <-session.done:
+		case <-time.After(10 * time.Second):
+			return response.InternalError(fmt.Errorf("Timed out waiting for the command to exit"))
+		}
+	}
+
+	err := execSessionRemove(session)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// execSessionRemove removes an exited session along with its output log.
+func execSessionRemove(session *execSession) error {
+	execSessionsLock.Lock()
+	delete(execSessions, session.id)
+	execSessionsLock.Unlock()
+
+	err := os.Remove(session.logPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// execSessionsRemoveInstance forgets the sessions of an instance that is deleted or renamed, killing any command
+// still running. Their output logs are left to the instance log directory.
+func execSessionsRemoveInstance(projectName string, instanceName string) {
+	execSessionsLock.Lock()
+	defer execSessionsLock.Unlock()
+
+	for id, session := range execSessions {
+		if session.project != projectName || session.instance.Name() != instanceName {
+			continue
+		}
+
+		err := session.signal(unix.SIGKILL)
+		if err != nil {
+			logger.Warn("Failed killing exec session command", logger.Ctx{"project": projectName, "instance": instanceName, "session": id, "err": err})
+		}
+
+		delete(execSessions, id)
+	}
+}
+
+// pruneExitedExecSessions removes the sessions which exited more than execSessionExpiry ago.
+func pruneExitedExecSessions() {
+	execSessionsLock.Lock()
+	sessions := make([]*execSession, 0, len(execSessions))
+	for _, session := range execSessions {
+		sessions = append(sessions, session)
+	}
+
+	execSessionsLock.Unlock()
+
+	for _, session := range sessions {
+		if session.running() {
+			continue
+		}
+
+		session.mu.Lock()
+		exitedAt := session.exitedAt
+		session.mu.Unlock()
+
+		if time.Since(exitedAt) < execSessionExpiry {
+			continue
+		}
+
+		err := execSessionRemove(session)
+		if err != nil {
+			logger.Warn("Failed removing expired exec session", logger.Ctx{"project": session.project, "instance": session.instance.Name(), "session": session.id, "err": err})
+		}
+	}
+}
+
+func pruneExitedExecSessionsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		pruneExitedExecSessions()
+	}
+
+	return f, task.Hourly()
+}
+
+// execSessionAttachWs bridges a client to a detached exec session.
+type execSessionAttachWs struct {
+	session *execSession
+	req     api.InstanceExecSessionAttachPost
+
+	conns                map[int]*websocket.Conn
+	connsLock            sync.Mutex
+	waitDataConnected    *cancel.Canceller
+	waitControlConnected *cancel.Canceller
+	fds                  map[int]string
+}
+
+func (s *execSessionAttachWs) Metadata() any {
+	fds := shared.Jmap{}
+	for fd, secret := range s.fds {
+		if fd == execWSControl {
+			fds[api.SecretNameControl] = secret
+		} else {
+			fds[strconv.Itoa(fd)] = secret
+		}
+	}
+
+	return shared.Jmap{
+		"fds":     fds,
+		"session": s.session.id,
+	}
+}
+
+func (s *execSessionAttachWs) Connect(op *operations.Operation, r *http.Request, w http.ResponseWriter) error {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	for fd, fdSecret := range s.fds {
+		if secret != fdSecret {
+			continue
+		}
+
+		s.connsLock.Lock()
+		defer s.connsLock.Unlock()
+
+		if s.conns[fd] != nil {
+			return fmt.Errorf("Websocket number already connected")
+		}
+
+		conn, err := ws.Upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return err
+		}
+
+		s.conns[fd] = conn
+
+		if fd == execWSControl {
+			s.waitControlConnected.Cancel()
+		} else {
+			s.waitDataConnected.Cancel()
+		}
+
+		return nil
+	}
+
+	/* If we didn't find the right secret, the user provided a bad one,
+	 * which 403, not 404, since this operation actually exists */
+	return os.ErrPermission
+}
+
+func (s *execSessionAttachWs) Do(op *operations.Operation) error {
+	// Once this function ends ensure that any connected websockets are closed.
+	defer func() {
+		s.connsLock.Lock()
+		for i := range s.conns {
+			if s.conns[i] != nil {
+				_ = s.conns[i].Close()
+			}
+		}
+
+		s.connsLock.Unlock()
+	}()
+
+	select {
+	case <-s.waitDataConnected.Done():
+		break
+	case <-time.After(time.Second * 5):
+		return fmt.Errorf("Timed out waiting for websockets to connect")
+	}
+
+	s.connsLock.Lock()
+	conn := s.conns[0]
+	s.connsLock.Unlock()
+
+	e := s.session
+	l := logger.AddContext(logger.Ctx{"project": e.project, "instance": e.instance.Name(), "session": e.id})
+	l.Debug("Attached to exec session")
+	defer l.Debug("Detached from exec session")
+
+	history, output := e.output.Subscribe()
+	defer e.output.Unsubscribe(output)
+
+	ctx, detach := context.WithCancel(context.Background())
+	defer detach()
+
+	if s.req.Width > 0 && s.req.Height > 0 {
+		_ = e.resize(s.req.Width, s.req.Height)
+	}
+
+	// Forward input from the client, detaching when the connection goes away.
+	go func() {
+		defer detach()
+
+		for {
+			_, r, err := conn.NextReader()
+			if err != nil {
+				return
+			}
+
+			_, stdin, _ := e.command()
+			if stdin == nil {
+				_, _ = io.Copy(io.Discard, r)
+				continue
+			}
+
+			_, err = io.Copy(stdin, r)
+			if err != nil {
+				l.Debug("Failed forwarding input to exec session", logger.Ctx{"err": err})
+			}
+		}
+	}()
+
+	// Handle the control connection, detaching when it is closed.
+	go func() {
+		select {
+		case <-s.waitControlConnected.Done():
+		case <-ctx.Done():
+			return
+		}
+
+		defer detach()
+
+		s.connsLock.Lock()
+		conn := s.conns[execWSControl]
+		s.connsLock.Unlock()
+
+		for {
+			_, r, err := conn.NextReader()
+			if err != nil {
+				return
+			}
+
+			buf, err := io.ReadAll(r)
+			if err != nil {
+				return
+			}
+
+			command := api.InstanceExecControl{}
+
+			err = json.Unmarshal(buf, &command)
+			if err != nil {
+				l.Debug("Failed to unmarshal control socket command", logger.Ctx{"err": err})
+				continue
+			}
+
+			if command.Command == "window-resize" {
+				winchWidth, err := strconv.Atoi(command.Args["width"])
+				if err != nil {
+					l.Debug("Unable to extract window width", logger.Ctx{"err": err})
+					continue
+				}
+
+				winchHeight, err := strconv.Atoi(command.Args["height"])
+				if err != nil {
+					l.Debug("Unable to extract window height", logger.Ctx{"err": err})
+					continue
+				}
+
+				err = e.resize(winchWidth, winchHeight)
+				if err != nil {
+					l.Debug("Failed to set window size", logger.Ctx{"err": err, "width": winchWidth, "height": winchHeight})
+					continue
+				}
+			} else if command.Command == "signal" {
+				err := e.signal(unix.Signal(command.Signal))
+				if err != nil {
+					l.Debug("Failed forwarding signal", logger.Ctx{"err": err, "signal": command.Signal})
+					continue
+				}
+			}
+		}
+	}()
+
+	// Replay the buffered output and then follow the live output until detached or the command exits.
+	if len(history) > 0 {
+		err := conn.WriteMessage(websocket.BinaryMessage, history)
+		if err != nil {
+			return nil
+		}
+	}
+
+	for {
+		select {
+		case buf, ok := <-output:
+			if !ok {
+				// Either the command exited or the client fell behind.
+				if !e.running() {
+					e.mu.Lock()
+					exitCode := e.exitCode
+					e.mu.Unlock()
+
+					return op.ExtendMetadata(shared.Jmap{"return": exitCode})
+				}
+
+				return nil
+			}
+
+			err := conn.WriteMessage(websocket.BinaryMessage, buf)
+			if err != nil {
+				return nil
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// swagger:operation POST /1.0/instances/{name}/exec-sessions/{id}/attach instances instance_exec-session_attach_post
+//
+//	Attach to a detached exec session
+//
+//	Attaches to a detached exec session.
+//
+//	The returned operation metadata will contain two websockets.
+//	The "0" websocket first receives the buffered output of the session followed by its live output.
+//	For interactive sessions, data sent on it is passed to the command input.
+//
+//	The "control" websocket can be used to send signals and window sizing information.
+//	Closing either websocket detaches from the session without affecting the command.
+//	If the command exits while attached, its exit code is set in the "return" field of the operation metadata.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: attach
+//	    description: Attach request
+//	    schema:
+//	      $ref: "#/definitions/InstanceExecSessionAttachPost"
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceExecSessionAttachPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	id, err := url.PathUnescape(mux.Vars(r)["id"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	post := api.InstanceExecSessionAttachPost{}
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if len(buf) > 0 {
+		err = json.Unmarshal(buf, &post)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	// Forward the request if the instance is remote.
+	client, err := cluster.ConnectIfInstanceIsRemote(s.DB.Cluster, projectName, name, s.Endpoints.NetworkCert(), s.ServerCert(), r, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if client != nil {
+		url := api.NewURL().Path(version.APIVersion, "instances", name, "exec-sessions", id, "attach").Project(projectName)
+		resp, _, err := client.RawQuery("POST", url.String(), post, "")
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		opAPI, err := resp.MetadataAsOperation()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return operations.ForwardedOperationResponse(projectName, opAPI)
+	}
+
+	session, resp := execSessionLoad(s, r, false)
+	if resp != nil {
+		return resp
+	}
+
+	ws := &execSessionAttachWs{}
+	ws.session = session
+	ws.req = post
+	ws.fds = map[int]string{}
+	ws.conns = map[int]*websocket.Conn{}
+	ws.conns[execWSControl] = nil
+	ws.conns[0] = nil
+	ws.waitDataConnected = cancel.New(context.Background())
+	ws.waitControlConnected = cancel.New(context.Background())
+
+	for i := range ws.conns {
+		ws.fds[i], err = shared.RandomCryptoString()
+		if err != nil {
+			return response.InternalError(err)
+		}
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", session.instance.Name())}
+
+	if session.instance.Type() == instancetype.Container {
+		resources["containers"] = resources["instances"]
+	}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassWebsocket, operationtype.CommandExec, resources, ws.Metadata(), ws.Do, nil, ws.Connect, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -13,15 +14,21 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/pkg/sftp"
 
+	"github.com/canonical/lxd/lxd/cluster"
 	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/lifecycle"
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/lxd/lxd/revert"
 	"github.com/canonical/lxd/lxd/state"
 	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/tarstream"
+	"github.com/canonical/lxd/shared/ws"
 )
 
 func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
@@ -43,6 +50,11 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// File watches are websockets which need to be proxied rather than forwarded.
+	if r.Method == "GET" && shared.IsTrue(r.FormValue("watch")) {
+		return instanceFileWatch(s, r, projectName, name, instanceType)
+	}
+
 	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
 	if err != nil {
 		return response.SmartError(err)
@@ -87,11 +99,15 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //	Get a file
 //
 //	Gets the file content. If it's a directory, a json list of files will be returned instead.
+//	If the X-LXD-type header is set to "tar", the directory is returned recursively as a tar stream.
+//	If the watch parameter is set, the request is upgraded to a websocket on which a JSON message is
+//	sent for every file created, modified or deleted below the path.
 //
 //	---
 //	produces:
 //	  - application/json
 //	  - application/octet-stream
+//	  - application/x-tar
 //	parameters:
 //	  - in: query
 //	    name: path
@@ -99,11 +115,24 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //	    type: string
 //	    example: default
 //	  - in: query
+//	    name: watch
+//	    description: Whether to watch the path for changes
+//	    type: boolean
+//	    example: true
+//	  - in: query
 //	    name: project
 //	    description: Project name
 //	    type: string
 //	    example: default
+//	  - in: header
+//	    name: X-LXD-type
+//	    description: Set to "tar" to retrieve a directory as a tar stream
+//	    schema:
+//	      type: string
+//	    example: tar
 //	responses:
+//	  "101":
+//	    description: Switching protocols to websocket
 //	  "200":
 //	     description: Raw file or directory listing
 //	     headers:
@@ -151,6 +180,10 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //	  "500":
 //	    $ref: "#/responses/InternalServerError"
 func instanceFileGet(s *state.State, inst instance.Instance, path string, r *http.Request) response.Response {
+	if r.Header.Get("X-LXD-type") == "tar" {
+		return instanceFileArchiveGet(s, inst, path, r)
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -367,10 +400,12 @@ func instanceFileHead(s *state.State, inst instance.Instance, path string, r *ht
 //	Create or replace a file
 //
 //	Creates a new file in the instance.
+//	If the X-LXD-type header is set to "tar", the body is a tar stream extracted into the directory at path.
 //
 //	---
 //	consumes:
 //	  - application/octet-stream
+//	  - application/x-tar
 //	produces:
 //	  - application/json
 //	parameters:
@@ -407,7 +442,7 @@ func instanceFileHead(s *state.State, inst instance.Instance, path string, r *ht
 //	    example: 0644
 //	  - in: header
 //	    name: X-LXD-type
-//	    description: Type of file (file, symlink or directory)
+//	    description: Type of file (file, symlink, directory or tar)
 //	    schema:
 //	      type: string
 //	    example: file
@@ -429,6 +464,10 @@ func instanceFileHead(s *state.State, inst instance.Instance, path string, r *ht
 //	  "500":
 //	    $ref: "#/responses/InternalServerError"
 func instanceFilePost(s *state.State, inst instance.Instance, path string, r *http.Request) response.Response {
+	if r.Header.Get("X-LXD-type") == "tar" {
+		return instanceFileArchivePost(s, inst, path, r)
+	}
+
 	// Get a SFTP client.
 	client, err := inst.FileSFTP()
 	if err != nil {
@@ -554,6 +593,140 @@ func instanceFilePost(s *state.State, inst instance.Instance, path string, r *ht
 	}
 }
 
+// instanceFileArchiveGet streams the directory tree at path as a tar stream.
+func instanceFileArchiveGet(s *state.State, inst instance.Instance, path string, r *http.Request) response.Response {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Get a connection to the file server.
+	conn, err := inst.FileSFTPConn()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	revert.Add(func() { _ = conn.Close() })
+
+	reader, err := tarstream.Pull(conn, path)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	cleanup := revert.Clone()
+	revert.Success()
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		defer cleanup.Fail()
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("X-LXD-type", "tar")
+		w.WriteHeader(http.StatusOK)
+
+		_, err := io.Copy(w, reader)
+		if err != nil {
+			return err
+		}
+
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceFileRetrieved.Event(inst, logger.Ctx{"path": path, "type": "tar"}))
+		return nil
+	})
+}
+
+// instanceFileArchivePost extracts the tar stream in the request body into the directory at path.
+func instanceFileArchivePost(s *state.State, inst instance.Instance, path string, r *http.Request) response.Response {
+	// Get a connection to the file server.
+	conn, err := inst.FileSFTPConn()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	err = tarstream.Push(conn, path, r.Body)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceFilePushed.Event(inst, logger.Ctx{"path": path, "type": "tar"}))
+	return response.EmptySyncResponse
+}
+
+// instanceFileWatch streams the changes below the path of the request over a websocket.
+func instanceFileWatch(s *state.State, r *http.Request, projectName string, name string, instanceType instancetype.Type) response.Response {
+	path := r.FormValue("path")
+	if path == "" {
+		return response.BadRequest(fmt.Errorf("Missing path argument"))
+	}
+
+	// Proxy the watch if the instance is remote.
+	client, err := cluster.ConnectIfInstanceIsRemote(s.DB.Cluster, projectName, name, s.Endpoints.NetworkCert(), s.ServerCert(), r, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if client != nil {
+		source, err := client.GetInstanceFileWatch(name, path)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			conn, err := ws.Upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				_ = source.Close()
+				return err
+			}
+
+			<-ws.Proxy(source, conn)
+			return nil
+		})
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		conn, err := ws.Upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		// Stop watching once the client goes away.
+		go func() {
+			for {
+				_, _, err := conn.NextReader()
+				if err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		err = inst.FileWatch(ctx, path, func(event api.InstanceFileEvent) {
+			err := conn.WriteJSON(event)
+			if err != nil {
+				cancel()
+			}
+		})
+		if err != nil {
+			logger.Warn("Failed watching instance files", logger.Ctx{"project": projectName, "instance": name, "path": path, "err": err})
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		}
+
+		return nil
+	})
+}
+
 // swagger:operation DELETE /1.0/instances/{name}/files instances instance_files_delete
 //
 //	Delete a file
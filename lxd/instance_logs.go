@@ -269,7 +269,7 @@ func instanceLogGet(d *Daemon, r *http.Request) response.Response {
 //
 //	Delete the log file
 //
-//	Removes the log file.
+//	Removes the log file or session recording.
 //
 //	---
 //	produces:
@@ -339,8 +339,8 @@ func instanceLogDelete(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Log file name %q not valid", file))
 	}
 
-	if !strings.HasSuffix(file, ".log") || file == "lxc.log" || file == "qemu.log" {
-		return response.BadRequest(fmt.Errorf("Only log files excluding qemu.log and lxc.log may be deleted"))
+	if !isSessionRecordingFileName(file) && (!strings.HasSuffix(file, ".log") || file == "lxc.log" || file == "qemu.log") {
+		return response.BadRequest(fmt.Errorf("Only session recordings and log files excluding qemu.log and lxc.log may be deleted"))
 	}
 
 	err = os.Remove(shared.LogPath(project.Instance(projectName, name), file))
@@ -688,10 +688,11 @@ func validLogFileName(fname string) bool {
 		fname == "qemu.log" ||
 		fname == "qemu.conf" ||
 		strings.HasPrefix(fname, "migration_") ||
+		strings.HasPrefix(fname, "session_") ||
 		strings.HasPrefix(fname, "snapshot_")
 }
 
 func validExecOutputFileName(fName string) bool {
-	return (strings.HasSuffix(fName, ".stdout") || strings.HasSuffix(fName, ".stderr")) &&
+	return (strings.HasSuffix(fName, ".stdout") || strings.HasSuffix(fName, ".stderr") || strings.HasSuffix(fName, ".log")) &&
 		strings.HasPrefix(fName, "exec_")
 }
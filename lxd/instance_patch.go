@@ -153,7 +153,10 @@ func instancePatch(d *Daemon, r *http.Request) response.Response {
 
 	// Check if config was passed
 	if req.Config == nil {
-		req.Config = c.LocalConfig()
+		req.Config = make(map[string]string, len(c.LocalConfig()))
+		for k, v := range c.LocalConfig() {
+			req.Config[k] = v
+		}
 	} else {
 		for k, v := range c.LocalConfig() {
 			_, ok := req.Config[k]
@@ -175,6 +178,11 @@ func instancePatch(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	err = instanceUpdateAdmission(s, r, c, &req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	// Check project limits.
 	apiProfiles := make([]api.Profile, 0, len(req.Profiles))
 	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
@@ -209,6 +217,9 @@ func instancePatch(d *Daemon, r *http.Request) response.Response {
 		Project:      projectName,
 	}
 
+	// Attribute the recorded configuration revision to the requestor.
+	c.SetRequestor(r)
+
 	err = c.Update(args, true)
 	if err != nil {
 		return response.SmartError(err)
@@ -315,6 +315,14 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		req.Name = inst.Name()
 	}
 
+	// Run the instance admission scriptlet on renames.
+	if req.Name != inst.Name() {
+		req.Name, err = instanceRenameAdmission(s, r, inst, req.Name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	// Check the new instance name is valid.
 	err = instance.ValidName(req.Name, false)
 	if err != nil {
@@ -440,7 +448,14 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	run := func(*operations.Operation) error {
-		return inst.Rename(req.Name, true)
+		err := inst.Rename(req.Name, true)
+		if err != nil {
+			return err
+		}
+
+		execSessionsRemoveInstance(projectName, name)
+
+		return nil
 	}
 
 	resources := map[string][]api.URL{}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/lifecycle"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// swagger:operation GET /1.0/instances/{name}/processes instances instance_processes_get
+//
+//	Get the processes
+//
+//	Gets the processes running inside the instance.
+//	For virtual machines, this requires the lxd-agent.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: Processes
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of processes
+//	          items:
+//	            $ref: "#/definitions/InstanceProcess"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceProcessesGet(d *Daemon, r *http.Request) response.Response {
+	inst, resp := instanceProcessesLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	processes, err := inst.Processes()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, processes)
+}
+
+// swagger:operation POST /1.0/instances/{name}/processes instances instance_processes_post
+//
+//	Send a signal to a process
+//
+//	Sends a signal to a process running inside the instance.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: signal
+//	    description: Process and signal
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceProcessesPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceProcessesPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	req := api.InstanceProcessesPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.PID <= 0 {
+		return response.BadRequest(fmt.Errorf("Invalid PID %d", req.PID))
+	}
+
+	signal, err := util.ParseSignal(req.Signal)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	inst, resp := instanceProcessesLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	err = inst.SignalProcess(req.PID, signal)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceProcessSignaled.Event(inst, logger.Ctx{"pid": req.PID, "signal": int(signal)}))
+
+	return response.EmptySyncResponse
+}
+
+// instanceProcessesLoad loads the running instance of the request, or returns the response to send instead.
+func instanceProcessesLoad(d *Daemon, r *http.Request) (instance.Instance, response.Response) {
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return nil, response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if resp != nil {
+		return nil, resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if !inst.IsRunning() {
+		return nil, response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	return inst, nil
+}
@@ -117,6 +117,11 @@ func instancePut(d *Daemon, r *http.Request) response.Response {
 	var do func(*operations.Operation) error
 	var opType operationtype.Type
 	if configRaw.Restore == "" {
+		err = instanceUpdateAdmission(s, r, inst, &configRaw)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
 		// Check project limits.
 		apiProfiles := make([]api.Profile, 0, len(configRaw.Profiles))
 		err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
@@ -152,6 +157,8 @@ func instancePut(d *Daemon, r *http.Request) response.Response {
 				Project:      projectName,
 			}
 
+			inst.SetOperation(op)
+
 			err = inst.Update(args, true)
 			if err != nil {
 				return err
@@ -162,6 +169,11 @@ func instancePut(d *Daemon, r *http.Request) response.Response {
 
 		opType = operationtype.InstanceUpdate
 	} else {
+		err = instanceRestoreAdmission(s, r, inst, configRaw.Restore)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
 		// Snapshot Restore
 		do = func(op *operations.Operation) error {
 			return instanceSnapRestore(s, projectName, name, configRaw.Restore, configRaw.Stateful)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/instance"
+	"github.com/canonical/lxd/lxd/lifecycle"
+	"github.com/canonical/lxd/lxd/operations"
+	"github.com/canonical/lxd/lxd/recording"
+	"github.com/canonical/lxd/lxd/state"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/units"
+)
+
+// sessionRecordingDefaultMaxSize is the default maximum size of a session recording.
+const sessionRecordingDefaultMaxSize = "100MiB"
+
+// sessionRecordingDefaultExpiry is the default number of days after which session recordings are deleted.
+const sessionRecordingDefaultExpiry = 30
+
+// Types of recorded sessions.
+const (
+	sessionTypeExec    = "exec"
+	sessionTypeConsole = "console"
+)
+
+// sessionRecordingEnabled returns whether the exec and console sessions of the instance are recorded.
+// Recording is enabled by either the instance or its project.
+func sessionRecordingEnabled(inst instance.Instance) bool {
+	return shared.IsTrue(inst.ExpandedConfig()["security.session_recording"]) || shared.IsTrue(inst.Project().Config["security.session_recording"])
+}
+
+// sessionRecordingFileName returns the name of the recording of a session in the instance log directory.
+func sessionRecordingFileName(sessionID string) string {
+	return fmt.Sprintf("session_%s.cast", sessionID)
+}
+
+// sessionRecordingStart starts recording the session of the operation if enabled for the instance.
+// The returned recorder is nil when recording is disabled.
+func sessionRecordingStart(inst instance.Instance, op *operations.Operation, width int, height int, command []string) (*recording.Recorder, error) {
+	if !sessionRecordingEnabled(inst) {
+		return nil, nil
+	}
+
+	header := recording.Header{
+		Width:   width,
+		Height:  height,
+		Command: strings.Join(command, " "),
+		Title:   fmt.Sprintf("%s/%s", inst.Project().Name, inst.Name()),
+	}
+
+	maxSize := inst.Project().Config["security.session_recording.max_size"]
+	if maxSize == "" {
+		maxSize = sessionRecordingDefaultMaxSize
+	}
+
+	maxSizeBytes, err := units.ParseByteSizeString(maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return recording.Create(filepath.Join(inst.LogPath(), sessionRecordingFileName(op.ID())), header, maxSizeBytes)
+}
+
+// isSessionRecordingFileName returns whether fileName is the name of a session recording.
+func isSessionRecordingFileName(fileName string) bool {
+	return strings.HasPrefix(fileName, "session_") && strings.HasSuffix(fileName, ".cast") && !strings.Contains(fileName, "/")
+}
+
+// sessionRecordingExpiry returns the number of days after which the session recordings of the instances of the
+// project are deleted, zero meaning never.
+func sessionRecordingExpiry(p api.Project) int64 {
+	expiry, err := strconv.ParseInt(p.Config["security.session_recording.expiry"], 10, 64)
+	if err != nil {
+		return sessionRecordingDefaultExpiry
+	}
+
+	return expiry
+}
+
+// sessionRecordingEnd completes the recording of the session of the operation and emits
+// the lifecycle event identifying who requested the session.
+func sessionRecordingEnd(s *state.State, inst instance.Instance, op *operations.Operation, rec *recording.Recorder, sessionType string, command []string) {
+	if rec == nil {
+		return
+	}
+
+	err := rec.Close()
+	if err != nil {
+		logger.Warn("Failed recording session", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "session": op.ID(), "err": err})
+	}
+
+	ctx := logger.Ctx{"session": op.ID(), "type": sessionType}
+	if command != nil {
+		ctx["command"] = command
+	}
+
+	s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceSessionRecorded.Event(sessionRecordingFileName(op.ID()), inst, op.Requestor(), ctx))
+}
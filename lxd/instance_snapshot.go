@@ -728,6 +728,11 @@ func snapshotPost(s *state.State, r *http.Request, snapInst instance.Instance) r
 		return response.Conflict(fmt.Errorf("Name '%s' already in use", fullName))
 	}
 
+	err = snapshotCheckRestoreOnStop(s, snapInst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	rename := func(op *operations.Operation) error {
 		return snapInst.Rename(fullName, false)
 	}
@@ -775,6 +780,11 @@ func snapshotPost(s *state.State, r *http.Request, snapInst instance.Instance) r
 //	  "500":
 //	    $ref: "#/responses/InternalServerError"
 func snapshotDelete(s *state.State, r *http.Request, snapInst instance.Instance) response.Response {
+	err := snapshotCheckRestoreOnStop(s, snapInst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	remove := func(op *operations.Operation) error {
 		return snapInst.Delete(false)
 	}
@@ -796,3 +806,32 @@ func snapshotDelete(s *state.State, r *http.Request, snapInst instance.Instance)
 
 	return operations.OperationResponse(op)
 }
+
+// snapshotCheckRestoreOnStop returns an error if the snapshot is used by its parent instance's
+// snapshots.restore_on_stop setting and so cannot be renamed or deleted.
+func snapshotCheckRestoreOnStop(s *state.State, snapInst instance.Instance) error {
+	inUse, err := snapshotIsRestoreOnStopBaseline(s, snapInst)
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		_, snapName, _ := api.GetParentAndSnapshotName(snapInst.Name())
+		return api.StatusErrorf(http.StatusBadRequest, "Snapshot %q is in use by snapshots.restore_on_stop", snapName)
+	}
+
+	return nil
+}
+
+// snapshotIsRestoreOnStopBaseline returns whether the snapshot is the one its parent instance is restored to
+// on stop.
+func snapshotIsRestoreOnStopBaseline(s *state.State, snapInst instance.Instance) (bool, error) {
+	parentName, snapName, _ := api.GetParentAndSnapshotName(snapInst.Name())
+
+	parent, err := instance.LoadByProjectAndName(s, snapInst.Project().Name, parentName)
+	if err != nil {
+		return false, err
+	}
+
+	return parent.ExpandedConfig()["snapshots.restore_on_stop"] == snapName, nil
+}
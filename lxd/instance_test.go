@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/canonical/lxd/lxd/instance"
 	"github.com/canonical/lxd/lxd/instance/instancetype"
 	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/request"
 	storagePools "github.com/canonical/lxd/lxd/storage"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
@@ -333,6 +336,166 @@ func (suite *containerTestSuite) TestContainer_Rename() {
 	suite.Req.Equal(shared.VarPath("containers", "testFoo2"), c.Path())
 }
 
+func (suite *containerTestSuite) TestContainer_PatchRecordsRequestor() {
+	args := db.InstanceArgs{
+		Type:      instancetype.Container,
+		Ephemeral: false,
+		Name:      "testFoo",
+	}
+
+	c, op, _, err := instance.CreateInternal(suite.d.State(), args, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c.Delete(true) }()
+
+	ctx := context.WithValue(context.Background(), request.CtxUsername, "alice")
+	ctx = context.WithValue(ctx, request.CtxProtocol, "tls")
+	r := httptest.NewRequest(http.MethodPatch, "/1.0/instances/testFoo", nil).WithContext(ctx)
+
+	args = db.InstanceArgs{
+		Architecture: c.Architecture(),
+		Config:       map[string]string{"user.foo": "bar"},
+		Devices:      c.LocalDevices(),
+		Profiles:     c.Profiles(),
+		Project:      project.Default,
+	}
+
+	c.SetRequestor(r)
+	suite.Req.Nil(c.Update(args, true))
+
+	var revisions []api.ConfigRevision
+	err = suite.d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		revisions, err = tx.GetInstanceConfigRevisions(ctx, c.ID())
+		return err
+	})
+	suite.Req.Nil(err)
+	suite.Req.NotEmpty(revisions)
+
+	latest := revisions[len(revisions)-1]
+	suite.Equal("alice", latest.Username)
+	suite.Equal("tls", latest.Protocol)
+}
+
+func (suite *containerTestSuite) TestContainer_RestoreOnStop() {
+	args := db.InstanceArgs{
+		Type:      instancetype.Container,
+		Ephemeral: false,
+		Name:      "testFoo",
+	}
+
+	c, op, _, err := instance.CreateInternal(suite.d.State(), args, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c.Delete(true) }()
+
+	updateArgs := db.InstanceArgs{
+		Architecture: c.Architecture(),
+		Config:       map[string]string{"snapshots.restore_on_stop": "base"},
+		Devices:      c.LocalDevices(),
+		Profiles:     c.Profiles(),
+		Project:      project.Default,
+	}
+
+	// The baseline snapshot must exist.
+	suite.Req.NotNil(c.Update(updateArgs, true))
+
+	expired := time.Now().Add(-time.Hour)
+	suite.Req.Nil(c.Snapshot("base", expired, false))
+	suite.Req.Nil(c.Snapshot("expired", expired, false))
+	suite.Req.Nil(c.Snapshot("newer", time.Time{}, false))
+	suite.Req.Nil(c.Update(updateArgs, true))
+
+	// The baseline snapshot is excluded from expiry.
+	snapshots, err := c.Snapshots()
+	suite.Req.Nil(err)
+	suite.Req.Nil(pruneExpiredInstanceSnapshots(context.Background(), suite.d.State(), snapshots[:2]))
+
+	snapshots, err = c.Snapshots()
+	suite.Req.Nil(err)
+	suite.Req.Len(snapshots, 2)
+	suite.Equal("testFoo/base", snapshots[0].Name())
+	suite.Equal("testFoo/newer", snapshots[1].Name())
+
+	// Restoring the baseline keeps the more recent snapshots.
+	pool, err := storagePools.LoadByInstance(suite.d.State(), c)
+	suite.Req.Nil(err)
+	suite.Req.Nil(pool.RestoreInstanceSnapshotOnStop(c, snapshots[0], nil))
+
+	snapshots, err = c.Snapshots()
+	suite.Req.Nil(err)
+	suite.Len(snapshots, 2)
+}
+
+func (suite *containerTestSuite) TestContainer_ReadonlyRootfsOverlayVolume() {
+	state := suite.d.State()
+
+	pool, err := storagePools.LoadByName(state, lxdTestSuiteDefaultStoragePool)
+	suite.Req.Nil(err)
+
+	volumes := map[string]int{
+		"overlay":  db.StoragePoolVolumeContentTypeFS,
+		"block":    db.StoragePoolVolumeContentTypeBlock,
+		"attached": db.StoragePoolVolumeContentTypeFS,
+	}
+
+	for name, contentType := range volumes {
+		_, err = state.DB.Cluster.CreateStoragePoolVolume(project.Default, name, "", db.StoragePoolVolumeTypeCustom, pool.ID(), nil, contentType, time.Now())
+		suite.Req.Nil(err)
+	}
+
+	c1, op, _, err := instance.CreateInternal(state, db.InstanceArgs{Type: instancetype.Container, Name: "c1"}, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c1.Delete(true) }()
+
+	c2, op, _, err := instance.CreateInternal(state, db.InstanceArgs{
+		Type: instancetype.Container,
+		Name: "c2",
+		Devices: deviceConfig.Devices{
+			"data": deviceConfig.Device{"type": "disk", "pool": lxdTestSuiteDefaultStoragePool, "source": "attached", "path": "/data"},
+		},
+	}, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c2.Delete(true) }()
+
+	overlayArgs := func(c instance.Instance, volName string) db.InstanceArgs {
+		return db.InstanceArgs{
+			Architecture: c.Architecture(),
+			Config: map[string]string{
+				"security.rootfs.readonly":                "true",
+				"security.rootfs.readonly.overlay":        "volume",
+				"security.rootfs.readonly.overlay.volume": volName,
+			},
+			Devices:  c.LocalDevices(),
+			Profiles: c.Profiles(),
+			Project:  project.Default,
+		}
+	}
+
+	cases := map[string]string{
+		"overlay/snap0": "snapshot",
+		"a/b":           "invalid name",
+		"missing":       "missing volume",
+		"block":         "block volume",
+		"attached":      "volume attached to another instance",
+	}
+
+	for volName, reason := range cases {
+		suite.Req.NotNil(c1.Update(overlayArgs(c1, volName), true), "Overlay volume %q accepted (%s)", volName, reason)
+	}
+
+	suite.Req.Nil(c1.Update(overlayArgs(c1, "overlay"), true))
+
+	// The volume can't be shared with another container's overlay.
+	c3, op, _, err := instance.CreateInternal(state, db.InstanceArgs{Type: instancetype.Container, Name: "c3"}, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c3.Delete(true) }()
+
+	suite.Req.NotNil(c3.Update(overlayArgs(c3, "overlay"), true))
+}
+
 func (suite *containerTestSuite) TestContainer_findIdmap_isolated() {
 	c1, op, _, err := instance.CreateInternal(suite.d.State(), db.InstanceArgs{
 		Type: instancetype.Container,
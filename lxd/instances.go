@@ -75,6 +75,18 @@ var instanceStateCmd = APIEndpoint{
 	Put: APIEndpointAction{Handler: instanceStatePut, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceProcessesCmd = APIEndpoint{
+	Name: "instanceProcesses",
+	Path: "instances/{name}/processes",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerProcesses", Path: "containers/{name}/processes"},
+		{Name: "vmProcesses", Path: "virtual-machines/{name}/processes"},
+	},
+
+	Get:  APIEndpointAction{Handler: instanceProcessesGet, AccessHandler: allowProjectPermission("containers", "view")},
+	Post: APIEndpointAction{Handler: instanceProcessesPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
 var instanceSFTPCmd = APIEndpoint{
 	Name: "instanceFile",
 	Path: "instances/{name}/sftp",
@@ -136,10 +148,20 @@ var instanceConsoleCmd = APIEndpoint{
 	},
 
 	Get:    APIEndpointAction{Handler: instanceConsoleLogGet, AccessHandler: allowProjectPermission("containers", "view")},
-	Post:   APIEndpointAction{Handler: instanceConsolePost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+	Post:   APIEndpointAction{Handler: instanceConsolePost, AccessHandler: allowInstanceConsole},
 	Delete: APIEndpointAction{Handler: instanceConsoleLogDelete, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceDiffCmd = APIEndpoint{
+	Name: "instanceDiff",
+	Path: "instances/{name}/diff",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerDiff", Path: "containers/{name}/diff"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceDiffPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
 var instanceExecCmd = APIEndpoint{
 	Name: "instanceExec",
 	Path: "instances/{name}/exec",
@@ -151,6 +173,40 @@ var instanceExecCmd = APIEndpoint{
 	Post: APIEndpointAction{Handler: instanceExecPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceExecSessionsCmd = APIEndpoint{
+	Name: "instanceExecSessions",
+	Path: "instances/{name}/exec-sessions",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerExecSessions", Path: "containers/{name}/exec-sessions"},
+		{Name: "vmExecSessions", Path: "virtual-machines/{name}/exec-sessions"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceExecSessionsGet, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
+var instanceExecSessionCmd = APIEndpoint{
+	Name: "instanceExecSession",
+	Path: "instances/{name}/exec-sessions/{id}",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerExecSession", Path: "containers/{name}/exec-sessions/{id}"},
+		{Name: "vmExecSession", Path: "virtual-machines/{name}/exec-sessions/{id}"},
+	},
+
+	Get:    APIEndpointAction{Handler: instanceExecSessionGet, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+	Delete: APIEndpointAction{Handler: instanceExecSessionDelete, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
+var instanceExecSessionAttachCmd = APIEndpoint{
+	Name: "instanceExecSessionAttach",
+	Path: "instances/{name}/exec-sessions/{id}/attach",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerExecSessionAttach", Path: "containers/{name}/exec-sessions/{id}/attach"},
+		{Name: "vmExecSessionAttach", Path: "virtual-machines/{name}/exec-sessions/{id}/attach"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceExecSessionAttachPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
 var instanceMetadataCmd = APIEndpoint{
 	Name: "instanceMetadata",
 	Path: "instances/{name}/metadata",
@@ -177,6 +233,28 @@ var instanceMetadataTemplatesCmd = APIEndpoint{
 	Delete: APIEndpointAction{Handler: instanceMetadataTemplatesDelete, AccessHandler: allowProjectPermission("containers", "manage-containers")},
 }
 
+var instanceRevisionsCmd = APIEndpoint{
+	Name: "instanceRevisions",
+	Path: "instances/{name}/revisions",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerRevisions", Path: "containers/{name}/revisions"},
+		{Name: "vmRevisions", Path: "virtual-machines/{name}/revisions"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceRevisionsGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
+var instanceRevisionCmd = APIEndpoint{
+	Name: "instanceRevision",
+	Path: "instances/{name}/revisions/{revision}",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerRevision", Path: "containers/{name}/revisions/{revision}"},
+		{Name: "vmRevision", Path: "virtual-machines/{name}/revisions/{revision}"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceRevisionGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
 var instanceBackupsCmd = APIEndpoint{
 	Name: "instanceBackups",
 	Path: "instances/{name}/backups",
@@ -430,6 +430,10 @@ func createFromCopy(s *state.State, r *http.Request, projectName string, profile
 		serverName := s.ServerName
 
 		if serverName != source.Location() {
+			if req.Type != "" && req.Type != api.InstanceType(source.Type().String()) {
+				return response.BadRequest(fmt.Errorf("Instance type conversion requires the source instance to be on the same cluster member"))
+			}
+
 			// Check if we are copying from a ceph-based container.
 			_, rootDevice, _ := shared.GetRootDiskDevice(source.ExpandedDevices().CloneNative())
 			sourcePoolName := rootDevice["pool"]
@@ -510,8 +514,38 @@ func createFromCopy(s *state.State, r *http.Request, projectName string, profile
 		dbType = source.Type()
 	}
 
-	if dbType != instancetype.Any && dbType != source.Type() {
-		return response.BadRequest(fmt.Errorf("Instance type should not be specified or should match source type"))
+	var convertProject *api.Project
+	convert := dbType != instancetype.Any && dbType != source.Type()
+	if convert {
+		if req.Source.Refresh {
+			return response.BadRequest(fmt.Errorf("Instance type conversion can't be combined with refresh"))
+		}
+
+		if req.Stateful {
+			return response.BadRequest(fmt.Errorf("Instance type conversion can't be combined with stateful copy"))
+		}
+
+		if source.IsSnapshot() {
+			return response.BadRequest(fmt.Errorf("Instance type conversion of snapshots isn't supported"))
+		}
+
+		if source.IsRunning() {
+			return response.BadRequest(fmt.Errorf("Instance must be stopped to be converted to a different type"))
+		}
+
+		// Devices are validated against the project the new instance is created in.
+		err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+			dbProject, err := dbCluster.GetProject(ctx, tx.Tx(), targetProject)
+			if err != nil {
+				return fmt.Errorf("Failed loading project: %w", err)
+			}
+
+			convertProject, err = dbProject.ToAPI(ctx, tx.Tx())
+			return err
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
 	}
 
 	args := db.InstanceArgs{
@@ -529,6 +563,29 @@ func createFromCopy(s *state.State, r *http.Request, projectName string, profile
 	}
 
 	run := func(op *operations.Operation) error {
+		if convert {
+			// Drop the config and devices the target instance type doesn't support.
+			var incompatible []string
+			args.Type = dbType
+			args.Config, args.Devices, incompatible = instanceConvertConfig(s, *convertProject, dbType, args.Config, args.Devices, profiles)
+			if len(incompatible) > 0 {
+				logger.Warn("Dropped incompatible settings during instance type conversion", logger.Ctx{"project": targetProject, "instance": req.Name, "settings": incompatible})
+
+				err := op.UpdateMetadata(map[string]any{"incompatible": incompatible})
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := instanceCreateAsConversion(s, instanceCreateAsCopyOpts{
+				sourceInstance: source,
+				targetInstance: args,
+				instanceOnly:   true,
+			}, op)
+
+			return err
+		}
+
 		_, err := instanceCreateAsCopy(s, instanceCreateAsCopyOpts{
 			sourceInstance:       source,
 			targetInstance:       args,
@@ -627,6 +684,16 @@ func createFromBackup(s *state.State, r *http.Request, projectName string, data
 		return response.BadRequest(err)
 	}
 
+	// Run the instance admission scriptlet on the imported instance.
+	if instanceName == "" {
+		instanceName = bInfo.Name
+	}
+
+	instanceName, err = instanceImportAdmission(s, r, projectName, instanceName, bInfo.Config.Container)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	// Check project permissions.
 	err = s.DB.Cluster.Transaction(s.ShutdownCtx, func(ctx context.Context, tx *db.ClusterTx) error {
 		req := api.InstancesPost{
@@ -1070,6 +1137,54 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Run the instance admission scriptlet, unless the request comes from another cluster member which has
+	// already run it before forwarding the request.
+	if s.GlobalConfig.InstancesAdmissionScriptlet() != "" && r.Context().Value(request.CtxProtocol) != "cluster" {
+		admission := apiScriptlet.InstanceAdmission{
+			InstancePut: req.InstancePut,
+			Action:      apiScriptlet.InstanceAdmissionActionCreate,
+			Project:     targetProjectName,
+			Name:        req.Name,
+			Type:        string(req.Type),
+			Source:      &req.Source,
+		}
+
+		err = instanceAdmission(s, r, &admission)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		req.InstancePut = admission.InstancePut
+		req.Name = admission.Name
+
+		// Reload the profiles and check the project limits again as the scriptlet may have changed the request.
+		err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+			dbProfiles, err := dbCluster.GetProfilesIfEnabled(ctx, tx.Tx(), targetProjectName, req.Profiles)
+			if err != nil {
+				return err
+			}
+
+			profiles = make([]api.Profile, 0, len(dbProfiles))
+			for _, dbProfile := range dbProfiles {
+				apiProfile, err := dbProfile.ToAPI(ctx, tx.Tx())
+				if err != nil {
+					return err
+				}
+
+				profiles = append(profiles, *apiProfile)
+			}
+
+			if clustered && targetMemberInfo == nil {
+				return nil
+			}
+
+			return project.AllowInstanceCreation(tx, targetProjectName, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	err = instance.ValidName(req.Name, false)
 	if err != nil {
 		return response.BadRequest(err)
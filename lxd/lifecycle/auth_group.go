@@ -0,0 +1,29 @@
+package lifecycle
+
+import (
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// AuthGroupAction represents a lifecycle event action for authorization groups.
+type AuthGroupAction string
+
+// All supported lifecycle events for authorization groups.
+const (
+	AuthGroupCreated = AuthGroupAction(api.EventLifecycleAuthGroupCreated)
+	AuthGroupDeleted = AuthGroupAction(api.EventLifecycleAuthGroupDeleted)
+	AuthGroupUpdated = AuthGroupAction(api.EventLifecycleAuthGroupUpdated)
+	AuthGroupRenamed = AuthGroupAction(api.EventLifecycleAuthGroupRenamed)
+)
+
+// Event creates the lifecycle event for an action on an authorization group.
+func (a AuthGroupAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "auth", "groups", name)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
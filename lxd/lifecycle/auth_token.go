@@ -0,0 +1,27 @@
+package lifecycle
+
+import (
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// AuthTokenAction represents a lifecycle event action for API bearer tokens.
+type AuthTokenAction string
+
+// All supported lifecycle events for API bearer tokens.
+const (
+	AuthTokenCreated = AuthTokenAction(api.EventLifecycleAuthTokenCreated)
+	AuthTokenDeleted = AuthTokenAction(api.EventLifecycleAuthTokenDeleted)
+)
+
+// Event creates the lifecycle event for an action on an API bearer token.
+func (a AuthTokenAction) Event(fingerprint string, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "auth", "tokens", fingerprint)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,17 @@
+package lifecycle
+
+import (
+	"github.com/canonical/lxd/shared/api"
+)
+
+// ConfigRevisionContext returns the lifecycle event context of a change recorded as a configuration revision.
+func ConfigRevisionContext(revision *api.ConfigRevision) map[string]any {
+	if revision == nil {
+		return nil
+	}
+
+	return map[string]any{
+		"revision": revision.Revision,
+		"changes":  revision.Changes,
+	}
+}
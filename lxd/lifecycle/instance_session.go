@@ -0,0 +1,26 @@
+package lifecycle
+
+import (
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// InstanceSessionAction represents a lifecycle event action for recorded instance sessions.
+type InstanceSessionAction string
+
+// All supported lifecycle events for recorded instance sessions.
+const (
+	InstanceSessionRecorded = InstanceSessionAction(api.EventLifecycleInstanceSessionRecorded)
+)
+
+// Event creates the lifecycle event for an action on a recorded instance session.
+func (a InstanceSessionAction) Event(file string, inst instance, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "instances", inst.Name(), "logs", file).Project(inst.Project().Name)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
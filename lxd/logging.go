@@ -65,8 +65,10 @@ func expireLogs(ctx context.Context, state *state.State) error {
 
 	// Build the expected names.
 	names := []string{}
+	recordingExpiry := map[string]int64{}
 	for _, inst := range instances {
 		names = append(names, project.Instance(inst.Project().Name, inst.Name()))
+		recordingExpiry[project.Instance(inst.Project().Name, inst.Name())] = sessionRecordingExpiry(inst.Project())
 	}
 
 	newestFile := func(path string, dir os.FileInfo) time.Time {
@@ -139,6 +141,19 @@ func expireLogs(ctx context.Context, state *state.State) error {
 					continue
 				}
 
+				// Remove session recordings older than the expiry of their project.
+				if isSessionRecordingFileName(instInfo.Name()) {
+					expiry := recordingExpiry[fi.Name()]
+					if expiry > 0 && time.Since(instInfo.ModTime()) >= time.Duration(expiry)*24*time.Hour {
+						err := os.Remove(path)
+						if err != nil {
+							return err
+						}
+					}
+
+					continue
+				}
+
 				// Only remove old log files (keep other files, such as conf, pid, monitor etc).
 				if strings.HasSuffix(instInfo.Name(), ".log") || strings.HasSuffix(instInfo.Name(), ".log.old") {
 					// Remove any log file which wasn't modified in the past 48 hours.
@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/dskit/backoff"
 	"github.com/sirupsen/logrus"
 
+	"github.com/canonical/lxd/lxd/audit"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 )
@@ -343,6 +344,69 @@ func (c *Client) HandleEvent(event api.Event) {
 	c.entries <- entry
 }
 
+// HandleAudit handles the audited API requests.
+func (c *Client) HandleAudit(auditEntry audit.Entry) {
+	if !shared.StringInSlice("audit", c.cfg.types) {
+		return
+	}
+
+	entry := entry{
+		labels: LabelSet{
+			"app":      "lxd",
+			"type":     "audit",
+			"location": auditEntry.Location,
+		},
+		Entry: Entry{
+			Timestamp: auditEntry.Timestamp,
+		},
+	}
+
+	// Build map. These key-value pairs will either be added as labels, or be part of the
+	// log message itself.
+	context := map[string]string{
+		"username":    auditEntry.Username,
+		"protocol":    auditEntry.Protocol,
+		"address":     auditEntry.Address,
+		"project":     auditEntry.Project,
+		"status":      strconv.Itoa(auditEntry.Status),
+		"duration_ms": strconv.FormatInt(auditEntry.DurationMs, 10),
+	}
+
+	// Add key-value pairs as labels but don't override any labels.
+	for k, v := range context {
+		if shared.StringInSlice(k, c.cfg.labels) {
+			_, ok := entry.labels[k]
+			if !ok {
+				entry.labels[k] = v
+				delete(context, k)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(context))
+	for k := range context {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	messagePrefix := ""
+
+	// Add the remaining context as the message prefix.
+	for _, k := range keys {
+		messagePrefix += fmt.Sprintf("%s=\"%s\" ", k, context[k])
+	}
+
+	entry.Line = fmt.Sprintf("%s%s %s", messagePrefix, auditEntry.Method, auditEntry.Path)
+
+	// Don't block if the client got stopped.
+	select {
+	case c.entries <- entry:
+	case <-c.quit:
+	case <-c.ctx.Done():
+	}
+}
+
 func buildNestedContext(prefix string, m map[string]any) map[string]string {
 	labels := map[string]string{}
 
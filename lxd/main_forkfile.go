@@ -85,6 +85,8 @@ void forkfile(void)
 import "C"
 
 import (
+	"bufio"
+	"io"
 	"net"
 	"os"
 	"os/signal"
@@ -95,6 +97,8 @@ import (
 	"github.com/pkg/sftp"
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/shared/tarstream"
 )
 
 type cmdForkfile struct {
@@ -111,7 +115,8 @@ func (c *cmdForkfile) Command() *cobra.Command {
 
   This spawns a daemon inside of the instance's filesystem which can
   then receive command over a simple SFTP API operating on the provided
-  listen fd.
+  listen fd. Connections can also request whole directory trees to be
+  transferred as a tar stream.
 
   The command can be called with PID and PIDFd set to 0 to just operate on the rootfs fd.
   In such cases, it's the responsibility of the caller to handle any kind of userns shifting.
@@ -228,14 +233,23 @@ func (c *cmdForkfile) Run(cmd *cobra.Command, args []string) error {
 			connections += 1
 			mu.Unlock()
 
-			// Spawn the server.
-			server, err := sftp.NewServer(conn)
-			if err != nil {
-				return
+			reader := bufio.NewReader(conn)
+			if tarstream.IsRequest(reader) {
+				// Handle tar stream transfers.
+				_ = tarstream.Serve(reader, conn)
+			} else {
+				// Spawn the server.
+				server, err := sftp.NewServer(struct {
+					io.Reader
+					io.WriteCloser
+				}{reader, conn})
+				if err != nil {
+					return
+				}
+
+				_ = server.Serve()
 			}
 
-			_ = server.Serve()
-
 			// Sync the filesystem.
 			_ = unix.Syncfs(int(rootfsFD))
 		}(conn)
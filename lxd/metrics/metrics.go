@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // NewMetricSet returns a new MetricSet.
@@ -37,6 +38,15 @@ func (m *MetricSet) AddSamples(metricType MetricType, samples ...Sample) {
 	m.set[metricType] = append(m.set[metricType], samples...)
 }
 
+// SetStartTime sets the time the counters of all the samples of the MetricSet started from.
+func (m *MetricSet) SetStartTime(startTime time.Time) {
+	for _, samples := range m.set {
+		for i := range samples {
+			samples[i].StartTime = startTime
+		}
+	}
+}
+
 // Merge merges two MetricSets.
 func (m *MetricSet) Merge(metricSet *MetricSet) {
 	if metricSet == nil {
@@ -48,6 +58,20 @@ func (m *MetricSet) Merge(metricSet *MetricSet) {
 	}
 }
 
+// metricTypeName returns the OpenMetrics type of the metric, either "counter" or "gauge".
+func metricTypeName(metricType MetricType) string {
+	// ProcsTotal is a gauge according to the OpenMetrics spec as its value can decrease.
+	if metricType == ProcsTotal || metricType == CPUs || metricType == GoGoroutines || metricType == GoHeapObjects {
+		return "gauge"
+	} else if strings.HasSuffix(MetricNames[metricType], "_total") || strings.HasSuffix(MetricNames[metricType], "_seconds") {
+		return "counter"
+	} else if strings.HasSuffix(MetricNames[metricType], "_bytes") {
+		return "gauge"
+	}
+
+	return ""
+}
+
 func (m *MetricSet) String() string {
 	var out strings.Builder
 	metricTypes := []MetricType{}
@@ -68,19 +92,8 @@ func (m *MetricSet) String() string {
 			return ""
 		}
 
-		metricTypeName := ""
-
-		// ProcsTotal is a gauge according to the OpenMetrics spec as its value can decrease.
-		if metricType == ProcsTotal || metricType == CPUs || metricType == GoGoroutines || metricType == GoHeapObjects {
-			metricTypeName = "gauge"
-		} else if strings.HasSuffix(MetricNames[metricType], "_total") || strings.HasSuffix(MetricNames[metricType], "_seconds") {
-			metricTypeName = "counter"
-		} else if strings.HasSuffix(MetricNames[metricType], "_bytes") {
-			metricTypeName = "gauge"
-		}
-
 		// Add TYPE message as specified by OpenMetrics
-		_, err = out.WriteString(fmt.Sprintf("# TYPE %s %s\n", MetricNames[metricType], metricTypeName))
+		_, err = out.WriteString(fmt.Sprintf("# TYPE %s %s\n", MetricNames[metricType], metricTypeName(metricType)))
 		if err != nil {
 			return ""
 		}
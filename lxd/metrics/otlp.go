@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/lxd/lxd/otlp"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// OTLP converts the MetricSet to OTLP metrics observed at the given time. Counters are exported as monotonic
+// cumulative sums starting at the start time of their sample, or startTime if unset, the other metrics as gauges.
+func (m *MetricSet) OTLP(startTime time.Time, now time.Time) []otlp.Metric {
+	metricTypes := make([]MetricType, 0, len(m.set))
+	for metricType := range m.set {
+		metricTypes = append(metricTypes, metricType)
+	}
+
+	sort.Slice(metricTypes, func(i, j int) bool {
+		return int(metricTypes[i]) < int(metricTypes[j])
+	})
+
+	start := strconv.FormatInt(startTime.UnixNano(), 10)
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+
+	out := make([]otlp.Metric, 0, len(metricTypes))
+	for _, metricType := range metricTypes {
+		name := MetricNames[metricType]
+		counter := metricTypeName(metricType) == "counter"
+
+		dataPoints := make([]otlp.NumberDataPoint, 0, len(m.set[metricType]))
+		for _, sample := range m.set[metricType] {
+			labels := make(map[string]any, len(sample.Labels))
+			for labelName, labelValue := range sample.Labels {
+				labels[labelName] = labelValue
+			}
+
+			dataPoint := otlp.NumberDataPoint{
+				Attributes:   otlp.Attributes(labels),
+				TimeUnixNano: timestamp,
+				AsDouble:     sample.Value,
+			}
+
+			if counter {
+				dataPoint.StartTimeUnixNano = start
+				if !sample.StartTime.IsZero() {
+					dataPoint.StartTimeUnixNano = strconv.FormatInt(sample.StartTime.UnixNano(), 10)
+				}
+			}
+
+			dataPoints = append(dataPoints, dataPoint)
+		}
+
+		metric := otlp.Metric{
+			Name:        name,
+			Description: strings.TrimPrefix(MetricHeaders[metricType], "# HELP "+name+" "),
+		}
+
+		if strings.HasSuffix(name, "_bytes") {
+			metric.Unit = "By"
+		} else if strings.HasSuffix(name, "_seconds") || strings.HasSuffix(name, "_seconds_total") {
+			metric.Unit = "s"
+		}
+
+		if counter {
+			metric.Sum = &otlp.Sum{
+				DataPoints:             dataPoints,
+				AggregationTemporality: otlp.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			metric.Gauge = &otlp.Gauge{DataPoints: dataPoints}
+		}
+
+		out = append(out, metric)
+	}
+
+	return out
+}
+
+// Exporter periodically gathers the metrics of a server and pushes them to an OTLP collector.
+type Exporter struct {
+	client    *otlp.Client
+	resource  otlp.Resource
+	interval  time.Duration
+	startTime time.Time
+	gather    func(ctx context.Context) (*MetricSet, error)
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewExporter returns an Exporter pushing the metrics returned by gather through the client every interval,
+// until stopped or the context is cancelled. The startTime is the start of the counters, usually the daemon
+// start time.
+func NewExporter(ctx context.Context, client *otlp.Client, serverName string, interval time.Duration, startTime time.Time, gather func(ctx context.Context) (*MetricSet, error)) *Exporter {
+	ctx, cancel := context.WithCancel(ctx)
+
+	e := &Exporter{
+		client:    client,
+		resource:  otlp.NewResource(serverName),
+		interval:  interval,
+		startTime: startTime,
+		gather:    gather,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	e.wg.Add(1)
+	go e.run()
+
+	return e
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+
+		case <-ticker.C:
+			e.export()
+		}
+	}
+}
+
+func (e *Exporter) export() {
+	metricSet, err := e.gather(e.ctx)
+	if err != nil {
+		logger.Warn("Failed gathering metrics", logger.Ctx{"err": err})
+		return
+	}
+
+	req := otlp.MetricsRequest{
+		ResourceMetrics: []otlp.ResourceMetrics{{
+			Resource: e.resource,
+			ScopeMetrics: []otlp.ScopeMetrics{{
+				Scope:   otlp.NewScope(),
+				Metrics: metricSet.OTLP(e.startTime, time.Now()),
+			}},
+		}},
+	}
+
+	err = e.client.Export(e.ctx, otlp.MetricsPath, req)
+	if err != nil && e.ctx.Err() == nil {
+		logger.Warn("Failed exporting metrics", logger.Ctx{"err": err})
+	}
+}
+
+// Stop stops the exporter, aborting any ongoing export.
+func (e *Exporter) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/metrics"
+	"github.com/canonical/lxd/lxd/otlp"
+)
+
+func TestMetricSet_OTLP(t *testing.T) {
+	set := metrics.NewMetricSet(map[string]string{"project": "default", "name": "c1"})
+	set.AddSamples(metrics.CPUSecondsTotal, metrics.Sample{Value: 12.5, Labels: map[string]string{"mode": "user", "cpu": "0"}})
+	set.AddSamples(metrics.MemoryMemFreeBytes, metrics.Sample{Value: 1024})
+
+	startTime := time.Unix(100, 0)
+	now := time.Unix(200, 0)
+
+	out := set.OTLP(startTime, now)
+	require.Len(t, out, 2)
+
+	// Counters are cumulative sums.
+	assert.Equal(t, "lxd_cpu_seconds_total", out[0].Name)
+	assert.Equal(t, "The total number of CPU time used in seconds.", out[0].Description)
+	assert.Nil(t, out[0].Gauge)
+	require.NotNil(t, out[0].Sum)
+	assert.True(t, out[0].Sum.IsMonotonic)
+	assert.Equal(t, otlp.AggregationTemporalityCumulative, out[0].Sum.AggregationTemporality)
+	require.Len(t, out[0].Sum.DataPoints, 1)
+	assert.Equal(t, 12.5, out[0].Sum.DataPoints[0].AsDouble)
+	assert.Equal(t, "100000000000", out[0].Sum.DataPoints[0].StartTimeUnixNano)
+	assert.Equal(t, "200000000000", out[0].Sum.DataPoints[0].TimeUnixNano)
+	assert.Equal(t, otlp.Attributes(map[string]any{"cpu": "0", "mode": "user", "name": "c1", "project": "default"}), out[0].Sum.DataPoints[0].Attributes)
+
+	// Other metrics are gauges.
+	assert.Equal(t, "lxd_memory_MemFree_bytes", out[1].Name)
+	assert.Equal(t, "By", out[1].Unit)
+	assert.Nil(t, out[1].Sum)
+	require.NotNil(t, out[1].Gauge)
+	require.Len(t, out[1].Gauge.DataPoints, 1)
+	assert.Equal(t, float64(1024), out[1].Gauge.DataPoints[0].AsDouble)
+	assert.Empty(t, out[1].Gauge.DataPoints[0].StartTimeUnixNano)
+
+	// Counters of samples with their own start time (e.g. restarted instances) start from it.
+	set.SetStartTime(time.Unix(150, 0))
+	out = set.OTLP(startTime, now)
+	require.Len(t, out, 2)
+	assert.Equal(t, "150000000000", out[0].Sum.DataPoints[0].StartTimeUnixNano)
+	assert.Empty(t, out[1].Gauge.DataPoints[0].StartTimeUnixNano)
+}
+
+func TestExporter(t *testing.T) {
+	requests := make(chan otlp.MetricsRequest, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, otlp.MetricsPath, r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		req := otlp.MetricsRequest{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		assert.NoError(t, err)
+
+		select {
+		case requests <- req:
+		default:
+		}
+	}))
+
+	defer server.Close()
+
+	client, err := otlp.NewClient(server.URL, map[string]string{"Authorization": "secret"}, "")
+	require.NoError(t, err)
+
+	gather := func(ctx context.Context) (*metrics.MetricSet, error) {
+		set := metrics.NewMetricSet(nil)
+		set.AddSamples(metrics.GoGoroutines, metrics.Sample{Value: 10})
+
+		return set, nil
+	}
+
+	exporter := metrics.NewExporter(context.Background(), client, "lxd01", 10*time.Millisecond, time.Now(), gather)
+	defer exporter.Stop()
+
+	select {
+	case req := <-requests:
+		require.Len(t, req.ResourceMetrics, 1)
+		assert.Contains(t, req.ResourceMetrics[0].Resource.Attributes, otlp.Attributes(map[string]any{"service.instance.id": "lxd01"})[0])
+		require.Len(t, req.ResourceMetrics[0].ScopeMetrics, 1)
+		require.Len(t, req.ResourceMetrics[0].ScopeMetrics[0].Metrics, 1)
+
+		metric := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+		assert.Equal(t, "lxd_go_goroutines", metric.Name)
+		require.NotNil(t, metric.Gauge)
+		assert.Equal(t, float64(10), metric.Gauge.DataPoints[0].AsDouble)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the metrics")
+	}
+}
@@ -1,9 +1,17 @@
 package metrics
 
+import (
+	"time"
+)
+
 // A Sample represents an OpenMetrics sample containing labels and the value.
 type Sample struct {
 	Labels map[string]string
 	Value  float64
+
+	// StartTime is the time the counter started from when it doesn't match the start of the exporter, for
+	// example the start of the instance the sample belongs to.
+	StartTime time.Time
 }
 
 // MetricSet represents a set of metrics.
@@ -144,10 +152,13 @@ const (
 	GoOtherSysBytes
 	// GoNextGCBytes represents the number of heap bytes when next garbage collection will take place.
 	GoNextGCBytes
+	// APIRequestsThrottledTotal represents the number of API requests rejected by the rate limits.
+	APIRequestsThrottledTotal
 )
 
 // MetricNames associates a metric type to its name.
 var MetricNames = map[MetricType]string{
+	APIRequestsThrottledTotal:   "lxd_api_requests_throttled_total",
 	CPUSecondsTotal:             "lxd_cpu_seconds_total",
 	CPUs:                        "lxd_cpu_effective_total",
 	DiskReadBytesTotal:          "lxd_disk_read_bytes_total",
@@ -216,6 +227,7 @@ var MetricNames = map[MetricType]string{
 
 // MetricHeaders represents the metric headers which contain help messages as specified by OpenMetrics.
 var MetricHeaders = map[MetricType]string{
+	APIRequestsThrottledTotal:   "# HELP lxd_api_requests_throttled_total The total number of API requests rejected by the rate limits.",
 	CPUSecondsTotal:             "# HELP lxd_cpu_seconds_total The total number of CPU time used in seconds.",
 	CPUs:                        "# HELP lxd_cpu_effective_total The total number of effective CPUs.",
 	DiskReadBytesTotal:          "# HELP lxd_disk_read_bytes_total The total number of bytes read.",
@@ -14,6 +14,7 @@ import (
 	"github.com/canonical/lxd/lxd/request"
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/lxd/lxd/state"
+	"github.com/canonical/lxd/lxd/tracing"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/cancel"
@@ -108,6 +109,9 @@ type Operation struct {
 	requestor   *api.EventLifecycleRequestor
 	logger      logger.Logger
 
+	// Context carrying the trace of the operation.
+	traceCtx context.Context
+
 	// Those functions are called at various points in the Operation lifecycle
 	onRun     func(*Operation) error
 	onCancel  func(*Operation) error
@@ -186,6 +190,12 @@ func OperationCreate(s *state.State, projectName string, opClass OperationClass,
 		op.SetRequestor(r)
 	}
 
+	// Continue the trace of the request, if any.
+	op.traceCtx = context.Background()
+	if r != nil {
+		op.traceCtx = tracing.Detach(r.Context())
+	}
+
 	operationsLock.Lock()
 	operations[op.id] = &op
 	operationsLock.Unlock()
@@ -269,6 +279,43 @@ func (op *Operation) done() {
 	}()
 }
 
+// StartSpan starts a span as part of the trace of the operation, the spans started by the operation until it
+// ends being its children. It returns a nil span if the operation is nil or isn't traced, so that the work done
+// outside of operations doesn't start new traces.
+func (op *Operation) StartSpan(name string, attributes map[string]any) *tracing.Span {
+	if op == nil || !tracing.Enabled() {
+		return nil
+	}
+
+	op.lock.Lock()
+	defer op.lock.Unlock()
+
+	parentCtx := op.traceCtx
+	_, traced := tracing.SpanContextFromContext(parentCtx)
+	if !traced {
+		return nil
+	}
+
+	ctx, span := tracing.Start(parentCtx, name, attributes)
+	if span == nil {
+		return nil
+	}
+
+	op.traceCtx = ctx
+
+	// Restore the parent span unless another span was started meanwhile.
+	span.OnEnd(func() {
+		op.lock.Lock()
+		defer op.lock.Unlock()
+
+		if op.traceCtx == ctx {
+			op.traceCtx = parentCtx
+		}
+	})
+
+	return span
+}
+
 // Start a pending operation. It returns an error if the operation cannot be started.
 func (op *Operation) Start() error {
 	op.lock.Lock()
@@ -280,8 +327,18 @@ func (op *Operation) Start() error {
 	op.status = api.Running
 
 	if op.onRun != nil {
+		var span *tracing.Span
+		op.traceCtx, span = tracing.Start(op.traceCtx, op.description, map[string]any{
+			"lxd.operation.id":    op.id,
+			"lxd.operation.class": op.class.String(),
+			"lxd.project":         op.projectName,
+		})
+
 		go func(op *Operation) {
 			err := op.onRun(op)
+			span.SetError(err)
+			span.End()
+
 			if err != nil {
 				op.lock.Lock()
 				op.status = api.Failure
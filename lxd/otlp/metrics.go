@@ -0,0 +1,53 @@
+package otlp
+
+// MetricsPath is the collector path the metrics get exported to.
+const MetricsPath = "/v1/metrics"
+
+// AggregationTemporalityCumulative indicates sums accumulated since a fixed start time.
+const AggregationTemporalityCumulative = 2
+
+// MetricsRequest represents the payload of a metrics export.
+type MetricsRequest struct {
+	ResourceMetrics []ResourceMetrics `json:"resourceMetrics"`
+}
+
+// ResourceMetrics represents the metrics of a resource.
+type ResourceMetrics struct {
+	Resource     Resource       `json:"resource"`
+	ScopeMetrics []ScopeMetrics `json:"scopeMetrics"`
+}
+
+// ScopeMetrics represents the metrics of an instrumentation scope.
+type ScopeMetrics struct {
+	Scope   Scope    `json:"scope"`
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric represents a metric, which is either a gauge or a sum.
+type Metric struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Gauge       *Gauge `json:"gauge,omitempty"`
+	Sum         *Sum   `json:"sum,omitempty"`
+}
+
+// Gauge represents the data points of a metric whose value can go up and down.
+type Gauge struct {
+	DataPoints []NumberDataPoint `json:"dataPoints"`
+}
+
+// Sum represents the data points of a metric accumulating values, such as a counter.
+type Sum struct {
+	DataPoints             []NumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+// NumberDataPoint represents a data point. The timestamps are nanoseconds since the epoch, encoded as strings.
+type NumberDataPoint struct {
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsDouble          float64    `json:"asDouble"`
+}
@@ -0,0 +1,239 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// This implements the OTLP/HTTP protocol with JSON encoding, see https://opentelemetry.io/docs/specs/otlp/.
+
+const (
+	contentType  = "application/json"
+	maxErrMsgLen = 1024
+)
+
+// Client represents a client exporting data to an OTLP/HTTP collector.
+type Client struct {
+	endpoint      *url.URL
+	headers       map[string]string
+	client        *http.Client
+	backoffConfig backoff.Config
+	timeout       time.Duration
+}
+
+// NewClient returns a Client exporting to the collector at the given endpoint (such as http://collector:4318),
+// adding the given headers to the requests and trusting the given CA certificate if set.
+func NewClient(endpoint string, headers map[string]string, caCert string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+
+	client := &Client{
+		endpoint: u,
+		headers:  headers,
+		client:   &http.Client{},
+		backoffConfig: backoff.Config{
+			MinBackoff: 500 * time.Millisecond,
+			MaxBackoff: 30 * time.Second,
+			MaxRetries: 5,
+		},
+		timeout: 10 * time.Second,
+	}
+
+	if caCert != "" {
+		tlsConfig, err := shared.GetTLSConfigMem("", "", caCert, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid OTLP CA certificate: %w", err)
+		}
+
+		client.client.Transport = &http.Transport{
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	return client, nil
+}
+
+// ParseHeaders parses a comma separated list of key=value headers.
+func ParseHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	if value == "" {
+		return headers, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		key, value, found := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("Invalid header %q, must be key=value", entry)
+		}
+
+		headers[key] = strings.TrimSpace(value)
+	}
+
+	return headers, nil
+}
+
+// Export sends the payload to the given path of the collector (such as /v1/traces), retrying on 429s, 5xx and
+// connection-level errors.
+func (c *Client) Export(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	retries := backoff.New(ctx, c.backoffConfig)
+
+	for {
+		var status int
+
+		status, err = c.send(ctx, path, body)
+		if err == nil {
+			return nil
+		}
+
+		// Only retry 429s, 500s and connection-level errors.
+		if status > 0 && status != http.StatusTooManyRequests && status/100 != 5 {
+			return err
+		}
+
+		if !retries.Ongoing() {
+			return err
+		}
+
+		retries.Wait()
+	}
+}
+
+func (c *Client) send(ctx context.Context, path string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.endpoint.String(), "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", version.UserAgent)
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxErrMsgLen))
+		line := ""
+
+		if scanner.Scan() {
+			line = scanner.Text()
+		}
+
+		return resp.StatusCode, fmt.Errorf("Collector returned HTTP status %s: %s", resp.Status, line)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// AnyValue represents an attribute value.
+type AnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// KeyValue represents an attribute.
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// Resource represents the entity producing the data.
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// Scope represents the instrumentation scope producing the data.
+type Scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Attributes converts a map into a list of attributes sorted by key. Values of unsupported types are
+// formatted as strings.
+func Attributes(values map[string]any) []KeyValue {
+	attributes := make([]KeyValue, 0, len(values))
+	for key, value := range values {
+		attributes = append(attributes, KeyValue{Key: key, Value: valueOf(value)})
+	}
+
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+
+	return attributes
+}
+
+func valueOf(value any) AnyValue {
+	switch v := value.(type) {
+	case string:
+		return AnyValue{StringValue: &v}
+	case bool:
+		return AnyValue{BoolValue: &v}
+	case int:
+		s := strconv.FormatInt(int64(v), 10)
+		return AnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return AnyValue{IntValue: &s}
+	case uint64:
+		s := strconv.FormatUint(v, 10)
+		return AnyValue{IntValue: &s}
+	case float64:
+		return AnyValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return AnyValue{StringValue: &s}
+	}
+}
+
+// NewResource returns the resource describing the given LXD server.
+func NewResource(serverName string) Resource {
+	values := map[string]any{
+		"service.name":    "lxd",
+		"service.version": version.Version,
+	}
+
+	if serverName != "" {
+		values["service.instance.id"] = serverName
+	}
+
+	return Resource{Attributes: Attributes(values)}
+}
+
+// NewScope returns the instrumentation scope of LXD.
+func NewScope() Scope {
+	return Scope{Name: "github.com/canonical/lxd", Version: version.Version}
+}
@@ -0,0 +1,34 @@
+package otlp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/otlp"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := otlp.ParseHeaders("Authorization=Bearer abc, X-Scope-OrgID = lxd")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc", "X-Scope-OrgID": "lxd"}, headers)
+
+	headers, err = otlp.ParseHeaders("")
+	require.NoError(t, err)
+	assert.Empty(t, headers)
+
+	_, err = otlp.ParseHeaders("Authorization")
+	assert.EqualError(t, err, `Invalid header "Authorization", must be key=value`)
+}
+
+func TestAttributes(t *testing.T) {
+	attributes := otlp.Attributes(map[string]any{"b": 1, "a": "foo", "c": true, "d": 0.5})
+	require.Len(t, attributes, 4)
+
+	assert.Equal(t, "a", attributes[0].Key)
+	assert.Equal(t, "foo", *attributes[0].Value.StringValue)
+	assert.Equal(t, "1", *attributes[1].Value.IntValue)
+	assert.True(t, *attributes[2].Value.BoolValue)
+	assert.Equal(t, 0.5, *attributes[3].Value.DoubleValue)
+}
@@ -48,6 +48,18 @@ var profileCmd = APIEndpoint{
 	Put:    APIEndpointAction{Handler: profilePut, AccessHandler: allowProjectPermission("profiles", "manage-profiles")},
 }
 
+var profileRevisionsCmd = APIEndpoint{
+	Path: "profiles/{name}/revisions",
+
+	Get: APIEndpointAction{Handler: profileRevisionsGet, AccessHandler: allowProjectPermission("profiles", "view")},
+}
+
+var profileRevisionCmd = APIEndpoint{
+	Path: "profiles/{name}/revisions/{revision}",
+
+	Get: APIEndpointAction{Handler: profileRevisionGet, AccessHandler: allowProjectPermission("profiles", "view")},
+}
+
 // swagger:operation GET /1.0/profiles profiles profiles_get
 //
 //  Get the profiles
@@ -506,7 +518,8 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	err = doProfileUpdate(s, *p, name, id, profile, req)
+	requestor := request.CreateRequestor(r)
+	revision, err := doProfileUpdate(s, *p, name, id, profile, req, requestor)
 
 	if err == nil && !isClusterNotification(r) {
 		// Notify all other nodes. If a node is down, it will be ignored.
@@ -523,8 +536,7 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	requestor := request.CreateRequestor(r)
-	s.Events.SendLifecycle(p.Name, lifecycle.ProfileUpdated.Event(name, p.Name, requestor, nil))
+	s.Events.SendLifecycle(p.Name, lifecycle.ProfileUpdated.Event(name, p.Name, requestor, lifecycle.ConfigRevisionContext(revision)))
 
 	return response.SmartError(err)
 }
@@ -656,9 +668,10 @@ func profilePatch(d *Daemon, r *http.Request) response.Response {
 	}
 
 	requestor := request.CreateRequestor(r)
-	s.Events.SendLifecycle(p.Name, lifecycle.ProfileUpdated.Event(name, p.Name, requestor, nil))
+	revision, err := doProfileUpdate(s, *p, name, id, profile, req, requestor)
+	s.Events.SendLifecycle(p.Name, lifecycle.ProfileUpdated.Event(name, p.Name, requestor, lifecycle.ConfigRevisionContext(revision)))
 
-	return response.SmartError(doProfileUpdate(s, *p, name, id, profile, req))
+	return response.SmartError(err)
 }
 
 // swagger:operation POST /1.0/profiles/{name} profiles profile_post
@@ -15,31 +15,33 @@ import (
 	"github.com/canonical/lxd/shared/api"
 )
 
-func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64, profile *api.Profile, req api.ProfilePut) error {
+// doProfileUpdate updates a profile and the instances using it, recording the change made by requestor
+// into the configuration history of the profile. Returns the recorded revision, if any.
+func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64, profile *api.Profile, req api.ProfilePut, requestor *api.EventLifecycleRequestor) (*api.ConfigRevision, error) {
 	// Check project limits.
 	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		return project.AllowProfileUpdate(tx, p.Name, profileName, req)
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Quick checks.
 	err = instance.ValidConfig(s.OS, req.Config, false, instancetype.Any)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Profiles can be applied to any instance type, so just use instancetype.Any type for validation so that
 	// instance type specific validation checks are not performed.
 	err = instance.ValidDevices(s, p, instancetype.Any, deviceConfig.NewDevices(req.Devices), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	insts, projects, err := getProfileInstancesInfo(s.DB.Cluster, p.Name, profileName)
 	if err != nil {
-		return fmt.Errorf("Failed to query instances associated with profile %q: %w", profileName, err)
+		return nil, fmt.Errorf("Failed to query instances associated with profile %q: %w", profileName, err)
 	}
 
 	// Check if the root disk device's pool would be changed or removed and prevent that if there are instances
@@ -59,7 +61,7 @@ func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64
 			for i := len(inst.Profiles) - 1; i >= 0; i-- {
 				_, profile, err := s.DB.Cluster.GetProfile(p.Name, inst.Profiles[i].Name)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				// Check if we find a match for the device.
@@ -68,7 +70,7 @@ func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64
 					// Found the profile.
 					if inst.Profiles[i].Name == profileName {
 						// If it's the current profile, then we can't modify that root device.
-						return fmt.Errorf("At least one instance relies on this profile's root disk device")
+						return nil, fmt.Errorf("At least one instance relies on this profile's root disk device")
 					}
 
 					// If it's not, then move on to the next instance.
@@ -79,6 +81,7 @@ func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64
 	}
 
 	// Update the database.
+	var revision *api.ConfigRevision
 	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		devices, err := cluster.APIToDevices(req.Devices)
 		if err != nil {
@@ -118,10 +121,19 @@ func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64
 			return fmt.Errorf("Failed to find profile %q in project %q", profileName, p.Name)
 		}
 
-		return nil
+		old := api.ConfigRevision{Config: profile.Config, Devices: profile.Devices}
+		new := api.ConfigRevision{Config: req.Config, Devices: req.Devices}
+		if requestor != nil {
+			new.Username = requestor.Username
+			new.Protocol = requestor.Protocol
+		}
+
+		revision, err = tx.CreateProfileConfigRevision(ctx, int(id), old, new)
+
+		return err
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update all the instances on this node using the profile. Must be done after db.TxCommit due to DB lock.
@@ -145,10 +157,10 @@ func doProfileUpdate(s *state.State, p api.Project, profileName string, id int64
 			msg += fmt.Sprintf(" - Project: %s, Instance: %s: %v\n", inst.Project, inst.Name, err)
 		}
 
-		return fmt.Errorf("%s", msg)
+		return revision, fmt.Errorf("%s", msg)
 	}
 
-	return nil
+	return revision, nil
 }
 
 // Like doProfileUpdate but does not update the database, since it was already
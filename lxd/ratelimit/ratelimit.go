@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit represents a maximum number of requests per interval.
+type Limit struct {
+	Requests int
+	Interval time.Duration
+}
+
+// ParseLimit parses a limit in the "<requests>/<unit>" format, where the unit is one of "s", "m" or "h".
+func ParseLimit(value string) (*Limit, error) {
+	requestsStr, unit, found := strings.Cut(value, "/")
+	if !found {
+		return nil, fmt.Errorf("Invalid rate limit %q, must be a number of requests followed by a slash and a unit", value)
+	}
+
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil || requests < 1 {
+		return nil, fmt.Errorf("Invalid number of requests %q, must be a positive integer", requestsStr)
+	}
+
+	intervals := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+	}
+
+	interval, found := intervals[unit]
+	if !found {
+		return nil, fmt.Errorf("Invalid rate limit unit %q, must be one of s, m or h", unit)
+	}
+
+	return &Limit{Requests: requests, Interval: interval}, nil
+}
+
+// bucket holds the tokens available to a key.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter enforces a limit through a token bucket per key. Each bucket holds up to the limit's number of
+// requests and is refilled at the limit's rate.
+type Limiter struct {
+	limit   Limit
+	buckets map[string]*bucket
+	calls   int
+	lock    sync.Mutex
+
+	// Allows tests to control time.
+	now func() time.Time
+}
+
+// NewLimiter returns a Limiter enforcing the given limit.
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the key. If none is available, it returns false along with the time
+// after which the next token will be.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	capacity := float64(l.limit.Requests)
+	rate := capacity / l.limit.Interval.Seconds()
+
+	// Forget about the keys whose bucket is full again every so often.
+	l.calls++
+	if l.calls%1000 == 0 {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*rate >= capacity {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill the bucket.
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
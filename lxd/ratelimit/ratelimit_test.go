@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("20/s")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 20, Interval: time.Second}, *limit)
+
+	limit, err = ParseLimit("100/m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 100, Interval: time.Minute}, *limit)
+
+	for _, value := range []string{"", "20", "0/s", "-1/s", "a/s", "20/d", "20/"} {
+		_, err = ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+
+	l := NewLimiter(Limit{Requests: 2, Interval: time.Second})
+	l.now = func() time.Time { return now }
+
+	// The bucket starts full.
+	for i := 0; i < 2; i++ {
+		allowed, _ := l.Allow("alice")
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := l.Allow("alice")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other keys have their own bucket.
+	allowed, _ = l.Allow("bob")
+	assert.True(t, allowed)
+
+	// A token gets added every half second.
+	now = now.Add(500 * time.Millisecond)
+
+	allowed, _ = l.Allow("alice")
+	assert.True(t, allowed)
+
+	allowed, _ = l.Allow("alice")
+	assert.False(t, allowed)
+
+	// The bucket doesn't hold more than the limit.
+	now = now.Add(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := l.Allow("alice")
+		assert.True(t, allowed)
+	}
+
+	allowed, _ = l.Allow("alice")
+	assert.False(t, allowed)
+}
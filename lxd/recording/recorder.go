@@ -0,0 +1,260 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Event types of the asciicast v2 format.
+const (
+	eventOutput = "o"
+	eventInput  = "i"
+	eventResize = "r"
+)
+
+// Header is the header line of an asciicast v2 recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// truncatedMessage is recorded as output when a recording reaches its maximum size.
+const truncatedMessage = "\r\n[Session recording stopped: maximum size reached]\r\n"
+
+// Recorder records a terminal session to a file in the asciicast v2 format.
+// All methods are safe to call on a nil Recorder, in which case nothing is recorded.
+type Recorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	start   time.Time
+	pending map[string][]byte
+	err     error
+	size    int64
+	maxSize int64
+	full    bool
+}
+
+// Create creates the recording file at path and writes its header.
+// A zero width or height in the header defaults to an 80x24 terminal.
+// Once the recording would grow beyond maxSize bytes (unless zero), a message noting it is recorded and the rest
+// of the session isn't.
+func Create(path string, header Header, maxSize int64) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating session recording: %w", err)
+	}
+
+	start := time.Now()
+
+	header.Version = 2
+	header.Timestamp = start.Unix()
+
+	if header.Width <= 0 || header.Height <= 0 {
+		header.Width = 80
+		header.Height = 24
+	}
+
+	line, err := json.Marshal(header)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("Failed writing session recording header: %w", err)
+	}
+
+	return &Recorder{
+		f:       f,
+		start:   start,
+		pending: map[string][]byte{},
+		size:    int64(len(line) + 1),
+		maxSize: maxSize,
+	}, nil
+}
+
+// event appends an event to the recording.
+// Data of input and output events is buffered until it ends on a complete UTF-8 sequence.
+func (r *Recorder) event(kind string, data []byte) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil || r.f == nil || r.full {
+		return
+	}
+
+	if kind != eventResize {
+		data = append(r.pending[kind], data...)
+
+		// Keep an incomplete trailing UTF-8 sequence for the next event.
+		end := len(data)
+		for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+			if utf8.RuneStart(data[len(data)-i]) {
+				if !utf8.FullRune(data[len(data)-i:]) {
+					end = len(data) - i
+				}
+
+				break
+			}
+		}
+
+		r.pending[kind] = append([]byte(nil), data[end:]...)
+		data = data[:end]
+
+		if len(data) == 0 {
+			return
+		}
+	}
+
+	line, err := json.Marshal([]any{time.Since(r.start).Seconds(), kind, string(data)})
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	if r.maxSize > 0 && r.size+int64(len(line)+1) > r.maxSize {
+		r.full = true
+
+		line, err = json.Marshal([]any{time.Since(r.start).Seconds(), eventOutput, truncatedMessage})
+		if err != nil {
+			r.err = err
+			return
+		}
+	}
+
+	n, err := r.f.Write(append(line, '\n'))
+	r.size += int64(n)
+	r.err = err
+}
+
+// Input records data sent to the terminal.
+func (r *Recorder) Input(data []byte) {
+	r.event(eventInput, data)
+}
+
+// Output records data printed by the terminal.
+func (r *Recorder) Output(data []byte) {
+	r.event(eventOutput, data)
+}
+
+// Resize records a change of the terminal size.
+func (r *Recorder) Resize(width int, height int) {
+	r.event(eventResize, []byte(fmt.Sprintf("%dx%d", width, height)))
+}
+
+// Write records p as output, so that the Recorder can be used as an io.Writer.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.Output(p)
+
+	return len(p), nil
+}
+
+// Close closes the recording file and returns the first error encountered while recording.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return r.err
+	}
+
+	err := r.f.Close()
+	r.f = nil
+
+	if r.err != nil {
+		return r.err
+	}
+
+	return err
+}
+
+type recordedReadWriteCloser struct {
+	io.ReadWriteCloser
+	r *Recorder
+}
+
+func (rwc recordedReadWriteCloser) Read(p []byte) (int, error) {
+	n, err := rwc.ReadWriteCloser.Read(p)
+	rwc.r.Output(p[:n])
+
+	return n, err
+}
+
+func (rwc recordedReadWriteCloser) Write(p []byte) (int, error) {
+	n, err := rwc.ReadWriteCloser.Write(p)
+	rwc.r.Input(p[:n])
+
+	return n, err
+}
+
+// ReadWriteCloser wraps the terminal rwc, recording what is read from it as output
+// and what is written to it as input.
+func (r *Recorder) ReadWriteCloser(rwc io.ReadWriteCloser) io.ReadWriteCloser {
+	if r == nil {
+		return rwc
+	}
+
+	return recordedReadWriteCloser{ReadWriteCloser: rwc, r: r}
+}
+
+type recordedReadCloser struct {
+	io.ReadCloser
+	r *Recorder
+}
+
+func (rc recordedReadCloser) Read(p []byte) (int, error) {
+	n, err := rc.ReadCloser.Read(p)
+	rc.r.Output(p[:n])
+
+	return n, err
+}
+
+// Reader wraps rc, recording what is read from it as output.
+func (r *Recorder) Reader(rc io.ReadCloser) io.ReadCloser {
+	if r == nil {
+		return rc
+	}
+
+	return recordedReadCloser{ReadCloser: rc, r: r}
+}
+
+type recordedWriteCloser struct {
+	io.WriteCloser
+	r *Recorder
+}
+
+func (wc recordedWriteCloser) Write(p []byte) (int, error) {
+	n, err := wc.WriteCloser.Write(p)
+	wc.r.Input(p[:n])
+
+	return n, err
+}
+
+// Writer wraps wc, recording what is written to it as input.
+func (r *Recorder) Writer(wc io.WriteCloser) io.WriteCloser {
+	if r == nil {
+		return wc
+	}
+
+	return recordedWriteCloser{WriteCloser: wc, r: r}
+}
@@ -0,0 +1,94 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+
+	r, err := Create(path, Header{Command: "bash"}, 0)
+	require.NoError(t, err)
+
+	r.Output([]byte("$ "))
+	r.Input([]byte("ls\r"))
+	r.Resize(120, 40)
+
+	// A multi-byte character split across two reads is recorded once complete.
+	r.Output([]byte{0xc3})
+	r.Output([]byte{0xa9, '\n'})
+
+	require.NoError(t, r.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+
+	require.True(t, scanner.Scan())
+	header := Header{}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, 80, header.Width)
+	assert.Equal(t, 24, header.Height)
+	assert.Equal(t, "bash", header.Command)
+
+	events := [][2]string{}
+	for scanner.Scan() {
+		event := []any{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		require.Len(t, event, 3)
+
+		events = append(events, [2]string{event[1].(string), event[2].(string)})
+	}
+
+	assert.Equal(t, [][2]string{
+		{"o", "$ "},
+		{"i", "ls\r"},
+		{"r", "120x40"},
+		{"o", "é\n"},
+	}, events)
+}
+
+func TestRecorderNil(t *testing.T) {
+	var r *Recorder
+
+	r.Output([]byte("ignored"))
+	n, err := r.Write([]byte("ignored"))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+	assert.NoError(t, r.Close())
+}
+
+func TestRecorderMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+
+	r, err := Create(path, Header{}, 200)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		r.Output([]byte("0123456789"))
+	}
+
+	require.NoError(t, r.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// The recording stops once full, ending with a message noting it.
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Less(t, len(lines), 10)
+
+	event := []any{}
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &event))
+	assert.Equal(t, truncatedMessage, event[2])
+}
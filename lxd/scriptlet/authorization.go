@@ -0,0 +1,112 @@
+package scriptlet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.starlark.net/starlark"
+
+	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// authorizationInit caches the globals of the initialised authorization scriptlet, which is only initialised
+// again once the scriptlet changes.
+var authorizationInit struct {
+	mu      sync.Mutex
+	prog    *starlark.Program
+	globals starlark.StringDict
+}
+
+// authorizationGlobals returns the globals of the authorization scriptlet program, initialising it on the given
+// thread if not yet done. The log functions of the scriptlet use the logger of the run that initialised it.
+func authorizationGlobals(prog *starlark.Program, thread *starlark.Thread, l logger.Logger) (starlark.StringDict, error) {
+	authorizationInit.mu.Lock()
+	defer authorizationInit.mu.Unlock()
+
+	if authorizationInit.prog == prog {
+		return authorizationInit.globals, nil
+	}
+
+	logFunc := newLogFunc(l, "Authorization scriptlet")
+
+	// Remember to match the entries in scriptletLoad.AuthorizationCompile() with this list so Starlark can
+	// perform compile time validation of functions used.
+	env := starlark.StringDict{
+		"log_info":  starlark.NewBuiltin("log_info", logFunc),
+		"log_warn":  starlark.NewBuiltin("log_warn", logFunc),
+		"log_error": starlark.NewBuiltin("log_error", logFunc),
+	}
+
+	globals, err := prog.Init(thread, env)
+	if err != nil {
+		return nil, fmt.Errorf("Failed initializing: %w", err)
+	}
+
+	globals.Freeze()
+
+	authorizationInit.prog = prog
+	authorizationInit.globals = globals
+
+	return globals, nil
+}
+
+// AuthorizationRun runs the authorization scriptlet.
+// Returns whether the identity in details is granted the permission on the project.
+// An empty project is used when checking whether the identity is a global admin.
+func AuthorizationRun(ctx context.Context, l logger.Logger, details *apiScriptlet.AuthorizationDetails, project string, permission string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prog, thread, err := scriptletLoad.AuthorizationProgram()
+	if err != nil {
+		return false, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		thread.Cancel("Request finished")
+	}()
+
+	globals, err := authorizationGlobals(prog, thread, l)
+	if err != nil {
+		return false, err
+	}
+
+	// Retrieve a global variable from starlark environment.
+	authorize := globals["authorize"]
+	if authorize == nil {
+		return false, fmt.Errorf("Scriptlet missing authorize function")
+	}
+
+	rv, err := StarlarkMarshal(details)
+	if err != nil {
+		return false, fmt.Errorf("Marshalling details failed: %w", err)
+	}
+
+	// Call starlark function from Go.
+	v, err := starlark.Call(thread, authorize, nil, []starlark.Tuple{
+		{
+			starlark.String("details"),
+			rv,
+		}, {
+			starlark.String("project"),
+			starlark.String(project),
+		}, {
+			starlark.String("permission"),
+			starlark.String(permission),
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("Failed to run: %w", err)
+	}
+
+	allowed, ok := v.(starlark.Bool)
+	if !ok {
+		return false, fmt.Errorf("Failed with unexpected return value: %v", v)
+	}
+
+	return bool(allowed), nil
+}
@@ -0,0 +1,106 @@
+package scriptlet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+const authorizationTestScriptlet = `
+def authorize(details, project, permission):
+    if details.protocol == "oidc" and "admins" in details.claims.get("groups", []):
+        return True
+
+    if project == "dev":
+        return True
+
+    return project == "prod" and permission == "view"
+`
+
+func TestAuthorizationRun(t *testing.T) {
+	require.NoError(t, scriptletLoad.AuthorizationSet(authorizationTestScriptlet))
+	defer func() { _ = scriptletLoad.AuthorizationSet("") }()
+
+	admin := &apiScriptlet.AuthorizationDetails{
+		Username: "alice@example.com",
+		Protocol: "oidc",
+		Claims:   map[string]any{"groups": []any{"admins"}},
+	}
+
+	user := &apiScriptlet.AuthorizationDetails{
+		Username: "0123456789abcdef",
+		Protocol: "tls",
+	}
+
+	tests := []struct {
+		details    *apiScriptlet.AuthorizationDetails
+		project    string
+		permission string
+		allowed    bool
+	}{
+		{admin, "", "admin", true},
+		{admin, "prod", "manage-containers", true},
+		{user, "", "admin", false},
+		{user, "dev", "manage-containers", true},
+		{user, "prod", "view", true},
+		{user, "prod", "manage-containers", false},
+	}
+
+	for _, test := range tests {
+		allowed, err := AuthorizationRun(context.Background(), logger.Log, test.details, test.project, test.permission)
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, allowed, "%s %s %s", test.details.Username, test.project, test.permission)
+	}
+}
+
+func TestAuthorizationRunInvalidReturn(t *testing.T) {
+	require.NoError(t, scriptletLoad.AuthorizationSet("def authorize(details, project, permission):\n    return \"yes\"\n"))
+	defer func() { _ = scriptletLoad.AuthorizationSet("") }()
+
+	_, err := AuthorizationRun(context.Background(), logger.Log, &apiScriptlet.AuthorizationDetails{}, "default", "view")
+	assert.Error(t, err)
+}
+
+func TestAuthorizationRunNotLoaded(t *testing.T) {
+	_, err := AuthorizationRun(context.Background(), logger.Log, &apiScriptlet.AuthorizationDetails{}, "default", "view")
+	assert.Error(t, err)
+}
+
+func TestAuthorizationRun_Init(t *testing.T) {
+	src := `
+def authorize(details, project, permission):
+    return True
+`
+
+	require.NoError(t, scriptletLoad.AuthorizationSet(src))
+	defer func() { _ = scriptletLoad.AuthorizationSet("") }()
+
+	details := &apiScriptlet.AuthorizationDetails{Username: "0123456789abcdef", Protocol: "tls"}
+
+	// The initialised scriptlet is reused across runs.
+	for i := 0; i < 2; i++ {
+		allowed, err := AuthorizationRun(context.Background(), logger.Log, details, "default", "view")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	prog, _, err := scriptletLoad.AuthorizationProgram()
+	require.NoError(t, err)
+	assert.Equal(t, prog, authorizationInit.prog)
+
+	// A new scriptlet gets initialised again.
+	require.NoError(t, scriptletLoad.AuthorizationSet(`
+def authorize(details, project, permission):
+    return False
+`))
+
+	allowed, err := AuthorizationRun(context.Background(), logger.Log, details, "default", "view")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
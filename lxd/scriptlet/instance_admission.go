@@ -0,0 +1,235 @@
+package scriptlet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.starlark.net/starlark"
+
+	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// InstanceAdmissionRun runs the instance admission scriptlet.
+// The scriptlet can modify the request in place or reject it, in which case a forbidden error is returned.
+func InstanceAdmissionRun(ctx context.Context, l logger.Logger, req *apiScriptlet.InstanceAdmission) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logFunc := newLogFunc(l, "Instance admission scriptlet")
+
+	var rejection string
+
+	// Only the configuration of the instances being created or updated can be changed.
+	canChange := shared.StringInSlice(req.Action, []string{apiScriptlet.InstanceAdmissionActionCreate, apiScriptlet.InstanceAdmissionActionUpdate})
+
+	rejectFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var message string
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "message", &message)
+		if err != nil {
+			return nil, err
+		}
+
+		if message == "" {
+			message = "No reason given"
+		}
+
+		rejection = message
+
+		return starlark.None, nil
+	}
+
+	setNameFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !shared.StringInSlice(req.Action, []string{apiScriptlet.InstanceAdmissionActionCreate, apiScriptlet.InstanceAdmissionActionRename, apiScriptlet.InstanceAdmissionActionImport}) {
+			return nil, fmt.Errorf("The instance name can only be set when creating, renaming or importing an instance")
+		}
+
+		req.Name = name
+
+		return starlark.None, nil
+	}
+
+	setConfigFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var key string
+		var value string
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "key", &key, "value", &value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !canChange {
+			return nil, fmt.Errorf("The instance configuration can only be changed when creating or updating an instance")
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		if value == "" {
+			delete(req.Config, key)
+		} else {
+			req.Config[key] = value
+		}
+
+		return starlark.None, nil
+	}
+
+	setDeviceFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		var device *starlark.Dict
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "device", &device)
+		if err != nil {
+			return nil, err
+		}
+
+		if !canChange {
+			return nil, fmt.Errorf("The instance configuration can only be changed when creating or updating an instance")
+		}
+
+		config := make(map[string]string, device.Len())
+		for _, item := range device.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("Device key %v isn't a string", item[0])
+			}
+
+			value, ok := starlark.AsString(item[1])
+			if !ok {
+				return nil, fmt.Errorf("Value of device key %q isn't a string", key)
+			}
+
+			config[key] = value
+		}
+
+		if req.Devices == nil {
+			req.Devices = map[string]map[string]string{}
+		}
+
+		req.Devices[name] = config
+
+		return starlark.None, nil
+	}
+
+	removeDeviceFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !canChange {
+			return nil, fmt.Errorf("The instance configuration can only be changed when creating or updating an instance")
+		}
+
+		delete(req.Devices, name)
+
+		return starlark.None, nil
+	}
+
+	setProfilesFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var profiles *starlark.List
+
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "profiles", &profiles)
+		if err != nil {
+			return nil, err
+		}
+
+		if !canChange {
+			return nil, fmt.Errorf("The instance configuration can only be changed when creating or updating an instance")
+		}
+
+		names := make([]string, 0, profiles.Len())
+		for i := 0; i < profiles.Len(); i++ {
+			name, ok := starlark.AsString(profiles.Index(i))
+			if !ok {
+				return nil, fmt.Errorf("Profile name %v isn't a string", profiles.Index(i))
+			}
+
+			names = append(names, name)
+		}
+
+		req.Profiles = names
+
+		return starlark.None, nil
+	}
+
+	// Remember to match the entries in scriptletLoad.InstanceAdmissionCompile() with this list so Starlark can
+	// perform compile time validation of functions used.
+	env := starlark.StringDict{
+		"log_info":      starlark.NewBuiltin("log_info", logFunc),
+		"log_warn":      starlark.NewBuiltin("log_warn", logFunc),
+		"log_error":     starlark.NewBuiltin("log_error", logFunc),
+		"reject":        starlark.NewBuiltin("reject", rejectFunc),
+		"set_name":      starlark.NewBuiltin("set_name", setNameFunc),
+		"set_config":    starlark.NewBuiltin("set_config", setConfigFunc),
+		"set_device":    starlark.NewBuiltin("set_device", setDeviceFunc),
+		"remove_device": starlark.NewBuiltin("remove_device", removeDeviceFunc),
+		"set_profiles":  starlark.NewBuiltin("set_profiles", setProfilesFunc),
+	}
+
+	prog, thread, err := scriptletLoad.InstanceAdmissionProgram()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		thread.Cancel("Request finished")
+	}()
+
+	globals, err := prog.Init(thread, env)
+	if err != nil {
+		return fmt.Errorf("Failed initializing: %w", err)
+	}
+
+	globals.Freeze()
+
+	// Retrieve a global variable from starlark environment.
+	instanceAdmission := globals["instance_admission"]
+	if instanceAdmission == nil {
+		return fmt.Errorf("Scriptlet missing instance_admission function")
+	}
+
+	// The request passed to the scriptlet isn't affected by the changes made through the functions above.
+	rv, err := StarlarkMarshal(req)
+	if err != nil {
+		return fmt.Errorf("Marshalling request failed: %w", err)
+	}
+
+	// Call starlark function from Go.
+	v, err := starlark.Call(thread, instanceAdmission, nil, []starlark.Tuple{
+		{
+			starlark.String("request"),
+			rv,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to run: %w", err)
+	}
+
+	if v.Type() != "NoneType" {
+		return fmt.Errorf("Failed with unexpected return value: %v", v)
+	}
+
+	if rejection != "" {
+		l.Info("Instance admission scriptlet rejected request", logger.Ctx{"project": req.Project, "instance": req.Name, "action": req.Action, "reason": rejection})
+		return api.StatusErrorf(http.StatusForbidden, "Instance %s rejected: %s", req.Action, rejection)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package scriptlet
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	scriptletLoad "github.com/canonical/lxd/lxd/scriptlet/load"
+	"github.com/canonical/lxd/shared/api"
+	apiScriptlet "github.com/canonical/lxd/shared/api/scriptlet"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+const instanceAdmissionTestScriptlet = `
+def instance_admission(request):
+    if request.requestor.username == "mallory":
+        reject("User not allowed")
+        return
+
+    if request.action == "create" and not request.name.startswith("web-"):
+        set_name("web-" + request.name)
+
+    if request.config.get("limits.memory", "") == "":
+        set_config("limits.memory", "1GiB")
+
+    set_config("security.privileged", "")
+    set_device("eth0", {"type": "nic", "network": "lxdbr0"})
+    remove_device("gpu")
+    set_profiles(request.profiles + ["monitoring"])
+`
+
+func TestInstanceAdmissionRun(t *testing.T) {
+	require.NoError(t, scriptletLoad.InstanceAdmissionSet(instanceAdmissionTestScriptlet))
+	defer func() { _ = scriptletLoad.InstanceAdmissionSet("") }()
+
+	req := &apiScriptlet.InstanceAdmission{
+		InstancePut: api.InstancePut{
+			Config:   map[string]string{"security.privileged": "true"},
+			Devices:  map[string]map[string]string{"gpu": {"type": "gpu"}},
+			Profiles: []string{"default"},
+		},
+		Action:    apiScriptlet.InstanceAdmissionActionCreate,
+		Project:   "default",
+		Name:      "c1",
+		Type:      "container",
+		Requestor: api.EventLifecycleRequestor{Username: "alice", Protocol: "tls"},
+	}
+
+	err := InstanceAdmissionRun(context.Background(), logger.Log, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "web-c1", req.Name)
+	assert.Equal(t, map[string]string{"limits.memory": "1GiB"}, req.Config)
+	assert.Equal(t, map[string]map[string]string{"eth0": {"type": "nic", "network": "lxdbr0"}}, req.Devices)
+	assert.Equal(t, []string{"default", "monitoring"}, req.Profiles)
+
+	// Rejected requests.
+	req.Requestor.Username = "mallory"
+	err = InstanceAdmissionRun(context.Background(), logger.Log, req)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusForbidden))
+
+}
+
+func TestInstanceAdmissionRunUpdate(t *testing.T) {
+	require.NoError(t, scriptletLoad.InstanceAdmissionSet("def instance_admission(request):\n    set_name(\"c2\")\n"))
+	defer func() { _ = scriptletLoad.InstanceAdmissionSet("") }()
+
+	req := &apiScriptlet.InstanceAdmission{
+		Action: apiScriptlet.InstanceAdmissionActionUpdate,
+		Name:   "c1",
+	}
+
+	// The name can't be changed on update.
+	err := InstanceAdmissionRun(context.Background(), logger.Log, req)
+	assert.Error(t, err)
+	assert.Equal(t, "c1", req.Name)
+}
+
+func TestInstanceAdmissionRunRestore(t *testing.T) {
+	require.NoError(t, scriptletLoad.InstanceAdmissionSet("def instance_admission(request):\n    set_config(\"limits.cpu\", \"2\")\n"))
+	defer func() { _ = scriptletLoad.InstanceAdmissionSet("") }()
+
+	req := &apiScriptlet.InstanceAdmission{
+		Action: apiScriptlet.InstanceAdmissionActionRestore,
+		Name:   "c1",
+	}
+
+	// The configuration can't be changed on restore.
+	err := InstanceAdmissionRun(context.Background(), logger.Log, req)
+	assert.Error(t, err)
+	assert.Empty(t, req.Config)
+
+	// The name can be changed on rename.
+	require.NoError(t, scriptletLoad.InstanceAdmissionSet("def instance_admission(request):\n    set_name(\"prod-\" + request.name)\n"))
+
+	req.Action = apiScriptlet.InstanceAdmissionActionRename
+	err = InstanceAdmissionRun(context.Background(), logger.Log, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-c1", req.Name)
+}
+
+func TestInstanceAdmissionCompile(t *testing.T) {
+	// Functions of the instance placement scriptlet aren't available.
+	err := scriptletLoad.InstanceAdmissionValidate("def instance_admission(request):\n    set_target(\"foo\")\n")
+	assert.Error(t, err)
+}
@@ -12,22 +12,20 @@ import (
 // nameInstancePlacement is the name used in Starlark for the instance placement scriptlet.
 const nameInstancePlacement = "instance_placement"
 
-// InstancePlacementCompile compiles the instance placement scriptlet.
-func InstancePlacementCompile(src string) (*starlark.Program, error) {
+// nameInstanceAdmission is the name used in Starlark for the instance admission scriptlet.
+const nameInstanceAdmission = "instance_admission"
+
+// nameAuthorization is the name used in Starlark for the authorization scriptlet.
+const nameAuthorization = "authorization"
+
+// compile compiles a scriptlet allowing the given predeclared functions.
+func compile(name string, src string, preDeclared []string) (*starlark.Program, error) {
 	isPreDeclared := func(name string) bool {
-		return shared.StringInSlice(name, []string{
-			"log_info",
-			"log_warn",
-			"log_error",
-			"set_target",
-			"get_cluster_member_resources",
-			"get_cluster_member_state",
-			"get_instance_resources",
-		})
+		return shared.StringInSlice(name, preDeclared)
 	}
 
 	// Parse, resolve, and compile a Starlark source file.
-	_, mod, err := starlark.SourceProgram(nameInstancePlacement, src, isPreDeclared)
+	_, mod, err := starlark.SourceProgram(name, src, isPreDeclared)
 	if err != nil {
 		return nil, err
 	}
@@ -35,31 +33,64 @@ func InstancePlacementCompile(src string) (*starlark.Program, error) {
 	return mod, nil
 }
 
+var programsMu sync.Mutex
+var programs = make(map[string]*starlark.Program)
+
+// set stores a compiled scriptlet program in memory, or deletes it if nil.
+func set(name string, prog *starlark.Program) {
+	programsMu.Lock()
+	defer programsMu.Unlock()
+
+	if prog == nil {
+		delete(programs, name)
+	} else {
+		programs[name] = prog
+	}
+}
+
+// program returns a stored scriptlet program and a new thread to run it.
+func program(name string) (*starlark.Program, *starlark.Thread, bool) {
+	programsMu.Lock()
+	prog, found := programs[name]
+	programsMu.Unlock()
+	if !found {
+		return nil, nil, false
+	}
+
+	return prog, &starlark.Thread{Name: name}, true
+}
+
+// InstancePlacementCompile compiles the instance placement scriptlet.
+func InstancePlacementCompile(src string) (*starlark.Program, error) {
+	return compile(nameInstancePlacement, src, []string{
+		"log_info",
+		"log_warn",
+		"log_error",
+		"set_target",
+		"get_cluster_member_resources",
+		"get_cluster_member_state",
+		"get_instance_resources",
+	})
+}
+
 // InstancePlacementValidate validates the instance placement scriptlet.
 func InstancePlacementValidate(src string) error {
 	_, err := InstancePlacementCompile(src)
 	return err
 }
 
-var programsMu sync.Mutex
-var programs = make(map[string]*starlark.Program)
-
 // InstancePlacementSet compiles the instance placement scriptlet into memory for use with InstancePlacementRun.
 // If empty src is provided the current program is deleted.
 func InstancePlacementSet(src string) error {
 	if src == "" {
-		programsMu.Lock()
-		delete(programs, nameInstancePlacement)
-		programsMu.Unlock()
+		set(nameInstancePlacement, nil)
 	} else {
 		prog, err := InstancePlacementCompile(src)
 		if err != nil {
 			return err
 		}
 
-		programsMu.Lock()
-		programs[nameInstancePlacement] = prog
-		programsMu.Unlock()
+		set(nameInstancePlacement, prog)
 	}
 
 	return nil
@@ -67,14 +98,100 @@ func InstancePlacementSet(src string) error {
 
 // InstancePlacementProgram returns the precompiled instance placement scriptlet program.
 func InstancePlacementProgram() (*starlark.Program, *starlark.Thread, error) {
-	programsMu.Lock()
-	prog, found := programs[nameInstancePlacement]
-	programsMu.Unlock()
+	prog, thread, found := program(nameInstancePlacement)
 	if !found {
 		return nil, nil, fmt.Errorf("Instance placement scriptlet not loaded")
 	}
 
-	thread := &starlark.Thread{Name: nameInstancePlacement}
+	return prog, thread, nil
+}
+
+// InstanceAdmissionCompile compiles the instance admission scriptlet.
+func InstanceAdmissionCompile(src string) (*starlark.Program, error) {
+	return compile(nameInstanceAdmission, src, []string{
+		"log_info",
+		"log_warn",
+		"log_error",
+		"reject",
+		"set_name",
+		"set_config",
+		"set_device",
+		"remove_device",
+		"set_profiles",
+	})
+}
+
+// InstanceAdmissionValidate validates the instance admission scriptlet.
+func InstanceAdmissionValidate(src string) error {
+	_, err := InstanceAdmissionCompile(src)
+	return err
+}
+
+// InstanceAdmissionSet compiles the instance admission scriptlet into memory for use with InstanceAdmissionRun.
+// If empty src is provided the current program is deleted.
+func InstanceAdmissionSet(src string) error {
+	if src == "" {
+		set(nameInstanceAdmission, nil)
+	} else {
+		prog, err := InstanceAdmissionCompile(src)
+		if err != nil {
+			return err
+		}
+
+		set(nameInstanceAdmission, prog)
+	}
+
+	return nil
+}
+
+// InstanceAdmissionProgram returns the precompiled instance admission scriptlet program.
+func InstanceAdmissionProgram() (*starlark.Program, *starlark.Thread, error) {
+	prog, thread, found := program(nameInstanceAdmission)
+	if !found {
+		return nil, nil, fmt.Errorf("Instance admission scriptlet not loaded")
+	}
+
+	return prog, thread, nil
+}
+
+// AuthorizationCompile compiles the authorization scriptlet.
+func AuthorizationCompile(src string) (*starlark.Program, error) {
+	return compile(nameAuthorization, src, []string{
+		"log_info",
+		"log_warn",
+		"log_error",
+	})
+}
+
+// AuthorizationValidate validates the authorization scriptlet.
+func AuthorizationValidate(src string) error {
+	_, err := AuthorizationCompile(src)
+	return err
+}
+
+// AuthorizationSet compiles the authorization scriptlet into memory for use with AuthorizationRun.
+// If empty src is provided the current program is deleted.
+func AuthorizationSet(src string) error {
+	if src == "" {
+		set(nameAuthorization, nil)
+	} else {
+		prog, err := AuthorizationCompile(src)
+		if err != nil {
+			return err
+		}
+
+		set(nameAuthorization, prog)
+	}
+
+	return nil
+}
+
+// AuthorizationProgram returns the precompiled authorization scriptlet program.
+func AuthorizationProgram() (*starlark.Program, *starlark.Thread, error) {
+	prog, thread, found := program(nameAuthorization)
+	if !found {
+		return nil, nil, fmt.Errorf("Authorization scriptlet not loaded")
+	}
 
 	return prog, thread, nil
 }
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"go.starlark.net/starlark"
+
+	"github.com/canonical/lxd/shared/logger"
 )
 
 // starlarkObject wraps a starlark.Dict and is used to provide custom object types to the Starlark scriptlets.
@@ -203,3 +206,30 @@ func starlarkMarshal(input any, parent *starlark.Dict) (starlark.Value, error) {
 
 	return sv, nil
 }
+
+// newLogFunc returns a Starlark builtin implementation of the log_info, log_warn and log_error functions,
+// which add the concatenation of their arguments to the given logger, prefixed with the scriptlet description.
+func newLogFunc(l logger.Logger, prefix string) func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			s, err := strconv.Unquote(arg.String())
+			if err != nil {
+				s = arg.String()
+			}
+
+			sb.WriteString(s)
+		}
+
+		switch b.Name() {
+		case "log_info":
+			l.Info(fmt.Sprintf("%s: %s", prefix, sb.String()))
+		case "log_warn":
+			l.Warn(fmt.Sprintf("%s: %s", prefix, sb.String()))
+		default:
+			l.Error(fmt.Sprintf("%s: %s", prefix, sb.String()))
+		}
+
+		return starlark.None, nil
+	}
+}
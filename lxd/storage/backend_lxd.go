@@ -45,6 +45,7 @@ import (
 	"github.com/canonical/lxd/lxd/storage/memorypipe"
 	"github.com/canonical/lxd/lxd/storage/s3"
 	"github.com/canonical/lxd/lxd/storage/s3/miniod"
+	"github.com/canonical/lxd/lxd/tracing"
 	"github.com/canonical/lxd/lxd/util"
 	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/api"
@@ -489,6 +490,27 @@ func (b *lxdBackend) ApplyPatch(name string) error {
 	return nil
 }
 
+// spanAttributes returns the given span attributes along with those of the storage pool.
+func (b *lxdBackend) spanAttributes(attributes map[string]any) map[string]any {
+	attributes["lxd.storage.pool"] = b.name
+	attributes["lxd.storage.driver"] = b.driver.Info().Name
+
+	return attributes
+}
+
+// startInstanceSpan starts a span for a storage action on an instance, as part of the trace of the operation.
+func (b *lxdBackend) startInstanceSpan(action string, inst instance.Instance, op *operations.Operation) *tracing.Span {
+	// Only trace the actions performed by operations, rather than those of the background tasks.
+	if op == nil || !tracing.Enabled() {
+		return nil
+	}
+
+	return op.StartSpan("storage."+action, b.spanAttributes(map[string]any{
+		"lxd.project":  inst.Project().Name,
+		"lxd.instance": inst.Name(),
+	}))
+}
+
 // ensureInstanceSymlink creates a symlink in the instance directory to the instance's mount path
 // if doesn't exist already.
 func (b *lxdBackend) ensureInstanceSymlink(instanceType instancetype.Type, projectName string, instanceName string, mountPath string) error {
@@ -614,12 +636,14 @@ func (b *lxdBackend) applyInstanceRootDiskOverrides(inst instance.Instance, vol
 }
 
 // CreateInstance creates an empty instance.
-func (b *lxdBackend) CreateInstance(inst instance.Instance, op *operations.Operation) error {
+func (b *lxdBackend) CreateInstance(inst instance.Instance, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 	l.Debug("CreateInstance started")
 	defer l.Debug("CreateInstance finished")
+	span := b.startInstanceSpan("CreateInstance", inst, op)
+	defer func() { span.EndWithError(err) }()
 
-	err := b.isStatusReady()
+	err = b.isStatusReady()
 	if err != nil {
 		return err
 	}
@@ -916,13 +940,123 @@ func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.
 	return postHook, revertHook, nil
 }
 
+// CreateInstanceFromConversion creates a new instance volume from the volume of an instance of a different type.
+// The source instance's root is mounted and passed to the convert function along with the root of the new
+// instance, which is the rootfs directory for containers and the root disk for virtual machines, and the size of
+// the new root disk in bytes. Snapshots aren't converted.
+func (b *lxdBackend) CreateInstanceFromConversion(inst instance.Instance, src instance.Instance, convert func(srcPath string, targetPath string, targetSize int64) error, op *operations.Operation) error {
+	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "src": src.Name()})
+	l.Debug("CreateInstanceFromConversion started")
+	defer l.Debug("CreateInstanceFromConversion finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	if inst.Type() == src.Type() {
+		return fmt.Errorf("Instance types must differ")
+	}
+
+	if src.IsRunning() {
+		return fmt.Errorf("Source instance must be stopped to be converted")
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Mount the source instance (which may be on another pool).
+	srcPool, err := LoadByInstance(b.state, src)
+	if err != nil {
+		return err
+	}
+
+	var srcMount *MountInfo
+	if src.IsSnapshot() {
+		srcMount, err = srcPool.MountInstanceSnapshot(src, op)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = srcPool.UnmountInstanceSnapshot(src, op) }()
+	} else {
+		srcMount, err = srcPool.MountInstance(src, op)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = srcPool.UnmountInstance(src, op) }()
+	}
+
+	srcPath := src.RootfsPath()
+	if src.Type() == instancetype.VM {
+		srcPath = srcMount.DiskPath
+	}
+
+	// Validate config and create database entry for new storage volume.
+	volumeConfig := make(map[string]string)
+	err = VolumeDBCreate(b, inst.Project().Name, inst.Name(), "", volType, false, volumeConfig, inst.CreationDate(), time.Time{}, contentType, false, false)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = VolumeDBDelete(b, inst.Project().Name, inst.Name(), volType) })
+
+	// Generate the effective root device volume for instance.
+	volStorageName := project.Instance(inst.Project().Name, inst.Name())
+	vol := b.GetVolume(volType, contentType, volStorageName, volumeConfig)
+	err = b.applyInstanceRootDiskOverrides(inst, &vol)
+	if err != nil {
+		return err
+	}
+
+	volFiller := drivers.VolumeFiller{
+		Fill: func(vol drivers.Volume, rootBlockPath string, allowUnsafeResize bool) (int64, error) {
+			if rootBlockPath == "" {
+				return 0, convert(srcPath, filepath.Join(vol.MountPath(), "rootfs"), 0)
+			}
+
+			sizeBytes, err := units.ParseByteSizeString(vol.ConfigSize())
+			if err != nil {
+				return 0, err
+			}
+
+			return sizeBytes, convert(srcPath, rootBlockPath, sizeBytes)
+		},
+	}
+
+	err = b.driver.CreateVolume(vol, &volFiller, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = b.DeleteInstance(inst, op) })
+
+	err = b.ensureInstanceSymlink(inst.Type(), inst.Project().Name, inst.Name(), vol.MountPath())
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
 // CreateInstanceFromCopy copies an instance volume and optionally its snapshots to new volume(s).
-func (b *lxdBackend) CreateInstanceFromCopy(inst instance.Instance, src instance.Instance, snapshots bool, allowInconsistent bool, op *operations.Operation) error {
+func (b *lxdBackend) CreateInstanceFromCopy(inst instance.Instance, src instance.Instance, snapshots bool, allowInconsistent bool, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "src": src.Name(), "snapshots": snapshots})
 	l.Debug("CreateInstanceFromCopy started")
 	defer l.Debug("CreateInstanceFromCopy finished")
+	span := b.startInstanceSpan("CreateInstanceFromCopy", inst, op)
+	defer func() { span.EndWithError(err) }()
 
-	err := b.isStatusReady()
+	err = b.isStatusReady()
 	if err != nil {
 		return err
 	}
@@ -1591,7 +1725,7 @@ func (b *lxdBackend) RefreshInstance(inst instance.Instance, src instance.Instan
 // The function returned will unpack the specified image archive into the specified mount path
 // provided, and for VM images, a raw root block path is required to unpack the qcow2 image into.
 func (b *lxdBackend) imageFiller(fingerprint string, op *operations.Operation) func(vol drivers.Volume, rootBlockPath string, allowUnsafeResize bool) (int64, error) {
-	return func(vol drivers.Volume, rootBlockPath string, allowUnsafeResize bool) (int64, error) {
+	return func(vol drivers.Volume, rootBlockPath string, allowUnsafeResize bool) (size int64, err error) {
 		var tracker *ioprogress.ProgressTracker
 		if op != nil { // Not passed when being done as part of pre-migration setup.
 			metadata := make(map[string]any)
@@ -1602,6 +1736,9 @@ func (b *lxdBackend) imageFiller(fingerprint string, op *operations.Operation) f
 				}}
 		}
 
+		span := op.StartSpan("storage.ImageUnpack", b.spanAttributes(map[string]any{"lxd.image.fingerprint": fingerprint, "lxd.storage.volume": vol.Name()}))
+		defer func() { span.EndWithError(err) }()
+
 		imageFile := shared.VarPath("images", fingerprint)
 		return ImageUnpack(imageFile, vol, rootBlockPath, b.state.OS, allowUnsafeResize, tracker)
 	}
@@ -1625,12 +1762,14 @@ func (b *lxdBackend) isoFiller(data io.Reader) func(vol drivers.Volume, rootBloc
 
 // CreateInstanceFromImage creates a new volume for an instance populated with the image requested.
 // On failure caller is expected to call DeleteInstance() to clean up.
-func (b *lxdBackend) CreateInstanceFromImage(inst instance.Instance, fingerprint string, op *operations.Operation) error {
+func (b *lxdBackend) CreateInstanceFromImage(inst instance.Instance, fingerprint string, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 	l.Debug("CreateInstanceFromImage started")
 	defer l.Debug("CreateInstanceFromImage finished")
+	span := b.startInstanceSpan("CreateInstanceFromImage", inst, op)
+	defer func() { span.EndWithError(err) }()
 
-	err := b.isStatusReady()
+	err = b.isStatusReady()
 	if err != nil {
 		return err
 	}
@@ -1747,12 +1886,14 @@ func (b *lxdBackend) CreateInstanceFromImage(inst instance.Instance, fingerprint
 
 // CreateInstanceFromMigration receives an instance being migrated.
 // The args.Name and args.Config fields are ignored and, instance properties are used instead.
-func (b *lxdBackend) CreateInstanceFromMigration(inst instance.Instance, conn io.ReadWriteCloser, args migration.VolumeTargetArgs, op *operations.Operation) error {
+func (b *lxdBackend) CreateInstanceFromMigration(inst instance.Instance, conn io.ReadWriteCloser, args migration.VolumeTargetArgs, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "args": fmt.Sprintf("%+v", args)})
 	l.Debug("CreateInstanceFromMigration started")
 	defer l.Debug("CreateInstanceFromMigration finished")
+	span := b.startInstanceSpan("CreateInstanceFromMigration", inst, op)
+	defer func() { span.EndWithError(err) }()
 
-	err := b.isStatusReady()
+	err = b.isStatusReady()
 	if err != nil {
 		return err
 	}
@@ -2113,10 +2254,12 @@ func (b *lxdBackend) RenameInstance(inst instance.Instance, newName string, op *
 }
 
 // DeleteInstance removes the instance's root volume (all snapshots need to be removed first).
-func (b *lxdBackend) DeleteInstance(inst instance.Instance, op *operations.Operation) error {
+func (b *lxdBackend) DeleteInstance(inst instance.Instance, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 	l.Debug("DeleteInstance started")
 	defer l.Debug("DeleteInstance finished")
+	span := b.startInstanceSpan("DeleteInstance", inst, op)
+	defer func() { span.EndWithError(err) }()
 
 	if inst.IsSnapshot() {
 		return fmt.Errorf("Instance must not be a snapshot")
@@ -2294,10 +2437,12 @@ func (b *lxdBackend) UpdateInstanceSnapshot(inst instance.Instance, newDesc stri
 
 // MigrateInstance sends an instance volume for migration.
 // The args.Name field is ignored and the name of the instance is used instead.
-func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) error {
+func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "args": fmt.Sprintf("%+v", args)})
 	l.Debug("MigrateInstance started")
 	defer l.Debug("MigrateInstance finished")
+	span := b.startInstanceSpan("MigrateInstance", inst, op)
+	defer func() { span.EndWithError(err) }()
 
 	volType, err := InstanceTypeToVolumeType(inst.Type())
 	if err != nil {
@@ -2614,12 +2759,14 @@ func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, vmSta
 }
 
 // MountInstance mounts the instance's root volume.
-func (b *lxdBackend) MountInstance(inst instance.Instance, op *operations.Operation) (*MountInfo, error) {
+func (b *lxdBackend) MountInstance(inst instance.Instance, op *operations.Operation) (_ *MountInfo, err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 	l.Debug("MountInstance started")
 	defer l.Debug("MountInstance finished")
+	span := b.startInstanceSpan("MountInstance", inst, op)
+	defer func() { span.EndWithError(err) }()
 
-	err := b.isStatusReady()
+	err = b.isStatusReady()
 	if err != nil {
 		return nil, err
 	}
@@ -2693,10 +2840,12 @@ func (b *lxdBackend) MountInstance(inst instance.Instance, op *operations.Operat
 }
 
 // UnmountInstance unmounts the instance's root volume.
-func (b *lxdBackend) UnmountInstance(inst instance.Instance, op *operations.Operation) error {
+func (b *lxdBackend) UnmountInstance(inst instance.Instance, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 	l.Debug("UnmountInstance started")
 	defer l.Debug("UnmountInstance finished")
+	span := b.startInstanceSpan("UnmountInstance", inst, op)
+	defer func() { span.EndWithError(err) }()
 
 	// Check we can convert the instance to the volume type needed.
 	volType, err := InstanceTypeToVolumeType(inst.Type())
@@ -2732,6 +2881,130 @@ func (b *lxdBackend) UnmountInstance(inst instance.Instance, op *operations.Oper
 	return err
 }
 
+// DiffInstance returns the files of a container that differ from the image it was created from.
+// The storage driver is used to compare the instance volume with the cached image volume when supported.
+// Otherwise both root filesystems are walked, unpacking the image into a temporary directory if the pool
+// doesn't have a cached image volume. The image is locked against being regenerated or deleted during the
+// comparison, and only one image is unpacked at a time to limit the disk space used.
+func (b *lxdBackend) DiffInstance(inst instance.Instance, op *operations.Operation) ([]api.InstanceFileChange, error) {
+	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
+	l.Debug("DiffInstance started")
+	defer l.Debug("DiffInstance finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.Type() != instancetype.Container {
+		return nil, fmt.Errorf("Instance type must be container: %w", drivers.ErrNotSupported)
+	}
+
+	fingerprint := inst.LocalConfig()["volatile.base_image"]
+	if fingerprint == "" {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Instance wasn't created from an image")
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	// Load storage volume from database.
+	dbVol, err := VolumeDBGet(b, inst.Project().Name, inst.Name(), volType)
+	if err != nil {
+		return nil, err
+	}
+
+	volStorageName := project.Instance(inst.Project().Name, inst.Name())
+	vol := b.GetVolume(volType, InstanceContentType(inst), volStorageName, dbVol.Config)
+
+	_, err = b.MountInstance(inst, op)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = b.UnmountInstance(inst, op) }()
+
+	// Lock the image so that it isn't regenerated or deleted while comparing with it.
+	unlock := locking.Lock(context.TODO(), drivers.OperationLockName("EnsureImage", b.name, drivers.VolumeTypeImage, "", fingerprint))
+	defer unlock()
+
+	unlockDelete := locking.Lock(context.TODO(), drivers.OperationLockName("DeleteImage", b.name, drivers.VolumeTypeImage, "", fingerprint))
+	defer unlockDelete()
+
+	// Load the cached image volume (if any).
+	imgDBVol, err := VolumeDBGet(b, project.Default, fingerprint, drivers.VolumeTypeImage)
+	if err != nil && !response.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	var imgVol drivers.Volume
+	if imgDBVol != nil {
+		imgVol = b.GetVolume(drivers.VolumeTypeImage, drivers.ContentTypeFS, fingerprint, imgDBVol.Config)
+	} else {
+		imgVol = b.GetVolume(drivers.VolumeTypeImage, drivers.ContentTypeFS, fingerprint, nil)
+	}
+
+	changes, err := b.driver.DiffVolume(vol, imgVol, op)
+	if err == nil {
+		return changes, nil
+	} else if !errors.Is(err, drivers.ErrNotSupported) {
+		return nil, fmt.Errorf("Failed comparing instance volume with image volume: %w", err)
+	}
+
+	// Fallback to comparing the files of both root filesystems.
+	var imgPath string
+	if imgDBVol != nil {
+		err = b.driver.MountVolume(imgVol, op)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() { _, _ = b.driver.UnmountVolume(imgVol, false, op) }()
+
+		imgPath = imgVol.MountPath()
+	} else {
+		imageFile := shared.VarPath("images", fingerprint)
+		if !shared.PathExists(imageFile) {
+			return nil, api.StatusErrorf(http.StatusNotFound, "Image %q isn't available anymore", fingerprint)
+		}
+
+		// Only unpack one image at a time, as the unpacked tree can be much larger than the image.
+		unlockUnpack := locking.Lock(context.TODO(), "DiffInstance_unpack")
+		defer unlockUnpack()
+
+		imgPath, err = os.MkdirTemp(shared.VarPath("images"), "lxd_diff_")
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() { _ = os.RemoveAll(imgPath) }()
+
+		err = imageUnpackContainer(imageFile, imgPath, false, b.state.OS, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Failed unpacking image %q: %w", fingerprint, err)
+		}
+	}
+
+	// Compare the ownership of the files with the one used inside the container.
+	var shift func(uid int64, gid int64) (int64, int64)
+
+	c, ok := inst.(instance.Container)
+	if ok {
+		diskIdmap, err := c.DiskIdmap()
+		if err != nil {
+			return nil, err
+		}
+
+		if diskIdmap != nil {
+			shift = diskIdmap.ShiftIntoNs
+		}
+	}
+
+	return filesystem.Diff(filepath.Join(imgPath, "rootfs"), filepath.Join(vol.MountPath(), "rootfs"), shift)
+}
+
 // getInstanceDisk returns the location of the disk.
 func (b *lxdBackend) getInstanceDisk(inst instance.Instance) (string, error) {
 	if inst.Type() != instancetype.VM {
@@ -2762,10 +3035,12 @@ func (b *lxdBackend) getInstanceDisk(inst instance.Instance) (string, error) {
 }
 
 // CreateInstanceSnapshot creates a snaphot of an instance volume.
-func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance.Instance, op *operations.Operation) error {
+func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance.Instance, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "src": src.Name()})
 	l.Debug("CreateInstanceSnapshot started")
 	defer l.Debug("CreateInstanceSnapshot finished")
+	span := b.startInstanceSpan("CreateInstanceSnapshot", inst, op)
+	defer func() { span.EndWithError(err) }()
 
 	if inst.Type() != src.Type() {
 		return fmt.Errorf("Instance types must match")
@@ -2979,6 +3254,30 @@ func (b *lxdBackend) RestoreInstanceSnapshot(inst instance.Instance, src instanc
 	l.Debug("RestoreInstanceSnapshot started")
 	defer l.Debug("RestoreInstanceSnapshot finished")
 
+	// Target instance must not be running.
+	if inst.IsRunning() {
+		return fmt.Errorf("Instance must not be running to restore")
+	}
+
+	return b.restoreInstanceSnapshot(inst, src, false, op)
+}
+
+// RestoreInstanceSnapshotOnStop restores an instance snapshot from the instance's stop path.
+// Unlike RestoreInstanceSnapshot it doesn't check whether the instance is running, as the instance still holds
+// its stop operation lock (and so reports itself as running) at that point. The caller must ensure that the
+// instance has stopped and that its volume has been unmounted.
+// The more recent snapshots of the instance are always kept, the restore fails if the driver would delete them.
+func (b *lxdBackend) RestoreInstanceSnapshotOnStop(inst instance.Instance, src instance.Instance, op *operations.Operation) error {
+	l := b.logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "src": src.Name()})
+	l.Debug("RestoreInstanceSnapshotOnStop started")
+	defer l.Debug("RestoreInstanceSnapshotOnStop finished")
+
+	return b.restoreInstanceSnapshot(inst, src, true, op)
+}
+
+// restoreInstanceSnapshot restores the instance's volume (and volume config) from the source snapshot.
+// If keepSnapshots is true, the restore fails rather than deleting the more recent snapshots.
+func (b *lxdBackend) restoreInstanceSnapshot(inst instance.Instance, src instance.Instance, keepSnapshots bool, op *operations.Operation) error {
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -2994,11 +3293,6 @@ func (b *lxdBackend) RestoreInstanceSnapshot(inst instance.Instance, src instanc
 		return fmt.Errorf("Source instance must be a snapshot")
 	}
 
-	// Target instance must not be running.
-	if inst.IsRunning() {
-		return fmt.Errorf("Instance must not be running to restore")
-	}
-
 	// Check we can convert the instance to the volume type needed.
 	volType, err := InstanceTypeToVolumeType(inst.Type())
 	if err != nil {
@@ -3052,6 +3346,10 @@ func (b *lxdBackend) RestoreInstanceSnapshot(inst instance.Instance, src instanc
 	err = b.driver.RestoreVolume(vol, snapshotName, op)
 	if err != nil {
 		snapErr, ok := err.(drivers.ErrDeleteSnapshots)
+		if ok && keepSnapshots {
+			return fmt.Errorf("Snapshot %q can't be restored without deleting the more recent snapshots %v", snapshotName, snapErr.Snapshots)
+		}
+
 		if ok {
 			// We need to delete some snapshots and try again.
 			snaps, err := inst.Snapshots()
@@ -3180,12 +3478,15 @@ func (b *lxdBackend) UnmountInstanceSnapshot(inst instance.Instance, op *operati
 // doesn't already exist. If the volume already exists then it is checked to ensure it matches the pools current
 // volume settings ("volume.size" and "block.filesystem" if applicable). If not the optimized volume is removed
 // and regenerated to apply the pool's current volume settings.
-func (b *lxdBackend) EnsureImage(fingerprint string, op *operations.Operation) error {
+func (b *lxdBackend) EnsureImage(fingerprint string, op *operations.Operation) (err error) {
 	l := b.logger.AddContext(logger.Ctx{"fingerprint": fingerprint})
 	l.Debug("EnsureImage started")
 	defer l.Debug("EnsureImage finished")
 
-	err := b.isStatusReady()
+	span := op.StartSpan("storage.EnsureImage", b.spanAttributes(map[string]any{"lxd.image.fingerprint": fingerprint}))
+	defer func() { span.EndWithError(err) }()
+
+	err = b.isStatusReady()
 	if err != nil {
 		return err
 	}
@@ -116,6 +116,10 @@ func (b *mockBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io
 	return nil, nil, nil
 }
 
+func (b *mockBackend) CreateInstanceFromConversion(inst instance.Instance, src instance.Instance, convert func(srcPath string, targetPath string, targetSize int64) error, op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) CreateInstanceFromCopy(inst instance.Instance, src instance.Instance, snapshots bool, allowInconsistent bool, op *operations.Operation) error {
 	return nil
 }
@@ -200,6 +204,10 @@ func (b *mockBackend) UnmountInstance(inst instance.Instance, op *operations.Ope
 	return nil
 }
 
+func (b *mockBackend) DiffInstance(inst instance.Instance, op *operations.Operation) ([]api.InstanceFileChange, error) {
+	return nil, nil
+}
+
 func (b *mockBackend) CreateInstanceSnapshot(i instance.Instance, src instance.Instance, op *operations.Operation) error {
 	return nil
 }
@@ -216,6 +224,10 @@ func (b *mockBackend) RestoreInstanceSnapshot(inst instance.Instance, src instan
 	return nil
 }
 
+func (b *mockBackend) RestoreInstanceSnapshotOnStop(inst instance.Instance, src instance.Instance, op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) MountInstanceSnapshot(inst instance.Instance, op *operations.Operation) (*MountInfo, error) {
 	return &MountInfo{}, nil
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/canonical/lxd/lxd/state"
 	"github.com/canonical/lxd/lxd/storage/filesystem"
 	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/instancewriter"
 	"github.com/canonical/lxd/shared/logger"
 )
@@ -443,6 +444,11 @@ func (d *common) RenameVolumeSnapshot(snapVol Volume, newSnapshotName string, op
 	return ErrNotSupported
 }
 
+// DiffVolume returns the files of a volume that differ from the image volume it was created from.
+func (d *common) DiffVolume(vol Volume, imgVol Volume, op *operations.Operation) ([]api.InstanceFileChange, error) {
+	return nil, ErrNotSupported
+}
+
 // ValidateBucket validates the supplied bucket name.
 func (d *common) ValidateBucket(bucket Volume) error {
 	projectName, bucketName := project.StorageVolumeParts(bucket.name)
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pborman/uuid"
@@ -514,3 +515,27 @@ func (d *zfs) delegateDataset(vol Volume, pid int) error {
 func ZFSSupportsDelegation() bool {
 	return zfsDelegate
 }
+
+// zfsDiffUnescape decodes a path printed by "zfs diff", where spaces, backslashes and
+// non-printable characters are escaped as a backslash followed by four octal digits.
+func zfsDiffUnescape(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+5 <= len(path) {
+			value, err := strconv.ParseUint(path[i+1:i+5], 8, 8)
+			if err == nil {
+				b.WriteByte(byte(value))
+				i += 4
+				continue
+			}
+		}
+
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package drivers
+
+import (
+	"fmt"
+)
+
+func Example_zfsDiffUnescape() {
+	fmt.Println(zfsDiffUnescape("/rootfs/etc/hosts"))
+	fmt.Println(zfsDiffUnescape("/rootfs/home/my\\0040file"))
+	fmt.Println(zfsDiffUnescape("/rootfs/back\\0134slash"))
+	fmt.Println(zfsDiffUnescape("/rootfs/trailing\\"))
+
+	// Output: /rootfs/etc/hosts
+	// /rootfs/home/my file
+	// /rootfs/back\slash
+	// /rootfs/trailing\
+}
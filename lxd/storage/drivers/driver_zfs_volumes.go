@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -2545,6 +2546,76 @@ func (d *zfs) migrateVolumeOptimized(vol Volume, conn io.ReadWriteCloser, volSrc
 	return nil
 }
 
+// DiffVolume returns the files of a filesystem volume that differ from the image volume it was cloned from,
+// using "zfs diff" between the readonly snapshot of the image volume and the volume.
+// The volume must be mounted.
+func (d *zfs) DiffVolume(vol Volume, imgVol Volume, op *operations.Operation) ([]api.InstanceFileChange, error) {
+	if vol.contentType != ContentTypeFS || d.isBlockBacked(vol) {
+		return nil, ErrNotSupported
+	}
+
+	dataset := d.dataset(vol, false)
+
+	// Only clones of the image volume can be compared, the image volume may have been deleted since.
+	origin, err := d.getDatasetProperty(dataset, "origin")
+	if err != nil {
+		return nil, err
+	}
+
+	if origin != fmt.Sprintf("%s@readonly", d.dataset(imgVol, false)) && origin != fmt.Sprintf("%s@readonly", d.dataset(imgVol, true)) {
+		return nil, ErrNotSupported
+	}
+
+	out, err := shared.RunCommand("zfs", "diff", "-F", "-H", origin, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	rootfsPath := filepath.Join(vol.MountPath(), "rootfs")
+	changes := []api.InstanceFileChange{}
+
+	addChange := func(path string, change string) {
+		path = zfsDiffUnescape(path)
+
+		// Skip the files outside of the root filesystem, like the image metadata and templates.
+		if !strings.HasPrefix(path, rootfsPath+"/") {
+			return
+		}
+
+		changes = append(changes, api.InstanceFileChange{Path: strings.TrimPrefix(path, rootfsPath), Change: change})
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		// Each line is made of the type of change, the type of file, the path and the new path for renames.
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		switch fields[0] {
+		case "+":
+			addChange(fields[2], filesystem.DiffAdded)
+		case "-":
+			addChange(fields[2], filesystem.DiffRemoved)
+		case "M":
+			// Directories are modified whenever their content changes, which is reported separately.
+			if fields[1] != "/" {
+				addChange(fields[2], filesystem.DiffChanged)
+			}
+
+		case "R":
+			if len(fields) > 3 {
+				addChange(fields[2], filesystem.DiffRemoved)
+				addChange(fields[3], filesystem.DiffAdded)
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
 func (d *zfs) readonlySnapshot(vol Volume) (string, revert.Hook, error) {
 	revert := revert.New()
 	defer revert.Fail()
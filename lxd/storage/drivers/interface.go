@@ -101,6 +101,9 @@ type Driver interface {
 	VolumeSnapshots(vol Volume, op *operations.Operation) ([]string, error)
 	RestoreVolume(vol Volume, snapshotName string, op *operations.Operation) error
 
+	// DiffVolume returns the files of a filesystem volume that differ from the image volume it was created from.
+	DiffVolume(vol Volume, imgVol Volume, op *operations.Operation) ([]api.InstanceFileChange, error)
+
 	// Migration.
 	MigrationTypes(contentType ContentType, refresh bool, copySnapshots bool) []migration.Type
 	MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *migration.VolumeSourceArgs, op *operations.Operation) error
@@ -0,0 +1,137 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Types of changes reported by Diff.
+const (
+	DiffAdded   = "added"
+	DiffChanged = "changed"
+	DiffRemoved = "removed"
+)
+
+// diffEntry holds the attributes of a file that are compared by Diff.
+type diffEntry struct {
+	mode  fs.FileMode
+	uid   int64
+	gid   int64
+	size  int64
+	mtime int64
+	rdev  uint64
+	link  string
+}
+
+// Diff returns the files of the tree at targetPath that were added, changed or removed compared to the tree
+// at basePath, sorted by path. Paths are relative to the roots of the trees and start with a "/".
+//
+// Files are compared on their type, permissions, ownership, size, modification time and symlink target or
+// device number. The size and modification time of directories are ignored as they change with their content.
+// If not nil, shift is used to map the ownership of the files of the target tree to the one of the base tree.
+func Diff(basePath string, targetPath string, shift func(uid int64, gid int64) (int64, int64)) ([]api.InstanceFileChange, error) {
+	base := map[string]diffEntry{}
+	err := diffWalk(basePath, func(path string, entry diffEntry) {
+		base[path] = entry
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []api.InstanceFileChange{}
+	err = diffWalk(targetPath, func(path string, entry diffEntry) {
+		if shift != nil {
+			entry.uid, entry.gid = shift(entry.uid, entry.gid)
+		}
+
+		baseEntry, found := base[path]
+		if !found {
+			changes = append(changes, api.InstanceFileChange{Path: path, Change: DiffAdded})
+			return
+		}
+
+		delete(base, path)
+
+		if baseEntry != entry {
+			changes = append(changes, api.InstanceFileChange{Path: path, Change: DiffChanged})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range base {
+		changes = append(changes, api.InstanceFileChange{Path: path, Change: DiffRemoved})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
+// diffWalk calls fn with the relative path and attributes of every file below root.
+// Files removed while walking the tree are skipped.
+func diffWalk(root string, fn func(path string, entry diffEntry)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if path == root {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		entry := diffEntry{
+			mode:  info.Mode(),
+			size:  info.Size(),
+			mtime: info.ModTime().UnixNano(),
+		}
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if ok {
+			entry.uid = int64(stat.Uid)
+			entry.gid = int64(stat.Gid)
+			entry.rdev = uint64(stat.Rdev)
+		}
+
+		if info.IsDir() {
+			entry.size = 0
+			entry.mtime = 0
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			entry.link, err = os.Readlink(path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		fn("/"+relPath, entry)
+
+		return nil
+	})
+}
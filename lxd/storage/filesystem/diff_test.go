@@ -0,0 +1,78 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+func TestDiff(t *testing.T) {
+	base := t.TempDir()
+	target := t.TempDir()
+
+	mtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Create the same tree in both directories.
+	for _, root := range []string{base, target} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "etc", "old"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "usr"), 0755))
+
+		for _, name := range []string{"etc/hosts", "etc/hostname", "etc/old/file", "usr/lib"} {
+			path := filepath.Join(root, name)
+			require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+			require.NoError(t, os.Chtimes(path, mtime, mtime))
+		}
+
+		require.NoError(t, os.Symlink("hosts", filepath.Join(root, "etc", "link")))
+	}
+
+	// Change the target tree.
+	require.NoError(t, os.WriteFile(filepath.Join(target, "etc", "hosts"), []byte("new content"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(target, "etc", "hostname"), 0600))
+	require.NoError(t, os.RemoveAll(filepath.Join(target, "etc", "old")))
+	require.NoError(t, os.Remove(filepath.Join(target, "etc", "link")))
+	require.NoError(t, os.Symlink("hostname", filepath.Join(target, "etc", "link")))
+	require.NoError(t, os.MkdirAll(filepath.Join(target, "srv", "www"), 0755))
+
+	changes, err := Diff(base, target, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []api.InstanceFileChange{
+		{Path: "/etc/hostname", Change: DiffChanged},
+		{Path: "/etc/hosts", Change: DiffChanged},
+		{Path: "/etc/link", Change: DiffChanged},
+		{Path: "/etc/old", Change: DiffRemoved},
+		{Path: "/etc/old/file", Change: DiffRemoved},
+		{Path: "/srv", Change: DiffAdded},
+		{Path: "/srv/www", Change: DiffAdded},
+	}, changes)
+}
+
+func TestDiffShift(t *testing.T) {
+	base := t.TempDir()
+	target := t.TempDir()
+
+	for _, root := range []string{base, target} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0755))
+	}
+
+	changes, err := Diff(base, target, nil)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// The ownership of the target tree is compared once shifted.
+	changes, err = Diff(base, target, func(uid int64, gid int64) (int64, int64) { return uid + 1, gid })
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceFileChange{{Path: "/dir", Change: DiffChanged}}, changes)
+}
+
+func TestDiffMissingBase(t *testing.T) {
+	_, err := Diff(filepath.Join(t.TempDir(), "missing"), t.TempDir(), nil)
+	assert.Error(t, err)
+}
@@ -496,6 +496,38 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 	return rules
 }
 
+// imageUnpackContainer unpacks a container image file and its separate rootfs file (if any) into destPath.
+func imageUnpackContainer(imageFile string, destPath string, blockBackend bool, sysOS *sys.OS, tracker *ioprogress.ProgressTracker) error {
+	imageRootfsFile := imageFile + ".rootfs"
+	rootfsPath := filepath.Join(destPath, "rootfs")
+
+	// Unpack the main image file.
+	err := archive.Unpack(imageFile, destPath, blockBackend, sysOS, tracker)
+	if err != nil {
+		return err
+	}
+
+	// Check for separate root file.
+	if shared.PathExists(imageRootfsFile) {
+		err = os.MkdirAll(rootfsPath, 0755)
+		if err != nil {
+			return fmt.Errorf("Error creating rootfs directory")
+		}
+
+		err = archive.Unpack(imageRootfsFile, rootfsPath, blockBackend, sysOS, tracker)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check that the container image unpack has resulted in a rootfs dir.
+	if !shared.PathExists(rootfsPath) {
+		return fmt.Errorf("Image is missing a rootfs: %s", imageFile)
+	}
+
+	return nil
+}
+
 // ImageUnpack unpacks a filesystem image into the destination path.
 // There are several formats that images can come in:
 // Container Format A: Separate metadata tarball and root squashfs file.
@@ -519,32 +551,11 @@ func ImageUnpack(imageFile string, vol drivers.Volume, destBlockFile string, sys
 
 	// If no destBlockFile supplied then this is a container image unpack.
 	if destBlockFile == "" {
-		rootfsPath := filepath.Join(destPath, "rootfs")
-
-		// Unpack the main image file.
-		err := archive.Unpack(imageFile, destPath, vol.IsBlockBacked(), sysOS, tracker)
+		err := imageUnpackContainer(imageFile, destPath, vol.IsBlockBacked(), sysOS, tracker)
 		if err != nil {
 			return -1, err
 		}
 
-		// Check for separate root file.
-		if shared.PathExists(imageRootfsFile) {
-			err = os.MkdirAll(rootfsPath, 0755)
-			if err != nil {
-				return -1, fmt.Errorf("Error creating rootfs directory")
-			}
-
-			err = archive.Unpack(imageRootfsFile, rootfsPath, vol.IsBlockBacked(), sysOS, tracker)
-			if err != nil {
-				return -1, err
-			}
-		}
-
-		// Check that the container image unpack has resulted in a rootfs dir.
-		if !shared.PathExists(rootfsPath) {
-			return -1, fmt.Errorf("Image is missing a rootfs: %s", imageFile)
-		}
-
 		// Done with this.
 		return 0, nil
 	}
@@ -68,7 +68,7 @@ func storagePoolVolumeUpdateUsers(s *state.State, projectName string, oldPoolNam
 		pUpdate.Config = profile.Config
 		pUpdate.Description = profile.Description
 		pUpdate.Devices = profile.Devices
-		err = doProfileUpdate(s, p, profile.Name, profileID, &profile, pUpdate)
+		_, err = doProfileUpdate(s, p, profile.Name, profileID, &profile, pUpdate, nil)
 		if err != nil {
 			return err
 		}
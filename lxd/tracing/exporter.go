@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/canonical/lxd/lxd/otlp"
+	"github.com/canonical/lxd/shared/logger"
+)
+
+const (
+	// Maximum number of spans exported at once.
+	batchSize = 512
+
+	// Maximum time the ended spans wait before being exported.
+	batchWait = 5 * time.Second
+
+	// Maximum number of ended spans waiting to be exported, after which new spans are dropped.
+	queueSize = 4096
+
+	// Maximum time spent exporting the queued spans when stopping.
+	stopTimeout = 5 * time.Second
+)
+
+// Exporter batches the ended spans and exports them to an OTLP collector.
+type Exporter struct {
+	client   *otlp.Client
+	resource otlp.Resource
+	ctx      context.Context
+	spans    chan otlp.Span
+	quit     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewExporter returns an Exporter sending the spans of the given server through the client, until stopped
+// or the context is cancelled.
+func NewExporter(ctx context.Context, client *otlp.Client, serverName string) *Exporter {
+	e := &Exporter{
+		client:   client,
+		resource: otlp.NewResource(serverName),
+		ctx:      ctx,
+		spans:    make(chan otlp.Span, queueSize),
+		quit:     make(chan struct{}),
+	}
+
+	e.wg.Add(1)
+	go e.run()
+
+	return e
+}
+
+// add queues an ended span, dropping it if the queue is full.
+func (e *Exporter) add(span otlp.Span) {
+	select {
+	case e.spans <- span:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(batchWait)
+	defer ticker.Stop()
+
+	batch := make([]otlp.Span, 0, batchSize)
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+
+		e.export(ctx, batch)
+		batch = make([]otlp.Span, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+
+		case <-e.quit:
+			// Export the queued spans before stopping, without retrying for long if the collector is unavailable.
+			ctx, cancel := context.WithTimeout(e.ctx, stopTimeout)
+			defer cancel()
+
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+					if len(batch) >= batchSize {
+						flush(ctx)
+					}
+
+				default:
+					flush(ctx)
+					return
+				}
+			}
+
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				flush(e.ctx)
+			}
+
+		case <-ticker.C:
+			flush(e.ctx)
+		}
+	}
+}
+
+func (e *Exporter) export(ctx context.Context, spans []otlp.Span) {
+	req := otlp.TracesRequest{
+		ResourceSpans: []otlp.ResourceSpans{{
+			Resource: e.resource,
+			ScopeSpans: []otlp.ScopeSpans{{
+				Scope: otlp.NewScope(),
+				Spans: spans,
+			}},
+		}},
+	}
+
+	err := e.client.Export(ctx, otlp.TracesPath, req)
+	if err != nil {
+		logger.Warn("Failed exporting traces", logger.Ctx{"spans": len(spans), "err": err})
+	}
+}
+
+// Stop exports the queued spans and stops the exporter, giving up on the export after a short while.
+func (e *Exporter) Stop() {
+	e.once.Do(func() { close(e.quit) })
+	e.wg.Wait()
+}
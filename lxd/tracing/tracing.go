@@ -0,0 +1,307 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/lxd/lxd/otlp"
+)
+
+// HeaderTraceParent is the W3C trace context header propagating the trace between cluster members.
+const HeaderTraceParent = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid returns whether the span context identifies a span.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the span context formatted as a traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceParent parses a traceparent header value.
+func ParseTraceParent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("Invalid traceparent %q", value)
+	}
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, fmt.Errorf("Invalid trace ID %q", parts[1])
+	}
+
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, fmt.Errorf("Invalid span ID %q", parts[2])
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("Invalid trace flags %q", parts[3])
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags&0x01 != 0
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("Invalid traceparent %q", value)
+	}
+
+	return sc, nil
+}
+
+type contextKey int
+
+const spanContextKey contextKey = 0
+
+// ContextWithSpanContext returns a context carrying the given span context.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey, sc)
+}
+
+// SpanContextFromContext returns the span context carried by the context, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ctx.Value(spanContextKey).(SpanContext)
+
+	return sc, ok && sc.IsValid()
+}
+
+// Detach returns a background context carrying the span context of the given context, for work outliving it.
+func Detach(ctx context.Context) context.Context {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return context.Background()
+	}
+
+	return ContextWithSpanContext(context.Background(), sc)
+}
+
+// Inject sets the traceparent header for the span carried by the context, if any.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(HeaderTraceParent, sc.TraceParent())
+}
+
+// Extract returns a context carrying the span context of the traceparent header, if valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	value := header.Get(HeaderTraceParent)
+	if value == "" {
+		return ctx
+	}
+
+	sc, err := ParseTraceParent(value)
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithSpanContext(ctx, sc)
+}
+
+var exporter *Exporter
+var exporterLock sync.RWMutex
+
+// SetExporter sets the exporter the ended spans are sent to, tracing being disabled if nil.
+func SetExporter(e *Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+
+	exporter = e
+}
+
+// Enabled returns whether tracing is enabled.
+func Enabled() bool {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+
+	return exporter != nil
+}
+
+// Span represents a timed operation within a trace. All its methods can be called on a nil span, which is
+// returned when tracing is disabled.
+type Span struct {
+	sc         SpanContext
+	parentID   [8]byte
+	name       string
+	kind       int
+	start      time.Time
+	attributes map[string]any
+	err        error
+	ended      bool
+	onEnd      []func()
+	exporter   *Exporter
+	lock       sync.Mutex
+}
+
+// Start starts an internal span as a child of the span carried by the context, if any, and returns a context
+// carrying the new span. When tracing is disabled or the parent span isn't sampled, the context is returned
+// as is along with a nil span.
+func Start(ctx context.Context, name string, attributes map[string]any) (context.Context, *Span) {
+	return start(ctx, name, otlp.SpanKindInternal, attributes)
+}
+
+// StartServer starts a span for a request received by the server, see Start.
+func StartServer(ctx context.Context, name string, attributes map[string]any) (context.Context, *Span) {
+	return start(ctx, name, otlp.SpanKindServer, attributes)
+}
+
+func start(ctx context.Context, name string, kind int, attributes map[string]any) (context.Context, *Span) {
+	exporterLock.RLock()
+	e := exporter
+	exporterLock.RUnlock()
+
+	if e == nil {
+		return ctx, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]any{},
+		exporter:   e,
+	}
+
+	parent, ok := SpanContextFromContext(ctx)
+	if ok {
+		if !parent.Sampled {
+			return ctx, nil
+		}
+
+		span.sc.TraceID = parent.TraceID
+		span.parentID = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.sc.TraceID[:])
+	}
+
+	_, _ = rand.Read(span.sc.SpanID[:])
+	span.sc.Sampled = true
+
+	for key, value := range attributes {
+		span.attributes[key] = value
+	}
+
+	return ContextWithSpanContext(ctx, span.sc), span
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with the given error, if not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.err = err
+}
+
+// OnEnd adds a function to call once the span ends.
+func (s *Span) OnEnd(fn func()) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.onEnd = append(s.onEnd, fn)
+}
+
+// EndWithError marks the span as failed with the given error, if not nil, and ends it.
+func (s *Span) EndWithError(err error) {
+	s.SetError(err)
+	s.End()
+}
+
+// End ends the span and hands it over to the exporter. Calling it more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+
+	s.ended = true
+
+	span := otlp.Span{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		Attributes:        otlp.Attributes(s.attributes),
+		Status:            otlp.SpanStatus{Code: otlp.StatusCodeUnset},
+	}
+
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+
+	if s.err != nil {
+		span.Status = otlp.SpanStatus{Code: otlp.StatusCodeError, Message: s.err.Error()}
+	}
+
+	onEnd := s.onEnd
+	s.lock.Unlock()
+
+	s.exporter.add(span)
+
+	// Call the functions without the lock held as they may take other locks.
+	for _, fn := range onEnd {
+		fn()
+	}
+}
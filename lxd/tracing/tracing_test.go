@@ -0,0 +1,151 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/otlp"
+	"github.com/canonical/lxd/lxd/tracing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := tracing.ParseTraceParent(value)
+	require.NoError(t, err)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, value, sc.TraceParent())
+
+	cases := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+
+	for _, value := range cases {
+		t.Run(value, func(t *testing.T) {
+			_, err := tracing.ParseTraceParent(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStart_Disabled(t *testing.T) {
+	ctx, span := tracing.Start(context.Background(), "test", nil)
+	assert.Nil(t, span)
+	assert.False(t, tracing.Enabled())
+
+	_, ok := tracing.SpanContextFromContext(ctx)
+	assert.False(t, ok)
+
+	// The methods of a nil span are no-ops.
+	span.SetAttribute("foo", "bar")
+	span.SetError(fmt.Errorf("Failed"))
+	span.OnEnd(func() { t.Error("Unexpected call") })
+	span.EndWithError(fmt.Errorf("Failed"))
+}
+
+func TestExporter(t *testing.T) {
+	var lock sync.Mutex
+	var spans []otlp.Span
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, otlp.TracesPath, r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		req := otlp.TracesRequest{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		assert.NoError(t, err)
+
+		lock.Lock()
+		for _, resourceSpans := range req.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				spans = append(spans, scopeSpans.Spans...)
+			}
+		}
+
+		lock.Unlock()
+	}))
+
+	defer server.Close()
+
+	client, err := otlp.NewClient(server.URL, map[string]string{"Authorization": "secret"}, "")
+	require.NoError(t, err)
+
+	exporter := tracing.NewExporter(context.Background(), client, "lxd01")
+	tracing.SetExporter(exporter)
+	defer tracing.SetExporter(nil)
+
+	// Continue the trace of a remote parent.
+	header := http.Header{}
+	header.Set(tracing.HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, parent := tracing.StartServer(tracing.Extract(context.Background(), header), "GET /1.0", nil)
+	_, child := tracing.Start(tracing.Detach(ctx), "child", map[string]any{"instance": "c1"})
+
+	ended := 0
+	child.OnEnd(func() { ended++ })
+	child.EndWithError(fmt.Errorf("Failed"))
+	child.End()
+	assert.Equal(t, 1, ended)
+
+	parent.End()
+	parent.End()
+
+	// The child span gets propagated.
+	header = http.Header{}
+	tracing.Inject(ctx, header)
+	assert.Contains(t, header.Get(tracing.HeaderTraceParent), "00-4bf92f3577b34da6a3ce929d0e0e4736-")
+
+	exporter.Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, otlp.StatusCodeError, spans[0].Status.Code)
+	assert.Equal(t, "Failed", spans[0].Status.Message)
+	assert.Equal(t, "instance", spans[0].Attributes[0].Key)
+	assert.Equal(t, "GET /1.0", spans[1].Name)
+	assert.Equal(t, otlp.SpanKindServer, spans[1].Kind)
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].ParentSpanID)
+}
+
+func TestExporter_StopTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	defer server.Close()
+
+	client, err := otlp.NewClient(server.URL, nil, "")
+	require.NoError(t, err)
+
+	exporter := tracing.NewExporter(context.Background(), client, "lxd01")
+	tracing.SetExporter(exporter)
+	defer tracing.SetExporter(nil)
+
+	_, span := tracing.Start(context.Background(), "span", nil)
+	span.End()
+
+	// The final export isn't retried for long when the collector is unavailable.
+	start := time.Now()
+	exporter.Stop()
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -0,0 +1,271 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// ResolveRootfsPath resolves relPath inside the root filesystem at rootfsPath, following any symlinks as if
+// rootfsPath was the root directory so that the returned path never points outside of it.
+// Components of relPath that don't exist yet are kept as is.
+func ResolveRootfsPath(rootfsPath string, relPath string) (string, error) {
+	resolved := "/"
+	remaining := strings.Split(filepath.Clean("/"+relPath), "/")
+
+	for hops := 0; len(remaining) > 0; {
+		part := remaining[0]
+		remaining = remaining[1:]
+
+		if part == "" || part == "." {
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		target, err := os.Readlink(filepath.Join(rootfsPath, next))
+		if err != nil {
+			// Not a symlink (or doesn't exist yet).
+			resolved = next
+			continue
+		}
+
+		hops++
+		if hops > 40 {
+			return "", fmt.Errorf("Too many levels of symbolic links resolving %q", relPath)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(resolved, target)
+		}
+
+		resolved = "/"
+		remaining = append(strings.Split(filepath.Clean(target), "/"), remaining...)
+	}
+
+	return filepath.Join(rootfsPath, resolved), nil
+}
+
+// openInRoot opens relPath inside the root directory root. The path is resolved by the kernel as if root was the
+// root directory (RESOLVE_IN_ROOT), so symlinks and ".." components can never lead outside of it.
+func openInRoot(root *os.File, relPath string, flags int, mode uint32) (*os.File, error) {
+	fd, err := unix.Openat2(int(root.Fd()), relPath, &unix.OpenHow{
+		Flags:   uint64(flags | unix.O_CLOEXEC),
+		Mode:    uint64(mode),
+		Resolve: unix.RESOLVE_IN_ROOT | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "openat2", Path: relPath, Err: err}
+	}
+
+	return os.NewFile(uintptr(fd), filepath.Join(root.Name(), relPath)), nil
+}
+
+// mkdirAllInRoot creates the directory relPath along with any missing parents inside the root directory root.
+// The targets of dangling symlinks found along the way are created too, up to a depth of hops symlinks.
+func mkdirAllInRoot(root *os.File, relPath string, mode os.FileMode, hops int) error {
+	if hops > 40 {
+		return fmt.Errorf("Too many levels of symbolic links resolving %q", relPath)
+	}
+
+	parent := "/"
+	for _, part := range strings.Split(filepath.Clean("/"+relPath), "/") {
+		if part == "" {
+			continue
+		}
+
+		dir, err := openInRoot(root, parent, unix.O_PATH|unix.O_DIRECTORY, 0)
+		if err != nil {
+			return err
+		}
+
+		err = unix.Mkdirat(int(dir.Fd()), part, uint32(mode.Perm()))
+		if err != nil && !errors.Is(err, unix.EEXIST) {
+			_ = dir.Close()
+			return &os.PathError{Op: "mkdirat", Path: filepath.Join(parent, part), Err: err}
+		}
+
+		// Create the target of dangling symlinks.
+		buf := make([]byte, unix.PathMax)
+		n, err := unix.Readlinkat(int(dir.Fd()), part, buf)
+		_ = dir.Close()
+		if err == nil {
+			target := string(buf[:n])
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(parent, target)
+			}
+
+			err = mkdirAllInRoot(root, target, mode, hops+1)
+			if err != nil {
+				return err
+			}
+		}
+
+		parent = filepath.Join(parent, part)
+	}
+
+	// Check that what's there is a directory (or a symlink to one within the root).
+	dir, err := openInRoot(root, parent, unix.O_PATH|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+
+	return dir.Close()
+}
+
+// MkdirAllInRoot creates the directory relPath along with any missing parents inside the root filesystem at
+// rootfsPath. Symlinks are resolved as if rootfsPath was the root directory.
+func MkdirAllInRoot(rootfsPath string, relPath string, mode os.FileMode) error {
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	return mkdirAllInRoot(root, relPath, mode, 0)
+}
+
+// WriteFileInRoot writes a file at relPath inside the root filesystem at rootfsPath, creating any missing parent
+// directories. Symlinks are resolved as if rootfsPath was the root directory and an existing entry at relPath is
+// replaced rather than written through, so the write can't end up outside of the root filesystem or in a device.
+func WriteFileInRoot(rootfsPath string, relPath string, content []byte, mode os.FileMode) error {
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	dirPath := filepath.Dir(filepath.Clean("/" + relPath))
+	name := filepath.Base(filepath.Clean("/" + relPath))
+
+	err = mkdirAllInRoot(root, dirPath, 0755, 0)
+	if err != nil {
+		return err
+	}
+
+	dir, err := openInRoot(root, dirPath, unix.O_PATH|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = dir.Close() }()
+
+	err = unix.Unlinkat(int(dir.Fd()), name, 0)
+	if err != nil && !errors.Is(err, unix.ENOENT) {
+		return &os.PathError{Op: "unlinkat", Path: relPath, Err: err}
+	}
+
+	f, err := openInRoot(dir, name, unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|unix.O_NOFOLLOW, uint32(mode.Perm()))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// Apply the mode regardless of the umask.
+	err = f.Chmod(mode.Perm())
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ReadFileInRoot reads the regular file at relPath inside the root filesystem at rootfsPath.
+// Symlinks are resolved as if rootfsPath was the root directory.
+func ReadFileInRoot(rootfsPath string, relPath string) ([]byte, error) {
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	f, err := openInRoot(root, relPath, unix.O_RDONLY|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q isn't a regular file", relPath)
+	}
+
+	return io.ReadAll(f)
+}
+
+// ReadDirInRoot lists the directory at relPath inside the root filesystem at rootfsPath.
+// Symlinks are resolved as if rootfsPath was the root directory.
+func ReadDirInRoot(rootfsPath string, relPath string) ([]os.DirEntry, error) {
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	dir, err := openInRoot(root, relPath, unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = dir.Close() }()
+
+	return dir.ReadDir(-1)
+}
+
+// SymlinkInRoot creates a symlink at relPath pointing to target inside the root filesystem at rootfsPath,
+// replacing any existing entry. Missing parent directories are created and resolved as if rootfsPath was the
+// root directory.
+func SymlinkInRoot(rootfsPath string, relPath string, target string) error {
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	dirPath := filepath.Dir(filepath.Clean("/" + relPath))
+	name := filepath.Base(filepath.Clean("/" + relPath))
+
+	err = mkdirAllInRoot(root, dirPath, 0755, 0)
+	if err != nil {
+		return err
+	}
+
+	dir, err := openInRoot(root, dirPath, unix.O_PATH|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = dir.Close() }()
+
+	err = unix.Unlinkat(int(dir.Fd()), name, 0)
+	if err != nil && !errors.Is(err, unix.ENOENT) {
+		return &os.PathError{Op: "unlinkat", Path: relPath, Err: err}
+	}
+
+	err = unix.Symlinkat(target, int(dir.Fd()), name)
+	if err != nil {
+		return &os.PathError{Op: "symlinkat", Path: relPath, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/lxd/util"
+)
+
+func TestResolveRootfsPath(t *testing.T) {
+	rootfs := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "usr", "lib"), 0755))
+	require.NoError(t, os.Symlink("usr/lib", filepath.Join(rootfs, "lib")))
+	require.NoError(t, os.Symlink("/usr/lib", filepath.Join(rootfs, "lib64")))
+	require.NoError(t, os.Symlink("../../../..", filepath.Join(rootfs, "usr", "lib", "up")))
+	require.NoError(t, os.Symlink("loop", filepath.Join(rootfs, "loop")))
+
+	tests := []struct {
+		relPath string
+		want    string
+	}{
+		{relPath: "etc/fstab", want: "/etc/fstab"},
+		{relPath: "lib/systemd/system", want: "/usr/lib/systemd/system"},
+		{relPath: "/lib64/udev", want: "/usr/lib/udev"},
+		{relPath: "lib/up/etc/passwd", want: "/etc/passwd"},
+		{relPath: "../../etc", want: "/etc"},
+	}
+
+	for _, test := range tests {
+		got, err := util.ResolveRootfsPath(rootfs, test.relPath)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(rootfs, test.want), got, test.relPath)
+	}
+
+	_, err := util.ResolveRootfsPath(rootfs, "loop/foo")
+	assert.Error(t, err)
+}
+
+func TestWriteFileInRoot(t *testing.T) {
+	rootfs := t.TempDir()
+	outside := t.TempDir()
+
+	// Symlinks pointing outside of the root filesystem must be resolved inside of it.
+	require.NoError(t, os.Symlink(outside, filepath.Join(rootfs, "lib")))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "etc"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "fstab"), filepath.Join(rootfs, "etc", "fstab")))
+
+	require.NoError(t, util.WriteFileInRoot(rootfs, "lib/systemd/system/foo.service", []byte("foo"), 0644))
+	require.NoError(t, util.WriteFileInRoot(rootfs, "etc/fstab", []byte("bar"), 0600))
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	content, err := util.ReadFileInRoot(rootfs, "lib/systemd/system/foo.service")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+
+	content, err = os.ReadFile(filepath.Join(rootfs, outside, "systemd", "system", "foo.service"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+
+	info, err := os.Lstat(filepath.Join(rootfs, "etc", "fstab"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, util.SymlinkInRoot(rootfs, "etc/systemd/system/multi-user.target.wants/foo.service", "/lib/systemd/system/foo.service"))
+	target, err := os.Readlink(filepath.Join(rootfs, "etc", "systemd", "system", "multi-user.target.wants", "foo.service"))
+	require.NoError(t, err)
+	assert.Equal(t, "/lib/systemd/system/foo.service", target)
+
+	_, err = util.ReadFileInRoot(rootfs, "etc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// procClockTicks is the number of clock ticks per second used for the CPU times in /proc/<pid>/stat.
+const procClockTicks = 100
+
+// Process represents a process along with its PID as seen by the caller.
+type Process struct {
+	api.InstanceProcess
+
+	HostPID int
+
+	startTime uint64
+}
+
+// GetProcesses returns the processes in the cgroup at the given path and in its descendant cgroups.
+// The reported PIDs are those in the PID namespace of the process with the given PID while the user IDs are as
+// seen by the caller.
+func GetProcesses(cgroupPath string, nsPID int) ([]Process, error) {
+	nsPIDs, err := getNSPIDs(nsPID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting PID namespace of %d: %w", nsPID, err)
+	}
+
+	// The namespaced PIDs are listed from the outermost namespace to the innermost one.
+	level := len(nsPIDs) - 1
+
+	pids := []int{}
+	err = filepath.WalkDir(cgroupPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Cgroups may be removed while walking the hierarchy.
+			if path != cgroupPath && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		for _, field := range strings.Fields(string(content)) {
+			pid, err := strconv.Atoi(field)
+			if err == nil {
+				pids = append(pids, pid)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing processes of cgroup %q: %w", cgroupPath, err)
+	}
+
+	return getProcesses(pids, level), nil
+}
+
+// GetAllProcesses returns all the processes visible to the caller, as seen by it.
+func GetAllProcesses() ([]Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err == nil {
+			pids = append(pids, pid)
+		}
+	}
+
+	return getProcesses(pids, 0), nil
+}
+
+// getProcesses reads the information about the processes with the given PIDs, skipping those that exited.
+func getProcesses(pids []int, level int) []Process {
+	processes := make([]Process, 0, len(pids))
+	for _, pid := range pids {
+		process, err := getProcess(pid, level)
+		if err != nil {
+			// The process exited in the meantime.
+			continue
+		}
+
+		processes = append(processes, *process)
+	}
+
+	return processes
+}
+
+// SignalProcess sends a signal to a process through a pidfd. It fails with unix.ESRCH if the process exited since
+// it was listed, even if its PID has been reused by another process in the meantime.
+func SignalProcess(process Process, signal unix.Signal) error {
+	pidfd, err := unix.PidfdOpen(process.HostPID, 0)
+	if err != nil {
+		if !errors.Is(err, unix.ENOSYS) {
+			return err
+		}
+
+		// Fallback for kernels without pidfds, only reducing the window for signalling another process.
+		pidfd = -1
+	} else {
+		defer func() { _ = unix.Close(pidfd) }()
+	}
+
+	// Now that the pidfd refers to whichever process has the PID, check that it's the listed one.
+	startTime, err := getStartTime(process.HostPID)
+	if err != nil || startTime != process.startTime {
+		return unix.ESRCH
+	}
+
+	if pidfd < 0 {
+		return unix.Kill(process.HostPID, signal)
+	}
+
+	return unix.PidfdSendSignal(pidfd, signal, nil, 0)
+}
+
+// getNSPIDs returns the PIDs of the process in each of the PID namespaces it belongs to, from the outermost
+// namespace to the innermost one.
+func getNSPIDs(pid int) ([]string, error) {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(status), "\n") {
+		if strings.HasPrefix(line, "NSpid:") {
+			return strings.Fields(strings.TrimPrefix(line, "NSpid:")), nil
+		}
+	}
+
+	return nil, fmt.Errorf("No namespaced PIDs found")
+}
+
+// getStatFields returns the fields of /proc/<pid>/stat following the command name, starting with the state.
+func getStatFields(pid int) ([]string, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the command name which may contain spaces.
+	end := strings.LastIndex(string(stat), ")")
+	if end < 0 {
+		return nil, fmt.Errorf("Invalid stat file of process %d", pid)
+	}
+
+	return strings.Fields(string(stat)[end+1:]), nil
+}
+
+// GetProcessStartTime returns the time the process started at, given the boot time of the system.
+func GetProcessStartTime(pid int, bootTime time.Time) (time.Time, error) {
+	ticks, err := getStartTime(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return bootTime.Add(time.Duration(ticks) * time.Second / procClockTicks), nil
+}
+
+// getStartTime returns the start time of the process in clock ticks after boot.
+func getStartTime(pid int) (uint64, error) {
+	fields, err := getStatFields(pid)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("Invalid stat file of process %d", pid)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// getProcess reads the information about a single process from procfs, reporting its PID in the PID namespace
+// at the given level.
+func getProcess(pid int, level int) (*Process, error) {
+	process := Process{HostPID: pid}
+	process.PID = int64(pid)
+
+	name := ""
+	status, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = status.Close() }()
+
+	scanner := bufio.NewScanner(status)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch key {
+		case "Name":
+			name = fields[0]
+		case "Uid":
+			// Use the effective user ID.
+			if len(fields) > 1 {
+				process.UID, _ = strconv.ParseInt(fields[1], 10, 64)
+			}
+
+		case "NSpid":
+			if len(fields) > level {
+				process.PID, _ = strconv.ParseInt(fields[level], 10, 64)
+			}
+
+		case "VmRSS":
+			rss, _ := strconv.ParseInt(fields[0], 10, 64)
+			process.MemoryUsage = rss * 1024
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the CPU time from the utime and stime fields along with the start time.
+	fields, err := getStatFields(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 19 {
+		utime, _ := strconv.ParseInt(fields[11], 10, 64)
+		stime, _ := strconv.ParseInt(fields[12], 10, 64)
+		process.CPUUsage = (utime + stime) * (1000000000 / procClockTicks)
+		process.startTime, _ = strconv.ParseUint(fields[19], 10, 64)
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	process.Command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	if process.Command == "" {
+		// Kernel threads and zombies don't have a command line.
+		process.Command = fmt.Sprintf("[%s]", name)
+	}
+
+	return &process, nil
+}
+
+// GetUsernames returns the user names keyed by user ID from the passwd file at the given path.
+func GetUsernames(passwdPath string) map[int64]string {
+	usernames := map[int64]string{}
+
+	content, err := os.ReadFile(filepath.Clean(passwdPath))
+	if err != nil {
+		return usernames
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+
+		uid, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		_, ok := usernames[uid]
+		if !ok {
+			usernames[uid] = fields[0]
+		}
+	}
+
+	return usernames
+}
+
+// ParseSignal returns the signal given either by name, with or without the SIG prefix, or by number.
+func ParseSignal(signal string) (unix.Signal, error) {
+	num, err := strconv.Atoi(signal)
+	if err == nil {
+		if num <= 0 || num > 64 {
+			return 0, fmt.Errorf("Invalid signal %q", signal)
+		}
+
+		return unix.Signal(num), nil
+	}
+
+	name := strings.ToUpper(signal)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("Invalid signal %q", signal)
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,89 @@
+package util_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/canonical/lxd/lxd/util"
+)
+
+func TestGetProcesses(t *testing.T) {
+	// Fake a cgroup holding the test process with a nested one holding its parent.
+	cgroupPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupPath, "cgroup.procs"), []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(cgroupPath, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupPath, "nested", "cgroup.procs"), []byte(fmt.Sprintf("%d\n", os.Getppid())), 0644))
+
+	processes, err := util.GetProcesses(cgroupPath, os.Getpid())
+	require.NoError(t, err)
+	require.Len(t, processes, 2)
+
+	self := processes[0]
+	assert.Equal(t, os.Getpid(), self.HostPID)
+	assert.Equal(t, int64(os.Getpid()), self.PID)
+	assert.Equal(t, int64(os.Geteuid()), self.UID)
+	assert.NotEmpty(t, self.Command)
+	assert.Greater(t, self.MemoryUsage, int64(0))
+	assert.Equal(t, os.Getppid(), processes[1].HostPID)
+
+	// Signal the test process itself.
+	require.NoError(t, util.SignalProcess(self, 0))
+
+	// Processes that exited since they were listed can't be signalled anymore.
+	cmd := exec.Command("sleep", "60")
+	require.NoError(t, cmd.Start())
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupPath, "cgroup.procs"), []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0644))
+	require.NoError(t, os.RemoveAll(filepath.Join(cgroupPath, "nested")))
+
+	processes, err = util.GetProcesses(cgroupPath, os.Getpid())
+	require.NoError(t, err)
+	require.Len(t, processes, 1)
+
+	require.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+
+	assert.ErrorIs(t, util.SignalProcess(processes[0], unix.SIGTERM), unix.ESRCH)
+}
+
+func TestGetAllProcesses(t *testing.T) {
+	processes, err := util.GetAllProcesses()
+	require.NoError(t, err)
+
+	found := false
+	for _, process := range processes {
+		if process.HostPID == os.Getpid() {
+			found = true
+			assert.Equal(t, int64(os.Getpid()), process.PID)
+		}
+	}
+
+	assert.True(t, found)
+}
+
+func TestGetUsernames(t *testing.T) {
+	passwd := filepath.Join(t.TempDir(), "passwd")
+	require.NoError(t, os.WriteFile(passwd, []byte("root:x:0:0:root:/root:/bin/bash\nubuntu:x:1000:1000::/home/ubuntu:/bin/sh\ninvalid\n"), 0644))
+
+	assert.Equal(t, map[int64]string{0: "root", 1000: "ubuntu"}, util.GetUsernames(passwd))
+	assert.Empty(t, util.GetUsernames(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestParseSignal(t *testing.T) {
+	for _, value := range []string{"SIGTERM", "TERM", "term", "15"} {
+		sig, err := util.ParseSignal(value)
+		require.NoError(t, err)
+		assert.Equal(t, unix.SIGTERM, sig)
+	}
+
+	for _, value := range []string{"", "FOO", "0", "100"} {
+		_, err := util.ParseSignal(value)
+		assert.Error(t, err)
+	}
+}
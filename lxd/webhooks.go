@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/lxd/client"
+	"github.com/canonical/lxd/lxd/cluster"
+	"github.com/canonical/lxd/lxd/db"
+	"github.com/canonical/lxd/lxd/lifecycle"
+	"github.com/canonical/lxd/lxd/project"
+	"github.com/canonical/lxd/lxd/request"
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/lxd/webhooks"
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/version"
+)
+
+var webhooksCmd = APIEndpoint{
+	Path: "webhooks",
+
+	Get:  APIEndpointAction{Handler: webhooksGet},
+	Post: APIEndpointAction{Handler: webhooksPost},
+}
+
+var webhookCmd = APIEndpoint{
+	Path: "webhooks/{name}",
+
+	Delete: APIEndpointAction{Handler: webhookDelete},
+	Get:    APIEndpointAction{Handler: webhookGet},
+	Put:    APIEndpointAction{Handler: webhookPut},
+}
+
+// updateWebhookCache loads the webhooks into the dispatcher, only listening to the events when there are any.
+func updateWebhookCache(d *Daemon) {
+	s := d.State()
+
+	logger.Debug("Refreshing webhook cache")
+
+	var hooks []api.Webhook
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		hooks, err = tx.GetWebhooks(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed reading webhooks from global database", logger.Ctx{"err": err})
+		return
+	}
+
+	d.webhooks.SetWebhooks(hooks)
+
+	if len(hooks) > 0 {
+		d.webhookListener.AddHandler("webhooks", d.webhooks.HandleEvent)
+	} else {
+		d.webhookListener.RemoveHandler("webhooks")
+	}
+}
+
+// webhooksNotify refreshes the webhook cache of this member and notifies the other cluster members.
+func webhooksNotify(d *Daemon, r *http.Request, hook func(client lxd.InstanceServer) error) error {
+	updateWebhookCache(d)
+
+	if isClusterNotification(r) {
+		return nil
+	}
+
+	s := d.State()
+
+	notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAlive)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// webhookValidate validates the name, URL and event types of a webhook.
+func webhookValidate(name string, req api.WebhookPut) error {
+	if name == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if strings.ContainsAny(name, `/ '"`) {
+		return fmt.Errorf("Webhook names may not contain slashes, spaces or quotes")
+	}
+
+	if shared.StringInSlice(name, []string{".", ".."}) {
+		return fmt.Errorf("Invalid webhook name %q", name)
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || !shared.StringInSlice(u.Scheme, []string{"http", "https"}) || u.Host == "" {
+		return fmt.Errorf("Invalid URL %q, must be an HTTP or HTTPS URL", req.URL)
+	}
+
+	for _, eventType := range req.Types {
+		if !shared.StringInSlice(eventType, webhooks.EventTypes) {
+			return fmt.Errorf("Invalid event type %q, must be one of %s", eventType, strings.Join(webhooks.EventTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+// swagger:operation GET /1.0/webhooks webhooks webhooks_get
+//
+//	Get the webhooks
+//
+//	Returns a list of webhooks (URLs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of endpoints
+//	          items:
+//	            type: string
+//	          example: |-
+//	            [
+//	              "/1.0/webhooks/deploy"
+//	            ]
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/webhooks?recursion=1 webhooks webhooks_get_recursion1
+//
+//	Get the webhooks
+//
+//	Returns a list of webhooks (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of webhooks
+//	          items:
+//	            $ref: "#/definitions/Webhook"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func webhooksGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	var hooks []api.Webhook
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		hooks, err = tx.GetWebhooks(ctx)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		// The secrets are write only.
+		for i := range hooks {
+			hooks[i].Secret = nil
+		}
+
+		return response.SyncResponse(true, hooks)
+	}
+
+	urls := make([]string, 0, len(hooks))
+	for _, hook := range hooks {
+		urls = append(urls, api.NewURL().Path(version.APIVersion, "webhooks", hook.Name).String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/webhooks webhooks webhooks_post
+//
+//	Add a webhook
+//
+//	Creates a new webhook.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: webhook
+//	    description: Webhook
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/WebhooksPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func webhooksPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	req := api.WebhooksPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Quick checks.
+		err = webhookValidate(req.Name, req.WebhookPut)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return tx.CreateWebhook(ctx, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	// The other members reload the webhook from the database, don't send them the secret.
+	err = webhooksNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.CreateWebhook(api.WebhooksPost{Name: req.Name})
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	lc := lifecycle.WebhookCreated.Event(req.Name, request.CreateRequestor(r), nil)
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lc)
+	}
+
+	return response.SyncResponseLocation(true, nil, lc.Source)
+}
+
+// swagger:operation GET /1.0/webhooks/{name} webhooks webhook_get
+//
+//	Get the webhook
+//
+//	Gets a specific webhook (without its secret).
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Webhook
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/Webhook"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func webhookGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var hook *api.Webhook
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		hook, err = tx.GetWebhook(ctx, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// The secret is write only.
+	hook.Secret = nil
+
+	return response.SyncResponseETag(true, hook, hook.Writable())
+}
+
+// swagger:operation PUT /1.0/webhooks/{name} webhooks webhook_put
+//
+//	Update the webhook
+//
+//	Replaces the description, URL, event types and projects of the webhook.
+//	The secret is kept unless a new one is provided, an empty secret removes it.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: webhook
+//	    description: Webhook configuration
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/WebhookPut"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "412":
+//	    $ref: "#/responses/PreconditionFailed"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func webhookPut(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.WebhookPut{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Quick checks.
+		err = webhookValidate(name, req)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			hook, err := tx.GetWebhook(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			secret := hook.Secret
+			hook.Secret = nil
+
+			// Validate the ETag.
+			err = util.EtagCheck(r, hook.Writable())
+			if err != nil {
+				return err
+			}
+
+			// Keep the current secret unless a new one (or an empty one to remove it) is provided.
+			if req.Secret == nil {
+				req.Secret = secret
+			}
+
+			return tx.UpdateWebhook(ctx, name, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = webhooksNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.UpdateWebhook(name, api.WebhookPut{}, "")
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lifecycle.WebhookUpdated.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/webhooks/{name} webhooks webhook_delete
+//
+//	Delete the webhook
+//
+//	Removes the webhook, pending deliveries are still attempted.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func webhookDelete(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return tx.DeleteWebhook(ctx, name)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	err = webhooksNotify(d, r, func(client lxd.InstanceServer) error {
+		return client.DeleteWebhook(name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		s.Events.SendLifecycle(project.Default, lifecycle.WebhookDeleted.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,386 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+	"github.com/pborman/uuid"
+
+	"github.com/canonical/lxd/shared"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/version"
+)
+
+// EventTypes lists the types of events that can be delivered by webhooks.
+var EventTypes = []string{api.EventTypeLifecycle, api.EventTypeOperation}
+
+// Headers set on the deliveries.
+const (
+	HeaderDelivery  = "X-LXD-Delivery"
+	HeaderEventType = "X-LXD-Event-Type"
+	HeaderSignature = "X-LXD-Signature-256"
+	HeaderWebhook   = "X-LXD-Webhook"
+)
+
+// DeadLetter represents an event that couldn't be delivered by a webhook.
+type DeadLetter struct {
+	Timestamp time.Time `json:"timestamp"`
+	Webhook   string    `json:"webhook"`
+	URL       string    `json:"url"`
+	Delivery  string    `json:"delivery"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error"`
+	Event     api.Event `json:"event"`
+}
+
+// queueSize is the number of events that can wait for delivery by a webhook. Further events are recorded in the
+// dead-letter log straight away.
+const queueSize = 1000
+
+// The dead-letter log is rotated once it reaches deadLetterMaxSize bytes, keeping deadLetterMaxFiles previous files.
+const (
+	deadLetterMaxSize  = 10 * 1024 * 1024
+	deadLetterMaxFiles = 5
+)
+
+// Dispatcher delivers events to the matching webhooks as HTTP POST requests, retrying failed deliveries with
+// an exponential backoff. Deliveries that still fail are recorded in a dead-letter log.
+// Each webhook delivers its events one at a time from its own bounded queue, so that a slow or unreachable
+// webhook doesn't hold up the others.
+type Dispatcher struct {
+	ctx                context.Context
+	client             *http.Client
+	backoffConfig      backoff.Config
+	queueSize          int
+	deadLetterPath     string
+	deadLetterMaxSize  int64
+	deadLetterMaxFiles int
+	deadLetterLock     sync.Mutex
+
+	webhooks []api.Webhook
+	workers  map[string]*worker
+	lock     sync.Mutex
+}
+
+// worker delivers the events queued for a webhook.
+type worker struct {
+	queue  chan delivery
+	cancel context.CancelFunc
+}
+
+// delivery is an event to deliver to a webhook.
+type delivery struct {
+	webhook api.Webhook
+	event   api.Event
+	body    []byte
+}
+
+// NewDispatcher returns a Dispatcher recording the failed deliveries to the given path. Pending deliveries are
+// abandoned once the context is cancelled.
+func NewDispatcher(ctx context.Context, deadLetterPath string) *Dispatcher {
+	return &Dispatcher{
+		ctx:                ctx,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		deadLetterPath:     deadLetterPath,
+		deadLetterMaxSize:  deadLetterMaxSize,
+		deadLetterMaxFiles: deadLetterMaxFiles,
+		backoffConfig: backoff.Config{
+			MinBackoff: time.Second,
+			MaxBackoff: time.Minute,
+			MaxRetries: 5,
+		},
+		queueSize: queueSize,
+		workers:   map[string]*worker{},
+	}
+}
+
+// SetWebhooks replaces the webhooks events get delivered to. The pending deliveries of the removed webhooks are
+// abandoned.
+func (d *Dispatcher) SetWebhooks(webhooks []api.Webhook) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.webhooks = webhooks
+
+	names := map[string]bool{}
+	for _, webhook := range webhooks {
+		names[webhook.Name] = true
+
+		if d.workers[webhook.Name] == nil {
+			d.workers[webhook.Name] = d.startWorker()
+		}
+	}
+
+	for name, w := range d.workers {
+		if !names[name] {
+			w.cancel()
+			delete(d.workers, name)
+		}
+	}
+}
+
+// startWorker starts delivering the events queued to a new worker until it is cancelled.
+func (d *Dispatcher) startWorker() *worker {
+	ctx, cancel := context.WithCancel(d.ctx)
+
+	w := &worker{
+		queue:  make(chan delivery, d.queueSize),
+		cancel: cancel,
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item := <-w.queue:
+				d.deliver(ctx, item)
+			}
+		}
+	}()
+
+	return w
+}
+
+// Matches returns whether the webhook delivers the event.
+func Matches(webhook api.Webhook, event api.Event) bool {
+	if !shared.StringInSlice(event.Type, EventTypes) {
+		return false
+	}
+
+	if len(webhook.Types) > 0 && !shared.StringInSlice(event.Type, webhook.Types) {
+		return false
+	}
+
+	if len(webhook.Projects) > 0 && !shared.StringInSlice(event.Project, webhook.Projects) {
+		return false
+	}
+
+	return true
+}
+
+// Sign returns the signature of a delivery body with the given secret, as a hex encoded HMAC-SHA256 prefixed
+// with "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sanitize returns the event as delivered to webhooks. The metadata of operation events is removed as it may hold
+// the secrets of the operation websockets.
+func sanitize(event api.Event) (api.Event, error) {
+	if event.Type != api.EventTypeOperation {
+		return event, nil
+	}
+
+	op := api.Operation{}
+	err := json.Unmarshal(event.Metadata, &op)
+	if err != nil {
+		return event, err
+	}
+
+	op.Metadata = nil
+
+	event.Metadata, err = json.Marshal(op)
+	if err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
+
+// HandleEvent queues an event for delivery to the matching webhooks in the background. If the queue of a webhook
+// is full, the event is recorded in the dead-letter log instead.
+func (d *Dispatcher) HandleEvent(event api.Event) {
+	var body []byte
+	var overflows []api.Webhook
+
+	d.lock.Lock()
+	for _, webhook := range d.webhooks {
+		if !Matches(webhook, event) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+
+			event, err = sanitize(event)
+			if err == nil {
+				body, err = json.Marshal(event)
+			}
+
+			if err != nil {
+				d.lock.Unlock()
+				logger.Warn("Failed encoding event for webhooks", logger.Ctx{"err": err})
+				return
+			}
+		}
+
+		select {
+		case d.workers[webhook.Name].queue <- delivery{webhook: webhook, event: event, body: body}:
+		default:
+			overflows = append(overflows, webhook)
+		}
+	}
+
+	d.lock.Unlock()
+
+	for _, webhook := range overflows {
+		d.deadLetter(webhook, event, uuid.New(), 0, fmt.Errorf("Delivery queue is full"))
+	}
+}
+
+// deliver posts the event to the webhook until it succeeds, the retries are exhausted or the error can't be
+// solved by retrying, in which case the event is recorded in the dead-letter log.
+func (d *Dispatcher) deliver(ctx context.Context, item delivery) {
+	webhook := item.webhook
+	deliveryID := uuid.New()
+	retries := backoff.New(ctx, d.backoffConfig)
+	attempts := 0
+
+	var err error
+	for {
+		var status int
+
+		attempts++
+		status, err = d.post(ctx, webhook, item.event, deliveryID, item.body)
+		if err == nil {
+			return
+		}
+
+		// Only retry 429s, 500s and connection-level errors.
+		if status > 0 && status != http.StatusTooManyRequests && status/100 != 5 {
+			break
+		}
+
+		if !retries.Ongoing() {
+			break
+		}
+
+		retries.Wait()
+	}
+
+	// Don't record the deliveries abandoned on shutdown or removal of the webhook.
+	if ctx.Err() != nil {
+		return
+	}
+
+	d.deadLetter(webhook, item.event, deliveryID, attempts, err)
+}
+
+// deadLetter records an event that couldn't be delivered to the webhook in the dead-letter log.
+func (d *Dispatcher) deadLetter(webhook api.Webhook, event api.Event, deliveryID string, attempts int, err error) {
+	logger.Warn("Failed delivering event to webhook", logger.Ctx{"webhook": webhook.Name, "url": webhook.URL, "attempts": attempts, "err": err})
+
+	deadLetter := DeadLetter{
+		Timestamp: time.Now().UTC(),
+		Webhook:   webhook.Name,
+		URL:       webhook.URL,
+		Delivery:  deliveryID,
+		Attempts:  attempts,
+		Error:     err.Error(),
+		Event:     event,
+	}
+
+	err = d.recordDeadLetter(deadLetter)
+	if err != nil {
+		logger.Warn("Failed recording undelivered event", logger.Ctx{"webhook": webhook.Name, "err": err})
+	}
+}
+
+// post sends a single delivery attempt and returns the status code of the response (-1 if none was received).
+func (d *Dispatcher) post(ctx context.Context, webhook api.Webhook, event api.Event, delivery string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent)
+	req.Header.Set(HeaderDelivery, delivery)
+	req.Header.Set(HeaderEventType, event.Type)
+	req.Header.Set(HeaderWebhook, webhook.Name)
+
+	if webhook.Secret != nil && *webhook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(*webhook.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("Server returned HTTP error: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// recordDeadLetter appends an undelivered event to the dead-letter log.
+func (d *Dispatcher) recordDeadLetter(deadLetter DeadLetter) error {
+	line, err := json.Marshal(deadLetter)
+	if err != nil {
+		return err
+	}
+
+	d.deadLetterLock.Lock()
+	defer d.deadLetterLock.Unlock()
+
+	info, err := os.Stat(d.deadLetterPath)
+	if err == nil && info.Size() > 0 && info.Size()+int64(len(line))+1 > d.deadLetterMaxSize {
+		err = d.rotateDeadLetters()
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(d.deadLetterPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed opening webhooks dead-letter log %q: %w", d.deadLetterPath, err)
+	}
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("Failed writing webhooks dead-letter log %q: %w", d.deadLetterPath, err)
+	}
+
+	return file.Close()
+}
+
+// rotateDeadLetters shifts the dead-letter log and its previous files, dropping the oldest.
+func (d *Dispatcher) rotateDeadLetters() error {
+	if d.deadLetterMaxFiles < 1 {
+		return os.Remove(d.deadLetterPath)
+	}
+
+	err := os.Remove(fmt.Sprintf("%s.%d", d.deadLetterPath, d.deadLetterMaxFiles))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed removing old webhooks dead-letter log: %w", err)
+	}
+
+	for i := d.deadLetterMaxFiles - 1; i > 0; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", d.deadLetterPath, i), fmt.Sprintf("%s.%d", d.deadLetterPath, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed rotating webhooks dead-letter log: %w", err)
+		}
+	}
+
+	return os.Rename(d.deadLetterPath, d.deadLetterPath+".1")
+}
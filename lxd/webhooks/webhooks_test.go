@@ -0,0 +1,232 @@
+package webhooks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+func TestMatches(t *testing.T) {
+	lifecycle := api.Event{Type: api.EventTypeLifecycle, Project: "default"}
+	operation := api.Event{Type: api.EventTypeOperation, Project: "foo"}
+	logging := api.Event{Type: api.EventTypeLogging}
+
+	all := api.Webhook{}
+	assert.True(t, Matches(all, lifecycle))
+	assert.True(t, Matches(all, operation))
+	assert.False(t, Matches(all, logging))
+
+	filtered := api.Webhook{WebhookPut: api.WebhookPut{Types: []string{"lifecycle"}, Projects: []string{"default"}}}
+	assert.True(t, Matches(filtered, lifecycle))
+	assert.False(t, Matches(filtered, operation))
+	assert.False(t, Matches(filtered, api.Event{Type: api.EventTypeLifecycle, Project: "foo"}))
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deadLetterPath := filepath.Join(t.TempDir(), "webhooks.log")
+
+	d := NewDispatcher(ctx, deadLetterPath)
+	d.backoffConfig.MinBackoff = time.Millisecond
+	d.backoffConfig.MaxBackoff = time.Millisecond
+	d.backoffConfig.MaxRetries = 2
+
+	var lock sync.Mutex
+	attempts := map[string]int{}
+	received := make(chan *http.Request, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		name := r.Header.Get(HeaderWebhook)
+
+		lock.Lock()
+		attempts[name]++
+		n := attempts[name]
+		lock.Unlock()
+
+		switch name {
+		case "flaky":
+			// Fail the first attempt.
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+
+		case "rejected":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		assert.Equal(t, Sign("s3cr3t", body), r.Header.Get(HeaderSignature))
+		received <- r
+	}))
+	defer server.Close()
+
+	secret := "s3cr3t"
+	webhook := func(name string) api.Webhook {
+		return api.Webhook{Name: name, WebhookPut: api.WebhookPut{URL: server.URL, Secret: &secret}}
+	}
+
+	d.SetWebhooks([]api.Webhook{webhook("flaky"), webhook("broken"), webhook("rejected")})
+	d.HandleEvent(api.Event{Type: api.EventTypeLifecycle, Project: "default", Metadata: json.RawMessage(`{"action":"instance-created"}`)})
+
+	select {
+	case r := <-received:
+		assert.Equal(t, "flaky", r.Header.Get(HeaderWebhook))
+		assert.Equal(t, api.EventTypeLifecycle, r.Header.Get(HeaderEventType))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event not delivered")
+	}
+
+	// Wait for the failed deliveries to be recorded.
+	var deadLetters []DeadLetter
+	for i := 0; i < 50 && len(deadLetters) < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+
+		deadLetters = nil
+
+		file, err := os.Open(deadLetterPath)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			deadLetter := DeadLetter{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &deadLetter))
+			deadLetters = append(deadLetters, deadLetter)
+		}
+
+		_ = file.Close()
+	}
+
+	require.Len(t, deadLetters, 2)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, deadLetter := range deadLetters {
+		assert.JSONEq(t, `{"action":"instance-created"}`, string(deadLetter.Event.Metadata))
+
+		switch deadLetter.Webhook {
+		case "broken":
+			// Server errors are retried.
+			assert.Equal(t, 3, deadLetter.Attempts)
+			assert.Equal(t, 3, attempts["broken"])
+		case "rejected":
+			// Client errors aren't.
+			assert.Equal(t, 1, deadLetter.Attempts)
+			assert.Equal(t, 1, attempts["rejected"])
+		default:
+			t.Errorf("Unexpected dead letter for webhook %q", deadLetter.Webhook)
+		}
+	}
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deadLetterPath := filepath.Join(t.TempDir(), "webhooks.log")
+
+	d := NewDispatcher(ctx, deadLetterPath)
+	d.queueSize = 1
+
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	d.SetWebhooks([]api.Webhook{{Name: "slow", WebhookPut: api.WebhookPut{URL: server.URL}}})
+
+	event := api.Event{Type: api.EventTypeLifecycle, Project: "default"}
+
+	// The first event is being delivered, the second one is queued and the third one overflows.
+	d.HandleEvent(event)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event not delivered")
+	}
+
+	d.HandleEvent(event)
+	d.HandleEvent(event)
+
+	content, err := os.ReadFile(deadLetterPath)
+	require.NoError(t, err)
+
+	deadLetter := DeadLetter{}
+	require.NoError(t, json.Unmarshal(content, &deadLetter))
+	assert.Equal(t, "slow", deadLetter.Webhook)
+	assert.Equal(t, 0, deadLetter.Attempts)
+
+	// The queued event is delivered once the webhook caught up.
+	close(release)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Queued event not delivered")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	event := api.Event{Type: api.EventTypeOperation, Metadata: json.RawMessage(`{"id":"4f2a","metadata":{"fds":{"0":"s3cr3t"}}}`)}
+
+	sanitized, err := sanitize(event)
+	require.NoError(t, err)
+
+	op := api.Operation{}
+	require.NoError(t, json.Unmarshal(sanitized.Metadata, &op))
+	assert.Equal(t, "4f2a", op.ID)
+	assert.Nil(t, op.Metadata)
+
+	lifecycle := api.Event{Type: api.EventTypeLifecycle, Metadata: json.RawMessage(`{"action":"instance-created"}`)}
+
+	sanitized, err = sanitize(lifecycle)
+	require.NoError(t, err)
+	assert.Equal(t, lifecycle, sanitized)
+}
+
+func TestDeadLetterRotation(t *testing.T) {
+	deadLetterPath := filepath.Join(t.TempDir(), "webhooks.log")
+
+	d := NewDispatcher(context.Background(), deadLetterPath)
+	d.deadLetterMaxSize = 1
+	d.deadLetterMaxFiles = 2
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, d.recordDeadLetter(DeadLetter{Webhook: "deploy"}))
+	}
+
+	// Every entry rotates the file, only the two previous ones are kept.
+	for _, path := range []string{deadLetterPath, deadLetterPath + ".1", deadLetterPath + ".2"} {
+		assert.FileExists(t, path)
+	}
+
+	assert.NoFileExists(t, deadLetterPath+".3")
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"time"
+)
+
+// Entity types that permissions can be granted on.
+const (
+	AuthEntityTypeServer   = "server"
+	AuthEntityTypeProject  = "project"
+	AuthEntityTypeInstance = "instance"
+)
+
+// AuthGroupsPost represents the fields available for a new authorization group.
+//
+// swagger:model
+//
+// API extension: auth_groups.
+type AuthGroupsPost struct {
+	AuthGroupPut `yaml:",inline"`
+
+	// The name of the new group
+	// Example: operators
+	Name string `json:"name" yaml:"name"`
+}
+
+// AuthGroup represents an authorization group.
+//
+// swagger:model
+//
+// API extension: auth_groups.
+type AuthGroup struct {
+	AuthGroupPut  `yaml:",inline"`
+	AuthGroupPost `yaml:",inline"`
+}
+
+// AuthGroupPost represents the fields required to rename an authorization group.
+//
+// swagger:model
+//
+// API extension: auth_groups.
+type AuthGroupPost struct {
+	// The new name of the group
+	// Example: operators
+	Name string `json:"name" yaml:"name"`
+}
+
+// AuthGroupPut represents the modifiable fields of an authorization group.
+//
+// swagger:model
+//
+// API extension: auth_groups.
+type AuthGroupPut struct {
+	// Description of the group
+	// Example: Operators of the production project
+	Description string `json:"description" yaml:"description"`
+
+	// Identities in the group, as the authentication method followed by the certificate fingerprint or OIDC user
+	// Example: ["tls/2bf1c4a4c4e1b8a5b4d1a6c5f1e4b3a2c1d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9", "oidc/jane@example.com"]
+	Identities []string `json:"identities" yaml:"identities"`
+
+	// OpenID Connect claims granting membership of the group, as the claim name followed by an equal sign and the value
+	// Example: ["groups=lxd-operators", "email_domain=example.com"]
+	//
+	// API extension: auth_groups_oidc_claims
+	OIDCClaims []string `json:"oidc_claims" yaml:"oidc_claims"`
+
+	// Permissions granted to the identities of the group
+	Permissions []AuthPermission `json:"permissions" yaml:"permissions"`
+
+	// Configuration of the group
+	// Example: {"limits.rate.read": "100/m"}
+	//
+	// API extension: auth_groups_rate_limits
+	Config map[string]string `json:"config" yaml:"config"`
+}
+
+// AuthPermission represents an entitlement on an entity.
+//
+// swagger:model
+//
+// API extension: auth_groups.
+type AuthPermission struct {
+	// Type of the entity (server, project or instance)
+	// Example: instance
+	EntityType string `json:"entity_type" yaml:"entity_type"`
+
+	// URL of the entity
+	// Example: /1.0/instances/c1?project=prod
+	URL string `json:"url" yaml:"url"`
+
+	// Entitlement granted on the entity
+	// Example: can_exec
+	Entitlement string `json:"entitlement" yaml:"entitlement"`
+}
+
+// Writable converts a full AuthGroup struct into a AuthGroupPut struct (filters read-only fields).
+func (g *AuthGroup) Writable() AuthGroupPut {
+	return g.AuthGroupPut
+}
+
+// AuthTokensPost represents the fields available for a new API bearer token.
+//
+// swagger:model
+//
+// API extension: auth_tokens.
+type AuthTokensPost struct {
+	// Description of the token
+	// Example: CI pipeline
+	Description string `json:"description" yaml:"description"`
+
+	// Whether the token is restricted to a list of projects
+	// Example: true
+	Restricted bool `json:"restricted" yaml:"restricted"`
+
+	// List of allowed projects (applies when restricted)
+	// Example: ["default", "foo"]
+	Projects []string `json:"projects" yaml:"projects"`
+
+	// Whether the token only allows read-only requests
+	// Example: false
+	ReadOnly bool `json:"read_only" yaml:"read_only"`
+
+	// When the token expires (never if unset)
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+// AuthToken represents an API bearer token.
+//
+// swagger:model
+//
+// API extension: auth_tokens.
+type AuthToken struct {
+	AuthTokensPost `yaml:",inline"`
+
+	// SHA256 fingerprint of the token
+	// Read only: true
+	// Example: 0ff8b4c1c8ae6bb6b8d0a5a6f0f6c2b1e5d9c1e4c3a9d4e8f2a7b3c6d1e5f4a2
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+
+	// When the token was created
+	// Read only: true
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// When the token was last used (approximately)
+	// Read only: true
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	LastUsedAt time.Time `json:"last_used_at" yaml:"last_used_at"`
+}
+
+// URL returns the URL for the token.
+func (t *AuthToken) URL(apiVersion string) *URL {
+	return NewURL().Path(apiVersion, "auth", "tokens", t.Fingerprint)
+}
+
+// AuthTokenSecret represents a newly created API bearer token.
+//
+// swagger:model
+//
+// API extension: auth_tokens.
+type AuthTokenSecret struct {
+	// SHA256 fingerprint of the token
+	// Example: 0ff8b4c1c8ae6bb6b8d0a5a6f0f6c2b1e5d9c1e4c3a9d4e8f2a7b3c6d1e5f4a2
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+
+	// The bearer token, only returned on creation
+	// Example: lxd_9d3c5e7a1b2f4d6c8e0a1b3c5d7e9f1a2b4c6d8e0f1a3b5c7d9e1f2a4b6c8d0e
+	Token string `json:"token" yaml:"token"`
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"time"
+)
+
+// ConfigRevision represents a revision of the configuration of an instance or profile.
+//
+// swagger:model
+//
+// API extension: config_history.
+type ConfigRevision struct {
+	// Revision number
+	// Example: 3
+	Revision int64 `json:"revision" yaml:"revision"`
+
+	// When the revision was created
+	// Example: 2021-03-23T16:38:37.753398689-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Name of the user that made the change
+	// Example: foo
+	Username string `json:"username" yaml:"username"`
+
+	// Protocol used by the user that made the change
+	// Example: tls
+	Protocol string `json:"protocol" yaml:"protocol"`
+
+	// Configuration map (volatile keys excluded)
+	// Example: {"limits.cpu": "4"}
+	Config map[string]string `json:"config" yaml:"config"`
+
+	// Devices
+	// Example: {"root": {"type": "disk", "pool": "default", "path": "/"}}
+	Devices map[string]map[string]string `json:"devices" yaml:"devices"`
+
+	// List of profiles (instances only)
+	// Example: ["default"]
+	Profiles []string `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// Changes from the previous revision
+	Changes []ConfigChange `json:"changes" yaml:"changes"`
+}
+
+// ConfigChange represents a change of a single key between two configuration revisions.
+//
+// swagger:model
+//
+// API extension: config_history.
+type ConfigChange struct {
+	// Changed key, as a config key, a device key in the form "devices.<name>.<key>" or "profiles"
+	// Example: limits.cpu
+	Key string `json:"key" yaml:"key"`
+
+	// Previous value (empty if the key was added)
+	// Example: 2
+	Old string `json:"old" yaml:"old"`
+
+	// New value (empty if the key was removed)
+	// Example: 4
+	New string `json:"new" yaml:"new"`
+}
@@ -40,6 +40,12 @@ type Event struct {
 	//
 	// API extension: event_project
 	Project string `yaml:"project,omitempty" json:"project,omitempty"`
+
+	// ID of the event in the event log of the member serving the event stream (lifecycle and operation events only)
+	// Example: 42
+	//
+	// API extension: event_log
+	ID uint64 `yaml:"id,omitempty" json:"id,omitempty"`
 }
 
 // ToLogging creates log record for the event.
@@ -2,6 +2,12 @@ package api
 
 // Define consts for all the lifecycle events.
 const (
+	EventLifecycleAuthGroupCreated                  = "auth-group-created"
+	EventLifecycleAuthGroupDeleted                  = "auth-group-deleted"
+	EventLifecycleAuthGroupRenamed                  = "auth-group-renamed"
+	EventLifecycleAuthGroupUpdated                  = "auth-group-updated"
+	EventLifecycleAuthTokenCreated                  = "auth-token-created"
+	EventLifecycleAuthTokenDeleted                  = "auth-token-deleted"
 	EventLifecycleCertificateCreated                = "certificate-created"
 	EventLifecycleCertificateDeleted                = "certificate-deleted"
 	EventLifecycleCertificateUpdated                = "certificate-updated"
@@ -49,11 +55,13 @@ const (
 	EventLifecycleInstanceMetadataTemplateRetrieved = "instance-metadata-template-retrieved"
 	EventLifecycleInstanceMetadataUpdated           = "instance-metadata-updated"
 	EventLifecycleInstancePaused                    = "instance-paused"
+	EventLifecycleInstanceProcessSignaled           = "instance-process-signaled"
 	EventLifecycleInstanceReady                     = "instance-ready"
 	EventLifecycleInstanceRenamed                   = "instance-renamed"
 	EventLifecycleInstanceRestarted                 = "instance-restarted"
 	EventLifecycleInstanceRestored                  = "instance-restored"
 	EventLifecycleInstanceResumed                   = "instance-resumed"
+	EventLifecycleInstanceSessionRecorded           = "instance-session-recorded"
 	EventLifecycleInstanceShutdown                  = "instance-shutdown"
 	EventLifecycleInstanceSnapshotCreated           = "instance-snapshot-created"
 	EventLifecycleInstanceSnapshotDeleted           = "instance-snapshot-deleted"
@@ -119,4 +127,7 @@ const (
 	EventLifecycleWarningAcknowledged               = "warning-acknowledged"
 	EventLifecycleWarningDeleted                    = "warning-deleted"
 	EventLifecycleWarningReset                      = "warning-reset"
+	EventLifecycleWebhookCreated                    = "webhook-created"
+	EventLifecycleWebhookDeleted                    = "webhook-deleted"
+	EventLifecycleWebhookUpdated                    = "webhook-updated"
 )
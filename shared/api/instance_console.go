@@ -27,4 +27,10 @@ type InstanceConsolePost struct {
 	//
 	// API extension: console_vga_type
 	Type string `json:"type" yaml:"type"`
+
+	// Whether to only view the console, without sending input or resizing it (console type only)
+	// Example: false
+	//
+	// API extension: instance_console_shared
+	ReadOnly bool `json:"read_only" yaml:"read_only"`
 }
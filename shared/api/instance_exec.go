@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // InstanceExecControl represents a message on the instance exec "control" socket.
 //
 // API extension: instances.
@@ -53,4 +57,60 @@ type InstanceExecPost struct {
 	// Current working directory for the command
 	// Example: /home/foo/
 	Cwd string `json:"cwd" yaml:"cwd"`
+
+	// Whether to run the command in a session which keeps running when no client is attached
+	// Example: false
+	//
+	// API extension: instance_exec_detached
+	Detached bool `json:"detached" yaml:"detached"`
+}
+
+// InstanceExecSession represents a detached exec session.
+//
+// swagger:model
+//
+// API extension: instance_exec_detached.
+type InstanceExecSession struct {
+	// Session ID
+	// Example: 6916c8a6-9b7d-4abd-90b3-aedfec7ec7da
+	ID string `json:"id" yaml:"id"`
+
+	// Command and its arguments
+	// Example: ["make", "-j4"]
+	Command []string `json:"command" yaml:"command"`
+
+	// Whether the command runs in interactive mode
+	// Example: false
+	Interactive bool `json:"interactive" yaml:"interactive"`
+
+	// Session status (Running or Exited)
+	// Example: Running
+	Status string `json:"status" yaml:"status"`
+
+	// Exit code of the command (-1 while running)
+	// Example: 0
+	ExitCode int `json:"exit_code" yaml:"exit_code"`
+
+	// When the session was started
+	// Example: 2021-03-23T20:00:00-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// URL of the session output log
+	// Example: /1.0/instances/c1/logs/exec-output/exec_6916c8a6-9b7d-4abd-90b3-aedfec7ec7da.log
+	Output string `json:"output" yaml:"output"`
+}
+
+// InstanceExecSessionAttachPost represents a request to attach to a detached exec session.
+//
+// swagger:model
+//
+// API extension: instance_exec_detached.
+type InstanceExecSessionAttachPost struct {
+	// Terminal width in characters (for interactive sessions)
+	// Example: 80
+	Width int `json:"width" yaml:"width"`
+
+	// Terminal height in characters (for interactive sessions)
+	// Example: 24
+	Height int `json:"height" yaml:"height"`
 }
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"time"
+)
+
+// InstanceFileEvent represents a change to a file inside an instance, as sent on the file watch websocket.
+//
+// swagger:model
+//
+// API extension: instance_file_watch.
+type InstanceFileEvent struct {
+	// Path of the changed file inside the instance
+	// Example: /etc/hosts
+	Path string `json:"path" yaml:"path"`
+
+	// Type of change (create, modify or delete)
+	// Example: modify
+	Action string `json:"action" yaml:"action"`
+
+	// When the change was detected
+	// Example: 2021-03-23T20:00:00-04:00
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// InstanceFileChange represents a file of an instance that differs from the image the instance was created from.
+//
+// swagger:model
+//
+// API extension: instance_diff.
+type InstanceFileChange struct {
+	// Path of the file inside the instance
+	// Example: /etc/hosts
+	Path string `json:"path" yaml:"path"`
+
+	// Type of change (added, changed or removed)
+	// Example: changed
+	Change string `json:"change" yaml:"change"`
+}
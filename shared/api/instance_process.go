@@ -0,0 +1,47 @@
+package api
+
+// InstanceProcess represents a process running inside an instance.
+//
+// swagger:model
+//
+// API extension: instance_processes.
+type InstanceProcess struct {
+	// Process ID inside the instance
+	// Example: 1
+	PID int64 `json:"pid" yaml:"pid"`
+
+	// Command line of the process
+	// Example: /sbin/init
+	Command string `json:"command" yaml:"command"`
+
+	// User ID the process runs as
+	// Example: 0
+	UID int64 `json:"uid" yaml:"uid"`
+
+	// Name of the user the process runs as
+	// Example: root
+	User string `json:"user" yaml:"user"`
+
+	// CPU time used by the process (in nanoseconds)
+	// Example: 3637691016
+	CPUUsage int64 `json:"cpu_usage" yaml:"cpu_usage"`
+
+	// Resident memory used by the process (in bytes)
+	// Example: 73248768
+	MemoryUsage int64 `json:"memory_usage" yaml:"memory_usage"`
+}
+
+// InstanceProcessesPost represents a request to send a signal to a process running inside an instance.
+//
+// swagger:model
+//
+// API extension: instance_processes.
+type InstanceProcessesPost struct {
+	// Process ID inside the instance
+	// Example: 1234
+	PID int64 `json:"pid" yaml:"pid"`
+
+	// Signal to send, either by name or number
+	// Example: SIGTERM
+	Signal string `json:"signal" yaml:"signal"`
+}
@@ -0,0 +1,18 @@
+package scriptlet
+
+// AuthorizationDetails represents the identity passed to the authorization scriptlet.
+//
+// API extension: authorization_scriptlet.
+type AuthorizationDetails struct {
+	// Username of the requestor, this is the certificate fingerprint of TLS clients.
+	Username string `json:"username"`
+
+	// Authentication protocol (tls, oidc or candid).
+	Protocol string `json:"protocol"`
+
+	// Source address of the request.
+	Address string `json:"address"`
+
+	// Claims of the access token of OIDC clients.
+	Claims map[string]any `json:"claims"`
+}
@@ -31,3 +31,36 @@ type InstancePlacement struct {
 	Reason  string `json:"reason"`
 	Project string `json:"project"`
 }
+
+// InstanceAdmissionActionCreate is when a new instance is being created.
+const InstanceAdmissionActionCreate = "create"
+
+// InstanceAdmissionActionUpdate is when the configuration of an existing instance is being updated.
+const InstanceAdmissionActionUpdate = "update"
+
+// InstanceAdmissionActionRename is when an existing instance is being renamed.
+const InstanceAdmissionActionRename = "rename"
+
+// InstanceAdmissionActionRestore is when an existing instance is being restored from one of its snapshots.
+const InstanceAdmissionActionRestore = "restore"
+
+// InstanceAdmissionActionImport is when a new instance is being imported from a backup.
+const InstanceAdmissionActionImport = "import"
+
+// InstanceAdmission represents the instance admission request.
+//
+// API extension: instances_admission_scriptlet.
+type InstanceAdmission struct {
+	api.InstancePut `yaml:",inline"`
+
+	Action  string `json:"action"`
+	Project string `json:"project"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+
+	// Source of the instance, only set when creating an instance.
+	Source *api.InstanceSource `json:"source"`
+
+	// User making the request.
+	Requestor api.EventLifecycleRequestor `json:"requestor"`
+}
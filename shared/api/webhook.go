@@ -0,0 +1,60 @@
+package api
+
+// WebhookPut represents the modifiable fields of a webhook.
+//
+// swagger:model
+//
+// API extension: webhooks.
+type WebhookPut struct {
+	// Description of the webhook
+	// Example: Notify the deployment service
+	Description string `json:"description" yaml:"description"`
+
+	// URL the events get posted to
+	// Example: https://hooks.example.com/lxd
+	URL string `json:"url" yaml:"url"`
+
+	// Types of events to deliver (lifecycle or operation, all of them if empty)
+	// Example: ["lifecycle"]
+	Types []string `json:"types" yaml:"types"`
+
+	// Projects whose events get delivered (all of them if empty)
+	// Example: ["default", "foo"]
+	Projects []string `json:"projects" yaml:"projects"`
+
+	// Secret used to sign the deliveries (write only, kept on update if unset and removed if empty)
+	// Example: s3cr3t
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// WebhooksPost represents the fields of a new webhook.
+//
+// swagger:model
+//
+// API extension: webhooks.
+type WebhooksPost struct {
+	WebhookPut `yaml:",inline"`
+
+	// Name of the webhook
+	// Example: deploy
+	Name string `json:"name" yaml:"name"`
+}
+
+// Webhook represents a webhook delivering events to a remote URL.
+//
+// swagger:model
+//
+// API extension: webhooks.
+type Webhook struct {
+	WebhookPut `yaml:",inline"`
+
+	// Name of the webhook
+	// Read only: true
+	// Example: deploy
+	Name string `json:"name" yaml:"name"`
+}
+
+// Writable converts a full Webhook struct into a WebhookPut struct (filters read-only fields).
+func (w *Webhook) Writable() WebhookPut {
+	return w.WebhookPut
+}
@@ -128,6 +128,7 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 
 	"security.devlxd":            validate.Optional(validate.IsBool),
 	"security.protection.delete": validate.Optional(validate.IsBool),
+	"security.session_recording": validate.Optional(validate.IsBool),
 
 	"snapshots.schedule":         validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@startup", "@never"})),
 	"snapshots.schedule.stopped": validate.Optional(validate.IsBool),
@@ -137,6 +138,7 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 		_, err := GetExpiry(time.Time{}, value)
 		return err
 	},
+	"snapshots.restore_on_stop": validate.Optional(validate.IsURLSegmentSafe),
 
 	// Volatile keys.
 	"volatile.apply_template":         validate.IsAny,
@@ -221,6 +223,17 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 	"security.privileged":       validate.Optional(validate.IsBool),
 	"security.protection.shift": validate.Optional(validate.IsBool),
 
+	"security.rootfs.readonly":              validate.Optional(validate.IsBool),
+	"security.rootfs.readonly.overlay":      validate.Optional(validate.IsOneOf("tmpfs", "volume")),
+	"security.rootfs.readonly.overlay.size": validate.Optional(validate.IsSize),
+	"security.rootfs.readonly.overlay.volume": validate.Optional(func(value string) error {
+		if IsSnapshot(value) {
+			return fmt.Errorf("Snapshots cannot be used as overlay volumes")
+		}
+
+		return validate.IsURLSegmentSafe(value)
+	}),
+
 	"security.syscalls.allow":                        validate.IsAny,
 	"security.syscalls.blacklist_default":            validate.Optional(validate.IsBool),
 	"security.syscalls.blacklist_compat":             validate.Optional(validate.IsBool),
@@ -244,6 +257,8 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 	"volatile.idmap.base":       validate.IsAny,
 	"volatile.idmap.current":    validate.IsAny,
 	"volatile.idmap.next":       validate.IsAny,
+
+	"volatile.rootfs.overlay.volume": validate.IsAny,
 }
 
 // InstanceConfigKeysVM is a map of config key to validator. (keys applying to VM only).
@@ -0,0 +1,204 @@
+package tarstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// protocolMagic starts tar stream requests sent on a connection that otherwise carries SFTP.
+// SFTP packets start with a big endian length, so their first byte is always zero for the initial packet.
+const protocolMagic = "LXDTAR\n"
+
+const (
+	// ActionPull requests the server to send the directory tree as a tar stream.
+	ActionPull = "pull"
+
+	// ActionPush requests the server to extract the following tar stream.
+	ActionPush = "push"
+)
+
+// request is sent by the client ahead of a tar stream transfer.
+type request struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+}
+
+// response is sent by the server to report the result of a request.
+type response struct {
+	Error      string `json:"error"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// IsRequest returns whether the connection read by r starts with a tar stream request rather than SFTP.
+func IsRequest(r *bufio.Reader) bool {
+	b, err := r.Peek(1)
+	if err != nil {
+		return false
+	}
+
+	return b[0] == protocolMagic[0]
+}
+
+// Serve handles a single tar stream request read from r, writing the reply to w.
+func Serve(r *bufio.Reader, w io.Writer) error {
+	magic, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	if magic != protocolMagic {
+		return fmt.Errorf("Invalid tar stream request")
+	}
+
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+
+	req := request{}
+	err = json.Unmarshal(line, &req)
+	if err != nil {
+		return err
+	}
+
+	switch req.Action {
+	case ActionPull:
+		fi, err := os.Lstat(req.Path)
+		if err == nil && !fi.IsDir() {
+			err = fmt.Errorf("%q isn't a directory", req.Path)
+		}
+
+		if err != nil {
+			return writeResponse(w, err)
+		}
+
+		err = writeResponse(w, nil)
+		if err != nil {
+			return err
+		}
+
+		return Pack(w, req.Path)
+	case ActionPush:
+		unpackErr := Unpack(r, req.Path)
+
+		err = writeResponse(w, unpackErr)
+		if err != nil {
+			return err
+		}
+
+		// Let the client finish sending so it can read the response.
+		if unpackErr != nil {
+			_, _ = io.Copy(io.Discard, r)
+		}
+
+		return unpackErr
+	default:
+		return writeResponse(w, fmt.Errorf("Unknown action %q", req.Action))
+	}
+}
+
+// Pull requests the directory tree at path from the server on conn.
+// The returned reader yields the tar stream.
+func Pull(conn io.ReadWriter, path string) (io.Reader, error) {
+	err := writeRequest(conn, ActionPull, path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+
+	err = readResponse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Push sends the tar stream read from tarReader to the server on conn, to be extracted at path.
+func Push(conn io.ReadWriter, path string, tarReader io.Reader) error {
+	err := writeRequest(conn, ActionPush, path)
+	if err != nil {
+		return err
+	}
+
+	_, copyErr := io.Copy(conn, tarReader)
+
+	// The server reports extraction errors even if it stopped reading early.
+	err = readResponse(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+
+	return copyErr
+}
+
+// writeRequest sends a request header to the server.
+func writeRequest(w io.Writer, action string, path string) error {
+	data, err := json.Marshal(request{Action: action, Path: path})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(protocolMagic + string(data) + "\n"))
+	return err
+}
+
+// writeResponse reports the result of a request to the client.
+func writeResponse(w io.Writer, reqErr error) error {
+	resp := response{}
+	if reqErr != nil {
+		resp.Error = reqErr.Error()
+		resp.StatusCode = http.StatusBadRequest
+
+		statusCode, found := api.StatusErrorMatch(reqErr)
+		if found {
+			resp.StatusCode = statusCode
+		} else if errors.Is(reqErr, os.ErrNotExist) {
+			resp.StatusCode = http.StatusNotFound
+		} else if errors.Is(reqErr, os.ErrPermission) {
+			resp.StatusCode = http.StatusForbidden
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// readResponse reads the result of a request from the server.
+// Errors reported by the server are returned as api.StatusError so they map to the right HTTP status.
+func readResponse(r *bufio.Reader) error {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("Failed reading tar stream response: %w", err)
+	}
+
+	resp := response{}
+	err = json.Unmarshal(line, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Error != "" {
+		// Older servers don't report a status code.
+		if resp.StatusCode == 0 {
+			resp.StatusCode = http.StatusBadRequest
+		}
+
+		return api.StatusErrorf(resp.StatusCode, "%s", resp.Error)
+	}
+
+	return nil
+}
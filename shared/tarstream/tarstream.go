@@ -0,0 +1,423 @@
+// Package tarstream transfers whole directory trees as a single tar stream, preserving ownership, modes,
+// symlinks, hardlinks and extended attributes.
+package tarstream
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/xattr"
+)
+
+// paxXattrPrefix is the PAX record prefix used by GNU tar and libarchive to store extended attributes.
+const paxXattrPrefix = "SCHILY.xattr."
+
+// Pack writes the directory tree at root to w as a tar stream.
+// Entry names are relative to root, with root itself stored as "./".
+func Pack(w io.Writer, root string) error {
+	fi, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%q isn't a directory", root)
+	}
+
+	tw := tar.NewWriter(w)
+	links := map[uint64]string{}
+
+	err = filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Sockets cannot be stored in tarballs, just skip them (consistent with tar).
+		if fi.Mode()&os.ModeSocket == os.ModeSocket {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
+			link, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = "./" + filepath.ToSlash(rel)
+		if rel == "." {
+			hdr.Name = "./"
+		} else if fi.IsDir() {
+			hdr.Name += "/"
+		}
+
+		// Only keep numeric ownership, names may resolve differently on the other end.
+		hdr.Uname = ""
+		hdr.Gname = ""
+		hdr.Format = tar.FormatPAX
+
+		// Store additional references to the same inode as hardlinks.
+		if fi.Mode().IsRegular() {
+			ino, nlink := fileInode(fi)
+			if nlink > 1 {
+				target, found := links[ino]
+				if found {
+					hdr.Typeflag = tar.TypeLink
+					hdr.Linkname = target
+					hdr.Size = 0
+				} else {
+					links[ino] = hdr.Name
+				}
+			}
+		}
+
+		xattrs, err := packXattrs(p)
+		if err != nil {
+			return err
+		}
+
+		if len(xattrs) > 0 {
+			hdr.PAXRecords = xattrs
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("Failed writing header for %q: %w", p, err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg && hdr.Size > 0 {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+
+			defer func() { _ = f.Close() }()
+
+			_, err = io.CopyN(tw, f, hdr.Size)
+			if err != nil {
+				return fmt.Errorf("Failed copying %q: %w", p, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// Unpack extracts the tar stream from r into the directory at root, creating it if needed.
+// Entries are never written through symlinks or outside of root. Ownership is only restored when running as root.
+func Unpack(r io.Reader, root string) error {
+	err := os.Mkdir(root, 0755)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	fi, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%q isn't a directory", root)
+	}
+
+	tr := tar.NewReader(r)
+	dirs := []*tar.Header{}
+	restoreOwner := os.Geteuid() == 0
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name, err := entryName(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(root, filepath.FromSlash(name))
+
+		err = checkParents(root, name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fi, err := os.Lstat(target)
+			if err == nil && !fi.IsDir() {
+				err = os.Remove(target)
+				if err != nil {
+					return err
+				}
+			}
+
+			if err != nil || !fi.IsDir() {
+				err = os.Mkdir(target, 0700)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Permissions and timestamps are applied once the content is in place.
+			dirs = append(dirs, hdr)
+
+		case tar.TypeReg:
+			err = removeNonDir(target)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			_ = f.Close()
+			if err != nil {
+				return fmt.Errorf("Failed writing %q: %w", name, err)
+			}
+
+		case tar.TypeSymlink:
+			err = removeNonDir(target)
+			if err != nil {
+				return err
+			}
+
+			err = os.Symlink(hdr.Linkname, target)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeLink:
+			linkName, err := entryName(hdr.Linkname)
+			if err != nil {
+				return err
+			}
+
+			err = checkParents(root, linkName)
+			if err != nil {
+				return err
+			}
+
+			err = removeNonDir(target)
+			if err != nil {
+				return err
+			}
+
+			err = os.Link(filepath.Join(root, filepath.FromSlash(linkName)), target)
+			if err != nil {
+				return err
+			}
+
+			// Hardlinks share the metadata of their target.
+			continue
+
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			err = removeNonDir(target)
+			if err != nil {
+				return err
+			}
+
+			err = mknod(target, hdr)
+			if err != nil {
+				return fmt.Errorf("Failed creating %q: %w", name, err)
+			}
+
+		default:
+			return fmt.Errorf("Unsupported type %q for %q", hdr.Typeflag, name)
+		}
+
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		err = applyMetadata(target, hdr, restoreOwner)
+		if err != nil {
+			return fmt.Errorf("Failed applying metadata to %q: %w", name, err)
+		}
+	}
+
+	// Apply directory metadata deepest first so parents aren't locked before their content is written.
+	sort.SliceStable(dirs, func(i, j int) bool { return len(dirs[i].Name) > len(dirs[j].Name) })
+
+	for _, hdr := range dirs {
+		name, _ := entryName(hdr.Name)
+
+		err = applyMetadata(filepath.Join(root, filepath.FromSlash(name)), hdr, restoreOwner)
+		if err != nil {
+			return fmt.Errorf("Failed applying metadata to %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Size returns the total size of the regular files in the directory tree at root.
+// It can be used to report progress on Pack.
+func Size(root string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// entryName returns the cleaned name of a tar entry relative to the root of the stream.
+func entryName(name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("Invalid entry name %q", name)
+	}
+
+	return clean, nil
+}
+
+// checkParents ensures none of the parent directories of name below root is a symlink.
+func checkParents(root string, name string) error {
+	parts := strings.Split(name, "/")
+	current := root
+
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+
+		fi, err := os.Lstat(current)
+		if err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			return fmt.Errorf("Refusing to extract %q through non-directory %q", name, current)
+		}
+	}
+
+	return nil
+}
+
+// removeNonDir removes the existing entry at path unless it is a directory.
+func removeNonDir(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if fi.IsDir() {
+		return fmt.Errorf("%q is a directory", path)
+	}
+
+	return os.Remove(path)
+}
+
+// packXattrs returns the extended attributes of path as PAX records.
+func packXattrs(path string) (map[string]string, error) {
+	names, err := xattr.LList(path)
+	if err != nil {
+		// Some filesystems don't support extended attributes, treat this as having none.
+		if errors.Is(err, xattr.ENOATTR) || isUnsupported(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("Failed listing extended attributes of %q: %w", path, err)
+	}
+
+	records := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := xattr.LGet(path, name)
+		if err != nil {
+			// The attribute may have been removed in the meantime.
+			if errors.Is(err, xattr.ENOATTR) {
+				continue
+			}
+
+			return nil, fmt.Errorf("Failed getting extended attribute %q of %q: %w", name, path, err)
+		}
+
+		records[paxXattrPrefix+name] = string(value)
+	}
+
+	return records, nil
+}
+
+// applyMetadata restores the extended attributes, ownership, permissions and modification time of an entry.
+func applyMetadata(path string, hdr *tar.Header, restoreOwner bool) error {
+	for key, value := range hdr.PAXRecords {
+		if !strings.HasPrefix(key, paxXattrPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, paxXattrPrefix)
+
+		err := xattr.LSet(path, name, []byte(value))
+		if err != nil && !isUnsupported(err) && !errors.Is(err, fs.ErrPermission) {
+			return err
+		}
+	}
+
+	if restoreOwner {
+		err := os.Lchown(path, hdr.Uid, hdr.Gid)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Symlinks have no permissions of their own and changing their timestamps isn't portable.
+	if hdr.Typeflag == tar.TypeSymlink {
+		return nil
+	}
+
+	err := os.Chmod(path, hdr.FileInfo().Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky))
+	if err != nil {
+		return err
+	}
+
+	modTime := hdr.ModTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+
+	return os.Chtimes(path, modTime, modTime)
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package tarstream
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+)
+
+// fileInode returns the inode number and link count of a file.
+// Hardlinks aren't detected on this platform, so files are always stored as regular files.
+func fileInode(fi os.FileInfo) (uint64, uint64) {
+	return 0, 0
+}
+
+// mknod creates a device node or FIFO described by hdr.
+func mknod(path string, hdr *tar.Header) error {
+	return fmt.Errorf("Special files aren't supported on this platform")
+}
+
+// isUnsupported returns whether err indicates that extended attributes aren't supported.
+func isUnsupported(err error) bool {
+	return true
+}
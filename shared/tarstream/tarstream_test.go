@@ -0,0 +1,141 @@
+package tarstream
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+func TestPackUnpack(t *testing.T) {
+	src := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "a", "b"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a", "b", "file"), []byte("content"), 0640))
+	require.NoError(t, os.Symlink("b/file", filepath.Join(src, "a", "link")))
+	require.NoError(t, os.Link(filepath.Join(src, "a", "b", "file"), filepath.Join(src, "hardlink")))
+	require.NoError(t, os.Chmod(filepath.Join(src, "a"), 0700))
+
+	var buf bytes.Buffer
+	require.NoError(t, Pack(&buf, src))
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	require.NoError(t, Unpack(&buf, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "a", "b", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	fi, err := os.Stat(filepath.Join(dst, "a", "b", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "a"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+
+	target, err := os.Readlink(filepath.Join(dst, "a", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "b/file", target)
+
+	hardlinkInfo, err := os.Stat(filepath.Join(dst, "hardlink"))
+	require.NoError(t, err)
+	linkedInfo, err := os.Stat(filepath.Join(dst, "a", "b", "file"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(hardlinkInfo, linkedInfo))
+}
+
+func TestUnpackRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{
+			name:    "Parent directory",
+			headers: []*tar.Header{{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644}},
+		},
+		{
+			name:    "Absolute path",
+			headers: []*tar.Header{{Name: "/evil", Typeflag: tar.TypeReg, Mode: 0644}},
+		},
+		{
+			name: "Through symlink",
+			headers: []*tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/tmp"},
+				{Name: "link/evil", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name:    "Hardlink outside",
+			headers: []*tar.Header{{Name: "evil", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, hdr := range test.headers {
+				require.NoError(t, tw.WriteHeader(hdr))
+			}
+
+			require.NoError(t, tw.Close())
+
+			assert.Error(t, Unpack(&buf, t.TempDir()))
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file"), []byte("content"), 0644))
+
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	go func() {
+		defer func() { _ = server.Close() }()
+
+		r := bufio.NewReader(server)
+		if IsRequest(r) {
+			_ = Serve(r, server)
+		}
+	}()
+
+	r, err := Pull(client, src)
+	require.NoError(t, err)
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	require.NoError(t, Unpack(r, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+}
+
+func TestServeMissingPath(t *testing.T) {
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	go func() {
+		defer func() { _ = server.Close() }()
+
+		r := bufio.NewReader(server)
+		if IsRequest(r) {
+			_ = Serve(r, server)
+		}
+	}()
+
+	_, err := Pull(client, filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+}
@@ -380,6 +380,30 @@ var APIExtensions = []string{
 	"network_allocations",
 	"storage_api_remote_volume_snapshot_copy",
 	"zfs_delegate",
+	"instance_snapshots_restore_on_stop",
+	"container_rootfs_readonly",
+	"instance_type_conversion",
+	"instance_file_archive",
+	"instance_file_watch",
+	"instance_processes",
+	"instance_exec_detached",
+	"instance_session_recording",
+	"instance_console_shared",
+	"config_history",
+	"instance_diff",
+	"instances_admission_scriptlet",
+	"authorization_scriptlet",
+	"auth_groups",
+	"auth_groups_oidc_claims",
+	"auth_tokens",
+	"audit_log",
+	"api_rate_limits",
+	"webhooks",
+	"event_log",
+	"event_filter",
+	"tracing",
+	"metrics_otlp",
+	"auth_groups_rate_limits",
 }
 
 // APIExtensionsCount returns the number of available API extensions.